@@ -0,0 +1,73 @@
+package main
+
+import (
+	"expenser/internal/config"
+	database "expenser/internal/db"
+	"expenser/internal/notify"
+	"fmt"
+	"os"
+)
+
+const adminUsage = `Usage: web-app [command]
+
+Without a command the server starts. Commands:
+
+  reset-2fa <username>  Turn off two-factor authentication of a user locked
+                        out of it. They are emailed about it when their
+                        address is verified.
+`
+
+// runAdmin runs the admin command of args on the server's database and returns
+// the exit code. Admin commands need a shell on the server, not an account.
+func runAdmin(cfg *config.Config, args []string) int {
+	if args[0] != "reset-2fa" || len(args) != 2 {
+		fmt.Fprint(os.Stderr, adminUsage)
+		return 2
+	}
+
+	db, err := database.InitDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't initialize database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := resetTwoFactor(db, notify.NewMailer(db, cfg), args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't reset two-factor authentication of %s: %v\n", args[1], err)
+		return 1
+	}
+	return 0
+}
+
+// resetTwoFactor turns off the two-factor authentication of the user and
+// tells them, so a reset they didn't ask for doesn't go unnoticed.
+func resetTwoFactor(db *database.DB, mailer *notify.Mailer, username string) error {
+	user, err := db.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	twoFactor, err := db.GetTwoFactor(user.ID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled() {
+		return fmt.Errorf("two-factor authentication is off")
+	}
+
+	if err := db.DisableTwoFactor(user.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Turned off two-factor authentication of %s.\n", user.Username)
+
+	if email := user.VerifiedEmail(); email != "" && mailer.Enabled() {
+		if err := mailer.SendTwoFactorReset(email, user.Username); err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't email %s about it: %v\n", email, err)
+		} else {
+			fmt.Printf("Emailed %s about it.\n", email)
+		}
+	} else {
+		fmt.Println("They have no verified email address or no mail server is configured, so they weren't emailed.")
+	}
+	return nil
+}
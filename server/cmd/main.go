@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"expenser/internal/config"
 	database "expenser/internal/db"
 	"expenser/internal/handlers"
+	"expenser/internal/netguard"
+	"expenser/internal/notify"
+	"expenser/internal/reminders"
+	"expenser/internal/telegram"
+	"expenser/internal/webhook"
 	"fmt"
 	"html/template"
 	"log"
@@ -11,18 +17,27 @@ import (
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	router := gin.Default()
-
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalln("Couldn't load configuration.")
 	}
 
+	if len(os.Args) > 1 {
+		os.Exit(runAdmin(cfg, os.Args[1:]))
+	}
+
+	if err := netguard.Allow(cfg.OutboundAllowedHosts); err != nil {
+		log.Fatalf("Couldn't read OUTBOUND_ALLOWED_HOSTS: %v", err)
+	}
+
+	router := gin.Default()
+
 	var tPath string
 	if cfg.Mode == "" {
 		tPath = filepath.Join(config.GetProjectRootDir(), "internal/templates/**/*.html")
@@ -62,6 +77,16 @@ func main() {
 		}
 	}()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mailer := notify.NewMailer(db, cfg)
+	go reminders.NewWorker(db, notify.NewDispatcher(db, mailer), time.Hour).Run(ctx)
+	go notify.NewWorker(mailer, time.Hour).Run(ctx)
+	go webhook.NewWorker(db, 30*time.Second).Run(ctx)
+	if cfg.Telegram.Enabled() {
+		go telegram.NewBot(db, cfg.Telegram).Run(ctx)
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
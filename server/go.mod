@@ -3,11 +3,26 @@ module expenser
 go 1.23.0
 
 require (
+	github.com/coreos/go-oidc/v3 v3.14.1
+	github.com/fxamacker/cbor/v2 v2.5.0
 	github.com/gin-gonic/gin v1.10.1
+	github.com/go-webauthn/webauthn v0.9.4
 	github.com/joho/godotenv v1.5.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/lib/pq v1.10.9
+	github.com/makiuchi-d/gozxing v0.1.1
 	github.com/pressly/goose v2.7.0+incompatible
-	github.com/stretchr/testify v1.9.0
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/oauth2 v0.28.0
+)
+
+require (
+	github.com/go-jose/go-jose/v4 v4.0.5 // indirect
+	github.com/go-webauthn/x v0.1.5 // indirect
+	github.com/google/go-tpm v0.9.0 // indirect
+	github.com/mitchellh/mapstructure v1.5.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 )
 
 require (
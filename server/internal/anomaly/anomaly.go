@@ -0,0 +1,120 @@
+// Package anomaly flags new expenses that are outliers against the user's history
+// of the same expense type, so a bill that suddenly doubles is noticed right away.
+package anomaly
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MinHistory is how many past expenses of the type it takes to tell an outlier.
+	MinHistory = 5
+	// minSeasonal is how many past expenses of the same month it takes to compare by season.
+	minSeasonal = 3
+	// threshold is the robust z-score above which an expense is an outlier, after Iglewicz and Hoaglin.
+	threshold = 3.5
+	// minSpread is the smallest spread assumed relative to the median, so a history of
+	// identical amounts doesn't flag every small change.
+	minSpread = 0.05
+	// historyYears is how far back the history of a type is taken into account.
+	historyYears = 3
+)
+
+// median returns the median of values, which must not be empty. It sorts values.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// Detect compares an expense with the past expenses of its type, using the median
+// and the median absolute deviation (MAD) so a few earlier outliers don't skew the
+// baseline. When enough past expenses fall in the same month of the year, only those
+// are compared with, so a heating bill in January is compared with other Januaries.
+// It returns false when the expense is normal or the history is too short to tell.
+func Detect(history []models.CategorisedExpense, exp *models.CategorisedExpense) (models.ExpenseAnomaly, bool) {
+	if len(history) < MinHistory {
+		return models.ExpenseAnomaly{}, false
+	}
+
+	var all, seasonal []float64
+	for _, past := range history {
+		all = append(all, past.Amount)
+		if past.Date.Month() == exp.Date.Month() {
+			seasonal = append(seasonal, past.Amount)
+		}
+	}
+
+	amounts, bySeason := all, len(seasonal) >= minSeasonal
+	if bySeason {
+		amounts = seasonal
+	}
+
+	expected := median(amounts)
+	deviations := make([]float64, len(amounts))
+	for i, amount := range amounts {
+		deviations[i] = math.Abs(amount - expected)
+	}
+	mad := math.Max(median(deviations), math.Abs(expected)*minSpread)
+	if mad == 0 {
+		return models.ExpenseAnomaly{}, false
+	}
+
+	score := 0.6745 * (exp.Amount - expected) / mad
+	if math.Abs(score) <= threshold {
+		return models.ExpenseAnomaly{}, false
+	}
+
+	return models.ExpenseAnomaly{
+		Tracker:   exp.Tracker,
+		ExpenseID: exp.ID,
+		Type:      exp.Type,
+		Date:      exp.Date,
+		Amount:    exp.Amount,
+		Expected:  math.Round(expected*100) / 100,
+		Score:     math.Round(score*100) / 100,
+		Seasonal:  bySeason,
+		BasedOn:   len(amounts),
+	}, true
+}
+
+// Detector checks newly created or imported expenses and records the outliers.
+type Detector struct {
+	DB *database.DB
+}
+
+func NewDetector(db *database.DB) *Detector {
+	return &Detector{
+		DB: db,
+	}
+}
+
+// Check compares a new expense with the user's expenses of its type from the years
+// before it and records it as an anomaly when it is an outlier. It returns nil when
+// the expense is normal.
+func (d *Detector) Check(userID uuid.UUID, exp *models.CategorisedExpense) (*models.ExpenseAnomaly, error) {
+	since := exp.Date.AddDate(-historyYears, 0, 0)
+	history, err := d.DB.GetExpenseHistory(userID, exp.Tracker, exp.TypeID, exp.ID, since, exp.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	anomaly, ok := Detect(history, exp)
+	if !ok {
+		return nil, nil
+	}
+
+	anomaly.CreatedBy = userID
+	if err := d.DB.CreateExpenseAnomaly(&anomaly); err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
@@ -0,0 +1,77 @@
+package anomaly
+
+import (
+	"expenser/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func expense(year int, month time.Month, amount float64) models.CategorisedExpense {
+	return models.CategorisedExpense{Date: time.Date(year, month, 10, 0, 0, 0, 0, time.UTC), Amount: amount}
+}
+
+func TestDetect(t *testing.T) {
+	history := []models.CategorisedExpense{
+		expense(2025, time.January, 20),
+		expense(2025, time.February, 22),
+		expense(2025, time.March, 19),
+		expense(2025, time.April, 21),
+	}
+
+	doubled := expense(2025, time.May, 42)
+	_, ok := Detect(history, &doubled)
+	assert.False(t, ok, "too short a history")
+
+	history = append(history, expense(2025, time.May, 20))
+	found, ok := Detect(history, &doubled)
+	assert.True(t, ok)
+	assert.Equal(t, 20.0, found.Expected)
+	assert.True(t, found.IsHigh())
+	assert.False(t, found.Seasonal)
+	assert.Equal(t, 5, found.BasedOn)
+
+	usual := expense(2025, time.June, 23)
+	_, ok = Detect(history, &usual)
+	assert.False(t, ok)
+
+	low := expense(2025, time.June, 2)
+	found, ok = Detect(history, &low)
+	assert.True(t, ok)
+	assert.False(t, found.IsHigh())
+}
+
+func TestDetectSeasonal(t *testing.T) {
+	// Heating makes the winter bills three times the summer ones.
+	var history []models.CategorisedExpense
+	for year := 2022; year <= 2024; year++ {
+		for month := time.January; month <= time.December; month++ {
+			amount := 30.0
+			if month <= time.February || month == time.December {
+				amount = 90
+			}
+			history = append(history, expense(year, month, amount+float64(year-2022)))
+		}
+	}
+
+	january := expense(2025, time.January, 92)
+	_, ok := Detect(history, &january)
+	assert.False(t, ok)
+
+	july := expense(2025, time.July, 92)
+	found, ok := Detect(history, &july)
+	assert.True(t, ok)
+	assert.True(t, found.Seasonal)
+	assert.Equal(t, 31.0, found.Expected)
+	assert.Equal(t, 3, found.BasedOn)
+
+	// Identical amounts don't flag a small change.
+	flat := []models.CategorisedExpense{
+		expense(2025, time.January, 50), expense(2025, time.February, 50), expense(2025, time.March, 50),
+		expense(2025, time.April, 50), expense(2025, time.May, 50),
+	}
+	raised := expense(2025, time.June, 55)
+	_, ok = Detect(flat, &raised)
+	assert.False(t, ok)
+}
@@ -0,0 +1,152 @@
+// Package bankimport reads booked debits out of bank statement downloads.
+// It understands CSV, ISO 20022 CAMT.053, OFX and SWIFT MT940 files.
+package bankimport
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"expenser/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a supported statement file format.
+type Format string
+
+const (
+	FormatCSV     Format = "CSV"
+	FormatCAMT053 Format = "CAMT.053"
+	FormatOFX     Format = "OFX"
+	FormatMT940   Format = "MT940"
+)
+
+// maxRefLength matches the bank_ref column size.
+const maxRefLength = 255
+
+var ErrNoTransactions = errors.New("no booked debits found in the file")
+
+// Detect guesses the statement format from the file contents.
+func Detect(data []byte) Format {
+	head := data
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+
+	switch {
+	case bytes.Contains(head, []byte("camt.053")) || bytes.Contains(data, []byte("<BkToCstmrStmt")):
+		return FormatCAMT053
+	case bytes.Contains(head, []byte("OFXHEADER")) || bytes.Contains(head, []byte("<OFX>")):
+		return FormatOFX
+	case bytes.Contains(head, []byte(":20:")) && bytes.Contains(data, []byte(":61:")):
+		return FormatMT940
+	}
+
+	return FormatCSV
+}
+
+// Parse detects the format of a statement file and returns its booked debits.
+func Parse(data []byte) (Format, []models.BankTransaction, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	format := Detect(data)
+
+	var txs []models.BankTransaction
+	var err error
+	switch format {
+	case FormatCAMT053:
+		txs, err = ParseCAMT053(data)
+	case FormatOFX:
+		txs, err = ParseOFX(data)
+	case FormatMT940:
+		txs, err = ParseMT940(data)
+	default:
+		txs, err = ParseCSV(data)
+	}
+
+	if err != nil {
+		return format, nil, fmt.Errorf("failed to parse %s statement: %w", format, err)
+	}
+
+	if len(txs) == 0 {
+		return format, nil, ErrNoTransactions
+	}
+
+	return format, txs, nil
+}
+
+// parseAmount reads amounts written either as 1,234.56 or 1.234,56.
+func parseAmount(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	value = strings.ReplaceAll(value, " ", "")
+	value = strings.ReplaceAll(value, "\u00a0", "")
+
+	comma := strings.LastIndex(value, ",")
+	dot := strings.LastIndex(value, ".")
+	switch {
+	case comma > dot && strings.Count(value, ",") > 1:
+		value = strings.ReplaceAll(value, ",", "")
+	case comma > dot:
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	case dot > comma && strings.Count(value, ".") > 1:
+		value = strings.ReplaceAll(value, ".", "")
+	case dot > comma:
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// reference returns the first usable bank reference, empty when the bank didn't
+// provide one.
+func reference(candidates ...string) string {
+	for _, ref := range candidates {
+		ref = strings.TrimSpace(ref)
+		if ref == "" || strings.EqualFold(ref, "NONREF") || strings.EqualFold(ref, "NOTPROVIDED") {
+			continue
+		}
+		if len(ref) > maxRefLength {
+			ref = ref[:maxRefLength]
+		}
+		return ref
+	}
+	return ""
+}
+
+// fillReferences gives the transactions without a bank reference a hash of their
+// date, amount and description. Identical ones, like two coffees on the same day,
+// also hash how many of them came before in the statement, so neither is taken
+// for a duplicate of the other.
+func fillReferences(format Format, txs []models.BankTransaction) {
+	seen := map[string]int{}
+	for i := range txs {
+		tx := &txs[i]
+		if tx.Ref != "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%.2f|%s", tx.Date.Format(time.DateOnly), tx.Amount, tx.Description)
+		hashed := key
+		if n := seen[key]; n > 0 {
+			hashed = fmt.Sprintf("%s|%d", key, n)
+		}
+		seen[key]++
+
+		sum := sha1.Sum([]byte(hashed))
+		tx.Ref = fmt.Sprintf("%s:%x", strings.ToLower(string(format)), sum)
+	}
+}
+
+// joinText joins the non-empty parts of a description, collapsing whitespace.
+func joinText(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		part = strings.Join(strings.Fields(part), " ")
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, " / ")
+}
@@ -0,0 +1,199 @@
+package bankimport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const camtSample = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Ntry>
+        <Amt Ccy="BGN">85.40</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2025-03-14</Dt></BookgDt>
+        <AcctSvcrRef>BNK-001</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <RltdPties><Cdtr><Nm>SHELL BULGARIA</Nm></Cdtr></RltdPties>
+          <RmtInf><Ustrd>Fuel station 12</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="BGN">2500.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2025-03-15</Dt></BookgDt>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="BGN">12.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>PDNG</Sts>
+        <BookgDt><Dt>2025-03-16</Dt></BookgDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+const ofxSample = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>BGN
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250314120000[+2:EET]
+<TRNAMT>-120.35
+<FITID>OFX-42
+<NAME>ENERGO-PRO
+<MEMO>Electricity &amp; fees
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20250315
+<TRNAMT>2500.00
+<FITID>OFX-43
+<NAME>SALARY
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+const mt940Sample = `{1:F01BANKBGSFAXXX0000000000}{4:
+:20:STMT0314
+:25:BG80BNBG96611020345678
+:28C:00001/001
+:60F:C250313BGN1000,00
+:61:2503140314D45,10NTRFNONREF//BREF-7
+:86:?20SOFIYSKA VODA?21water bill
+:61:2503150315C2500,00NTRFSALARY//BREF-8
+:86:Salary March
+:62F:C250315BGN3454,90
+-}`
+
+const csvSample = `Date;Amount;Description;Reference
+14.03.2025;-1.234,50;Car service Sofia;CSV-1
+15.03.2025;2500,00;Salary;CSV-2
+16.03.2025;-10,00;Parking;
+`
+
+func TestParse(t *testing.T) {
+	type testCase struct {
+		name        string
+		input       string
+		format      Format
+		count       int
+		ref         string
+		amount      float64
+		description string
+		date        time.Time
+	}
+
+	tests := []testCase{
+		{
+			name:        "CAMT.053",
+			input:       camtSample,
+			format:      FormatCAMT053,
+			count:       1,
+			ref:         "BNK-001",
+			amount:      85.40,
+			description: "SHELL BULGARIA / Fuel station 12",
+			date:        time.Date(2025, time.March, 14, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:        "OFX",
+			input:       ofxSample,
+			format:      FormatOFX,
+			count:       1,
+			ref:         "OFX-42",
+			amount:      120.35,
+			description: "ENERGO-PRO / Electricity & fees",
+			date:        time.Date(2025, time.March, 14, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:        "MT940",
+			input:       mt940Sample,
+			format:      FormatMT940,
+			count:       1,
+			ref:         "BREF-7",
+			amount:      45.10,
+			description: "SOFIYSKA VODA water bill",
+			date:        time.Date(2025, time.March, 14, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:        "CSV",
+			input:       csvSample,
+			format:      FormatCSV,
+			count:       2,
+			ref:         "CSV-1",
+			amount:      1234.50,
+			description: "Car service Sofia",
+			date:        time.Date(2025, time.March, 14, 0, 0, 0, 0, time.Local),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, txs, err := Parse([]byte(tt.input))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.format, format)
+			assert.Len(t, txs, tt.count)
+
+			assert.Equal(t, tt.ref, txs[0].Ref)
+			assert.Equal(t, tt.amount, txs[0].Amount)
+			assert.Equal(t, tt.description, txs[0].Description)
+			assert.Equal(t, tt.date, txs[0].Date)
+		})
+	}
+}
+
+func TestParseWithoutReference(t *testing.T) {
+	_, first, err := Parse([]byte(csvSample))
+	assert.NoError(t, err)
+
+	_, second, err := Parse([]byte(csvSample))
+	assert.NoError(t, err)
+
+	// The parking row has no reference, the generated one must stay stable between imports.
+	assert.Contains(t, first[1].Ref, "csv:")
+	assert.Equal(t, first[1].Ref, second[1].Ref)
+}
+
+func TestParseIdenticalWithoutReference(t *testing.T) {
+	statement := "date,amount,description\n14.03.2025,-3.20,Coffee\n14.03.2025,-3.20,Coffee\n"
+
+	_, txs, err := Parse([]byte(statement))
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	assert.NotEqual(t, txs[0].Ref, txs[1].Ref)
+
+	// The first one keeps the reference it had on its own.
+	_, single, err := Parse([]byte("date,amount,description\n14.03.2025,-3.20,Coffee\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, single[0].Ref, txs[0].Ref)
+}
+
+func TestParseAmount(t *testing.T) {
+	for input, want := range map[string]float64{
+		"1234.56":   1234.56,
+		"1,234.56":  1234.56,
+		"1.234,56":  1234.56,
+		"85,4":      85.40,
+		"-12.00":    -12.00,
+		"1 000,00":  1000.00,
+		"45,":       45.00,
+		" 3.5 ":     3.5,
+		"1,000,000": 1000000,
+		"1.000.000": 1000000,
+	} {
+		got, err := parseAmount(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+}
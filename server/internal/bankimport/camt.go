@@ -0,0 +1,132 @@
+package bankimport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"expenser/internal/models"
+	"strings"
+	"time"
+)
+
+// The CAMT.053 structs only list the elements we read. Tags carry no namespace
+// so every camt.053.001.xx version matches.
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtEntry struct {
+	NtryRef      string          `xml:"NtryRef"`
+	Amount       camtAmount      `xml:"Amt"`
+	CdtDbtInd    string          `xml:"CdtDbtInd"`
+	Status       camtStatus      `xml:"Sts"`
+	BookingDate  camtDate        `xml:"BookgDt"`
+	ValueDate    camtDate        `xml:"ValDt"`
+	AcctSvcrRef  string          `xml:"AcctSvcrRef"`
+	AddtlNtryInf string          `xml:"AddtlNtryInf"`
+	Details      []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// camtStatus is plain text up to version 08 and a <Cd> element afterwards.
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+func (s camtStatus) String() string {
+	if code := strings.TrimSpace(s.Code); code != "" {
+		return code
+	}
+	return strings.TrimSpace(s.Text)
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) Time() (time.Time, bool) {
+	if d.Date != "" {
+		t, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(d.Date), time.Local)
+		return t, err == nil
+	}
+	if d.DateTime != "" {
+		value := strings.TrimSpace(d.DateTime)
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, true
+		}
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", value, time.Local)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+type camtTxDetails struct {
+	AcctSvcrRef   string   `xml:"Refs>AcctSvcrRef"`
+	EndToEndID    string   `xml:"Refs>EndToEndId"`
+	TxID          string   `xml:"Refs>TxId"`
+	Creditor      string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorParty string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Unstructured  []string `xml:"RmtInf>Ustrd"`
+}
+
+// ParseCAMT053 returns the booked debit entries of an ISO 20022 bank to customer statement.
+func ParseCAMT053(data []byte) ([]models.BankTransaction, error) {
+	var doc camtDocument
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var txs []models.BankTransaction
+	for _, stmt := range doc.Statements {
+		for _, entry := range stmt.Entries {
+			if entry.Status.String() != "BOOK" || strings.TrimSpace(entry.CdtDbtInd) != "DBIT" {
+				continue
+			}
+
+			amount, err := parseAmount(entry.Amount.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			date, ok := entry.BookingDate.Time()
+			if !ok {
+				if date, ok = entry.ValueDate.Time(); !ok {
+					continue
+				}
+			}
+
+			tx := models.BankTransaction{
+				Date:     date,
+				Amount:   amount,
+				Currency: entry.Amount.Currency,
+			}
+
+			refs := []string{entry.AcctSvcrRef}
+			var parts []string
+			for _, details := range entry.Details {
+				refs = append(refs, details.AcctSvcrRef, details.EndToEndID, details.TxID)
+				parts = append(parts, details.Creditor, details.CreditorParty)
+				parts = append(parts, details.Unstructured...)
+			}
+			refs = append(refs, entry.NtryRef)
+			parts = append(parts, entry.AddtlNtryInf)
+
+			tx.Description = joinText(parts...)
+			tx.Ref = reference(refs...)
+			txs = append(txs, tx)
+		}
+	}
+
+	fillReferences(FormatCAMT053, txs)
+	return txs, nil
+}
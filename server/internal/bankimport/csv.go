@@ -0,0 +1,112 @@
+package bankimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"expenser/internal/models"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var csvDateLayouts = []string{time.DateOnly, "02.01.2006", "02/01/2006", "2.1.2006"}
+
+// csvColumns are the accepted header names for each column we read.
+var csvColumns = map[string][]string{
+	"date":        {"date", "booking date", "дата"},
+	"amount":      {"amount", "сума"},
+	"description": {"description", "details", "payee", "описание"},
+	"reference":   {"reference", "ref", "id", "референция"},
+	"currency":    {"currency", "валута"},
+}
+
+// ParseCSV returns the debits of a CSV export with a header row naming at least
+// the date, amount and description columns. Debits have a negative amount.
+// Both comma and semicolon separated files are accepted.
+func ParseCSV(data []byte) ([]models.BankTransaction, error) {
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, nil
+	}
+
+	index := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for column, aliases := range csvColumns {
+			for _, alias := range aliases {
+				if name == alias {
+					index[column] = i
+				}
+			}
+		}
+	}
+
+	for _, required := range []string{"date", "amount", "description"} {
+		if _, ok := index[required]; !ok {
+			return nil, errors.New("missing " + required + " column")
+		}
+	}
+
+	get := func(record []string, column string) string {
+		i, ok := index[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var txs []models.BankTransaction
+	for line, record := range records[1:] {
+		if get(record, "amount") == "" {
+			continue
+		}
+
+		amount, err := parseAmount(get(record, "amount"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount", line+2)
+		}
+		if amount >= 0 {
+			continue
+		}
+
+		date, err := parseCSVDate(get(record, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date", line+2)
+		}
+
+		tx := models.BankTransaction{
+			Date:        date,
+			Amount:      math.Abs(amount),
+			Currency:    get(record, "currency"),
+			Description: joinText(get(record, "description")),
+		}
+		tx.Ref = reference(get(record, "reference"))
+		txs = append(txs, tx)
+	}
+
+	fillReferences(FormatCSV, txs)
+	return txs, nil
+}
+
+func parseCSVDate(value string) (time.Time, error) {
+	for _, layout := range csvDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", value)
+}
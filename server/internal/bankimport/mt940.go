@@ -0,0 +1,150 @@
+package bankimport
+
+import (
+	"bufio"
+	"bytes"
+	"expenser/internal/models"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	// :61: value date, optional entry date, debit/credit mark, optional funds code,
+	// amount, transaction type, customer reference and optional bank reference.
+	mt940Statement = regexp.MustCompile(`^(\d{6})(\d{4})?(RD|RC|D|C)([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})([^/]*?)(?://(.*))?$`)
+	mt940Balance   = regexp.MustCompile(`^[DC]\d{6}([A-Z]{3})`)
+	mt940Subfield  = regexp.MustCompile(`\?\d{2}`)
+)
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+// mt940Fields splits a statement into its tagged fields, joining continuation lines.
+func mt940Fields(data []byte) []mt940Field {
+	var fields []mt940Field
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r ")
+
+		if match := mt940Tag.FindStringSubmatch(line); match != nil {
+			fields = append(fields, mt940Field{tag: match[1], value: match[2]})
+			continue
+		}
+
+		if line == "" || line == "-" || strings.HasPrefix(line, "-}") || strings.HasPrefix(line, "{") || len(fields) == 0 {
+			continue
+		}
+
+		fields[len(fields)-1].value += "\n" + line
+	}
+
+	return fields
+}
+
+// ParseMT940 returns the debits of a SWIFT MT940 customer statement.
+// MT940 carries booked entries only, interim entries come as MT942.
+func ParseMT940(data []byte) ([]models.BankTransaction, error) {
+	var txs []models.BankTransaction
+	var customerRef, bankRef string
+	var last *models.BankTransaction
+	currency := ""
+
+	flush := func() {
+		if last == nil {
+			return
+		}
+		last.Description = joinText(last.Description)
+		last.Ref = reference(bankRef, customerRef)
+		txs = append(txs, *last)
+		last = nil
+	}
+
+	for _, field := range mt940Fields(data) {
+		switch field.tag {
+		case "60F", "60M":
+			if match := mt940Balance.FindStringSubmatch(field.value); match != nil {
+				currency = match[1]
+			}
+		case "61":
+			flush()
+
+			lines := strings.SplitN(field.value, "\n", 2)
+			match := mt940Statement.FindStringSubmatch(lines[0])
+			if match == nil {
+				return nil, fmt.Errorf("invalid statement line %q", lines[0])
+			}
+
+			// Only debits and reversed credits take money out of the account.
+			if match[3] != "D" && match[3] != "RC" {
+				continue
+			}
+
+			date, err := mt940Date(match[1], match[2])
+			if err != nil {
+				return nil, err
+			}
+
+			amount, err := parseAmount(match[5])
+			if err != nil {
+				return nil, err
+			}
+
+			customerRef, bankRef = match[7], match[8]
+			last = &models.BankTransaction{
+				Date:     date,
+				Amount:   amount,
+				Currency: currency,
+			}
+			if len(lines) > 1 {
+				last.Description = lines[1]
+			}
+		case "86":
+			if last != nil {
+				info := mt940Subfield.ReplaceAllString(field.value, " ")
+				info = strings.ReplaceAll(info, "\n", "")
+				last.Description = joinText(info, last.Description)
+			}
+		case "62F", "62M":
+			flush()
+		}
+	}
+	flush()
+
+	fillReferences(FormatMT940, txs)
+	return txs, nil
+}
+
+// mt940Date returns the entry date when present, otherwise the value date.
+// The entry date has no year, so it is taken from the value date and
+// corrected when the two fall on different sides of new year.
+func mt940Date(valueDate, entryDate string) (time.Time, error) {
+	value, err := time.ParseInLocation("060102", valueDate, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if entryDate == "" {
+		return value, nil
+	}
+
+	entry, err := time.ParseInLocation("0102", entryDate, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	booked := time.Date(value.Year(), entry.Month(), entry.Day(), 0, 0, 0, 0, time.Local)
+	switch {
+	case booked.Sub(value) > 180*24*time.Hour:
+		booked = booked.AddDate(-1, 0, 0)
+	case value.Sub(booked) > 180*24*time.Hour:
+		booked = booked.AddDate(1, 0, 0)
+	}
+
+	return booked, nil
+}
@@ -0,0 +1,70 @@
+package bankimport
+
+import (
+	"expenser/internal/models"
+	"html"
+	"math"
+	"regexp"
+	"time"
+)
+
+var (
+	ofxTransaction = regexp.MustCompile(`(?s)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxCurrency    = regexp.MustCompile(`<CURDEF>\s*([A-Z]{3})`)
+)
+
+// ofxField reads a field from an OFX aggregate. It works for both the
+// SGML flavour of OFX 1.x, where leaf elements are not closed, and OFX 2.x XML.
+func ofxField(block, name string) string {
+	re := regexp.MustCompile(`<` + name + `>\s*([^<\r\n]*)`)
+	match := re.FindStringSubmatch(block)
+	if match == nil {
+		return ""
+	}
+	return html.UnescapeString(match[1])
+}
+
+// ParseOFX returns the debits of an OFX bank or credit card statement.
+// OFX statement transaction lists only carry posted transactions.
+func ParseOFX(data []byte) ([]models.BankTransaction, error) {
+	content := string(data)
+
+	currency := ""
+	if match := ofxCurrency.FindStringSubmatch(content); match != nil {
+		currency = match[1]
+	}
+
+	var txs []models.BankTransaction
+	for _, match := range ofxTransaction.FindAllStringSubmatch(content, -1) {
+		block := match[1]
+
+		amount, err := parseAmount(ofxField(block, "TRNAMT"))
+		if err != nil {
+			return nil, err
+		}
+		if amount >= 0 {
+			continue
+		}
+
+		posted := ofxField(block, "DTPOSTED")
+		if len(posted) < 8 {
+			continue
+		}
+		date, err := time.ParseInLocation("20060102", posted[:8], time.Local)
+		if err != nil {
+			return nil, err
+		}
+
+		tx := models.BankTransaction{
+			Date:        date,
+			Amount:      math.Abs(amount),
+			Currency:    currency,
+			Description: joinText(ofxField(block, "NAME"), ofxField(block, "MEMO")),
+		}
+		tx.Ref = reference(ofxField(block, "FITID"))
+		txs = append(txs, tx)
+	}
+
+	fillReferences(FormatOFX, txs)
+	return txs, nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,9 +15,17 @@ import (
 // Config struct to hold application configuration.
 type Config struct {
 	ServerPort string
+	BaseURL    string // BaseURL is where the app is reached, for links in emails.
 	DB         DB
 	JWT        JWT
+	SMTP       SMTP
+	Telegram   Telegram
+	OIDC       OIDC
 	Mode       string
+
+	// OutboundAllowedHosts are the hosts, IP addresses and CIDR networks webhooks
+	// and push channels may reach even though they are local or private.
+	OutboundAllowedHosts []string
 }
 
 // JWT holds JWT-related configuration
@@ -25,6 +34,49 @@ type JWT struct {
 	TokenExpiration time.Duration
 }
 
+// SMTP holds the outgoing mail server configuration. Emails aren't sent without a host.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Enabled reports whether a mail server is configured.
+func (s SMTP) Enabled() bool {
+	return s.Host != ""
+}
+
+// Telegram holds the bot users add and query expenses through. The bot doesn't
+// run without a token.
+type Telegram struct {
+	BotToken string
+	BotName  string // BotName is the bot's username, for linking to it.
+	APIURL   string // APIURL is the Bot API server, the official one unless set.
+}
+
+// Enabled reports whether a bot is configured.
+func (t Telegram) Enabled() bool {
+	return t.BotToken != ""
+}
+
+// OIDC holds the OpenID Connect provider users log in with. Single sign-on is
+// off without an issuer and a client.
+type OIDC struct {
+	IssuerURL        string
+	ClientID         string
+	ClientSecret     string
+	ProviderName     string // ProviderName labels the login button.
+	AllowSignup      bool   // AllowSignup creates an account for a new user of the provider.
+	DisablePasswords bool   // DisablePasswords leaves single sign-on and passkeys as the only logins.
+}
+
+// Enabled reports whether a provider is configured.
+func (o OIDC) Enabled() bool {
+	return o.IssuerURL != "" && o.ClientID != ""
+}
+
 type DB struct {
 	DBConnString     string
 	TestDBConnString string
@@ -64,6 +116,32 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// SMTP configuration, a MailHog-style server on localhost:1025 needs no credentials.
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587"
+	}
+
+	smtpFrom := os.Getenv("SMTP_FROM")
+	if smtpFrom == "" {
+		smtpFrom = "Expenser <expenser@localhost>"
+	}
+
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	telegramAPI := os.Getenv("TELEGRAM_API_URL")
+	if telegramAPI == "" {
+		telegramAPI = "https://api.telegram.org"
+	}
+
+	oidcName := os.Getenv("OIDC_PROVIDER_NAME")
+	if oidcName == "" {
+		oidcName = "SSO"
+	}
+
 	// Construct the database connection string.
 	dbConnString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		dbUser, dbPass, dbHost, dbPort, dbName)
@@ -79,12 +157,34 @@ func LoadConfig() (*Config, error) {
 
 	return &Config{
 		ServerPort: serverPort,
+		BaseURL:    strings.TrimRight(baseURL, "/"),
 		DB:         DB,
 		JWT: JWT{
 			SecretKey:       jwtSecret,
 			TokenExpiration: jwtExpiration,
 		},
-		Mode: mode,
+		SMTP: SMTP{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     smtpPort,
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			From:     smtpFrom,
+		},
+		Telegram: Telegram{
+			BotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+			BotName:  strings.TrimPrefix(os.Getenv("TELEGRAM_BOT_NAME"), "@"),
+			APIURL:   strings.TrimRight(telegramAPI, "/"),
+		},
+		OIDC: OIDC{
+			IssuerURL:        os.Getenv("OIDC_ISSUER_URL"),
+			ClientID:         os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret:     os.Getenv("OIDC_CLIENT_SECRET"),
+			ProviderName:     oidcName,
+			AllowSignup:      os.Getenv("OIDC_ALLOW_SIGNUP") != "false",
+			DisablePasswords: os.Getenv("OIDC_DISABLE_PASSWORDS") == "true",
+		},
+		Mode:                 mode,
+		OutboundAllowedHosts: strings.Split(os.Getenv("OUTBOUND_ALLOWED_HOSTS"), ","),
 	}, nil
 }
 
@@ -0,0 +1,360 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// accountMovements is every booked movement on any account with its sign:
+// expenses and outgoing transfers are negative, incomes and incoming transfers positive.
+const accountMovements = `
+	SELECT he.account_id, he.expense_date AS entry_date, 'House' AS source, ut.name AS description, he.notes, -he.amount AS amount
+		FROM home_expenses he
+		JOIN utility_types ut ON he.utility_type_id = ut.id
+		WHERE he.account_id IS NOT NULL
+	UNION ALL
+	SELECT ce.account_id, ce.expense_date, 'Car', ct.name, ce.notes, -ce.amount
+		FROM car_expenses ce
+		JOIN car_expense_types ct ON ce.car_expense_type_id = ct.id
+		WHERE ce.account_id IS NOT NULL
+	UNION ALL
+	SELECT i.account_id, i.income_date, 'Income', it.name, i.notes, i.amount
+		FROM incomes i
+		JOIN income_types it ON i.income_type_id = it.id
+		WHERE i.account_id IS NOT NULL
+	UNION ALL
+	SELECT t.from_account_id, t.transfer_date, 'Transfer', 'To ' || a.name, t.notes, -t.amount
+		FROM account_transfers t
+		JOIN accounts a ON t.to_account_id = a.id
+	UNION ALL
+	SELECT t.to_account_id, t.transfer_date, 'Transfer', 'From ' || a.name, t.notes, t.amount
+		FROM account_transfers t
+		JOIN accounts a ON t.from_account_id = a.id
+`
+
+// GetAccounts retrieves all accounts of a user with their current balances.
+func (db *DB) GetAccounts(userId uuid.UUID) (*[]models.Account, error) {
+	query := `
+		WITH movements AS (` + accountMovements + `)
+		SELECT
+			a.id, a.name, a.kind, a.opening_balance, a.created_by, a.created_at,
+			a.opening_balance + COALESCE(SUM(m.amount), 0) AS balance
+		FROM
+			accounts a
+		LEFT JOIN
+			movements m ON m.account_id = a.id
+		WHERE
+			a.created_by = $1
+		GROUP BY
+			a.id
+		ORDER BY
+			a.name;
+	`
+
+	rows, err := db.conn.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %v", err)
+	}
+	defer rows.Close()
+
+	var accounts []models.Account
+	for rows.Next() {
+		var acc models.Account
+		err = rows.Scan(&acc.ID,
+			&acc.Name,
+			&acc.Kind,
+			&acc.OpeningBalance,
+			&acc.CreatedBy,
+			&acc.CreatedAt,
+			&acc.Balance,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan accounts: %v", err)
+		}
+		accounts = append(accounts, acc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %v", err)
+	}
+
+	return &accounts, nil
+}
+
+// GetAccountByID retrieves an account with its current balance, returns nil when it doesn't exist.
+func (db *DB) GetAccountByID(id int) (*models.Account, error) {
+	query := `
+		SELECT id, name, kind, opening_balance, created_by, created_at
+			FROM accounts
+		WHERE id = $1;
+	`
+
+	var acc models.Account
+	err := db.conn.QueryRow(query, id).Scan(
+		&acc.ID,
+		&acc.Name,
+		&acc.Kind,
+		&acc.OpeningBalance,
+		&acc.CreatedBy,
+		&acc.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	acc.Balance, err = db.GetAccountBalanceBefore(acc.ID, time.Now().AddDate(100, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return &acc, nil
+}
+
+// GetAccountBalanceBefore returns the book balance of an account counting
+// every movement strictly before the given time.
+func (db *DB) GetAccountBalanceBefore(id int, before time.Time) (float64, error) {
+	query := `
+		WITH movements AS (` + accountMovements + `)
+		SELECT
+			a.opening_balance + COALESCE((
+				SELECT SUM(m.amount) FROM movements m
+				WHERE m.account_id = a.id AND m.entry_date < $2
+			), 0)
+		FROM accounts a
+		WHERE a.id = $1;
+	`
+
+	var balance float64
+	err := db.conn.QueryRow(query, id, before).Scan(&balance)
+	if err != nil {
+		return 0.00, fmt.Errorf("failed to get account balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+func (db *DB) CreateAccount(input *models.Account) error {
+	query := `
+		INSERT INTO accounts (name, kind, opening_balance, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.Name,
+		input.Kind,
+		input.OpeningBalance,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create account: %w", err)
+	}
+
+	input.Balance = input.OpeningBalance
+	return nil
+}
+
+func (db *DB) EditAccount(input *models.Account) error {
+	query := `
+		UPDATE accounts
+		SET
+			name = $2,
+			kind = $3,
+			opening_balance = $4
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.Name,
+		input.Kind,
+		input.OpeningBalance,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error editing account: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteAccount removes an account. Expenses and incomes linked to it are kept
+// but unlinked, transfers and reconciliations are removed with it.
+func (db *DB) DeleteAccount(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM accounts WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting account: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting account: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+func (db *DB) CreateTransfer(input *models.Transfer) error {
+	query := `
+		INSERT INTO account_transfers (from_account_id, to_account_id, amount, transfer_date, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at,
+			(SELECT name FROM accounts WHERE id = $1),
+			(SELECT name FROM accounts WHERE id = $2);
+	`
+
+	err := db.conn.QueryRow(query,
+		input.FromAccountID,
+		input.ToAccountID,
+		input.Amount,
+		input.Date,
+		input.Notes,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt, &input.FromAccount, &input.ToAccount)
+
+	if err != nil {
+		return fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccountStatement lists every movement on the account between from (inclusive)
+// and to (exclusive) with a running balance.
+func (db *DB) GetAccountStatement(account *models.Account, from, to time.Time) (*models.AccountStatement, error) {
+	opening, err := db.GetAccountBalanceBefore(account.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH movements AS (` + accountMovements + `)
+		SELECT entry_date, source, description, COALESCE(notes, ''), amount
+			FROM movements
+		WHERE
+			account_id = $1 AND entry_date >= $2 AND entry_date < $3
+		ORDER BY
+			entry_date ASC;
+	`
+
+	rows, err := db.conn.Query(query, account.ID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account statement: %v", err)
+	}
+	defer rows.Close()
+
+	statement := &models.AccountStatement{
+		Account:        account,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+	}
+
+	balance := opening
+	for rows.Next() {
+		var entry models.StatementEntry
+		var notes string
+		err = rows.Scan(&entry.Date,
+			&entry.Source,
+			&entry.Description,
+			&notes,
+			&entry.Amount,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account statement: %v", err)
+		}
+
+		if notes != "" {
+			entry.Description = fmt.Sprintf("%s: %s", entry.Description, notes)
+		}
+
+		balance += entry.Amount
+		entry.Balance = balance
+		statement.Entries = append(statement.Entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch account statement: %v", err)
+	}
+
+	statement.ClosingBalance = balance
+
+	statement.Reconciliation, err = db.GetLatestReconciliation(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+// CreateReconciliation stores the balance of a bank statement together with
+// the book balance at the end of the statement date.
+func (db *DB) CreateReconciliation(input *models.Reconciliation) error {
+	endOfDay := time.Date(input.StatementDate.Year(), input.StatementDate.Month(), input.StatementDate.Day()+1, 0, 0, 0, 0, input.StatementDate.Location())
+
+	bookBalance, err := db.GetAccountBalanceBefore(input.AccountID, endOfDay)
+	if err != nil {
+		return err
+	}
+	input.BookBalance = bookBalance
+
+	query := `
+		INSERT INTO account_reconciliations (account_id, statement_date, statement_balance, book_balance)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+
+	err = db.conn.QueryRow(query,
+		input.AccountID,
+		input.StatementDate,
+		input.StatementBalance,
+		input.BookBalance,
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestReconciliation returns the most recent reconciliation of an account or nil.
+func (db *DB) GetLatestReconciliation(accountID int) (*models.Reconciliation, error) {
+	query := `
+		SELECT id, account_id, statement_date, statement_balance, book_balance, created_at
+			FROM account_reconciliations
+		WHERE account_id = $1
+		ORDER BY statement_date DESC, id DESC
+		LIMIT 1;
+	`
+
+	var rec models.Reconciliation
+	err := db.conn.QueryRow(query, accountID).Scan(
+		&rec.ID,
+		&rec.AccountID,
+		&rec.StatementDate,
+		&rec.StatementBalance,
+		&rec.BookBalance,
+		&rec.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reconciliation: %w", err)
+	}
+
+	return &rec, nil
+}
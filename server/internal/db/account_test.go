@@ -0,0 +1,132 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAccount(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Create Account %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	type testCase struct {
+		name    string
+		input   *models.Account
+		wantErr bool
+	}
+
+	tests := []testCase{
+		{
+			name: "Valid",
+			input: &models.Account{
+				Name:           "Wallet",
+				Kind:           models.AccountCash,
+				OpeningBalance: 150.00,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid kind",
+			input: &models.Account{
+				Name: "Savings",
+				Kind: "piggy_bank",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetTestDB(testDB)
+			testDB.CreateUser(TestUserRegisterModel)
+
+			tt.input.CreatedBy = TestUserRegisterModel.ID
+			err := testDB.CreateAccount(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			got, err := testDB.GetAccountByID(tt.input.ID)
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+			assert.Equal(t, tt.input.Name, got.Name)
+			assert.Equal(t, tt.input.Kind, got.Kind)
+			assert.Equal(t, tt.input.OpeningBalance, got.Balance)
+		})
+	}
+}
+
+func TestAccountBalanceAndStatement(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Account Balance %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	bank := &models.Account{Name: "Bank", Kind: models.AccountBankAccount, OpeningBalance: 1000.00, CreatedBy: TestUserRegisterModel.ID}
+	cash := &models.Account{Name: "Cash", Kind: models.AccountCash, CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateAccount(bank))
+	assert.NoError(t, testDB.CreateAccount(cash))
+
+	day := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.Local)
+
+	assert.NoError(t, testDB.CreateIncome(&models.Income{
+		IncomeTypeID: 1,
+		Amount:       2000.00,
+		Date:         day,
+		CreatedBy:    TestUserRegisterModel.ID,
+		AccountID:    &bank.ID,
+	}))
+	assert.NoError(t, testDB.CreateHouseExpense(&models.HouseExpense{
+		UtilityTypeID: 1,
+		Amount:        300.00,
+		ExpenseDate:   day.AddDate(0, 0, 1),
+		CreatedBy:     TestUserRegisterModel.ID,
+		AccountID:     &bank.ID,
+	}))
+	assert.NoError(t, testDB.CreateTransfer(&models.Transfer{
+		FromAccountID: bank.ID,
+		ToAccountID:   cash.ID,
+		Amount:        200.00,
+		Date:          day.AddDate(0, 0, 2),
+		CreatedBy:     TestUserRegisterModel.ID,
+	}))
+
+	accounts, err := testDB.GetAccounts(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, *accounts, 2)
+	assert.Equal(t, 2500.00, (*accounts)[0].Balance)
+	assert.Equal(t, 200.00, (*accounts)[1].Balance)
+
+	statement, err := testDB.GetAccountStatement(bank, day.AddDate(0, 0, 1), day.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, 3000.00, statement.OpeningBalance)
+	assert.Len(t, statement.Entries, 2)
+	assert.Equal(t, "Transfer", statement.Entries[1].Source)
+	assert.Equal(t, 2500.00, statement.ClosingBalance)
+
+	rec := &models.Reconciliation{AccountID: bank.ID, StatementDate: day.AddDate(0, 0, 1), StatementBalance: 2690.00}
+	assert.NoError(t, testDB.CreateReconciliation(rec))
+	assert.Equal(t, 2700.00, rec.BookBalance)
+	assert.False(t, rec.Reconciled())
+	assert.Equal(t, -10.00, rec.Difference())
+}
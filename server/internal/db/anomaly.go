@@ -0,0 +1,165 @@
+package database
+
+import (
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetExpenseHistory retrieves the user's expenses of a tracker's expense type dated
+// from since to until, both days included, without the expense with excludeID.
+func (db *DB) GetExpenseHistory(userId uuid.UUID, tracker models.Tracker, typeID, excludeID int, since, until time.Time) ([]models.CategorisedExpense, error) {
+	query := `
+		SELECT id, utility_type_id, amount, expense_date
+		FROM home_expenses
+		WHERE created_by = $1 AND utility_type_id = $2 AND id <> $3 AND expense_date >= $4 AND expense_date <= $5
+		ORDER BY expense_date;
+	`
+	if tracker == models.TrackerCar {
+		query = `
+			SELECT id, car_expense_type_id, amount, expense_date
+			FROM car_expenses
+			WHERE created_by = $1 AND car_expense_type_id = $2 AND id <> $3 AND expense_date >= $4 AND expense_date <= $5
+			ORDER BY expense_date;
+		`
+	}
+
+	rows, err := db.conn.Query(query, userId, typeID, excludeID, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expense history: %v", err)
+	}
+	defer rows.Close()
+
+	var history []models.CategorisedExpense
+	for rows.Next() {
+		exp := models.CategorisedExpense{Tracker: tracker}
+		if err = rows.Scan(&exp.ID, &exp.TypeID, &exp.Amount, &exp.Date); err != nil {
+			return nil, fmt.Errorf("failed to scan expense history: %v", err)
+		}
+		history = append(history, exp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch expense history: %v", err)
+	}
+
+	return history, nil
+}
+
+// CreateExpenseAnomaly marks an expense as an anomaly. Marking it again is a no-op.
+func (db *DB) CreateExpenseAnomaly(input *models.ExpenseAnomaly) error {
+	query := `
+		INSERT INTO expense_anomalies (home_expense_id, car_expense_id, expected, score, seasonal, based_on, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at;
+	`
+
+	var homeExpenseID, carExpenseID *int
+	if input.Tracker == models.TrackerCar {
+		carExpenseID = &input.ExpenseID
+	} else {
+		homeExpenseID = &input.ExpenseID
+	}
+
+	rows, err := db.conn.Query(query,
+		homeExpenseID,
+		carExpenseID,
+		input.Expected,
+		input.Score,
+		input.Seasonal,
+		input.BasedOn,
+		input.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create expense anomaly: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err = rows.Scan(&input.ID, &input.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create expense anomaly: %w", err)
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetExpenseAnomalies retrieves the user's anomalies of a tracker that weren't
+// dismissed, newest expense first.
+func (db *DB) GetExpenseAnomalies(userId uuid.UUID, tracker models.Tracker) ([]models.ExpenseAnomaly, error) {
+	query := `
+		SELECT a.id, he.id, ut.name, he.expense_date, he.amount, a.expected, a.score, a.seasonal,
+			a.based_on, a.created_by, a.created_at
+		FROM expense_anomalies a
+		JOIN home_expenses he ON he.id = a.home_expense_id
+		JOIN utility_types ut ON ut.id = he.utility_type_id
+		WHERE a.created_by = $1 AND a.dismissed_at IS NULL
+		ORDER BY he.expense_date DESC, a.id DESC;
+	`
+	if tracker == models.TrackerCar {
+		query = `
+			SELECT a.id, ce.id, ct.name, ce.expense_date, ce.amount, a.expected, a.score, a.seasonal,
+				a.based_on, a.created_by, a.created_at
+			FROM expense_anomalies a
+			JOIN car_expenses ce ON ce.id = a.car_expense_id
+			JOIN car_expense_types ct ON ct.id = ce.car_expense_type_id
+			WHERE a.created_by = $1 AND a.dismissed_at IS NULL
+			ORDER BY ce.expense_date DESC, a.id DESC;
+		`
+	}
+
+	rows, err := db.conn.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expense anomalies: %v", err)
+	}
+	defer rows.Close()
+
+	var anomalies []models.ExpenseAnomaly
+	for rows.Next() {
+		anomaly := models.ExpenseAnomaly{Tracker: tracker}
+		err = rows.Scan(&anomaly.ID,
+			&anomaly.ExpenseID,
+			&anomaly.Type,
+			&anomaly.Date,
+			&anomaly.Amount,
+			&anomaly.Expected,
+			&anomaly.Score,
+			&anomaly.Seasonal,
+			&anomaly.BasedOn,
+			&anomaly.CreatedBy,
+			&anomaly.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expense anomalies: %v", err)
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch expense anomalies: %v", err)
+	}
+
+	return anomalies, nil
+}
+
+// DismissExpenseAnomaly hides one of the user's anomalies from the dashboard, returns
+// false when the user has no such anomaly.
+func (db *DB) DismissExpenseAnomaly(id int, userId uuid.UUID) (bool, error) {
+	res, err := db.conn.Exec(`
+		UPDATE expense_anomalies SET dismissed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND created_by = $2 AND dismissed_at IS NULL;
+	`, id, userId)
+	if err != nil {
+		return false, fmt.Errorf("error dismissing expense anomaly: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error dismissing expense anomaly: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
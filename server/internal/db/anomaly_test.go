@@ -0,0 +1,73 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpenseAnomalies(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Expense Anomalies %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	day := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		bill := &models.HouseExpense{UtilityTypeID: 2, Amount: 20, ExpenseDate: day.AddDate(0, i, 0), CreatedBy: TestUserRegisterModel.ID}
+		assert.NoError(t, testDB.CreateHouseExpense(bill))
+	}
+	gas := &models.HouseExpense{UtilityTypeID: 3, Amount: 80, ExpenseDate: day, CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateHouseExpense(gas))
+
+	doubled := &models.HouseExpense{UtilityTypeID: 2, Amount: 40, ExpenseDate: day.AddDate(0, 5, 0), CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateHouseExpense(doubled))
+
+	history, err := testDB.GetExpenseHistory(TestUserRegisterModel.ID, models.TrackerHouse, 2, doubled.ID, day.AddDate(-3, 0, 0), doubled.ExpenseDate)
+	assert.NoError(t, err)
+	assert.Len(t, history, 5)
+
+	anomaly := &models.ExpenseAnomaly{
+		Tracker:   models.TrackerHouse,
+		ExpenseID: doubled.ID,
+		Expected:  20,
+		Score:     13.49,
+		BasedOn:   5,
+		CreatedBy: TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateExpenseAnomaly(anomaly))
+	assert.NotZero(t, anomaly.ID)
+
+	// Checking the expense again doesn't mark it twice.
+	again := *anomaly
+	assert.NoError(t, testDB.CreateExpenseAnomaly(&again))
+
+	anomalies, err := testDB.GetExpenseAnomalies(TestUserRegisterModel.ID, models.TrackerHouse)
+	assert.NoError(t, err)
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, "Water", anomalies[0].Type)
+	assert.Equal(t, 40.0, anomalies[0].Amount)
+
+	cars, err := testDB.GetExpenseAnomalies(TestUserRegisterModel.ID, models.TrackerCar)
+	assert.NoError(t, err)
+	assert.Empty(t, cars)
+
+	ok, err := testDB.DismissExpenseAnomaly(anomaly.ID, TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	anomalies, err = testDB.GetExpenseAnomalies(TestUserRegisterModel.ID, models.TrackerHouse)
+	assert.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"expenser/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetImportedRefs returns which of the given bank references the user has already imported.
+func (db *DB) GetImportedRefs(userId uuid.UUID, refs []string) (map[string]bool, error) {
+	query := `
+		SELECT bank_ref FROM imported_transactions
+		WHERE created_by = $1 AND bank_ref = ANY($2);
+	`
+
+	rows, err := db.conn.Query(query, userId, pq.Array(refs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch imported transactions: %v", err)
+	}
+	defer rows.Close()
+
+	imported := make(map[string]bool)
+	for rows.Next() {
+		var ref string
+		if err = rows.Scan(&ref); err != nil {
+			return nil, fmt.Errorf("failed to scan imported transactions: %v", err)
+		}
+		imported[ref] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch imported transactions: %v", err)
+	}
+
+	return imported, nil
+}
+
+// ImportTransactions books the rows as house or car expenses in one transaction
+// and remembers their bank references. Rows whose reference was already imported
+// are skipped, the number of created expenses is returned and the id of each
+// created expense is set on its row.
+func (db *DB) ImportTransactions(userId uuid.UUID, accountID *int, rows []models.ImportRow) (int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start import: %w", err)
+	}
+	defer tx.Rollback()
+
+	houseQuery := `
+		INSERT INTO home_expenses (utility_type_id, amount, expense_date, notes, created_by, account_id, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;
+	`
+	carQuery := `
+		INSERT INTO car_expenses (car_expense_type_id, amount, expense_date, notes, created_by, account_id, tags, vehicle_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id;
+	`
+
+	imported := 0
+	for i := range rows {
+		row := &rows[i]
+		var exists bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM imported_transactions WHERE created_by = $1 AND bank_ref = $2)`,
+			userId, row.Ref,
+		).Scan(&exists)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check imported transaction: %w", err)
+		}
+		if exists {
+			continue
+		}
+
+		query := houseQuery
+		args := []any{row.ExpenseTypeID, row.Amount, row.Date, row.Description, userId, accountID, row.Tags}
+		if row.Tracker == models.TrackerCar {
+			query = carQuery
+			args = append(args, row.VehicleID)
+		}
+
+		var expenseID int
+		err = tx.QueryRow(query, args...).Scan(&expenseID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import transaction %s: %w", row.Ref, err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO imported_transactions (bank_ref, tracker, expense_id, created_by)
+			VALUES ($1, $2, $3, $4);
+		`, row.Ref, row.Tracker, expenseID, userId)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record imported transaction %s: %w", row.Ref, err)
+		}
+		row.ExpenseID = expenseID
+
+		imported++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return imported, nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateExpenseRule(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Create Expense Rule %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	fuel := &models.ExpenseRule{MatchText: "SHELL", Tracker: models.TrackerCar, ExpenseTypeID: 1, CreatedBy: TestUserRegisterModel.ID}
+	power := &models.ExpenseRule{MatchText: "ENERGO", Tracker: models.TrackerHouse, ExpenseTypeID: 1, CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateExpenseRule(fuel))
+	assert.NoError(t, testDB.CreateExpenseRule(power))
+
+	rules, err := testDB.GetExpenseRules(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, *rules, 2)
+	assert.Equal(t, "SHELL", (*rules)[0].MatchText)
+	assert.Equal(t, "Fuel", (*rules)[0].ExpenseType)
+	assert.Equal(t, "Electricity", (*rules)[1].ExpenseType)
+	assert.Less(t, (*rules)[0].Position, (*rules)[1].Position)
+
+	ok, err := testDB.DeleteExpenseRule(fuel.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	got, err := testDB.GetExpenseRuleByID(fuel.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestImportTransactions(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Import Transactions %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	date := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.Local)
+	rows := []models.ImportRow{
+		{
+			BankTransaction: models.BankTransaction{Ref: "BNK-1", Date: date, Amount: 85.40, Description: "SHELL"},
+			Tracker:         models.TrackerCar,
+			ExpenseTypeID:   1,
+		},
+		{
+			BankTransaction: models.BankTransaction{Ref: "BNK-2", Date: date, Amount: 120.00, Description: "ENERGO-PRO"},
+			Tracker:         models.TrackerHouse,
+			ExpenseTypeID:   1,
+		},
+	}
+
+	imported, err := testDB.ImportTransactions(TestUserRegisterModel.ID, nil, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	refs, err := testDB.GetImportedRefs(TestUserRegisterModel.ID, []string{"BNK-1", "BNK-2", "BNK-3"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"BNK-1": true, "BNK-2": true}, refs)
+
+	// Importing the same statement again must not create duplicates.
+	imported, err = testDB.ImportTransactions(TestUserRegisterModel.ID, nil, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, imported)
+
+	carExpenses, err := testDB.GetCarExpensesForYear(2025, TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, *carExpenses, 1)
+
+	houseExpenses, err := testDB.GetHouseExpensesForYear(2025, TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, *houseExpenses, 1)
+}
@@ -111,8 +111,14 @@ func (db *DB) GetCarExpenseByID(id int) (*models.CarExpense, error) {
 			ce.amount,
 			ce.expense_date,
 			ce.notes,
+			ce.tags,
 			ce.created_at,
-			ce.created_by
+			ce.created_by,
+			ce.account_id,
+			ce.car_expense_type_id,
+			ce.vehicle_id,
+			ce.odometer,
+			ce.fuel_litres
 		FROM
 			car_expenses ce
 		JOIN
@@ -130,8 +136,14 @@ func (db *DB) GetCarExpenseByID(id int) (*models.CarExpense, error) {
 		&expense.Amount,
 		&expense.Date,
 		&expense.Notes,
+		&expense.Tags,
 		&expense.CreatedAt,
-		&expense.CreatedBy)
+		&expense.CreatedBy,
+		&expense.AccountID,
+		&expense.ExpenseTypeID,
+		&expense.VehicleID,
+		&expense.Odometer,
+		&expense.FuelLitres)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -146,8 +158,8 @@ func (db *DB) GetCarExpenseByID(id int) (*models.CarExpense, error) {
 // Creates a new entry of a home expense. Automatically handles utility type FK.
 func (db *DB) CreateCarExpense(input *models.CarExpense) error {
 	query := `
-		INSERT INTO car_expenses (car_expense_type_id, amount, expense_date, notes, created_by)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO car_expenses (car_expense_type_id, amount, expense_date, notes, created_by, account_id, tags, vehicle_id, odometer, fuel_litres)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, (SELECT name FROM car_expense_types WHERE id = car_expense_type_id);
 	`
 
@@ -157,6 +169,11 @@ func (db *DB) CreateCarExpense(input *models.CarExpense) error {
 		input.Date,
 		input.Notes,
 		input.CreatedBy,
+		input.AccountID,
+		input.Tags,
+		input.VehicleID,
+		input.Odometer,
+		input.FuelLitres,
 	).Scan(&input.ID, &input.CreatedAt, &input.Type)
 
 	if err != nil {
@@ -168,7 +185,7 @@ func (db *DB) CreateCarExpense(input *models.CarExpense) error {
 
 func (db *DB) GetCarExpensesForMonth(month time.Month, year int, userId uuid.UUID) (*[]models.CarExpense, error) {
 	query := `
-		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.created_at
+		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.tags, ce.created_at
 			FROM car_expenses ce
 		JOIN
 			car_expense_types ct ON ce.car_expense_type_id = ct.id
@@ -201,6 +218,7 @@ func (db *DB) GetCarExpensesForMonth(month time.Month, year int, userId uuid.UUI
 			&exp.Amount,
 			&exp.Date,
 			&exp.Notes,
+			&exp.Tags,
 			&exp.CreatedAt,
 		)
 
@@ -215,7 +233,7 @@ func (db *DB) GetCarExpensesForMonth(month time.Month, year int, userId uuid.UUI
 
 func (db *DB) GetCarExpensesForYear(year int, userId uuid.UUID) (*[]models.CarExpense, error) {
 	query := `
-		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.created_at
+		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.tags, ce.created_at
 			FROM car_expenses ce
 		JOIN
 			car_expense_types ct ON ce.car_expense_type_id = ct.id
@@ -247,6 +265,7 @@ func (db *DB) GetCarExpensesForYear(year int, userId uuid.UUID) (*[]models.CarEx
 			&exp.Amount,
 			&exp.Date,
 			&exp.Notes,
+			&exp.Tags,
 			&exp.CreatedAt,
 		)
 
@@ -261,7 +280,7 @@ func (db *DB) GetCarExpensesForYear(year int, userId uuid.UUID) (*[]models.CarEx
 
 func (db *DB) GetCarExpensesByType(utility string, userId uuid.UUID) (*[]models.CarExpense, error) {
 	query := `
-		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.created_at
+		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.tags, ce.created_at
 			FROM car_expenses ce
 		JOIN
 			car_expense_types ct ON ce.car_expense_type_id = ct.id
@@ -290,6 +309,7 @@ func (db *DB) GetCarExpensesByType(utility string, userId uuid.UUID) (*[]models.
 			&exp.Amount,
 			&exp.Date,
 			&exp.Notes,
+			&exp.Tags,
 			&exp.CreatedAt,
 		)
 
@@ -309,9 +329,13 @@ func (db *DB) EditCarExpense(editExpense *models.CarExpense) error {
 			car_expense_type_id = $2,
 			amount = $3,
 			expense_date = $4,
-			notes = $5
+			notes = $5,
+			account_id = $6,
+			vehicle_id = $7,
+			odometer = $8,
+			fuel_litres = $9
 		WHERE id = $1
-		RETURNING (SELECT name FROM car_expense_types WHERE id = $2);
+		RETURNING (SELECT name FROM car_expense_types WHERE id = $2), tags;
 	`
 	err := db.conn.QueryRow(query,
 		editExpense.ID,
@@ -319,7 +343,11 @@ func (db *DB) EditCarExpense(editExpense *models.CarExpense) error {
 		editExpense.Amount,
 		editExpense.Date,
 		editExpense.Notes,
-	).Scan(&editExpense.Type)
+		editExpense.AccountID,
+		editExpense.VehicleID,
+		editExpense.Odometer,
+		editExpense.FuelLitres,
+	).Scan(&editExpense.Type, &editExpense.Tags)
 
 	if err != nil {
 		return fmt.Errorf("error editing car expense: %v", err)
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetCashFlowForYear returns income against combined house and car expenses
+// for every month of the given year. The running balance starts from everything
+// recorded before the year so it keeps adding up across years.
+func (db *DB) GetCashFlowForYear(year int, userId uuid.UUID) (*models.CashFlow, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+
+	openingQuery := `
+		SELECT
+			COALESCE((SELECT SUM(amount) FROM incomes WHERE created_by = $1 AND income_date < $2), 0)
+			- COALESCE((SELECT SUM(amount) FROM home_expenses WHERE created_by = $1 AND expense_date < $2), 0)
+			- COALESCE((SELECT SUM(amount) FROM car_expenses WHERE created_by = $1 AND expense_date < $2), 0)
+	`
+
+	var opening float64
+	err := db.conn.QueryRow(openingQuery, userId, start).Scan(&opening)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	incomeQuery := `
+		SELECT EXTRACT(MONTH FROM income_date)::int AS month, SUM(amount)
+			FROM incomes
+		WHERE
+			created_by = $1 AND EXTRACT(YEAR FROM income_date) = $2
+		GROUP BY month
+	`
+
+	income, err := db.sumByMonth(incomeQuery, userId, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly income: %w", err)
+	}
+
+	expenseQuery := `
+		SELECT month, SUM(amount) FROM (
+			SELECT EXTRACT(MONTH FROM expense_date)::int AS month, amount
+				FROM home_expenses
+			WHERE created_by = $1 AND EXTRACT(YEAR FROM expense_date) = $2
+			UNION ALL
+			SELECT EXTRACT(MONTH FROM expense_date)::int AS month, amount
+				FROM car_expenses
+			WHERE created_by = $1 AND EXTRACT(YEAR FROM expense_date) = $2
+		) AS expenses
+		GROUP BY month
+	`
+
+	expenses, err := db.sumByMonth(expenseQuery, userId, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly expenses: %w", err)
+	}
+
+	return buildCashFlow(year, opening, income, expenses), nil
+}
+
+// sumByMonth runs a query returning (month, amount) rows and maps them by month.
+func (db *DB) sumByMonth(query string, args ...any) (map[time.Month]float64, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sums := make(map[time.Month]float64)
+	for rows.Next() {
+		var month int
+		var amount float64
+		if err := rows.Scan(&month, &amount); err != nil {
+			return nil, err
+		}
+		sums[time.Month(month)] = amount
+	}
+
+	return sums, rows.Err()
+}
+
+func buildCashFlow(year int, opening float64, income, expenses map[time.Month]float64) *models.CashFlow {
+	cf := &models.CashFlow{
+		Year:           year,
+		OpeningBalance: opening,
+		Months:         make([]models.CashFlowMonth, 0, 12),
+	}
+
+	balance := opening
+	for m := time.January; m <= time.December; m++ {
+		net := income[m] - expenses[m]
+		balance += net
+
+		cf.Months = append(cf.Months, models.CashFlowMonth{
+			Month:       m,
+			Income:      income[m],
+			Expenses:    expenses[m],
+			Net:         net,
+			SavingsRate: savingsRate(income[m], net),
+			Balance:     balance,
+		})
+
+		cf.TotalIncome += income[m]
+		cf.TotalExpenses += expenses[m]
+	}
+
+	cf.TotalNet = cf.TotalIncome - cf.TotalExpenses
+	cf.SavingsRate = savingsRate(cf.TotalIncome, cf.TotalNet)
+
+	return cf
+}
+
+func savingsRate(income, net float64) float64 {
+	if income <= 0 {
+		return 0
+	}
+	return net / income * 100
+}
+
+// GetMonthBalances returns the income and expenses of the month starting on month
+// for every user who recorded an expense in it, for the reminders worker.
+func (db *DB) GetMonthBalances(month time.Time) ([]models.MonthBalance, error) {
+	query := `
+		SELECT created_by, SUM(income), SUM(expense) FROM (
+			SELECT created_by, 0 AS income, amount AS expense
+				FROM home_expenses
+			WHERE expense_date >= $1 AND expense_date < $2
+			UNION ALL
+			SELECT created_by, 0, amount
+				FROM car_expenses
+			WHERE expense_date >= $1 AND expense_date < $2
+			UNION ALL
+			SELECT created_by, amount, 0
+				FROM incomes
+			WHERE income_date >= $1 AND income_date < $2
+		) AS entries
+		GROUP BY created_by
+		HAVING SUM(expense) > 0
+		ORDER BY created_by;
+	`
+
+	rows, err := db.conn.Query(query, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch month balances: %v", err)
+	}
+	defer rows.Close()
+
+	var balances []models.MonthBalance
+	for rows.Next() {
+		balance := models.MonthBalance{Month: month}
+		if err = rows.Scan(&balance.UserID, &balance.Income, &balance.Expenses); err != nil {
+			return nil, fmt.Errorf("failed to scan month balances: %v", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch month balances: %v", err)
+	}
+
+	return balances, nil
+}
@@ -0,0 +1,310 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const chargingSessionColumns = `
+	s.id, s.vehicle_id, v.name, s.charged_on, s.kwh, s.duration_minutes, s.location,
+	s.tariff, s.cost, s.odometer, s.on_house_bill, s.car_expense_id, s.home_expense_id,
+	s.created_by, s.created_at, ce.account_id
+	FROM charging_sessions s
+	JOIN vehicles v ON v.id = s.vehicle_id
+	LEFT JOIN car_expenses ce ON ce.id = s.car_expense_id
+`
+
+func scanChargingSession(row interface{ Scan(...any) error }, session *models.ChargingSession) error {
+	return row.Scan(&session.ID,
+		&session.VehicleID,
+		&session.Vehicle,
+		&session.Date,
+		&session.KWh,
+		&session.DurationMinutes,
+		&session.Location,
+		&session.Tariff,
+		&session.Cost,
+		&session.Odometer,
+		&session.OnHouseBill,
+		&session.ExpenseID,
+		&session.HomeExpenseID,
+		&session.CreatedBy,
+		&session.CreatedAt,
+		&session.AccountID,
+	)
+}
+
+// GetChargingSessions retrieves a user's charging sessions from start to end,
+// both days included, newest first.
+func (db *DB) GetChargingSessions(userId uuid.UUID, start, end time.Time) ([]models.ChargingSession, error) {
+	query := `SELECT ` + chargingSessionColumns + `
+		WHERE s.created_by = $1 AND s.charged_on >= $2 AND s.charged_on <= $3
+		ORDER BY s.charged_on DESC, s.id DESC;
+	`
+
+	rows, err := db.conn.Query(query, userId, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch charging sessions: %v", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.ChargingSession
+	for rows.Next() {
+		var session models.ChargingSession
+		if err = scanChargingSession(rows, &session); err != nil {
+			return nil, fmt.Errorf("failed to scan charging sessions: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch charging sessions: %v", err)
+	}
+
+	return sessions, nil
+}
+
+// GetChargingSessionByID retrieves a charging session by Id, returns nil when it doesn't exist.
+func (db *DB) GetChargingSessionByID(id int) (*models.ChargingSession, error) {
+	query := `SELECT ` + chargingSessionColumns + `
+		WHERE s.id = $1;
+	`
+
+	var session models.ChargingSession
+	err := scanChargingSession(db.conn.QueryRow(query, id), &session)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get charging session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// syncChargingExpense keeps the car expense of a session in line with it: a session
+// paid separately gets a Charging expense with its cost, one on the house bill has none.
+func syncChargingExpense(tx *sql.Tx, session *models.ChargingSession) error {
+	if session.OnHouseBill {
+		if session.ExpenseID != nil {
+			if _, err := tx.Exec(`DELETE FROM car_expenses WHERE id = $1`, *session.ExpenseID); err != nil {
+				return err
+			}
+			session.ExpenseID = nil
+		}
+		return nil
+	}
+
+	if session.ExpenseID != nil {
+		res, err := tx.Exec(`
+			UPDATE car_expenses
+			SET amount = $2, expense_date = $3, notes = $4, vehicle_id = $5, odometer = $6, account_id = $7
+			WHERE id = $1;
+		`, *session.ExpenseID, session.Cost, session.Date, session.ExpenseNotes(), session.VehicleID, session.Odometer, session.AccountID)
+		if err != nil {
+			return err
+		}
+
+		rowCount, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowCount > 0 {
+			return nil
+		}
+	}
+
+	// The session is new, was on the house bill or its expense was deleted.
+	var expenseID int
+	err := tx.QueryRow(`
+		INSERT INTO car_expenses (car_expense_type_id, amount, expense_date, notes, created_by, vehicle_id, odometer, account_id)
+		VALUES ((SELECT id FROM car_expense_types WHERE name = $1), $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id;
+	`, models.ChargingExpenseType,
+		session.Cost,
+		session.Date,
+		session.ExpenseNotes(),
+		session.CreatedBy,
+		session.VehicleID,
+		session.Odometer,
+		session.AccountID,
+	).Scan(&expenseID)
+	if err != nil {
+		return err
+	}
+
+	session.ExpenseID = &expenseID
+	return nil
+}
+
+// CreateChargingSession adds a charging session and the car expense it rolls up into.
+func (db *DB) CreateChargingSession(input *models.ChargingSession) error {
+	query := `
+		INSERT INTO charging_sessions (vehicle_id, charged_on, kwh, duration_minutes, location, tariff, cost,
+			odometer, on_house_bill, car_expense_id, home_expense_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at;
+	`
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create charging session: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = syncChargingExpense(tx, input); err != nil {
+		return fmt.Errorf("failed to create charging expense: %w", err)
+	}
+
+	err = tx.QueryRow(query,
+		input.VehicleID,
+		input.Date,
+		input.KWh,
+		input.DurationMinutes,
+		input.Location,
+		input.Tariff,
+		input.Cost,
+		input.Odometer,
+		input.OnHouseBill,
+		input.ExpenseID,
+		input.HomeExpenseID,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create charging session: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create charging session: %w", err)
+	}
+
+	return nil
+}
+
+// EditChargingSession updates a charging session and its car expense. ExpenseID
+// must be the one of the session as stored.
+func (db *DB) EditChargingSession(input *models.ChargingSession) error {
+	query := `
+		UPDATE charging_sessions
+		SET
+			vehicle_id = $2,
+			charged_on = $3,
+			kwh = $4,
+			duration_minutes = $5,
+			location = $6,
+			tariff = $7,
+			cost = $8,
+			odometer = $9,
+			on_house_bill = $10,
+			car_expense_id = $11,
+			home_expense_id = $12
+		WHERE id = $1;
+	`
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("error editing charging session: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err = syncChargingExpense(tx, input); err != nil {
+		return fmt.Errorf("error editing charging expense: %v", err)
+	}
+
+	_, err = tx.Exec(query,
+		input.ID,
+		input.VehicleID,
+		input.Date,
+		input.KWh,
+		input.DurationMinutes,
+		input.Location,
+		input.Tariff,
+		input.Cost,
+		input.Odometer,
+		input.OnHouseBill,
+		input.ExpenseID,
+		input.HomeExpenseID,
+	)
+	if err != nil {
+		return fmt.Errorf("error editing charging session: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error editing charging session: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteChargingSession deletes a charging session with the car expense it rolled up into.
+func (db *DB) DeleteChargingSession(id int) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error deleting charging session: %v", err)
+	}
+	defer tx.Rollback()
+
+	var expenseID *int
+	err = tx.QueryRow(`DELETE FROM charging_sessions WHERE id = $1 RETURNING car_expense_id`, id).Scan(&expenseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error deleting charging session: %v", err)
+	}
+
+	if expenseID != nil {
+		if _, err = tx.Exec(`DELETE FROM car_expenses WHERE id = $1`, *expenseID); err != nil {
+			return false, fmt.Errorf("error deleting charging expense: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error deleting charging session: %v", err)
+	}
+
+	return true, nil
+}
+
+// GetElectricityBills retrieves the user's electricity home expenses from since on,
+// newest first, plus the one with includeID when given, for home charging sessions.
+func (db *DB) GetElectricityBills(userId uuid.UUID, since time.Time, includeID *int) ([]models.HouseExpense, error) {
+	query := `
+		SELECT he.id, ut.name, he.amount, he.expense_date, he.notes
+		FROM home_expenses he
+		JOIN utility_types ut ON he.utility_type_id = ut.id
+		WHERE he.created_by = $1 AND ut.name = 'Electricity' AND (he.expense_date >= $2 OR he.id = $3)
+		ORDER BY he.expense_date DESC, he.id DESC;
+	`
+
+	rows, err := db.conn.Query(query, userId, since, includeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch electricity bills: %v", err)
+	}
+	defer rows.Close()
+
+	var bills []models.HouseExpense
+	for rows.Next() {
+		var bill models.HouseExpense
+		err = rows.Scan(&bill.ID,
+			&bill.UtilityType,
+			&bill.Amount,
+			&bill.ExpenseDate,
+			&bill.Notes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan electricity bills: %v", err)
+		}
+		bills = append(bills, bill)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch electricity bills: %v", err)
+	}
+
+	return bills, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChargingSessions(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Charging Sessions %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	vehicle := &models.Vehicle{Name: "Leaf", PlateNumber: "CB1234AB", CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateVehicle(vehicle))
+
+	day := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	odometer := 12000
+	public := &models.ChargingSession{
+		VehicleID: vehicle.ID,
+		Date:      day,
+		KWh:       25,
+		Location:  models.ChargingPublic,
+		Tariff:    "Eldrive",
+		Cost:      17.5,
+		Odometer:  &odometer,
+		CreatedBy: TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateChargingSession(public))
+	assert.NotNil(t, public.ExpenseID)
+
+	expense, err := testDB.GetCarExpenseByID(*public.ExpenseID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ChargingExpenseType, expense.Type)
+	assert.Equal(t, 17.5, expense.Amount)
+	assert.Equal(t, vehicle.ID, *expense.VehicleID)
+
+	bill := &models.HouseExpense{UtilityTypeID: 1, Amount: 140, ExpenseDate: day, CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateHouseExpense(bill))
+
+	later := 12300
+	home := &models.ChargingSession{
+		VehicleID:     vehicle.ID,
+		Date:          day.AddDate(0, 0, 3),
+		KWh:           30,
+		Location:      models.ChargingHome,
+		Cost:          4.5,
+		Odometer:      &later,
+		OnHouseBill:   true,
+		HomeExpenseID: &bill.ID,
+		CreatedBy:     TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateChargingSession(home))
+	assert.Nil(t, home.ExpenseID)
+
+	// Readings of sessions on the house bill count for the distance too.
+	readings, err := testDB.GetOdometerReadings(vehicle.ID)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 2)
+
+	public.Cost = 20
+	assert.NoError(t, testDB.EditChargingSession(public))
+	expense, err = testDB.GetCarExpenseByID(*public.ExpenseID)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, expense.Amount)
+
+	// Paying the home session separately gives it a car expense.
+	home.OnHouseBill = false
+	home.HomeExpenseID = nil
+	assert.NoError(t, testDB.EditChargingSession(home))
+	assert.NotNil(t, home.ExpenseID)
+
+	sessions, err := testDB.GetChargingSessions(TestUserRegisterModel.ID, day, day.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	stats := models.SummarizeCharging(vehicle.Name, sessions, 300)
+	assert.Equal(t, 55.0, stats.KWh)
+	assert.Equal(t, "18.3", stats.KWhPer100Km())
+
+	ok, err := testDB.DeleteChargingSession(public.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	expense, err = testDB.GetCarExpenseByID(*public.ExpenseID)
+	assert.NoError(t, err)
+	assert.Nil(t, expense)
+}
@@ -28,7 +28,7 @@ func InitTestDB(cfg *config.Config) *DB {
 }
 
 func ResetTestDB(tdb *DB) {
-	_, err := tdb.conn.Exec(`TRUNCATE home_expenses, car_expenses, users RESTART IDENTITY CASCADE`)
+	_, err := tdb.conn.Exec(`TRUNCATE sessions, oidc_identities, passkeys, recovery_codes, password_resets, telegram_links, webhook_deliveries, webhook_endpoints, notification_channels, notification_settings, notifications, expense_anomalies, meter_readings, utility_tariffs, vehicle_valuations, charging_sessions, trips, vehicle_documents, service_plans, vehicles, imported_receipts, imported_transactions, expense_rules, home_expenses, car_expenses, incomes, account_transfers, account_reconciliations, accounts, users RESTART IDENTITY CASCADE`)
 	if err != nil {
 		log.Printf("\n Failed to truncate test DB; \n err: %v \n", err)
 	}
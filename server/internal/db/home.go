@@ -112,7 +112,10 @@ func (db *DB) GetHouseExpenseByID(id int) (*models.HouseExpense, error) {
 			he.amount,
 			he.expense_date,
 			he.notes,
-			he.created_at
+			he.tags,
+			he.created_at,
+			he.created_by,
+			he.account_id
 		FROM
 			home_expenses he
 		JOIN
@@ -130,7 +133,10 @@ func (db *DB) GetHouseExpenseByID(id int) (*models.HouseExpense, error) {
 		&expense.Amount,
 		&expense.ExpenseDate,
 		&expense.Notes,
-		&expense.CreatedAt)
+		&expense.Tags,
+		&expense.CreatedAt,
+		&expense.CreatedBy,
+		&expense.AccountID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -145,8 +151,8 @@ func (db *DB) GetHouseExpenseByID(id int) (*models.HouseExpense, error) {
 // Creates a new entry of a home expense. Automatically handles utility type FK.
 func (db *DB) CreateHouseExpense(input *models.HouseExpense) error {
 	query := `
-		INSERT INTO home_expenses (utility_type_id, amount, expense_date, notes, created_by)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO home_expenses (utility_type_id, amount, expense_date, notes, created_by, account_id, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, (SELECT name FROM utility_types WHERE id = utility_type_id);
 	`
 
@@ -156,6 +162,8 @@ func (db *DB) CreateHouseExpense(input *models.HouseExpense) error {
 		input.ExpenseDate,
 		input.Notes,
 		input.CreatedBy,
+		input.AccountID,
+		input.Tags,
 	).Scan(&input.ID, &input.CreatedAt, &input.UtilityType)
 
 	if err != nil {
@@ -167,7 +175,7 @@ func (db *DB) CreateHouseExpense(input *models.HouseExpense) error {
 
 func (db *DB) GetHouseExpensesForMonth(month time.Month, year int, userId uuid.UUID) (*[]models.HouseExpense, error) {
 	query := `
-		SELECT he.id, ut.name, he.amount, he.expense_date, he.notes, he.created_at, he.created_by
+		SELECT he.id, ut.name, he.amount, he.expense_date, he.notes, he.tags, he.created_at, he.created_by
 			FROM home_expenses he
 		JOIN
 			utility_types ut ON he.utility_type_id = ut.id
@@ -200,6 +208,7 @@ func (db *DB) GetHouseExpensesForMonth(month time.Month, year int, userId uuid.U
 			&exp.Amount,
 			&exp.ExpenseDate,
 			&exp.Notes,
+			&exp.Tags,
 			&exp.CreatedAt,
 			&exp.CreatedBy,
 		)
@@ -216,7 +225,7 @@ func (db *DB) GetHouseExpensesForMonth(month time.Month, year int, userId uuid.U
 func (db *DB) GetHouseExpensesForYear(year int, userId uuid.UUID) (*[]models.HouseExpense, error) {
 	query := `
 		SELECT
-			he.id, ut.name, he.amount, he.expense_date, he.notes, he.created_at, he.created_by
+			he.id, ut.name, he.amount, he.expense_date, he.notes, he.tags, he.created_at, he.created_by
 			FROM home_expenses he
 		JOIN 
 			utility_types ut ON he.utility_type_id = ut.id
@@ -246,6 +255,7 @@ func (db *DB) GetHouseExpensesForYear(year int, userId uuid.UUID) (*[]models.Hou
 			&exp.Amount,
 			&exp.ExpenseDate,
 			&exp.Notes,
+			&exp.Tags,
 			&exp.CreatedAt,
 			&exp.CreatedBy,
 		)
@@ -347,7 +357,8 @@ func (db *DB) EditHouseExpense(editExpense *models.HouseExpense) error {
 			utility_type_id = $2,
 			amount = $3,
 			expense_date = $4,
-			notes = $5
+			notes = $5,
+			account_id = $6
 		WHERE id = $1
 		RETURNING (SELECT name FROM utility_types WHERE id = $2);
 	`
@@ -357,6 +368,7 @@ func (db *DB) EditHouseExpense(editExpense *models.HouseExpense) error {
 		editExpense.Amount,
 		editExpense.ExpenseDate,
 		editExpense.Notes,
+		editExpense.AccountID,
 	).Scan(&editExpense.UtilityType)
 
 	if err != nil {
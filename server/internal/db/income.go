@@ -0,0 +1,286 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) GetIncomeTypes() (*[]models.IncomeType, error) {
+	query := `
+		SELECT id, name FROM income_types
+		ORDER BY id
+		`
+	var incomeTypes []models.IncomeType
+	rows, err := db.conn.Query(query)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch income types: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var incType models.IncomeType
+		err = rows.Scan(&incType.ID,
+			&incType.Name,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan income types: %v", err)
+		}
+		incomeTypes = append(incomeTypes, incType)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch income types: %v", err)
+	}
+
+	return &incomeTypes, nil
+}
+
+func (db *DB) GetTotalIncomeForMonth(date time.Time, userId uuid.UUID) (float64, error) {
+	query := `
+		SELECT SUM(amount) FROM incomes
+		WHERE EXTRACT(MONTH FROM income_date) = $1 AND EXTRACT(YEAR FROM income_date) = $2 AND created_by = $3
+		`
+
+	var totalAmount sql.NullFloat64
+	err := db.conn.QueryRow(query,
+		int(date.Month()),
+		date.Year(),
+		userId,
+	).Scan(&totalAmount)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0.00, nil
+		}
+		return 0.00, fmt.Errorf("failed to get total income: %w", err)
+	}
+
+	return totalAmount.Float64, nil
+}
+
+func (db *DB) GetHighestIncomeForMonth(date time.Time, userId uuid.UUID) (float64, string, error) {
+	query := `
+		SELECT
+			SUM(i.amount) AS amount,
+			it.name
+		FROM
+			incomes i
+		JOIN
+			income_types it ON i.income_type_id = it.id
+		WHERE
+			EXTRACT(MONTH FROM i.income_date) = $1 AND EXTRACT(YEAR FROM i.income_date) = $2 AND i.created_by = $3
+		GROUP BY
+			it.name
+		ORDER BY
+			amount DESC
+		LIMIT 1;
+	`
+
+	var highestIncome sql.NullFloat64
+	var incType string
+
+	err := db.conn.QueryRow(query,
+		int(date.Month()),
+		date.Year(),
+		userId,
+	).Scan(&highestIncome, &incType)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0.00, "", nil
+		}
+		return 0.00, "", fmt.Errorf("failed to get highest income: %w", err)
+	}
+
+	return highestIncome.Float64, incType, nil
+}
+
+// GetIncomeByID retrieves an income by Id, returns nil when it doesn't exist.
+func (db *DB) GetIncomeByID(id int) (*models.Income, error) {
+	query := `
+		SELECT
+			i.id,
+			i.income_type_id,
+			it.name AS type,
+			i.amount,
+			i.income_date,
+			i.notes,
+			i.created_at,
+			i.created_by,
+			i.account_id
+		FROM
+			incomes i
+		JOIN
+			income_types it ON i.income_type_id = it.id
+		WHERE
+			i.id = $1;
+	`
+
+	var income models.Income
+	err := db.conn.QueryRow(query,
+		id,
+	).Scan(
+		&income.ID,
+		&income.IncomeTypeID,
+		&income.Type,
+		&income.Amount,
+		&income.Date,
+		&income.Notes,
+		&income.CreatedAt,
+		&income.CreatedBy,
+		&income.AccountID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get income: %w", err)
+	}
+
+	return &income, nil
+}
+
+// CreateIncome creates a new income entry. Automatically handles income type FK.
+func (db *DB) CreateIncome(input *models.Income) error {
+	query := `
+		INSERT INTO incomes (income_type_id, amount, income_date, notes, created_by, account_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, (SELECT name FROM income_types WHERE id = income_type_id);
+	`
+
+	err := db.conn.QueryRow(query,
+		input.IncomeTypeID,
+		input.Amount,
+		input.Date,
+		input.Notes,
+		input.CreatedBy,
+		input.AccountID,
+	).Scan(&input.ID, &input.CreatedAt, &input.Type)
+
+	if err != nil {
+		return fmt.Errorf("failed to create income: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) GetIncomesForMonth(month time.Month, year int, userId uuid.UUID) (*[]models.Income, error) {
+	query := `
+		SELECT i.id, it.name, i.amount, i.income_date, i.notes, i.created_at
+			FROM incomes i
+		JOIN
+			income_types it ON i.income_type_id = it.id
+		WHERE
+			EXTRACT(MONTH FROM i.income_date) = $1 AND EXTRACT(YEAR FROM i.income_date) = $2 AND i.created_by = $3
+		ORDER BY
+			i.income_date DESC;
+	`
+
+	return db.queryIncomes(query, int(month), year, userId)
+}
+
+func (db *DB) GetIncomesForYear(year int, userId uuid.UUID) (*[]models.Income, error) {
+	query := `
+		SELECT i.id, it.name, i.amount, i.income_date, i.notes, i.created_at
+			FROM incomes i
+		JOIN
+			income_types it ON i.income_type_id = it.id
+		WHERE
+			EXTRACT(YEAR FROM i.income_date) = $1 AND i.created_by = $2
+		ORDER BY
+			i.income_date DESC;
+	`
+
+	return db.queryIncomes(query, year, userId)
+}
+
+func (db *DB) queryIncomes(query string, args ...any) (*[]models.Income, error) {
+	var incomes []models.Income
+	rows, err := db.conn.Query(query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch incomes: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inc models.Income
+		err = rows.Scan(&inc.ID,
+			&inc.Type,
+			&inc.Amount,
+			&inc.Date,
+			&inc.Notes,
+			&inc.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incomes: %v", err)
+		}
+		incomes = append(incomes, inc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch incomes: %v", err)
+	}
+
+	return &incomes, nil
+}
+
+func (db *DB) EditIncome(editIncome *models.Income) error {
+	query := `
+		UPDATE incomes
+		SET
+			income_type_id = $2,
+			amount = $3,
+			income_date = $4,
+			notes = $5,
+			account_id = $6
+		WHERE id = $1
+		RETURNING (SELECT name FROM income_types WHERE id = $2);
+	`
+	err := db.conn.QueryRow(query,
+		editIncome.ID,
+		editIncome.IncomeTypeID,
+		editIncome.Amount,
+		editIncome.Date,
+		editIncome.Notes,
+		editIncome.AccountID,
+	).Scan(&editIncome.Type)
+
+	if err != nil {
+		return fmt.Errorf("error editing income: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteIncome(id int) (bool, error) {
+	query := `
+		DELETE FROM incomes
+		WHERE id = $1`
+
+	res, err := db.conn.Exec(query,
+		id,
+	)
+
+	if err != nil {
+		return false, fmt.Errorf("error deleting income: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting income: %v", err)
+	}
+
+	if rowCount < 1 {
+		return false, nil
+	}
+
+	return true, nil
+}
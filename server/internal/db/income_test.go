@@ -0,0 +1,303 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateIncome(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Create Income %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	type testCase struct {
+		name     string
+		input    *models.Income
+		wantErr  bool
+		validate func(t *testing.T, got *models.Income)
+	}
+
+	incomeDate := time.Now()
+	tests := []testCase{
+		{
+			name: "Valid",
+			input: &models.Income{
+				Amount:       2500.00,
+				Date:         incomeDate,
+				IncomeTypeID: 1,
+				Notes:        "Salary",
+			},
+			wantErr: false,
+			validate: func(t *testing.T, got *models.Income) {
+				assert.Equal(t, 2500.00, got.Amount)
+				assert.Equal(t, incomeDate.Local().Round(time.Second), got.Date.Local().Round(time.Second))
+				assert.Equal(t, "Salary", got.Type)
+				assert.Equal(t, "Salary", got.Notes)
+				assert.Equal(t, TestUserRegisterModel.ID, got.CreatedBy)
+			},
+		},
+		{
+			name: "Invalid income type",
+			input: &models.Income{
+				Amount:       250.00,
+				Date:         incomeDate,
+				IncomeTypeID: -1,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetTestDB(testDB)
+			testDB.CreateUser(TestUserRegisterModel)
+
+			tt.input.CreatedBy = TestUserRegisterModel.ID
+			err := testDB.CreateIncome(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			got, err := testDB.GetIncomeByID(tt.input.ID)
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+			tt.validate(t, got)
+		})
+	}
+}
+
+func TestEditIncome(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Edit Income %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	incomeDate := time.Now()
+	initial := &models.Income{
+		Amount:       100.00,
+		Date:         incomeDate.AddDate(0, 0, 1),
+		IncomeTypeID: 1,
+		CreatedBy:    TestUserRegisterModel.ID,
+	}
+	err = testDB.CreateIncome(initial)
+	assert.NoError(t, err)
+
+	edit := &models.Income{
+		ID:           initial.ID,
+		Amount:       300.00,
+		Date:         incomeDate,
+		IncomeTypeID: 3,
+		Notes:        "Returned parcel",
+	}
+	err = testDB.EditIncome(edit)
+	assert.NoError(t, err)
+	assert.Equal(t, "Refund", edit.Type)
+
+	got, err := testDB.GetIncomeByID(initial.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 300.00, got.Amount)
+	assert.Equal(t, "Refund", got.Type)
+	assert.Equal(t, "Returned parcel", got.Notes)
+}
+
+func TestGetTotalIncomeForMonth(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Get Total Income Month %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	type testCase struct {
+		name     string
+		incomes  []models.Income
+		expTotal float64
+		expType  string
+	}
+
+	incomeDate := time.Now()
+	tests := []testCase{
+		{
+			name: "Incomes for month",
+			incomes: []models.Income{
+				{Amount: 2000.00, Date: incomeDate, IncomeTypeID: 1},
+				{Amount: 400.00, Date: incomeDate, IncomeTypeID: 2},
+				{Amount: 900.00, Date: incomeDate.AddDate(0, -1, 0), IncomeTypeID: 2},
+			},
+			expTotal: 2400.00,
+			expType:  "Salary",
+		},
+		{
+			name: "No incomes for month",
+			incomes: []models.Income{
+				{Amount: 900.00, Date: incomeDate.AddDate(0, -1, 0), IncomeTypeID: 2},
+			},
+			expTotal: 0.00,
+			expType:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetTestDB(testDB)
+			testDB.CreateUser(TestUserRegisterModel)
+
+			for i := range tt.incomes {
+				tt.incomes[i].CreatedBy = TestUserRegisterModel.ID
+				err := testDB.CreateIncome(&tt.incomes[i])
+				if err != nil {
+					t.Skipf("Error setting up income test: %v", err)
+				}
+			}
+
+			total, err := testDB.GetTotalIncomeForMonth(incomeDate, TestUserRegisterModel.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expTotal, total)
+
+			_, incType, err := testDB.GetHighestIncomeForMonth(incomeDate, TestUserRegisterModel.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expType, incType)
+		})
+	}
+}
+
+func TestDeleteIncome(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Delete Income %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	type testCase struct {
+		name     string
+		setup    func(t *testing.T) int
+		validate func(t *testing.T, got bool)
+	}
+
+	tests := []testCase{
+		{
+			name: "Has existing income",
+			setup: func(t *testing.T) int {
+				income := &models.Income{
+					Amount:       250.00,
+					Date:         time.Now(),
+					IncomeTypeID: 1,
+					CreatedBy:    TestUserRegisterModel.ID,
+				}
+				err := testDB.CreateIncome(income)
+				if err != nil {
+					t.Skipf("Error setting up deleting existing income test: %v", err)
+				}
+				return income.ID
+			},
+			validate: func(t *testing.T, got bool) {
+				assert.True(t, got)
+			},
+		},
+		{
+			name: "No existing income",
+			setup: func(t *testing.T) int {
+				return 15000
+			},
+			validate: func(t *testing.T, got bool) {
+				assert.False(t, got)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetTestDB(testDB)
+			testDB.CreateUser(TestUserRegisterModel)
+
+			got, err := testDB.DeleteIncome(tt.setup(t))
+			assert.NoError(t, err)
+			tt.validate(t, got)
+		})
+	}
+}
+
+func TestGetCashFlowForYear(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Get Cash Flow %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+	userID := TestUserRegisterModel.ID
+
+	year := time.Now().Year()
+	march := time.Date(year, time.March, 10, 12, 0, 0, 0, time.Local)
+
+	setup := []error{
+		testDB.CreateIncome(&models.Income{Amount: 100.00, Date: march.AddDate(-1, 0, 0), IncomeTypeID: 1, CreatedBy: userID}),
+		testDB.CreateIncome(&models.Income{Amount: 2000.00, Date: march, IncomeTypeID: 1, CreatedBy: userID}),
+		testDB.CreateHouseExpense(&models.HouseExpense{Amount: 300.00, ExpenseDate: march, UtilityTypeID: 1, CreatedBy: userID}),
+		testDB.CreateCarExpense(&models.CarExpense{Amount: 200.00, Date: march, ExpenseTypeID: 1, CreatedBy: userID}),
+		testDB.CreateCarExpense(&models.CarExpense{Amount: 50.00, Date: march.AddDate(0, 1, 0), ExpenseTypeID: 1, CreatedBy: userID}),
+	}
+	for _, err := range setup {
+		if err != nil {
+			t.Skipf("Error setting up cash flow test: %v", err)
+		}
+	}
+
+	got, err := testDB.GetCashFlowForYear(year, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.00, got.OpeningBalance)
+	assert.Len(t, got.Months, 12)
+
+	mar := got.Months[time.March-1]
+	assert.Equal(t, 2000.00, mar.Income)
+	assert.Equal(t, 500.00, mar.Expenses)
+	assert.Equal(t, 1500.00, mar.Net)
+	assert.Equal(t, 75.00, mar.SavingsRate)
+	assert.Equal(t, 1600.00, mar.Balance)
+
+	apr := got.Months[time.April-1]
+	assert.Equal(t, -50.00, apr.Net)
+	assert.Equal(t, 1550.00, apr.Balance)
+}
+
+func TestBuildCashFlow(t *testing.T) {
+	income := map[time.Month]float64{time.January: 1000.00}
+	expenses := map[time.Month]float64{time.January: 250.00, time.February: 100.00}
+
+	got := buildCashFlow(2025, 50.00, income, expenses)
+
+	assert.Equal(t, 800.00, got.Months[0].Balance)
+	assert.Equal(t, 75.00, got.Months[0].SavingsRate)
+	assert.Equal(t, 0.00, got.Months[1].SavingsRate)
+	assert.Equal(t, 700.00, got.Months[11].Balance)
+	assert.Equal(t, 650.00, got.TotalNet)
+	assert.Equal(t, 65.00, got.SavingsRate)
+}
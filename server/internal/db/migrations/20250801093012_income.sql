@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- 1. Create income_types lookup table
+CREATE TABLE IF NOT EXISTS income_types (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL
+);
+
+-- 2. Insert initial data into income_types
+INSERT INTO income_types (name) VALUES
+    ('Salary'),
+    ('Rent Received'),
+    ('Refund'),
+    ('Other');
+
+-- 3. Create incomes table with foreign key
+CREATE TABLE IF NOT EXISTS incomes (
+    id SERIAL PRIMARY KEY,
+    income_type_id INTEGER NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    income_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_income_type
+        FOREIGN KEY (income_type_id) REFERENCES income_types(id),
+
+    CONSTRAINT fk_incomes_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS incomes;
+
+DROP TABLE IF EXISTS income_types;
@@ -0,0 +1,82 @@
+-- +goose Up
+
+-- 1. Create accounts table, one row per card, cash envelope or bank account
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    opening_balance NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_accounts_kind
+        CHECK (kind IN ('cash', 'debit_card', 'credit_card', 'bank_account')),
+
+    CONSTRAINT fk_accounts_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT uq_accounts_name UNIQUE (created_by, name)
+);
+
+-- 2. Link expenses and incomes to the account they were paid from / into
+ALTER TABLE home_expenses ADD COLUMN IF NOT EXISTS account_id INTEGER
+    REFERENCES accounts(id) ON DELETE SET NULL;
+
+ALTER TABLE car_expenses ADD COLUMN IF NOT EXISTS account_id INTEGER
+    REFERENCES accounts(id) ON DELETE SET NULL;
+
+ALTER TABLE incomes ADD COLUMN IF NOT EXISTS account_id INTEGER
+    REFERENCES accounts(id) ON DELETE SET NULL;
+
+-- 3. Create transfers table for moving money between accounts
+CREATE TABLE IF NOT EXISTS account_transfers (
+    id SERIAL PRIMARY KEY,
+    from_account_id INTEGER NOT NULL,
+    to_account_id INTEGER NOT NULL,
+    amount NUMERIC(12, 2) NOT NULL,
+    transfer_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_transfers_from_account
+        FOREIGN KEY (from_account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_transfers_to_account
+        FOREIGN KEY (to_account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_transfers_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT chk_transfers_accounts CHECK (from_account_id <> to_account_id),
+    CONSTRAINT chk_transfers_amount CHECK (amount > 0)
+);
+
+-- 4. Create reconciliations table storing balances confirmed against bank statements
+CREATE TABLE IF NOT EXISTS account_reconciliations (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL,
+    statement_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    statement_balance NUMERIC(12, 2) NOT NULL,
+    book_balance NUMERIC(12, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_reconciliations_account
+        FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_home_expenses_account ON home_expenses(account_id);
+CREATE INDEX IF NOT EXISTS idx_car_expenses_account ON car_expenses(account_id);
+CREATE INDEX IF NOT EXISTS idx_incomes_account ON incomes(account_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS account_reconciliations;
+
+DROP TABLE IF EXISTS account_transfers;
+
+ALTER TABLE incomes DROP COLUMN IF EXISTS account_id;
+ALTER TABLE car_expenses DROP COLUMN IF EXISTS account_id;
+ALTER TABLE home_expenses DROP COLUMN IF EXISTS account_id;
+
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,42 @@
+-- +goose Up
+
+-- 1. Create expense rules table, mapping bank transaction texts to a tracker and expense type.
+-- expense_type_id points to utility_types for the house tracker and car_expense_types for the car tracker.
+CREATE TABLE IF NOT EXISTS expense_rules (
+    id SERIAL PRIMARY KEY,
+    position INTEGER NOT NULL DEFAULT 0,
+    match_text VARCHAR(255) NOT NULL,
+    tracker VARCHAR(10) NOT NULL,
+    expense_type_id INTEGER NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_expense_rules_tracker
+        CHECK (tracker IN ('house', 'car')),
+
+    CONSTRAINT fk_expense_rules_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 2. Create imported transactions table, remembering bank references already imported
+CREATE TABLE IF NOT EXISTS imported_transactions (
+    id SERIAL PRIMARY KEY,
+    bank_ref VARCHAR(255) NOT NULL,
+    tracker VARCHAR(10) NOT NULL,
+    expense_id INTEGER NOT NULL,
+    created_by UUID NOT NULL,
+    imported_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_imported_transactions_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT uq_imported_transactions_ref UNIQUE (created_by, bank_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_rules_created_by ON expense_rules(created_by, position);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS imported_transactions;
+
+DROP TABLE IF EXISTS expense_rules;
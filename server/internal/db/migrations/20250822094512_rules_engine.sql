@@ -0,0 +1,30 @@
+-- +goose Up
+
+-- 1. Extend expense rules with more conditions and tag assignment.
+-- Empty text conditions and NULL values match anything, weekday follows EXTRACT(DOW), 0 is Sunday.
+ALTER TABLE expense_rules ALTER COLUMN match_text SET DEFAULT '';
+ALTER TABLE expense_rules ADD COLUMN IF NOT EXISTS match_regex VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE expense_rules ADD COLUMN IF NOT EXISTS amount_min NUMERIC(10, 2);
+ALTER TABLE expense_rules ADD COLUMN IF NOT EXISTS amount_max NUMERIC(10, 2);
+ALTER TABLE expense_rules ADD COLUMN IF NOT EXISTS weekday SMALLINT
+    CONSTRAINT chk_expense_rules_weekday CHECK (weekday BETWEEN 0 AND 6);
+ALTER TABLE expense_rules ADD COLUMN IF NOT EXISTS account_id INTEGER
+    REFERENCES accounts(id) ON DELETE CASCADE;
+ALTER TABLE expense_rules ADD COLUMN IF NOT EXISTS tags VARCHAR(255) NOT NULL DEFAULT '';
+
+-- 2. Tags on expenses, stored comma separated
+ALTER TABLE home_expenses ADD COLUMN IF NOT EXISTS tags VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE car_expenses ADD COLUMN IF NOT EXISTS tags VARCHAR(255) NOT NULL DEFAULT '';
+
+-- +goose Down
+
+ALTER TABLE car_expenses DROP COLUMN IF EXISTS tags;
+ALTER TABLE home_expenses DROP COLUMN IF EXISTS tags;
+
+ALTER TABLE expense_rules DROP COLUMN IF EXISTS tags;
+ALTER TABLE expense_rules DROP COLUMN IF EXISTS account_id;
+ALTER TABLE expense_rules DROP COLUMN IF EXISTS weekday;
+ALTER TABLE expense_rules DROP COLUMN IF EXISTS amount_max;
+ALTER TABLE expense_rules DROP COLUMN IF EXISTS amount_min;
+ALTER TABLE expense_rules DROP COLUMN IF EXISTS match_regex;
+ALTER TABLE expense_rules ALTER COLUMN match_text DROP DEFAULT;
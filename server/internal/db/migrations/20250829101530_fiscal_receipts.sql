@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- 1. Create imported receipts table, remembering fiscal receipts already booked as expenses.
+-- A receipt is identified by the fiscal device number and the receipt number printed in its QR code.
+CREATE TABLE IF NOT EXISTS imported_receipts (
+    id SERIAL PRIMARY KEY,
+    device_number VARCHAR(32) NOT NULL,
+    receipt_number VARCHAR(32) NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    tracker VARCHAR(10) NOT NULL,
+    expense_id INTEGER NOT NULL,
+    created_by UUID NOT NULL,
+    imported_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_imported_receipts_tracker
+        CHECK (tracker IN ('house', 'car')),
+
+    CONSTRAINT fk_imported_receipts_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT uq_imported_receipts_fiscal_ids UNIQUE (created_by, device_number, receipt_number)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS imported_receipts;
@@ -0,0 +1,85 @@
+-- +goose Up
+
+-- 1. Create vehicles table. odometer is the last reading entered by hand,
+-- readings on car expenses newer than it are taken into account as well.
+CREATE TABLE IF NOT EXISTS vehicles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    plate_number VARCHAR(20) NOT NULL DEFAULT '',
+    odometer INTEGER NOT NULL DEFAULT 0,
+    odometer_date DATE,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_vehicles_odometer
+        CHECK (odometer >= 0),
+
+    CONSTRAINT fk_vehicles_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT uq_vehicles_name UNIQUE (created_by, name)
+);
+
+-- 2. Link car expenses to a vehicle, with the odometer reading at the time
+ALTER TABLE car_expenses ADD COLUMN IF NOT EXISTS vehicle_id INTEGER
+    REFERENCES vehicles(id) ON DELETE SET NULL;
+ALTER TABLE car_expenses ADD COLUMN IF NOT EXISTS odometer INTEGER
+    CONSTRAINT chk_car_expenses_odometer CHECK (odometer >= 0);
+
+-- 3. Create service plans table. A service is due interval_km after and/or interval_months
+-- after the last expense of car_expense_type_id on the vehicle, whichever comes first.
+CREATE TABLE IF NOT EXISTS service_plans (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    car_expense_type_id INTEGER NOT NULL,
+    interval_km INTEGER,
+    interval_months INTEGER,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_service_plans_interval
+        CHECK ((interval_km IS NOT NULL OR interval_months IS NOT NULL)
+            AND COALESCE(interval_km, 1) > 0 AND COALESCE(interval_months, 1) > 0),
+
+    CONSTRAINT fk_service_plans_vehicle
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_service_plans_type
+    FOREIGN KEY (car_expense_type_id) REFERENCES car_expense_types(id),
+
+    CONSTRAINT fk_service_plans_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 4. Create notifications table. dedupe_key keeps a reminder from being raised twice.
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    dedupe_key VARCHAR(255) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    link VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_notifications_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT uq_notifications_dedupe_key UNIQUE (user_id, dedupe_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_car_expenses_vehicle ON car_expenses(vehicle_id, car_expense_type_id, expense_date);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS notifications;
+
+DROP TABLE IF EXISTS service_plans;
+
+ALTER TABLE car_expenses DROP COLUMN IF EXISTS odometer;
+ALTER TABLE car_expenses DROP COLUMN IF EXISTS vehicle_id;
+
+DROP TABLE IF EXISTS vehicles;
@@ -0,0 +1,38 @@
+-- +goose Up
+
+-- 1. Create vehicle documents table, like insurance, vignette or inspection.
+-- A renewal is a new document of the same kind, car_expense_id links what it cost
+-- and remind_days are how many days before expiry reminders are raised.
+CREATE TABLE IF NOT EXISTS vehicle_documents (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    starts_on DATE NOT NULL,
+    expires_on DATE NOT NULL,
+    car_expense_id INTEGER,
+    remind_days INTEGER[] NOT NULL DEFAULT '{30,7,1}',
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_vehicle_documents_kind
+        CHECK (kind IN ('insurance', 'vignette', 'inspection', 'road_tax', 'other')),
+
+    CONSTRAINT chk_vehicle_documents_dates
+        CHECK (expires_on >= starts_on),
+
+    CONSTRAINT fk_vehicle_documents_vehicle
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_vehicle_documents_expense
+    FOREIGN KEY (car_expense_id) REFERENCES car_expenses(id) ON DELETE SET NULL,
+
+    CONSTRAINT fk_vehicle_documents_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_documents_expiry ON vehicle_documents(vehicle_id, kind, expires_on);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS vehicle_documents;
@@ -0,0 +1,47 @@
+-- +goose Up
+
+-- 1. Purchase and depreciation of a vehicle. straight_line depreciates the purchase
+-- price down to residual_value over useful_life_years, valuations interpolates
+-- between the purchase price and the values entered in vehicle_valuations.
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS purchase_price NUMERIC(12, 2)
+    CONSTRAINT chk_vehicles_purchase_price CHECK (purchase_price >= 0);
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS purchase_date DATE;
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS depreciation VARCHAR(20) NOT NULL DEFAULT 'straight_line'
+    CONSTRAINT chk_vehicles_depreciation CHECK (depreciation IN ('straight_line', 'valuations'));
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS useful_life_years INTEGER
+    CONSTRAINT chk_vehicles_useful_life CHECK (useful_life_years > 0);
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS residual_value NUMERIC(12, 2) NOT NULL DEFAULT 0
+    CONSTRAINT chk_vehicles_residual_value CHECK (residual_value >= 0);
+
+-- 2. Create vehicle valuations table, what the vehicle was worth on a date.
+CREATE TABLE IF NOT EXISTS vehicle_valuations (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    valued_on DATE NOT NULL,
+    value NUMERIC(12, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_vehicle_valuations_value
+        CHECK (value >= 0),
+
+    CONSTRAINT fk_vehicle_valuations_vehicle
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles(id) ON DELETE CASCADE,
+
+    CONSTRAINT uq_vehicle_valuations_date UNIQUE (vehicle_id, valued_on)
+);
+
+-- 3. Litres filled up with a fuel expense, for the fuel consumption.
+ALTER TABLE car_expenses ADD COLUMN IF NOT EXISTS fuel_litres NUMERIC(8, 2)
+    CONSTRAINT chk_car_expenses_fuel_litres CHECK (fuel_litres > 0);
+
+-- +goose Down
+
+ALTER TABLE car_expenses DROP COLUMN IF EXISTS fuel_litres;
+
+DROP TABLE IF EXISTS vehicle_valuations;
+
+ALTER TABLE vehicles DROP COLUMN IF EXISTS residual_value;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS useful_life_years;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS depreciation;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS purchase_date;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS purchase_price;
@@ -0,0 +1,46 @@
+-- +goose Up
+
+-- 1. Create trips table. distance is stored even when both odometer readings are
+-- entered, so trips logged with a distance alone sum up the same way.
+CREATE TABLE IF NOT EXISTS trips (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER,
+    trip_date DATE NOT NULL,
+    start_odometer INTEGER,
+    end_odometer INTEGER,
+    distance INTEGER NOT NULL,
+    purpose VARCHAR(255) NOT NULL,
+    business BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_trips_distance
+        CHECK (distance > 0),
+
+    CONSTRAINT chk_trips_odometer
+        CHECK (start_odometer IS NULL OR end_odometer IS NULL OR end_odometer > start_odometer),
+
+    CONSTRAINT fk_trips_vehicle
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles(id) ON DELETE SET NULL,
+
+    CONSTRAINT fk_trips_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_trips_created_by_date ON trips(created_by, trip_date);
+
+-- 2. Tolls, parking and fuel paid on a trip.
+ALTER TABLE car_expenses ADD COLUMN IF NOT EXISTS trip_id INTEGER
+    CONSTRAINT fk_car_expenses_trip REFERENCES trips(id) ON DELETE SET NULL;
+
+-- 3. The per-km rate business trips are reimbursed at.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mileage_rate NUMERIC(6, 3) NOT NULL DEFAULT 0
+    CONSTRAINT chk_users_mileage_rate CHECK (mileage_rate >= 0);
+
+-- +goose Down
+
+ALTER TABLE users DROP COLUMN IF EXISTS mileage_rate;
+
+ALTER TABLE car_expenses DROP COLUMN IF EXISTS trip_id;
+
+DROP TABLE IF EXISTS trips;
@@ -0,0 +1,65 @@
+-- +goose Up
+
+-- 1. Car expense type the charging sessions roll up into.
+INSERT INTO car_expense_types (name) VALUES ('Charging') ON CONFLICT (name) DO NOTHING;
+
+-- 2. Create charging sessions table. A session paid separately rolls up into the
+-- car expense car_expense_id, a home session on_house_bill is already paid with
+-- the house electricity bill, optionally home_expense_id, and has no car expense.
+CREATE TABLE IF NOT EXISTS charging_sessions (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    charged_on DATE NOT NULL,
+    kwh NUMERIC(8, 2) NOT NULL,
+    duration_minutes INTEGER,
+    location VARCHAR(10) NOT NULL,
+    tariff VARCHAR(100) NOT NULL DEFAULT '',
+    cost NUMERIC(10, 2) NOT NULL,
+    odometer INTEGER,
+    on_house_bill BOOLEAN NOT NULL DEFAULT FALSE,
+    car_expense_id INTEGER UNIQUE,
+    home_expense_id INTEGER,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_charging_sessions_kwh
+        CHECK (kwh > 0),
+
+    CONSTRAINT chk_charging_sessions_duration
+        CHECK (duration_minutes > 0),
+
+    CONSTRAINT chk_charging_sessions_cost
+        CHECK (cost >= 0),
+
+    CONSTRAINT chk_charging_sessions_location
+        CHECK (location IN ('home', 'public')),
+
+    CONSTRAINT chk_charging_sessions_house_bill
+        CHECK (location = 'home' OR NOT on_house_bill),
+
+    CONSTRAINT chk_charging_sessions_home_expense
+        CHECK (home_expense_id IS NULL OR on_house_bill),
+
+    CONSTRAINT fk_charging_sessions_vehicle
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_charging_sessions_car_expense
+    FOREIGN KEY (car_expense_id) REFERENCES car_expenses(id) ON DELETE SET NULL,
+
+    CONSTRAINT fk_charging_sessions_home_expense
+    FOREIGN KEY (home_expense_id) REFERENCES home_expenses(id) ON DELETE SET NULL,
+
+    CONSTRAINT fk_charging_sessions_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_charging_sessions_created_by_date ON charging_sessions(created_by, charged_on);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS charging_sessions;
+
+DELETE FROM car_expense_types
+WHERE name = 'Charging' AND NOT EXISTS (
+    SELECT 1 FROM car_expenses WHERE car_expense_type_id = car_expense_types.id
+);
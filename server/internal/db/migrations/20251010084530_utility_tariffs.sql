@@ -0,0 +1,61 @@
+-- +goose Up
+
+-- 1. Create utility tariffs table. A tariff applies to the user's bills of its utility
+-- type from effective_from until the next tariff of the type takes effect. Prices are
+-- without VAT, night_price is NULL for single zone tariffs.
+CREATE TABLE IF NOT EXISTS utility_tariffs (
+    id SERIAL PRIMARY KEY,
+    utility_type_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    effective_from DATE NOT NULL,
+    fixed_fee NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    unit_price NUMERIC(10, 5) NOT NULL,
+    night_price NUMERIC(10, 5),
+    vat_percent NUMERIC(5, 2) NOT NULL DEFAULT 20,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_utility_tariffs_effective_from
+        UNIQUE (created_by, utility_type_id, effective_from),
+
+    CONSTRAINT chk_utility_tariffs_prices
+        CHECK (fixed_fee >= 0 AND unit_price >= 0 AND (night_price IS NULL OR night_price >= 0)),
+
+    CONSTRAINT chk_utility_tariffs_vat
+        CHECK (vat_percent >= 0 AND vat_percent <= 100),
+
+    CONSTRAINT fk_utility_tariffs_utility_type
+    FOREIGN KEY (utility_type_id) REFERENCES utility_types(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_utility_tariffs_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 2. Create meter readings table. night_value is the night zone register of two
+-- zone electricity meters, NULL for single register meters.
+CREATE TABLE IF NOT EXISTS meter_readings (
+    id SERIAL PRIMARY KEY,
+    utility_type_id INTEGER NOT NULL,
+    read_on DATE NOT NULL,
+    value NUMERIC(12, 3) NOT NULL,
+    night_value NUMERIC(12, 3),
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_meter_readings_read_on
+        UNIQUE (created_by, utility_type_id, read_on),
+
+    CONSTRAINT chk_meter_readings_values
+        CHECK (value >= 0 AND (night_value IS NULL OR night_value >= 0)),
+
+    CONSTRAINT fk_meter_readings_utility_type
+    FOREIGN KEY (utility_type_id) REFERENCES utility_types(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_meter_readings_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS meter_readings;
+DROP TABLE IF EXISTS utility_tariffs;
@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- 1. Create expense anomalies table, marking new house or car expenses that are
+-- outliers against the user's history of the same type. expected is the typical
+-- amount they were compared with, score the robust z-score of the expense.
+CREATE TABLE IF NOT EXISTS expense_anomalies (
+    id SERIAL PRIMARY KEY,
+    home_expense_id INTEGER UNIQUE,
+    car_expense_id INTEGER UNIQUE,
+    expected NUMERIC(10, 2) NOT NULL,
+    score NUMERIC(8, 2) NOT NULL,
+    seasonal BOOLEAN NOT NULL DEFAULT FALSE,
+    based_on INTEGER NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    dismissed_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT chk_expense_anomalies_expense
+        CHECK ((home_expense_id IS NULL) <> (car_expense_id IS NULL)),
+
+    CONSTRAINT fk_expense_anomalies_home_expense
+    FOREIGN KEY (home_expense_id) REFERENCES home_expenses(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_expense_anomalies_car_expense
+    FOREIGN KEY (car_expense_id) REFERENCES car_expenses(id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_expense_anomalies_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_anomalies_created_by ON expense_anomalies(created_by) WHERE dismissed_at IS NULL;
+
+-- +goose Down
+
+DROP TABLE IF EXISTS expense_anomalies;
@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- 1. Create notification settings table, one row per user who changed them.
+-- Emails go to email only for what the user opted in to. last_digest_on is the
+-- month the latest monthly digest summarised, so each month is sent once.
+CREATE TABLE IF NOT EXISTS notification_settings (
+    user_id UUID PRIMARY KEY,
+    email VARCHAR(254) NOT NULL DEFAULT '',
+    email_notifications BOOLEAN NOT NULL DEFAULT FALSE,
+    monthly_digest BOOLEAN NOT NULL DEFAULT FALSE,
+    last_digest_on DATE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_notification_settings_email
+        CHECK (email <> '' OR NOT (email_notifications OR monthly_digest)),
+
+    CONSTRAINT fk_notification_settings_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS notification_settings;
@@ -0,0 +1,36 @@
+-- +goose Up
+
+-- 1. Create notification channels table, the push services a user gets notified through.
+-- url, secret and chat_id are used depending on the kind, see models.NotificationChannel.
+-- events are the notification kinds the channel is subscribed to.
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL DEFAULT '',
+    secret TEXT NOT NULL DEFAULT '',
+    chat_id VARCHAR(100) NOT NULL DEFAULT '',
+    events TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_notification_channels_kind
+        CHECK (kind IN ('webhook', 'ntfy', 'telegram')),
+
+    CONSTRAINT chk_notification_channels_target
+        CHECK (CASE kind
+            WHEN 'telegram' THEN secret <> '' AND chat_id <> ''
+            ELSE url <> ''
+        END),
+
+    CONSTRAINT fk_notification_channels_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 2. Create index for the channels of a user
+CREATE INDEX IF NOT EXISTS idx_notification_channels_user ON notification_channels(user_id);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_notification_channels_user;
+DROP TABLE IF EXISTS notification_channels;
@@ -0,0 +1,62 @@
+-- +goose Up
+
+-- 1. Create webhook endpoints table, the URLs a user registered for their expense events.
+-- events are the webhook events the endpoint is subscribed to. closed_through is the
+-- latest month the endpoint was posted the close of, it starts at the month before
+-- the endpoint was added so only months closing afterwards are posted.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    closed_through DATE NOT NULL DEFAULT (date_trunc('month', CURRENT_DATE) - INTERVAL '1 month'),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_webhook_endpoints_url
+        CHECK (url ~ '^https?://'),
+
+    CONSTRAINT chk_webhook_endpoints_secret
+        CHECK (secret <> ''),
+
+    CONSTRAINT fk_webhook_endpoints_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 2. Create webhook deliveries table, every event posted to an endpoint.
+-- A pending delivery is attempted at next_attempt_at, again with a growing delay
+-- after each failure until it succeeds or runs out of attempts. status_code and
+-- error are of the latest attempt.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    endpoint_id INTEGER NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status_code INTEGER,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT chk_webhook_deliveries_status
+        CHECK (status IN ('pending', 'succeeded', 'failed')),
+
+    CONSTRAINT fk_webhook_deliveries_endpoint
+    FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE
+);
+
+-- 3. Create indexes for the endpoints of a user and the deliveries due
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user ON webhook_endpoints(user_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_webhook_endpoints_user;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- 1. Create telegram links table, the Telegram chat a user talks to the bot from.
+-- A user gets a one-time link_code to send to the bot, chat_id is set once they did.
+CREATE TABLE IF NOT EXISTS telegram_links (
+    user_id UUID PRIMARY KEY,
+    chat_id BIGINT,
+    link_code VARCHAR(32),
+    code_expires_at TIMESTAMP WITH TIME ZONE,
+    linked_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT chk_telegram_links_code
+        CHECK (link_code IS NULL OR code_expires_at IS NOT NULL),
+
+    CONSTRAINT fk_telegram_links_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 2. Create unique indexes, a chat and a code belong to one user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_telegram_links_chat ON telegram_links(chat_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_telegram_links_code ON telegram_links(link_code);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_telegram_links_code;
+DROP INDEX IF EXISTS idx_telegram_links_chat;
+DROP TABLE IF EXISTS telegram_links;
@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- 1. Add the time sessions are valid from, tokens issued before it are rejected.
+-- It is moved forward on every password change to log out all other sessions.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMP WITH TIME ZONE;
+
+-- 2. Create password resets table, the one-time tokens emailed to reset a password.
+-- Only the SHA-256 of a token is stored.
+CREATE TABLE IF NOT EXISTS password_resets (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_password_resets_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 3. Create index for the resets of a user
+CREATE INDEX IF NOT EXISTS idx_password_resets_user ON password_resets(user_id);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_password_resets_user;
+DROP TABLE IF EXISTS password_resets;
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
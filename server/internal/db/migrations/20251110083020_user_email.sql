@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- 1. Add the optional email address of an account and when it was verified.
+-- An address only counts for login, notifications and password resets once verified.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(254);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- 2. Create unique index for the verified addresses, whatever their case.
+-- Unverified ones may repeat, so nobody can hold an address they don't own.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_verified_email ON users(LOWER(email)) WHERE email_verified_at IS NOT NULL;
+
+-- 3. Drop the check that opting in to emails needs an address in the settings,
+-- the verified address of the account is used when they have none.
+ALTER TABLE notification_settings DROP CONSTRAINT IF EXISTS chk_notification_settings_email;
+
+-- +goose Down
+
+ALTER TABLE notification_settings ADD CONSTRAINT chk_notification_settings_email
+    CHECK (email <> '' OR NOT (email_notifications OR monthly_digest)) NOT VALID;
+
+DROP INDEX IF EXISTS idx_users_verified_email;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
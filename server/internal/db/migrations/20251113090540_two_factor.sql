@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- 1. Add the TOTP two-factor authentication of users. The secret is pending until
+-- totp_enabled_at is set by the first valid code. totp_last_step is the time step
+-- of the latest code used, codes of it and earlier ones are refused so none is
+-- used twice. Too many wrong codes in a row lock the second step for a while.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_locked_until TIMESTAMP WITH TIME ZONE;
+
+-- 2. Create recovery codes table, the single-use codes that stand in for a TOTP
+-- code when the authenticator is lost. Only the SHA-256 of a code is stored.
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    user_id UUID NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_recovery_codes PRIMARY KEY (user_id, code_hash),
+
+    CONSTRAINT fk_recovery_codes_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS recovery_codes;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_failures;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
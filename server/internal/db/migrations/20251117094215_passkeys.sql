@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- 1. Create passkeys table, the WebAuthn credentials users sign in with instead
+-- of a password. credential is the JSON of the whole credential: its public key,
+-- flags and signature counter, which is updated on every login.
+CREATE TABLE IF NOT EXISTS passkeys (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    credential_id BYTEA NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    credential JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT uq_passkeys_credential_id UNIQUE (credential_id),
+
+    CONSTRAINT fk_passkeys_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 2. Index the passkeys of a user, listed in settings and loaded on every login.
+CREATE INDEX IF NOT EXISTS idx_passkeys_user_id ON passkeys(user_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS passkeys;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- 1. Create OIDC identities table, the accounts at the OpenID Connect provider
+-- users log in with. An identity is its subject at the issuer and is linked to
+-- one user, either created for it or the one with its verified email.
+CREATE TABLE IF NOT EXISTS oidc_identities (
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT pk_oidc_identities PRIMARY KEY (issuer, subject),
+
+    CONSTRAINT fk_oidc_identities_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS oidc_identities;
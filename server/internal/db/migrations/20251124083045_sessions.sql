@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- 1. Create sessions table, the logins of users on their devices. The session
+-- ID is carried by the token in the auth cookie, a token of a revoked session
+-- is refused even before it expires. last_seen_at and ip are updated as the
+-- session is used, at most once a minute.
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_sessions_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 2. Index the sessions of a user, listed on their sessions page.
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- 1. Let car rules book matching expenses on a vehicle. Deleting the vehicle
+-- keeps the rule, it then books on no vehicle.
+ALTER TABLE expense_rules ADD COLUMN IF NOT EXISTS vehicle_id INTEGER
+    REFERENCES vehicles(id) ON DELETE SET NULL;
+ALTER TABLE expense_rules ADD CONSTRAINT chk_expense_rules_vehicle
+    CHECK (vehicle_id IS NULL OR tracker = 'car');
+
+-- +goose Down
+
+ALTER TABLE expense_rules DROP CONSTRAINT IF EXISTS chk_expense_rules_vehicle;
+ALTER TABLE expense_rules DROP COLUMN IF EXISTS vehicle_id;
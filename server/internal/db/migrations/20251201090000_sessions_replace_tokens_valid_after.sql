@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- 1. Drop the time tokens are valid from. A password change revokes the
+-- user's other sessions instead, so a request checks only its session.
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
+
+-- +goose Down
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateNotification adds a notification unless one with the same key was
+// already raised for the user. It reports whether the notification is new.
+func (db *DB) CreateNotification(input *models.Notification) (bool, error) {
+	query := `
+		INSERT INTO notifications (user_id, kind, dedupe_key, title, message, link)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+		RETURNING id, created_at;
+	`
+
+	rows, err := db.conn.Query(query,
+		input.UserID,
+		input.Kind,
+		input.Key,
+		input.Title,
+		input.Message,
+		input.Link,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create notification: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+
+	if err = rows.Scan(&input.ID, &input.CreatedAt); err != nil {
+		return false, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetNotifications retrieves the user's latest notifications, newest first.
+func (db *DB) GetNotifications(userId uuid.UUID, limit int) (*[]models.Notification, error) {
+	query := `
+		SELECT id, user_id, kind, dedupe_key, title, message, link, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2;
+	`
+
+	rows, err := db.conn.Query(query, userId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %v", err)
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		err = rows.Scan(&n.ID,
+			&n.UserID,
+			&n.Kind,
+			&n.Key,
+			&n.Title,
+			&n.Message,
+			&n.Link,
+			&n.CreatedAt,
+			&n.ReadAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notifications: %v", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %v", err)
+	}
+
+	return &notifications, nil
+}
+
+// MarkNotificationsRead marks all of the user's notifications as read.
+func (db *DB) MarkNotificationsRead(userId uuid.UUID) error {
+	_, err := db.conn.Exec(`UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL`, userId)
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	return nil
+}
+
+// notificationSettingsColumns selects the settings of every user, with the
+// defaults for those who never changed them.
+const notificationSettingsColumns = `
+	u.id, COALESCE(ns.email, ''), CASE WHEN u.email_verified_at IS NULL THEN '' ELSE u.email END,
+	COALESCE(ns.email_notifications, FALSE), COALESCE(ns.monthly_digest, FALSE), ns.last_digest_on
+	FROM users u
+	LEFT JOIN notification_settings ns ON ns.user_id = u.id
+`
+
+func scanNotificationSettings(row interface{ Scan(...any) error }, settings *models.NotificationSettings) error {
+	return row.Scan(&settings.UserID,
+		&settings.Email,
+		&settings.AccountEmail,
+		&settings.EmailNotifications,
+		&settings.MonthlyDigest,
+		&settings.LastDigestOn,
+	)
+}
+
+// GetNotificationSettings retrieves the user's notification settings, nothing is
+// opted in to when the user never changed them.
+func (db *DB) GetNotificationSettings(userId uuid.UUID) (*models.NotificationSettings, error) {
+	query := `SELECT ` + notificationSettingsColumns + `
+		WHERE u.id = $1;
+	`
+
+	settings := &models.NotificationSettings{UserID: userId}
+	err := scanNotificationSettings(db.conn.QueryRow(query, userId), settings)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SaveNotificationSettings creates or updates the user's notification settings.
+func (db *DB) SaveNotificationSettings(input *models.NotificationSettings) error {
+	query := `
+		INSERT INTO notification_settings (user_id, email, email_notifications, monthly_digest)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email = EXCLUDED.email,
+			email_notifications = EXCLUDED.email_notifications,
+			monthly_digest = EXCLUDED.monthly_digest,
+			updated_at = CURRENT_TIMESTAMP;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.UserID,
+		input.Email,
+		input.EmailNotifications,
+		input.MonthlyDigest,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	return nil
+}
+
+// GetDigestRecipients retrieves the settings of the users who opted in to the
+// monthly digest and weren't sent the one of month yet.
+func (db *DB) GetDigestRecipients(month time.Time) ([]models.NotificationSettings, error) {
+	query := `SELECT ` + notificationSettingsColumns + `
+		WHERE ns.monthly_digest AND (ns.email <> '' OR u.email_verified_at IS NOT NULL)
+			AND (ns.last_digest_on IS NULL OR ns.last_digest_on < $1)
+		ORDER BY u.id;
+	`
+
+	rows, err := db.conn.Query(query, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch digest recipients: %v", err)
+	}
+	defer rows.Close()
+
+	var recipients []models.NotificationSettings
+	for rows.Next() {
+		var settings models.NotificationSettings
+		if err = scanNotificationSettings(rows, &settings); err != nil {
+			return nil, fmt.Errorf("failed to scan digest recipients: %v", err)
+		}
+		recipients = append(recipients, settings)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch digest recipients: %v", err)
+	}
+
+	return recipients, nil
+}
+
+// MarkDigestSent records that the user was sent the digest of month.
+func (db *DB) MarkDigestSent(userId uuid.UUID, month time.Time) error {
+	_, err := db.conn.Exec(`UPDATE notification_settings SET last_digest_on = $2 WHERE user_id = $1`, userId, month)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest sent: %w", err)
+	}
+
+	return nil
+}
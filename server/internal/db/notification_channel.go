@@ -0,0 +1,175 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const notificationChannelColumns = `
+	id, user_id, kind, name, url, secret, chat_id, events, created_at
+	FROM notification_channels
+`
+
+func scanNotificationChannel(row interface{ Scan(...any) error }, ch *models.NotificationChannel) error {
+	var events pq.StringArray
+
+	err := row.Scan(&ch.ID,
+		&ch.UserID,
+		&ch.Kind,
+		&ch.Name,
+		&ch.URL,
+		&ch.Secret,
+		&ch.ChatID,
+		&events,
+		&ch.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	ch.Events = make([]models.NotificationKind, 0, len(events))
+	for _, event := range events {
+		ch.Events = append(ch.Events, models.NotificationKind(event))
+	}
+
+	return nil
+}
+
+func channelEvents(events []models.NotificationKind) pq.StringArray {
+	array := make(pq.StringArray, 0, len(events))
+	for _, event := range events {
+		array = append(array, string(event))
+	}
+	return array
+}
+
+func (db *DB) queryNotificationChannels(query string, args ...any) ([]models.NotificationChannel, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notification channels: %v", err)
+	}
+	defer rows.Close()
+
+	var channels []models.NotificationChannel
+	for rows.Next() {
+		var ch models.NotificationChannel
+		if err = scanNotificationChannel(rows, &ch); err != nil {
+			return nil, fmt.Errorf("failed to scan notification channels: %v", err)
+		}
+		channels = append(channels, ch)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch notification channels: %v", err)
+	}
+
+	return channels, nil
+}
+
+// GetNotificationChannels retrieves the user's push channels in the order they were added.
+func (db *DB) GetNotificationChannels(userId uuid.UUID) ([]models.NotificationChannel, error) {
+	query := `SELECT ` + notificationChannelColumns + `
+		WHERE user_id = $1
+		ORDER BY id;
+	`
+	return db.queryNotificationChannels(query, userId)
+}
+
+// GetSubscribedChannels retrieves the user's push channels subscribed to the kind.
+func (db *DB) GetSubscribedChannels(userId uuid.UUID, kind models.NotificationKind) ([]models.NotificationChannel, error) {
+	query := `SELECT ` + notificationChannelColumns + `
+		WHERE user_id = $1 AND $2 = ANY(events)
+		ORDER BY id;
+	`
+	return db.queryNotificationChannels(query, userId, kind)
+}
+
+// GetNotificationChannelByID retrieves a push channel by Id, returns nil when it doesn't exist.
+func (db *DB) GetNotificationChannelByID(id int) (*models.NotificationChannel, error) {
+	query := `SELECT ` + notificationChannelColumns + `
+		WHERE id = $1;
+	`
+
+	var ch models.NotificationChannel
+	err := scanNotificationChannel(db.conn.QueryRow(query, id), &ch)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get notification channel: %w", err)
+	}
+
+	return &ch, nil
+}
+
+func (db *DB) CreateNotificationChannel(input *models.NotificationChannel) error {
+	query := `
+		INSERT INTO notification_channels (user_id, kind, name, url, secret, chat_id, events)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.UserID,
+		input.Kind,
+		input.Name,
+		input.URL,
+		input.Secret,
+		input.ChatID,
+		channelEvents(input.Events),
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditNotificationChannel(input *models.NotificationChannel) error {
+	query := `
+		UPDATE notification_channels
+		SET
+			kind = $2,
+			name = $3,
+			url = $4,
+			secret = $5,
+			chat_id = $6,
+			events = $7
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.Kind,
+		input.Name,
+		input.URL,
+		input.Secret,
+		input.ChatID,
+		channelEvents(input.Events),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error editing notification channel: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteNotificationChannel(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM notification_channels WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting notification channel: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting notification channel: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
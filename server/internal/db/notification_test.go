@@ -0,0 +1,136 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationSettings(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Notification Settings %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	// Nothing is opted in to before the user saves their settings.
+	settings, err := testDB.GetNotificationSettings(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.False(t, settings.EmailNotifications)
+	assert.False(t, settings.MonthlyDigest)
+
+	// Without an address in the settings, emails go to the verified one of the account.
+	settings.MonthlyDigest = true
+	assert.NoError(t, testDB.SaveNotificationSettings(settings))
+
+	month := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	recipients, err := testDB.GetDigestRecipients(month)
+	assert.NoError(t, err)
+	assert.Empty(t, recipients)
+
+	assert.NoError(t, testDB.SetEmail(TestUserRegisterModel.ID, "account@example.com"))
+	recipients, err = testDB.GetDigestRecipients(month)
+	assert.NoError(t, err)
+	assert.Empty(t, recipients)
+
+	verified, err := testDB.VerifyEmail(TestUserRegisterModel.ID, "account@example.com", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	recipients, err = testDB.GetDigestRecipients(month)
+	assert.NoError(t, err)
+	assert.Len(t, recipients, 1)
+	assert.Equal(t, "account@example.com", recipients[0].Address())
+
+	settings.Email = "test@example.com"
+	assert.NoError(t, testDB.SaveNotificationSettings(settings))
+
+	recipients, err = testDB.GetDigestRecipients(month)
+	assert.NoError(t, err)
+	assert.Len(t, recipients, 1)
+	assert.Equal(t, "test@example.com", recipients[0].Address())
+
+	assert.NoError(t, testDB.MarkDigestSent(TestUserRegisterModel.ID, month))
+	recipients, err = testDB.GetDigestRecipients(month)
+	assert.NoError(t, err)
+	assert.Empty(t, recipients)
+
+	recipients, err = testDB.GetDigestRecipients(month.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Len(t, recipients, 1)
+
+	settings.MonthlyDigest = false
+	settings.EmailNotifications = true
+	assert.NoError(t, testDB.SaveNotificationSettings(settings))
+
+	saved, err := testDB.GetNotificationSettings(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.True(t, saved.EmailNotifications)
+	assert.False(t, saved.MonthlyDigest)
+	assert.Equal(t, month, saved.LastDigestOn.UTC())
+}
+
+func TestNotificationChannels(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Notification Channels %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	ch := &models.NotificationChannel{
+		UserID: TestUserRegisterModel.ID,
+		Kind:   models.ChannelNtfy,
+		Name:   "Phone",
+		URL:    "https://ntfy.sh/my-expenses",
+		Events: []models.NotificationKind{models.NotificationDocumentExpiry, models.NotificationBillDue},
+	}
+	assert.NoError(t, testDB.CreateNotificationChannel(ch))
+	assert.NotZero(t, ch.ID)
+
+	// A Telegram channel needs a bot token and a chat.
+	assert.Error(t, testDB.CreateNotificationChannel(&models.NotificationChannel{
+		UserID: TestUserRegisterModel.ID,
+		Kind:   models.ChannelTelegram,
+		Name:   "Chat",
+	}))
+
+	subscribed, err := testDB.GetSubscribedChannels(TestUserRegisterModel.ID, models.NotificationBillDue)
+	assert.NoError(t, err)
+	assert.Len(t, subscribed, 1)
+	assert.Equal(t, ch.Events, subscribed[0].Events)
+
+	subscribed, err = testDB.GetSubscribedChannels(TestUserRegisterModel.ID, models.NotificationServiceDue)
+	assert.NoError(t, err)
+	assert.Empty(t, subscribed)
+
+	ch.Events = []models.NotificationKind{models.NotificationServiceDue}
+	assert.NoError(t, testDB.EditNotificationChannel(ch))
+
+	saved, err := testDB.GetNotificationChannelByID(ch.ID)
+	assert.NoError(t, err)
+	assert.True(t, saved.Subscribed(models.NotificationServiceDue))
+	assert.False(t, saved.Subscribed(models.NotificationBillDue))
+
+	deleted, err := testDB.DeleteNotificationChannel(ch.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	channels, err := testDB.GetNotificationChannels(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, channels)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+)
+
+// GetUserByOIDCIdentity retrieves the user the subject at the issuer is linked
+// to, nil when it isn't linked.
+func (db *DB) GetUserByOIDCIdentity(issuer, subject string) (*models.User, error) {
+	query := `
+		SELECT u.id, u.username, u.password_hash, COALESCE(u.email, ''), u.email_verified_at, u.created_at, u.updated_at
+		FROM oidc_identities oi
+		JOIN users u ON u.id = oi.user_id
+		WHERE oi.issuer = $1 AND oi.subject = $2`
+
+	user := &models.User{}
+	err := db.conn.QueryRow(query, issuer, subject).Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Email,
+		&user.EmailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user by identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// LinkOIDCIdentity links the subject at the issuer to the user, to log in as them.
+func (db *DB) LinkOIDCIdentity(user *models.User, issuer, subject string) error {
+	query := `
+		INSERT INTO oidc_identities (issuer, subject, user_id)
+		VALUES ($1, $2, $3);
+	`
+
+	if _, err := db.conn.Exec(query, issuer, subject, user.ID); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	return nil
+}
+
+// CreateOIDCUser creates the user of the subject at the issuer, with their email
+// verified at now when the provider verified it.
+func (db *DB) CreateOIDCUser(user *models.User, issuer, subject string, emailVerified bool, now time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	defer tx.Rollback()
+
+	var verifiedAt *time.Time
+	if emailVerified && user.Email != "" {
+		verifiedAt = &now
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO users (username, password_hash, email, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $5)
+		RETURNING id, email_verified_at, created_at, updated_at`,
+		user.Username,
+		user.PasswordHash,
+		user.Email,
+		verifiedAt,
+		now,
+	).Scan(&user.ID, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO oidc_identities (issuer, subject, user_id)
+		VALUES ($1, $2, $3);
+	`, issuer, subject, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCIdentities(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test OIDC Identities %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+
+	user, err := testDB.GetUserByOIDCIdentity("https://id.example.com", "user-1")
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+
+	// A new user of the provider gets an account without a password.
+	now := time.Now().Truncate(time.Second)
+	created := &models.User{Username: "ivan", Email: "ivan@example.com"}
+	assert.NoError(t, testDB.CreateOIDCUser(created, "https://id.example.com", "user-1", true, now))
+	assert.Equal(t, "ivan@example.com", created.VerifiedEmail())
+
+	user, err = testDB.GetUserByOIDCIdentity("https://id.example.com", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, user.ID)
+	assert.Empty(t, user.PasswordHash)
+
+	// An unverified address of the provider isn't taken as verified.
+	unverified := &models.User{Username: "petar", Email: "petar@example.com"}
+	assert.NoError(t, testDB.CreateOIDCUser(unverified, "https://id.example.com", "user-2", false, now))
+	assert.Empty(t, unverified.VerifiedEmail())
+
+	// An identity is linked to one user only.
+	assert.Error(t, testDB.CreateOIDCUser(&models.User{Username: "copy"}, "https://id.example.com", "user-1", false, now))
+	assert.Error(t, testDB.LinkOIDCIdentity(unverified, "https://id.example.com", "user-1"))
+
+	existing := &models.User{Username: "existing", PasswordHash: "hash123"}
+	assert.NoError(t, testDB.CreateUser(existing))
+	assert.NoError(t, testDB.LinkOIDCIdentity(existing, "https://other.example.com", "user-1"))
+
+	user, err = testDB.GetUserByOIDCIdentity("https://other.example.com", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID)
+}
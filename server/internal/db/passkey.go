@@ -0,0 +1,116 @@
+package database
+
+import (
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const passkeyColumns = `id, user_id, credential_id, name, credential, created_at, last_used_at
+		FROM passkeys`
+
+func (db *DB) queryPasskeys(query string, args ...any) ([]models.Passkey, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	defer rows.Close()
+
+	passkeys := []models.Passkey{}
+	for rows.Next() {
+		var p models.Passkey
+		if err := rows.Scan(&p.ID, &p.UserID, &p.CredentialID, &p.Name, &p.Credential, &p.CreatedAt, &p.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan passkey: %w", err)
+		}
+		passkeys = append(passkeys, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+
+	return passkeys, nil
+}
+
+// GetPasskeys retrieves the passkeys of the user, oldest first.
+func (db *DB) GetPasskeys(userId uuid.UUID) ([]models.Passkey, error) {
+	query := `SELECT ` + passkeyColumns + `
+		WHERE user_id = $1
+		ORDER BY id;
+	`
+	return db.queryPasskeys(query, userId)
+}
+
+// GetPasskeyByID retrieves the passkey with the ID, nil when there is none.
+func (db *DB) GetPasskeyByID(id int) (*models.Passkey, error) {
+	query := `SELECT ` + passkeyColumns + `
+		WHERE id = $1;
+	`
+
+	passkeys, err := db.queryPasskeys(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(passkeys) == 0 {
+		return nil, nil
+	}
+
+	return &passkeys[0], nil
+}
+
+func (db *DB) CreatePasskey(input *models.Passkey) error {
+	query := `
+		INSERT INTO passkeys (user_id, credential_id, name, credential)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.UserID,
+		input.CredentialID,
+		input.Name,
+		input.Credential,
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create passkey: %w", err)
+	}
+
+	return nil
+}
+
+// UsePasskey stores the credential of the passkey after a login at now, with
+// its new signature counter.
+func (db *DB) UsePasskey(id int, credential []byte, now time.Time) error {
+	_, err := db.conn.Exec(`UPDATE passkeys SET credential = $2, last_used_at = $3 WHERE id = $1`, id, credential, now)
+	if err != nil {
+		return fmt.Errorf("failed to update passkey: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) RenamePasskey(id int, name string) error {
+	_, err := db.conn.Exec(`UPDATE passkeys SET name = $2 WHERE id = $1`, id, name)
+	if err != nil {
+		return fmt.Errorf("failed to rename passkey: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeletePasskey(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM passkeys WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting passkey: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting passkey: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasskeys(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Passkeys %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	user := &models.User{Username: "passkeys", PasswordHash: "hash123"}
+	assert.NoError(t, testDB.CreateUser(user))
+
+	p := &models.Passkey{
+		UserID:       user.ID,
+		CredentialID: []byte{1, 2, 3},
+		Name:         "Laptop",
+		Credential:   []byte(`{"id":"AQID"}`),
+	}
+	assert.NoError(t, testDB.CreatePasskey(p))
+	assert.NotZero(t, p.ID)
+
+	// A credential belongs to one passkey only.
+	assert.Error(t, testDB.CreatePasskey(&models.Passkey{
+		UserID:       user.ID,
+		CredentialID: []byte{1, 2, 3},
+		Name:         "Copy",
+		Credential:   []byte(`{}`),
+	}))
+
+	now := time.Now().Truncate(time.Second)
+	assert.NoError(t, testDB.UsePasskey(p.ID, []byte(`{"id":"AQID","Authenticator":{"SignCount":1}}`), now))
+	assert.NoError(t, testDB.RenamePasskey(p.ID, "Phone"))
+
+	saved, err := testDB.GetPasskeyByID(p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Phone", saved.Name)
+	assert.Equal(t, []byte{1, 2, 3}, saved.CredentialID)
+	assert.JSONEq(t, `{"id":"AQID","Authenticator":{"SignCount":1}}`, string(saved.Credential))
+	assert.Equal(t, now, saved.LastUsedAt.Truncate(time.Second))
+
+	deleted, err := testDB.DeletePasskey(p.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	passkeys, err := testDB.GetPasskeys(user.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, passkeys)
+
+	missing, err := testDB.GetPasskeyByID(p.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
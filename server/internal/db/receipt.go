@@ -0,0 +1,91 @@
+package database
+
+import (
+	"expenser/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// IsReceiptImported reports whether the user already booked the fiscal receipt.
+func (db *DB) IsReceiptImported(userId uuid.UUID, receipt *models.FiscalReceipt) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM imported_receipts
+			WHERE created_by = $1 AND device_number = $2 AND receipt_number = $3
+		);
+	`
+
+	var exists bool
+	err := db.conn.QueryRow(query, userId, receipt.DeviceNumber, receipt.ReceiptNumber).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check imported receipt: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ImportReceipt books the receipt as a house or car expense and remembers its
+// fiscal identifiers in one transaction. It returns false without saving anything
+// when the receipt was already imported, otherwise the expense's ID and type are set.
+func (db *DB) ImportReceipt(userId uuid.UUID, receipt *models.FiscalReceipt, exp *models.CategorisedExpense) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to start receipt import: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM imported_receipts
+			WHERE created_by = $1 AND device_number = $2 AND receipt_number = $3
+		);
+	`, userId, receipt.DeviceNumber, receipt.ReceiptNumber).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check imported receipt: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	query := `
+		INSERT INTO home_expenses (utility_type_id, amount, expense_date, notes, created_by, account_id, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, (SELECT name FROM utility_types WHERE id = utility_type_id);
+	`
+	if exp.Tracker == models.TrackerCar {
+		query = `
+			INSERT INTO car_expenses (car_expense_type_id, amount, expense_date, notes, created_by, account_id, tags)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, (SELECT name FROM car_expense_types WHERE id = car_expense_type_id);
+		`
+	}
+
+	err = tx.QueryRow(query,
+		exp.TypeID,
+		exp.Amount,
+		exp.Date,
+		exp.Notes,
+		userId,
+		exp.AccountID,
+		exp.Tags,
+	).Scan(&exp.ID, &exp.Type)
+	if err != nil {
+		return false, fmt.Errorf("failed to import receipt %s/%s: %w", receipt.DeviceNumber, receipt.ReceiptNumber, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO imported_receipts (device_number, receipt_number, issued_at, amount, tracker, expense_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`, receipt.DeviceNumber, receipt.ReceiptNumber, receipt.IssuedAt, receipt.Amount, exp.Tracker, exp.ID, userId)
+	if err != nil {
+		return false, fmt.Errorf("failed to record imported receipt %s/%s: %w", receipt.DeviceNumber, receipt.ReceiptNumber, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit receipt import: %w", err)
+	}
+
+	return true, nil
+}
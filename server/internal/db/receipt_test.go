@@ -0,0 +1,52 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportReceipt(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Import Receipt %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	issuedAt := time.Date(2025, time.March, 15, 12, 34, 56, 0, time.Local)
+	receipt := &models.FiscalReceipt{DeviceNumber: "DT123456", ReceiptNumber: "0012345", IssuedAt: issuedAt, Amount: 85.40}
+
+	imported, err := testDB.IsReceiptImported(TestUserRegisterModel.ID, receipt)
+	assert.NoError(t, err)
+	assert.False(t, imported)
+
+	exp := &models.CategorisedExpense{Tracker: models.TrackerCar, TypeID: 1, Amount: 85.40, Date: issuedAt, Notes: "Receipt 0012345"}
+	ok, err := testDB.ImportReceipt(TestUserRegisterModel.ID, receipt, exp)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotZero(t, exp.ID)
+	assert.Equal(t, "Fuel", exp.Type)
+
+	imported, err = testDB.IsReceiptImported(TestUserRegisterModel.ID, receipt)
+	assert.NoError(t, err)
+	assert.True(t, imported)
+
+	again := &models.CategorisedExpense{Tracker: models.TrackerHouse, TypeID: 1, Amount: 85.40, Date: issuedAt}
+	ok, err = testDB.ImportReceipt(TestUserRegisterModel.ID, receipt, again)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	expenses, err := testDB.GetCategorisedExpenses(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, expenses, 1)
+}
@@ -0,0 +1,421 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const ruleColumns = `
+	r.id, r.position, r.match_text, r.match_regex, r.amount_min, r.amount_max, r.weekday,
+	r.account_id, COALESCE(a.name, ''),
+	r.tracker, r.expense_type_id,
+	COALESCE(CASE WHEN r.tracker = 'house' THEN ut.name ELSE ct.name END, ''),
+	r.tags, r.vehicle_id, COALESCE(v.name, ''), r.created_by, r.created_at
+	FROM expense_rules r
+	LEFT JOIN accounts a ON a.id = r.account_id
+	LEFT JOIN vehicles v ON v.id = r.vehicle_id
+	LEFT JOIN utility_types ut ON r.tracker = 'house' AND ut.id = r.expense_type_id
+	LEFT JOIN car_expense_types ct ON r.tracker = 'car' AND ct.id = r.expense_type_id
+`
+
+func scanRule(row interface{ Scan(...any) error }, rule *models.ExpenseRule) error {
+	var amountMin, amountMax sql.NullFloat64
+	var weekday sql.NullInt16
+
+	err := row.Scan(&rule.ID,
+		&rule.Position,
+		&rule.MatchText,
+		&rule.MatchRegex,
+		&amountMin,
+		&amountMax,
+		&weekday,
+		&rule.AccountID,
+		&rule.Account,
+		&rule.Tracker,
+		&rule.ExpenseTypeID,
+		&rule.ExpenseType,
+		&rule.Tags,
+		&rule.VehicleID,
+		&rule.Vehicle,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if amountMin.Valid {
+		rule.AmountMin = &amountMin.Float64
+	}
+	if amountMax.Valid {
+		rule.AmountMax = &amountMax.Float64
+	}
+	if weekday.Valid {
+		day := time.Weekday(weekday.Int16)
+		rule.Weekday = &day
+	}
+
+	return nil
+}
+
+// weekdayValue converts the optional weekday for storing.
+func weekdayValue(day *time.Weekday) sql.NullInt16 {
+	if day == nil {
+		return sql.NullInt16{}
+	}
+	return sql.NullInt16{Int16: int16(*day), Valid: true}
+}
+
+// GetExpenseRules retrieves the rules of a user in the order they are applied.
+func (db *DB) GetExpenseRules(userId uuid.UUID) (*[]models.ExpenseRule, error) {
+	query := `SELECT ` + ruleColumns + `
+		WHERE r.created_by = $1
+		ORDER BY r.position, r.id;
+	`
+
+	rows, err := db.conn.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expense rules: %v", err)
+	}
+	defer rows.Close()
+
+	var rules []models.ExpenseRule
+	for rows.Next() {
+		var rule models.ExpenseRule
+		if err = scanRule(rows, &rule); err != nil {
+			return nil, fmt.Errorf("failed to scan expense rules: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch expense rules: %v", err)
+	}
+
+	return &rules, nil
+}
+
+// GetExpenseRuleByID retrieves a rule by Id, returns nil when it doesn't exist.
+func (db *DB) GetExpenseRuleByID(id int) (*models.ExpenseRule, error) {
+	query := `SELECT ` + ruleColumns + `
+		WHERE r.id = $1;
+	`
+
+	var rule models.ExpenseRule
+	err := scanRule(db.conn.QueryRow(query, id), &rule)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get expense rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// CreateExpenseRule adds a rule after the user's existing rules.
+func (db *DB) CreateExpenseRule(input *models.ExpenseRule) error {
+	query := `
+		INSERT INTO expense_rules (
+			position, match_text, match_regex, amount_min, amount_max, weekday,
+			account_id, tracker, expense_type_id, tags, vehicle_id, created_by
+		)
+		VALUES (
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM expense_rules WHERE created_by = $11),
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)
+		RETURNING id, position, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.MatchText,
+		input.MatchRegex,
+		input.AmountMin,
+		input.AmountMax,
+		weekdayValue(input.Weekday),
+		input.AccountID,
+		input.Tracker,
+		input.ExpenseTypeID,
+		input.Tags,
+		input.VehicleID,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.Position, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create expense rule: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditExpenseRule(input *models.ExpenseRule) error {
+	query := `
+		UPDATE expense_rules
+		SET
+			match_text = $2,
+			match_regex = $3,
+			amount_min = $4,
+			amount_max = $5,
+			weekday = $6,
+			account_id = $7,
+			tracker = $8,
+			expense_type_id = $9,
+			tags = $10,
+			vehicle_id = $11
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.MatchText,
+		input.MatchRegex,
+		input.AmountMin,
+		input.AmountMax,
+		weekdayValue(input.Weekday),
+		input.AccountID,
+		input.Tracker,
+		input.ExpenseTypeID,
+		input.Tags,
+		input.VehicleID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error editing expense rule: %v", err)
+	}
+
+	return nil
+}
+
+// MoveExpenseRule swaps the rule's position with its previous (up) or next rule.
+// It returns false when the rule is already first or last.
+func (db *DB) MoveExpenseRule(rule *models.ExpenseRule, up bool) (bool, error) {
+	query := `
+		SELECT id, position FROM expense_rules
+		WHERE created_by = $1 AND (position, id) > ($2, $3)
+		ORDER BY position, id
+		LIMIT 1;
+	`
+	if up {
+		query = `
+			SELECT id, position FROM expense_rules
+			WHERE created_by = $1 AND (position, id) < ($2, $3)
+			ORDER BY position DESC, id DESC
+			LIMIT 1;
+		`
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to move expense rule: %w", err)
+	}
+	defer tx.Rollback()
+
+	var otherID, otherPosition int
+	err = tx.QueryRow(query, rule.CreatedBy, rule.Position, rule.ID).Scan(&otherID, &otherPosition)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to move expense rule: %w", err)
+	}
+
+	// Rules sharing a position are ordered by id, nudge them apart so the swap sticks.
+	if otherPosition == rule.Position {
+		if up {
+			otherPosition++
+		} else {
+			otherPosition--
+		}
+	}
+
+	if _, err = tx.Exec(`UPDATE expense_rules SET position = $2 WHERE id = $1`, rule.ID, otherPosition); err != nil {
+		return false, fmt.Errorf("failed to move expense rule: %w", err)
+	}
+	if _, err = tx.Exec(`UPDATE expense_rules SET position = $2 WHERE id = $1`, otherID, rule.Position); err != nil {
+		return false, fmt.Errorf("failed to move expense rule: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to move expense rule: %w", err)
+	}
+
+	return true, nil
+}
+
+func (db *DB) DeleteExpenseRule(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM expense_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting expense rule: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting expense rule: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+// GetCategorisedExpenses retrieves every house and car expense of a user
+// in the shape the rules engine works on, newest first.
+func (db *DB) GetCategorisedExpenses(userId uuid.UUID) ([]models.CategorisedExpense, error) {
+	query := `
+		SELECT he.id, 'house', he.utility_type_id, ut.name, he.amount, he.expense_date,
+			COALESCE(he.notes, ''), he.tags, he.account_id, NULL::INTEGER,
+			CASE
+				WHEN EXISTS (SELECT 1 FROM charging_sessions cs WHERE cs.home_expense_id = he.id) THEN 'a charging session'
+				ELSE ''
+			END
+			FROM home_expenses he
+			JOIN utility_types ut ON he.utility_type_id = ut.id
+		WHERE he.created_by = $1
+		UNION ALL
+		SELECT ce.id, 'car', ce.car_expense_type_id, ct.name, ce.amount, ce.expense_date,
+			COALESCE(ce.notes, ''), ce.tags, ce.account_id, ce.vehicle_id,
+			CASE
+				WHEN EXISTS (SELECT 1 FROM charging_sessions cs WHERE cs.car_expense_id = ce.id) THEN 'a charging session'
+				WHEN ce.trip_id IS NOT NULL THEN 'a trip'
+				WHEN EXISTS (SELECT 1 FROM vehicle_documents vd WHERE vd.car_expense_id = ce.id) THEN 'a vehicle document'
+				WHEN ce.odometer IS NOT NULL OR ce.fuel_litres IS NOT NULL THEN 'an odometer or fuel reading'
+				ELSE ''
+			END
+			FROM car_expenses ce
+			JOIN car_expense_types ct ON ce.car_expense_type_id = ct.id
+		WHERE ce.created_by = $1
+		ORDER BY 6 DESC;
+	`
+
+	rows, err := db.conn.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expenses: %v", err)
+	}
+	defer rows.Close()
+
+	var expenses []models.CategorisedExpense
+	for rows.Next() {
+		var exp models.CategorisedExpense
+		err = rows.Scan(&exp.ID,
+			&exp.Tracker,
+			&exp.TypeID,
+			&exp.Type,
+			&exp.Amount,
+			&exp.Date,
+			&exp.Notes,
+			&exp.Tags,
+			&exp.AccountID,
+			&exp.VehicleID,
+			&exp.Linked,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expenses: %v", err)
+		}
+		expenses = append(expenses, exp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch expenses: %v", err)
+	}
+
+	return expenses, nil
+}
+
+// ApplyRuleChanges recategorises expenses in one transaction, skipping blocked
+// changes. Expenses changing tracker are moved between the house and car tables,
+// keeping their account, creation time, anomaly and import or receipt reference,
+// and MovedTo is set to their new id. Car expenses get the change's vehicle.
+func (db *DB) ApplyRuleChanges(userId uuid.UUID, changes []models.RuleChange) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start applying rules: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range changes {
+		change := &changes[i]
+		exp := change.Expense
+
+		if change.Blocked != "" {
+			continue
+		}
+
+		if !change.Moves() {
+			query := `UPDATE home_expenses SET utility_type_id = $2, tags = $3 WHERE id = $1 AND created_by = $4`
+			args := []any{exp.ID, change.TypeID, change.Tags, userId}
+			if exp.Tracker == models.TrackerCar {
+				query = `UPDATE car_expenses SET car_expense_type_id = $2, tags = $3, vehicle_id = $5 WHERE id = $1 AND created_by = $4`
+				args = append(args, change.VehicleID)
+			}
+
+			if _, err = tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("failed to apply rule to expense %d: %w", exp.ID, err)
+			}
+			continue
+		}
+
+		// The new row is inserted before the old one is deleted, so the anomaly
+		// can be moved over instead of going with it.
+		move := `
+			INSERT INTO car_expenses (car_expense_type_id, amount, expense_date, notes, created_by, created_at, account_id, tags, vehicle_id)
+			SELECT $2, amount, expense_date, notes, created_by, created_at, account_id, $3, $5
+				FROM home_expenses WHERE id = $1 AND created_by = $4
+			RETURNING id;
+		`
+		moveAnomaly := `UPDATE expense_anomalies SET home_expense_id = NULL, car_expense_id = $2 WHERE home_expense_id = $1`
+		remove := `DELETE FROM home_expenses WHERE id = $1 AND created_by = $2`
+		args := []any{exp.ID, change.TypeID, change.Tags, userId, change.VehicleID}
+		if exp.Tracker == models.TrackerCar {
+			move = `
+				INSERT INTO home_expenses (utility_type_id, amount, expense_date, notes, created_by, created_at, account_id, tags)
+				SELECT $2, amount, expense_date, notes, created_by, created_at, account_id, $3
+					FROM car_expenses WHERE id = $1 AND created_by = $4
+				RETURNING id;
+			`
+			moveAnomaly = `UPDATE expense_anomalies SET car_expense_id = NULL, home_expense_id = $2 WHERE car_expense_id = $1`
+			remove = `DELETE FROM car_expenses WHERE id = $1 AND created_by = $2`
+			args = args[:4]
+		}
+
+		var newID int
+		err = tx.QueryRow(move, args...).Scan(&newID)
+		if err != nil {
+			return fmt.Errorf("failed to move expense %d: %w", exp.ID, err)
+		}
+
+		if _, err = tx.Exec(moveAnomaly, exp.ID, newID); err != nil {
+			return fmt.Errorf("failed to move expense %d: %w", exp.ID, err)
+		}
+
+		_, err = tx.Exec(`
+			UPDATE imported_transactions SET tracker = $4, expense_id = $5
+			WHERE created_by = $1 AND tracker = $2 AND expense_id = $3;
+		`, userId, exp.Tracker, exp.ID, change.Tracker, newID)
+		if err != nil {
+			return fmt.Errorf("failed to move expense %d: %w", exp.ID, err)
+		}
+
+		_, err = tx.Exec(`
+			UPDATE imported_receipts SET tracker = $4, expense_id = $5
+			WHERE created_by = $1 AND tracker = $2 AND expense_id = $3;
+		`, userId, exp.Tracker, exp.ID, change.Tracker, newID)
+		if err != nil {
+			return fmt.Errorf("failed to move expense %d: %w", exp.ID, err)
+		}
+
+		if _, err = tx.Exec(remove, exp.ID, userId); err != nil {
+			return fmt.Errorf("failed to move expense %d: %w", exp.ID, err)
+		}
+
+		change.MovedTo = newID
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit applied rules: %w", err)
+	}
+
+	return nil
+}
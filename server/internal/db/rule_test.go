@@ -0,0 +1,117 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveExpenseRule(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Move Expense Rule %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	first := &models.ExpenseRule{MatchText: "SHELL", Tracker: models.TrackerCar, ExpenseTypeID: 1, CreatedBy: TestUserRegisterModel.ID}
+	second := &models.ExpenseRule{MatchRegex: "^energo", Tracker: models.TrackerHouse, ExpenseTypeID: 1, CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateExpenseRule(first))
+	assert.NoError(t, testDB.CreateExpenseRule(second))
+
+	moved, err := testDB.MoveExpenseRule(first, true)
+	assert.NoError(t, err)
+	assert.False(t, moved)
+
+	moved, err = testDB.MoveExpenseRule(second, true)
+	assert.NoError(t, err)
+	assert.True(t, moved)
+
+	rules, err := testDB.GetExpenseRules(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, *rules, 2)
+	assert.Equal(t, second.ID, (*rules)[0].ID)
+	assert.Equal(t, first.ID, (*rules)[1].ID)
+}
+
+func TestApplyRuleChanges(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Apply Rule Changes %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	date := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.Local)
+	rows := []models.ImportRow{
+		{
+			BankTransaction: models.BankTransaction{Ref: "BNK-1", Date: date, Amount: 85.40, Description: "SHELL 1234"},
+			Tracker:         models.TrackerHouse,
+			ExpenseTypeID:   1,
+		},
+	}
+	_, err = testDB.ImportTransactions(TestUserRegisterModel.ID, nil, rows)
+	assert.NoError(t, err)
+
+	vehicle := &models.Vehicle{Name: "Golf", CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateVehicle(vehicle))
+
+	weekday := date.Weekday()
+	min := 50.0
+	rule := &models.ExpenseRule{
+		MatchRegex:    `shell \d+`,
+		AmountMin:     &min,
+		Weekday:       &weekday,
+		Tracker:       models.TrackerCar,
+		ExpenseTypeID: 1,
+		Tags:          "fuel",
+		VehicleID:     &vehicle.ID,
+		CreatedBy:     TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateExpenseRule(rule))
+
+	rules, err := testDB.GetExpenseRules(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Golf", (*rules)[0].Vehicle)
+
+	expenses, err := testDB.GetCategorisedExpenses(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, expenses, 1)
+
+	changes := models.PlanRuleChanges(*rules, expenses)
+	assert.Len(t, changes, 1)
+	assert.True(t, changes[0].TypeChanged)
+
+	assert.Empty(t, changes[0].Blocked)
+
+	assert.NoError(t, testDB.ApplyRuleChanges(TestUserRegisterModel.ID, changes))
+	assert.NotZero(t, changes[0].MovedTo)
+
+	expenses, err = testDB.GetCategorisedExpenses(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, expenses, 1)
+	assert.Equal(t, models.TrackerCar, expenses[0].Tracker)
+	assert.Equal(t, "fuel", expenses[0].Tags)
+	assert.Equal(t, &vehicle.ID, expenses[0].VehicleID)
+	assert.Equal(t, changes[0].MovedTo, expenses[0].ID)
+
+	refs, err := testDB.GetImportedRefs(TestUserRegisterModel.ID, []string{"BNK-1"})
+	assert.NoError(t, err)
+	assert.True(t, refs["BNK-1"])
+
+	assert.Empty(t, models.PlanRuleChanges(*rules, expenses))
+}
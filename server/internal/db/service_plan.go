@@ -0,0 +1,180 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// servicePlanColumns selects a plan with the vehicle's latest car expense of the
+// plan's type and the vehicle's latest known odometer reading.
+const servicePlanColumns = `
+	sp.id, sp.vehicle_id, v.name, sp.name, sp.car_expense_type_id, ct.name,
+	sp.interval_km, sp.interval_months, sp.created_by, sp.created_at,
+	last.id, last.expense_date, last.odometer,
+	GREATEST(v.odometer, COALESCE((SELECT MAX(ce.odometer) FROM car_expenses ce WHERE ce.vehicle_id = v.id), 0))
+	FROM service_plans sp
+	JOIN vehicles v ON v.id = sp.vehicle_id
+	JOIN car_expense_types ct ON ct.id = sp.car_expense_type_id
+	LEFT JOIN LATERAL (
+		SELECT ce.id, ce.expense_date, ce.odometer
+		FROM car_expenses ce
+		WHERE ce.vehicle_id = sp.vehicle_id AND ce.car_expense_type_id = sp.car_expense_type_id
+		ORDER BY ce.expense_date DESC, ce.id DESC
+		LIMIT 1
+	) last ON TRUE
+`
+
+func scanServicePlan(row interface{ Scan(...any) error }, plan *models.ServicePlan) error {
+	var lastDate sql.NullTime
+
+	err := row.Scan(&plan.ID,
+		&plan.VehicleID,
+		&plan.Vehicle,
+		&plan.Name,
+		&plan.ExpenseTypeID,
+		&plan.ExpenseType,
+		&plan.IntervalKm,
+		&plan.IntervalMonths,
+		&plan.CreatedBy,
+		&plan.CreatedAt,
+		&plan.LastExpenseID,
+		&lastDate,
+		&plan.LastOdometer,
+		&plan.CurrentOdometer,
+	)
+	if err != nil {
+		return err
+	}
+
+	if lastDate.Valid {
+		plan.LastDate = &lastDate.Time
+	}
+
+	return nil
+}
+
+func (db *DB) queryServicePlans(query string, args ...any) ([]models.ServicePlan, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch service plans: %v", err)
+	}
+	defer rows.Close()
+
+	var plans []models.ServicePlan
+	for rows.Next() {
+		var plan models.ServicePlan
+		if err = scanServicePlan(rows, &plan); err != nil {
+			return nil, fmt.Errorf("failed to scan service plans: %v", err)
+		}
+		plans = append(plans, plan)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch service plans: %v", err)
+	}
+
+	return plans, nil
+}
+
+// GetServicePlans retrieves the service plans of all vehicles of a user.
+func (db *DB) GetServicePlans(userId uuid.UUID) ([]models.ServicePlan, error) {
+	query := `SELECT ` + servicePlanColumns + `
+		WHERE sp.created_by = $1
+		ORDER BY v.name, sp.name;
+	`
+	return db.queryServicePlans(query, userId)
+}
+
+// GetAllServicePlans retrieves the service plans of every user, for the reminders worker.
+func (db *DB) GetAllServicePlans() ([]models.ServicePlan, error) {
+	query := `SELECT ` + servicePlanColumns + `
+		ORDER BY sp.created_by, v.name, sp.name;
+	`
+	return db.queryServicePlans(query)
+}
+
+// GetServicePlanByID retrieves a plan by Id, returns nil when it doesn't exist.
+func (db *DB) GetServicePlanByID(id int) (*models.ServicePlan, error) {
+	query := `SELECT ` + servicePlanColumns + `
+		WHERE sp.id = $1;
+	`
+
+	var plan models.ServicePlan
+	err := scanServicePlan(db.conn.QueryRow(query, id), &plan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get service plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+func (db *DB) CreateServicePlan(input *models.ServicePlan) error {
+	query := `
+		INSERT INTO service_plans (vehicle_id, name, car_expense_type_id, interval_km, interval_months, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.VehicleID,
+		input.Name,
+		input.ExpenseTypeID,
+		input.IntervalKm,
+		input.IntervalMonths,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create service plan: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditServicePlan(input *models.ServicePlan) error {
+	query := `
+		UPDATE service_plans
+		SET
+			vehicle_id = $2,
+			name = $3,
+			car_expense_type_id = $4,
+			interval_km = $5,
+			interval_months = $6
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.VehicleID,
+		input.Name,
+		input.ExpenseTypeID,
+		input.IntervalKm,
+		input.IntervalMonths,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error editing service plan: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteServicePlan(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM service_plans WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting service plan: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting service plan: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
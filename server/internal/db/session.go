@@ -0,0 +1,138 @@
+package database
+
+import (
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions`
+
+func (db *DB) querySessions(query string, args ...any) ([]models.Session, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (db *DB) CreateSession(input *models.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, user_agent, ip, last_seen_at, expires_at)
+		VALUES ($1, $2, LEFT($3, 512), LEFT($4, 45), $5, $6)
+		RETURNING created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.ID,
+		input.UserID,
+		input.UserAgent,
+		input.IP,
+		input.LastSeenAt,
+		input.ExpiresAt,
+	).Scan(&input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession retrieves the session with the ID, nil when there is none.
+func (db *DB) GetSession(id uuid.UUID) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + `
+		WHERE id = $1;
+	`
+
+	sessions, err := db.querySessions(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	return &sessions[0], nil
+}
+
+// GetActiveSessions retrieves the sessions of the user still logged in at now,
+// the latest seen first.
+func (db *DB) GetActiveSessions(userId uuid.UUID, now time.Time) ([]models.Session, error) {
+	query := `SELECT ` + sessionColumns + `
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC;
+	`
+	return db.querySessions(query, userId, now)
+}
+
+// TouchSession records the session being used at now from the IP.
+func (db *DB) TouchSession(id uuid.UUID, ip string, now time.Time) error {
+	_, err := db.conn.Exec(`UPDATE sessions SET last_seen_at = $2, ip = LEFT($3, 45) WHERE id = $1`, id, now, ip)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return nil
+}
+
+// RenewSession moves the expiry of the session, when it's issued a new token.
+func (db *DB) RenewSession(id uuid.UUID, expiresAt time.Time) error {
+	_, err := db.conn.Exec(`UPDATE sessions SET expires_at = $2 WHERE id = $1`, id, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to renew session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession logs the session of the user out at now. It reports false when
+// the user has no such active session.
+func (db *DB) RevokeSession(userId, id uuid.UUID, now time.Time) (bool, error) {
+	res, err := db.conn.Exec(`
+		UPDATE sessions SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+	`, id, userId, now)
+	if err != nil {
+		return false, fmt.Errorf("error revoking session: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error revoking session: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+// RevokeSessions logs out every session of the user at now but the kept one,
+// uuid.Nil to keep none.
+func (db *DB) RevokeSessions(userId, keep uuid.UUID, now time.Time) error {
+	_, err := db.conn.Exec(`
+		UPDATE sessions SET revoked_at = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL;
+	`, userId, keep, now)
+	if err != nil {
+		return fmt.Errorf("error revoking sessions: %v", err)
+	}
+
+	return nil
+}
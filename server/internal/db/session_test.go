@@ -0,0 +1,92 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Sessions %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	now := time.Now().Truncate(time.Second)
+	newSession := func(userAgent string) *models.Session {
+		s := &models.Session{
+			ID:         uuid.New(),
+			UserID:     TestUserRegisterModel.ID,
+			UserAgent:  userAgent,
+			IP:         "192.168.1.10",
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(24 * time.Hour),
+		}
+		assert.NoError(t, testDB.CreateSession(s))
+		return s
+	}
+
+	laptop := newSession("Mozilla/5.0 (X11; Linux x86_64; rv:133.0) Gecko/20100101 Firefox/133.0")
+	phone := newSession("Mozilla/5.0 (iPhone; CPU iPhone OS 18_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Mobile/15E148 Safari/604.1")
+	tablet := newSession("Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36")
+
+	assert.NoError(t, testDB.TouchSession(phone.ID, "10.0.0.2", now.Add(time.Minute)))
+
+	sessions, err := testDB.GetActiveSessions(TestUserRegisterModel.ID, now)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 3)
+	assert.Equal(t, phone.ID, sessions[0].ID)
+	assert.Equal(t, "10.0.0.2", sessions[0].IP)
+	assert.Equal(t, "Safari on iOS", sessions[0].Device())
+
+	saved, err := testDB.GetSession(laptop.ID)
+	assert.NoError(t, err)
+	assert.True(t, saved.Active(now))
+	assert.Equal(t, "Firefox on Linux", saved.Device())
+
+	// A revoked session stays revoked and only its user revokes it.
+	revoked, err := testDB.RevokeSession(uuid.New(), laptop.ID, now)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = testDB.RevokeSession(TestUserRegisterModel.ID, laptop.ID, now)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	saved, err = testDB.GetSession(laptop.ID)
+	assert.NoError(t, err)
+	assert.False(t, saved.Active(now))
+
+	// Signing out elsewhere keeps the current session.
+	assert.NoError(t, testDB.RevokeSessions(TestUserRegisterModel.ID, tablet.ID, now))
+	sessions, err = testDB.GetActiveSessions(TestUserRegisterModel.ID, now)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "Chrome on Android", sessions[0].Device())
+
+	// A renewed session outlives its first expiry, an expired one isn't active.
+	assert.NoError(t, testDB.RenewSession(tablet.ID, now.Add(48*time.Hour)))
+	sessions, err = testDB.GetActiveSessions(TestUserRegisterModel.ID, now.Add(30*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	sessions, err = testDB.GetActiveSessions(TestUserRegisterModel.ID, now.Add(49*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	missing, err := testDB.GetSession(uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
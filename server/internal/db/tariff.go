@@ -0,0 +1,340 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const utilityTariffColumns = `
+	t.id, t.utility_type_id, ut.name, t.name, t.effective_from, t.fixed_fee, t.unit_price,
+	t.night_price, t.vat_percent, t.created_by, t.created_at
+	FROM utility_tariffs t
+	JOIN utility_types ut ON ut.id = t.utility_type_id
+`
+
+func scanUtilityTariff(row interface{ Scan(...any) error }, tariff *models.UtilityTariff) error {
+	return row.Scan(&tariff.ID,
+		&tariff.UtilityTypeID,
+		&tariff.UtilityType,
+		&tariff.Name,
+		&tariff.EffectiveFrom,
+		&tariff.FixedFee,
+		&tariff.UnitPrice,
+		&tariff.NightPrice,
+		&tariff.VATPercent,
+		&tariff.CreatedBy,
+		&tariff.CreatedAt,
+	)
+}
+
+// GetUtilityTariffs retrieves all of a user's tariffs by utility type, newest first.
+func (db *DB) GetUtilityTariffs(userId uuid.UUID) ([]models.UtilityTariff, error) {
+	query := `SELECT ` + utilityTariffColumns + `
+		WHERE t.created_by = $1
+		ORDER BY ut.name, t.effective_from DESC;
+	`
+
+	rows, err := db.conn.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch utility tariffs: %v", err)
+	}
+	defer rows.Close()
+
+	var tariffs []models.UtilityTariff
+	for rows.Next() {
+		var tariff models.UtilityTariff
+		if err = scanUtilityTariff(rows, &tariff); err != nil {
+			return nil, fmt.Errorf("failed to scan utility tariffs: %v", err)
+		}
+		tariffs = append(tariffs, tariff)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch utility tariffs: %v", err)
+	}
+
+	return tariffs, nil
+}
+
+// GetUtilityTariffByID retrieves a tariff by Id, returns nil when it doesn't exist.
+func (db *DB) GetUtilityTariffByID(id int) (*models.UtilityTariff, error) {
+	query := `SELECT ` + utilityTariffColumns + `
+		WHERE t.id = $1;
+	`
+
+	var tariff models.UtilityTariff
+	err := scanUtilityTariff(db.conn.QueryRow(query, id), &tariff)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get utility tariff: %w", err)
+	}
+
+	return &tariff, nil
+}
+
+func (db *DB) CreateUtilityTariff(input *models.UtilityTariff) error {
+	query := `
+		INSERT INTO utility_tariffs (utility_type_id, name, effective_from, fixed_fee, unit_price,
+			night_price, vat_percent, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.UtilityTypeID,
+		input.Name,
+		input.EffectiveFrom,
+		input.FixedFee,
+		input.UnitPrice,
+		input.NightPrice,
+		input.VATPercent,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create utility tariff: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditUtilityTariff(input *models.UtilityTariff) error {
+	query := `
+		UPDATE utility_tariffs
+		SET
+			utility_type_id = $2,
+			name = $3,
+			effective_from = $4,
+			fixed_fee = $5,
+			unit_price = $6,
+			night_price = $7,
+			vat_percent = $8
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.UtilityTypeID,
+		input.Name,
+		input.EffectiveFrom,
+		input.FixedFee,
+		input.UnitPrice,
+		input.NightPrice,
+		input.VATPercent,
+	)
+	if err != nil {
+		return fmt.Errorf("error editing utility tariff: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteUtilityTariff(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM utility_tariffs WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting utility tariff: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting utility tariff: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+const meterReadingColumns = `
+	r.id, r.utility_type_id, ut.name, r.read_on, r.value, r.night_value, r.created_by, r.created_at
+	FROM meter_readings r
+	JOIN utility_types ut ON ut.id = r.utility_type_id
+`
+
+func scanMeterReading(row interface{ Scan(...any) error }, reading *models.MeterReading) error {
+	return row.Scan(&reading.ID,
+		&reading.UtilityTypeID,
+		&reading.UtilityType,
+		&reading.Date,
+		&reading.Value,
+		&reading.NightValue,
+		&reading.CreatedBy,
+		&reading.CreatedAt,
+	)
+}
+
+// GetMeterReadings retrieves all of a user's meter readings, newest first.
+func (db *DB) GetMeterReadings(userId uuid.UUID) ([]models.MeterReading, error) {
+	query := `SELECT ` + meterReadingColumns + `
+		WHERE r.created_by = $1
+		ORDER BY r.read_on DESC, ut.name;
+	`
+
+	rows, err := db.conn.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch meter readings: %v", err)
+	}
+	defer rows.Close()
+
+	var readings []models.MeterReading
+	for rows.Next() {
+		var reading models.MeterReading
+		if err = scanMeterReading(rows, &reading); err != nil {
+			return nil, fmt.Errorf("failed to scan meter readings: %v", err)
+		}
+		readings = append(readings, reading)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch meter readings: %v", err)
+	}
+
+	return readings, nil
+}
+
+// GetMeterReadingByID retrieves a meter reading by Id, returns nil when it doesn't exist.
+func (db *DB) GetMeterReadingByID(id int) (*models.MeterReading, error) {
+	query := `SELECT ` + meterReadingColumns + `
+		WHERE r.id = $1;
+	`
+
+	var reading models.MeterReading
+	err := scanMeterReading(db.conn.QueryRow(query, id), &reading)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get meter reading: %w", err)
+	}
+
+	return &reading, nil
+}
+
+func (db *DB) CreateMeterReading(input *models.MeterReading) error {
+	query := `
+		INSERT INTO meter_readings (utility_type_id, read_on, value, night_value, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.UtilityTypeID,
+		input.Date,
+		input.Value,
+		input.NightValue,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create meter reading: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditMeterReading(input *models.MeterReading) error {
+	query := `
+		UPDATE meter_readings
+		SET
+			utility_type_id = $2,
+			read_on = $3,
+			value = $4,
+			night_value = $5
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.UtilityTypeID,
+		input.Date,
+		input.Value,
+		input.NightValue,
+	)
+	if err != nil {
+		return fmt.Errorf("error editing meter reading: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteMeterReading(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM meter_readings WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting meter reading: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting meter reading: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+// GetMeterReadingUsers retrieves the users who recorded meter readings since, for
+// the reminders worker to check for bills due.
+func (db *DB) GetMeterReadingUsers(since time.Time) ([]uuid.UUID, error) {
+	rows, err := db.conn.Query(`SELECT DISTINCT created_by FROM meter_readings WHERE read_on >= $1 ORDER BY created_by`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch meter reading users: %v", err)
+	}
+	defer rows.Close()
+
+	var users []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan meter reading users: %v", err)
+		}
+		users = append(users, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch meter reading users: %v", err)
+	}
+
+	return users, nil
+}
+
+// GetMeteredBills retrieves the user's home expenses of the metered utility types
+// from since on, oldest first, to match them to the bill estimates.
+func (db *DB) GetMeteredBills(userId uuid.UUID, since time.Time) ([]models.HouseExpense, error) {
+	query := `
+		SELECT he.id, he.utility_type_id, ut.name, he.amount, he.expense_date, he.notes
+		FROM home_expenses he
+		JOIN utility_types ut ON he.utility_type_id = ut.id
+		WHERE he.created_by = $1 AND ut.name = ANY($2) AND he.expense_date >= $3
+		ORDER BY he.expense_date, he.id;
+	`
+
+	rows, err := db.conn.Query(query, userId, pq.Array(models.MeteredUtilities), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch utility bills: %v", err)
+	}
+	defer rows.Close()
+
+	var bills []models.HouseExpense
+	for rows.Next() {
+		var bill models.HouseExpense
+		err = rows.Scan(&bill.ID,
+			&bill.UtilityTypeID,
+			&bill.UtilityType,
+			&bill.Amount,
+			&bill.ExpenseDate,
+			&bill.Notes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan utility bills: %v", err)
+		}
+		bills = append(bills, bill)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch utility bills: %v", err)
+	}
+
+	return bills, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"expenser/internal/tariff"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUtilityTariffs(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Utility Tariffs %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	night := 0.12
+	electricity := &models.UtilityTariff{
+		UtilityTypeID: 1,
+		Name:          "Two zone",
+		EffectiveFrom: day,
+		FixedFee:      2.5,
+		UnitPrice:     0.25,
+		NightPrice:    &night,
+		VATPercent:    20,
+		CreatedBy:     TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateUtilityTariff(electricity))
+
+	// A schedule has one tariff per utility type and date.
+	duplicate := *electricity
+	assert.Error(t, testDB.CreateUtilityTariff(&duplicate))
+
+	electricity.FixedFee = 3
+	assert.NoError(t, testDB.EditUtilityTariff(electricity))
+
+	saved, err := testDB.GetUtilityTariffByID(electricity.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Electricity", saved.UtilityType)
+	assert.Equal(t, 3.0, saved.FixedFee)
+	assert.Equal(t, 0.12, *saved.NightPrice)
+
+	nightStart, nightEnd := 400.0, 480.0
+	first := &models.MeterReading{UtilityTypeID: 1, Date: day, Value: 1000, NightValue: &nightStart, CreatedBy: TestUserRegisterModel.ID}
+	second := &models.MeterReading{UtilityTypeID: 1, Date: day.AddDate(0, 1, 0), Value: 1150, NightValue: &nightEnd, CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateMeterReading(first))
+	assert.NoError(t, testDB.CreateMeterReading(second))
+
+	bill := &models.HouseExpense{UtilityTypeID: 1, Amount: 90, ExpenseDate: day.AddDate(0, 1, 10), CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateHouseExpense(bill))
+	internet := &models.HouseExpense{UtilityTypeID: 4, Amount: 30, ExpenseDate: day.AddDate(0, 1, 10), CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateHouseExpense(internet))
+
+	tariffs, err := testDB.GetUtilityTariffs(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	readings, err := testDB.GetMeterReadings(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 2)
+
+	bills, err := testDB.GetMeteredBills(TestUserRegisterModel.ID, day)
+	assert.NoError(t, err)
+	assert.Len(t, bills, 1)
+
+	estimates := tariff.Estimates(tariffs, readings, bills)
+	assert.Len(t, estimates, 1)
+	assert.Equal(t, 230.0, estimates[0].Units)
+	assert.Equal(t, bill.ID, estimates[0].Bill.ID)
+	assert.True(t, estimates[0].Flagged())
+
+	ok, err := testDB.DeleteMeterReading(first.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = testDB.DeleteUtilityTariff(electricity.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	deleted, err := testDB.GetUtilityTariffByID(electricity.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, deleted)
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetTelegramLink retrieves the user's Telegram link, returns nil when they never started linking.
+func (db *DB) GetTelegramLink(userId uuid.UUID) (*models.TelegramLink, error) {
+	query := `
+		SELECT user_id, chat_id, COALESCE(link_code, ''), code_expires_at, linked_at
+		FROM telegram_links
+		WHERE user_id = $1;
+	`
+
+	var l models.TelegramLink
+	err := db.conn.QueryRow(query, userId).Scan(&l.UserID, &l.ChatID, &l.LinkCode, &l.CodeExpiresAt, &l.LinkedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get telegram link: %w", err)
+	}
+
+	return &l, nil
+}
+
+// SetTelegramLinkCode gives the user a new link code valid until expiresAt. A
+// chat linked before stays linked until the code is used.
+func (db *DB) SetTelegramLinkCode(userId uuid.UUID, code string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO telegram_links (user_id, link_code, code_expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET link_code = EXCLUDED.link_code, code_expires_at = EXCLUDED.code_expires_at;
+	`
+
+	if _, err := db.conn.Exec(query, userId, code, expiresAt); err != nil {
+		return fmt.Errorf("failed to set telegram link code: %w", err)
+	}
+
+	return nil
+}
+
+// LinkTelegramChat links the chat to the user of the code, when it hasn't expired
+// by now. The chat is unlinked from any other user first. It returns the linked
+// user and false when the code is unknown or expired.
+func (db *DB) LinkTelegramChat(code string, chatID int64, now time.Time) (uuid.UUID, bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	err = tx.QueryRow(`
+		SELECT user_id FROM telegram_links
+		WHERE link_code = $1 AND code_expires_at > $2
+		FOR UPDATE;
+	`, code, now).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, false, nil
+		}
+		return uuid.Nil, false, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+
+	if _, err = tx.Exec(`UPDATE telegram_links SET chat_id = NULL, linked_at = NULL WHERE chat_id = $1`, chatID); err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE telegram_links
+		SET chat_id = $2, link_code = NULL, code_expires_at = NULL, linked_at = $3
+		WHERE user_id = $1;
+	`, userID, chatID, now)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+
+	return userID, true, nil
+}
+
+// GetTelegramChatUser retrieves the user the chat is linked to, returns false when it isn't.
+func (db *DB) GetTelegramChatUser(chatID int64) (uuid.UUID, bool, error) {
+	var userID uuid.UUID
+	err := db.conn.QueryRow(`SELECT user_id FROM telegram_links WHERE chat_id = $1`, chatID).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, false, nil
+		}
+		return uuid.Nil, false, fmt.Errorf("failed to get telegram chat user: %w", err)
+	}
+
+	return userID, true, nil
+}
+
+// DeleteTelegramLink unlinks the user's chat and drops their link code.
+func (db *DB) DeleteTelegramLink(userId uuid.UUID) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM telegram_links WHERE user_id = $1`, userId)
+	if err != nil {
+		return false, fmt.Errorf("error deleting telegram link: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting telegram link: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelegramLink(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Telegram Link %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	link, err := testDB.GetTelegramLink(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, link)
+
+	now := time.Now()
+	assert.NoError(t, testDB.SetTelegramLinkCode(TestUserRegisterModel.ID, "CODE1", now.Add(15*time.Minute)))
+
+	// An expired code doesn't link.
+	_, ok, err := testDB.LinkTelegramChat("CODE1", 99, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	userID, ok, err := testDB.LinkTelegramChat("CODE1", 99, now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, TestUserRegisterModel.ID, userID)
+
+	// The code is used up.
+	_, ok, err = testDB.LinkTelegramChat("CODE1", 99, now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	link, err = testDB.GetTelegramLink(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.True(t, link.Linked())
+	assert.Equal(t, int64(99), *link.ChatID)
+	assert.Empty(t, link.LinkCode)
+
+	userID, ok, err = testDB.GetTelegramChatUser(99)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, TestUserRegisterModel.ID, userID)
+
+	deleted, err := testDB.DeleteTelegramLink(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, ok, err = testDB.GetTelegramChatUser(99)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
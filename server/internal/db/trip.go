@@ -0,0 +1,304 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const tripColumns = `
+	t.id, t.vehicle_id, v.name, t.trip_date, t.start_odometer, t.end_odometer,
+	t.distance, t.purpose, t.business, t.created_by, t.created_at
+	FROM trips t
+	LEFT JOIN vehicles v ON v.id = t.vehicle_id
+`
+
+func scanTrip(row interface{ Scan(...any) error }, trip *models.Trip) error {
+	return row.Scan(&trip.ID,
+		&trip.VehicleID,
+		&trip.Vehicle,
+		&trip.Date,
+		&trip.StartOdometer,
+		&trip.EndOdometer,
+		&trip.Distance,
+		&trip.Purpose,
+		&trip.Business,
+		&trip.CreatedBy,
+		&trip.CreatedAt,
+	)
+}
+
+// GetTrips retrieves a user's trips from start to end, both days included,
+// newest first, with the expenses linked to them.
+func (db *DB) GetTrips(userId uuid.UUID, start, end time.Time) ([]models.Trip, error) {
+	query := `SELECT ` + tripColumns + `
+		WHERE t.created_by = $1 AND t.trip_date >= $2 AND t.trip_date <= $3
+		ORDER BY t.trip_date DESC, t.id DESC;
+	`
+
+	rows, err := db.conn.Query(query, userId, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trips: %v", err)
+	}
+	defer rows.Close()
+
+	var trips []models.Trip
+	for rows.Next() {
+		var trip models.Trip
+		if err = scanTrip(rows, &trip); err != nil {
+			return nil, fmt.Errorf("failed to scan trips: %v", err)
+		}
+		trips = append(trips, trip)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch trips: %v", err)
+	}
+
+	if err = db.attachTripExpenses(trips); err != nil {
+		return nil, err
+	}
+
+	return trips, nil
+}
+
+// attachTripExpenses fills in the expenses linked to each of the trips.
+func (db *DB) attachTripExpenses(trips []models.Trip) error {
+	if len(trips) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(trips))
+	ids := make([]int64, 0, len(trips))
+	for i, trip := range trips {
+		index[trip.ID] = i
+		ids = append(ids, int64(trip.ID))
+	}
+
+	query := `
+		SELECT ce.trip_id, ce.id, ct.name, ce.amount, ce.expense_date, ce.notes
+		FROM car_expenses ce
+		JOIN car_expense_types ct ON ce.car_expense_type_id = ct.id
+		WHERE ce.trip_id = ANY($1)
+		ORDER BY ce.expense_date, ce.id;
+	`
+
+	rows, err := db.conn.Query(query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to fetch trip expenses: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tripID int
+		var exp models.CarExpense
+		err = rows.Scan(&tripID,
+			&exp.ID,
+			&exp.Type,
+			&exp.Amount,
+			&exp.Date,
+			&exp.Notes,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan trip expenses: %v", err)
+		}
+
+		trip := &trips[index[tripID]]
+		trip.Expenses = append(trip.Expenses, exp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch trip expenses: %v", err)
+	}
+
+	return nil
+}
+
+// GetTripByID retrieves a trip by Id with its expenses, returns nil when it doesn't exist.
+func (db *DB) GetTripByID(id int) (*models.Trip, error) {
+	query := `SELECT ` + tripColumns + `
+		WHERE t.id = $1;
+	`
+
+	var trip models.Trip
+	err := scanTrip(db.conn.QueryRow(query, id), &trip)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	trips := []models.Trip{trip}
+	if err = db.attachTripExpenses(trips); err != nil {
+		return nil, err
+	}
+
+	return &trips[0], nil
+}
+
+// GetTripExpenseCandidates retrieves the user's car expenses a trip can be linked
+// to, newest first: the ones from since on that are not on another trip, plus the
+// ones already on the trip with tripID when given.
+func (db *DB) GetTripExpenseCandidates(userId uuid.UUID, since time.Time, tripID *int) ([]models.CarExpense, error) {
+	query := `
+		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes
+		FROM car_expenses ce
+		JOIN car_expense_types ct ON ce.car_expense_type_id = ct.id
+		WHERE ce.created_by = $1 AND (ce.trip_id = $3 OR (ce.trip_id IS NULL AND ce.expense_date >= $2))
+		ORDER BY ce.expense_date DESC, ce.id DESC;
+	`
+
+	rows, err := db.conn.Query(query, userId, since, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch car expenses: %v", err)
+	}
+	defer rows.Close()
+
+	var expenses []models.CarExpense
+	for rows.Next() {
+		var exp models.CarExpense
+		err = rows.Scan(&exp.ID,
+			&exp.Type,
+			&exp.Amount,
+			&exp.Date,
+			&exp.Notes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan car expenses: %v", err)
+		}
+		expenses = append(expenses, exp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch car expenses: %v", err)
+	}
+
+	return expenses, nil
+}
+
+// linkTripExpenses makes expenseIDs the expenses of the trip. Expenses of other
+// users or already on another trip are left alone.
+func linkTripExpenses(tx *sql.Tx, trip *models.Trip, expenseIDs []int) error {
+	ids := make([]int64, 0, len(expenseIDs))
+	for _, id := range expenseIDs {
+		ids = append(ids, int64(id))
+	}
+
+	_, err := tx.Exec(`
+		UPDATE car_expenses SET trip_id = NULL
+		WHERE trip_id = $1 AND NOT (id = ANY($2));
+	`, trip.ID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE car_expenses SET trip_id = $1
+		WHERE id = ANY($2) AND created_by = $3 AND trip_id IS NULL;
+	`, trip.ID, pq.Array(ids), trip.CreatedBy)
+	return err
+}
+
+// CreateTrip adds a trip and links the expenses with expenseIDs to it.
+func (db *DB) CreateTrip(input *models.Trip, expenseIDs []int) error {
+	query := `
+		INSERT INTO trips (vehicle_id, trip_date, start_odometer, end_odometer, distance, purpose, business, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at;
+	`
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create trip: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(query,
+		input.VehicleID,
+		input.Date,
+		input.StartOdometer,
+		input.EndOdometer,
+		input.Distance,
+		input.Purpose,
+		input.Business,
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create trip: %w", err)
+	}
+
+	if err = linkTripExpenses(tx, input, expenseIDs); err != nil {
+		return fmt.Errorf("failed to link trip expenses: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create trip: %w", err)
+	}
+
+	return nil
+}
+
+// EditTrip updates a trip and makes the expenses with expenseIDs the ones linked to it.
+func (db *DB) EditTrip(input *models.Trip, expenseIDs []int) error {
+	query := `
+		UPDATE trips
+		SET
+			vehicle_id = $2,
+			trip_date = $3,
+			start_odometer = $4,
+			end_odometer = $5,
+			distance = $6,
+			purpose = $7,
+			business = $8
+		WHERE id = $1;
+	`
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("error editing trip: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
+		input.ID,
+		input.VehicleID,
+		input.Date,
+		input.StartOdometer,
+		input.EndOdometer,
+		input.Distance,
+		input.Purpose,
+		input.Business,
+	)
+	if err != nil {
+		return fmt.Errorf("error editing trip: %v", err)
+	}
+
+	if err = linkTripExpenses(tx, input, expenseIDs); err != nil {
+		return fmt.Errorf("error linking trip expenses: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error editing trip: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteTrip(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM trips WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting trip: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting trip: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrips(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Trips %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	day := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	tolls := &models.CarExpense{Amount: 12, ExpenseTypeID: 5, Date: day, CreatedBy: TestUserRegisterModel.ID}
+	fuel := &models.CarExpense{Amount: 90, ExpenseTypeID: 1, Date: day, CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateCarExpense(tolls))
+	assert.NoError(t, testDB.CreateCarExpense(fuel))
+
+	start, end := 120000, 120230
+	business := &models.Trip{
+		Date:          day,
+		StartOdometer: &start,
+		EndOdometer:   &end,
+		Distance:      230,
+		Purpose:       "Client meeting",
+		Business:      true,
+		CreatedBy:     TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateTrip(business, []int{tolls.ID, fuel.ID}))
+
+	personal := &models.Trip{Date: day.AddDate(0, 1, 0), Distance: 40, Purpose: "Groceries", CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateTrip(personal, nil))
+
+	// An expense already on a trip can't be linked to another one.
+	other := &models.Trip{Date: day, Distance: 10, Purpose: "Parking", CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateTrip(other, []int{tolls.ID}))
+
+	saved, err := testDB.GetTripByID(business.ID)
+	assert.NoError(t, err)
+	assert.Len(t, saved.Expenses, 2)
+	assert.Equal(t, 12.0, saved.ReimbursableExpenses())
+
+	trips, err := testDB.GetTrips(TestUserRegisterModel.ID, day, day.AddDate(0, 2, 0))
+	assert.NoError(t, err)
+	assert.Len(t, trips, 3)
+
+	summary := models.SummarizeMileage(trips)
+	assert.Equal(t, 230, summary.BusinessKm)
+	assert.Equal(t, 50, summary.PersonalKm)
+	assert.Len(t, summary.Months, 2)
+
+	assert.NoError(t, testDB.SetMileageRate(TestUserRegisterModel.ID, 0.5))
+	rate, err := testDB.GetMileageRate(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+
+	report := models.BuildReimbursement(trips, rate, day, day.AddDate(0, 2, 0))
+	assert.Len(t, report.Lines, 1)
+	assert.Equal(t, 127.0, report.Total())
+
+	// Editing the trip unlinks the expenses left out, which can be linked again.
+	business.ID = saved.ID
+	assert.NoError(t, testDB.EditTrip(business, []int{fuel.ID}))
+
+	candidates, err := testDB.GetTripExpenseCandidates(TestUserRegisterModel.ID, day, nil)
+	assert.NoError(t, err)
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, tolls.ID, candidates[0].ID)
+
+	ok, err := testDB.DeleteTrip(business.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	unlinked, err := testDB.GetCarExpenseByID(fuel.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, unlinked)
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetTwoFactor retrieves the user's two-factor authentication.
+func (db *DB) GetTwoFactor(userId uuid.UUID) (*models.TwoFactor, error) {
+	query := `
+		SELECT COALESCE(totp_secret, ''), totp_enabled_at, totp_last_step, totp_locked_until,
+			(SELECT COUNT(*) FROM recovery_codes WHERE user_id = users.id AND used_at IS NULL)
+		FROM users
+		WHERE id = $1;
+	`
+
+	var tf models.TwoFactor
+	err := db.conn.QueryRow(query, userId).Scan(&tf.Secret, &tf.EnabledAt, &tf.LastStep, &tf.LockedUntil, &tf.RecoveryCodesLeft)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get two-factor authentication: %w", err)
+	}
+
+	return &tf, nil
+}
+
+// SetTOTPSecret gives the user a new pending secret to enrol with. It fails when
+// two-factor authentication is already enabled.
+func (db *DB) SetTOTPSecret(userId uuid.UUID, secret string) error {
+	query := `
+		UPDATE users SET totp_secret = $2
+		WHERE id = $1 AND totp_enabled_at IS NULL;
+	`
+
+	result, err := db.conn.Exec(query, userId, secret)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	return nil
+}
+
+// EnableTwoFactor enables the user's pending secret at now, after a valid code of
+// step, and gives them the recovery codes with the hashes.
+func (db *DB) EnableTwoFactor(userId uuid.UUID, step int64, codeHashes []string, now time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users
+		SET totp_enabled_at = $2, totp_last_step = $3, totp_failures = 0, totp_locked_until = NULL
+		WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL;
+	`, userId, now, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no pending two-factor authentication")
+	}
+
+	if err = replaceRecoveryCodes(tx, userId, codeHashes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes gives the user new recovery codes with the hashes, the
+// old ones stop working.
+func (db *DB) ReplaceRecoveryCodes(userId uuid.UUID, codeHashes []string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = replaceRecoveryCodes(tx, userId, codeHashes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userId uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userId); err != nil {
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userId, hash); err != nil {
+			return fmt.Errorf("failed to replace recovery codes: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DisableTwoFactor turns the user's two-factor authentication off and drops
+// their secret and recovery codes.
+func (db *DB) DisableTwoFactor(userId uuid.UUID) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, totp_failures = 0, totp_locked_until = NULL
+		WHERE id = $1;
+	`, userId)
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	if _, err = tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userId); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep records that the user logged in with a code of step. Returns false
+// when a code of step or a later one was used already, so no code works twice.
+func (db *DB) UseTOTPStep(userId uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE users SET totp_last_step = $2, totp_failures = 0
+		WHERE id = $1 AND totp_enabled_at IS NOT NULL AND (totp_last_step IS NULL OR totp_last_step < $2);
+	`
+
+	result, err := db.conn.Exec(query, userId, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UseRecoveryCode uses up the user's unused recovery code with the hash at now.
+// Returns false when there is no such code.
+func (db *DB) UseRecoveryCode(userId uuid.UUID, codeHash string, now time.Time) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE recovery_codes SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+	`, userId, codeHash, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if _, err = tx.Exec(`UPDATE users SET totp_failures = 0 WHERE id = $1`, userId); err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return true, nil
+}
+
+// RecordTwoFactorFailure counts a wrong code of the user. The maxFailures-th in a
+// row locks the second step of logging in until lockUntil.
+func (db *DB) RecordTwoFactorFailure(userId uuid.UUID, maxFailures int, lockUntil time.Time) error {
+	query := `
+		UPDATE users SET
+			totp_locked_until = CASE WHEN totp_failures + 1 >= $2 THEN $3 ELSE totp_locked_until END,
+			totp_failures = CASE WHEN totp_failures + 1 >= $2 THEN 0 ELSE totp_failures + 1 END
+		WHERE id = $1;
+	`
+
+	if _, err := db.conn.Exec(query, userId, maxFailures, lockUntil); err != nil {
+		return fmt.Errorf("failed to record two-factor failure: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactor(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test TwoFactor %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	user := &models.User{Username: "twofactor", PasswordHash: "hash123"}
+	assert.NoError(t, testDB.CreateUser(user))
+
+	tf, err := testDB.GetTwoFactor(user.ID)
+	assert.NoError(t, err)
+	assert.False(t, tf.Enabled())
+	assert.Empty(t, tf.Secret)
+
+	// Enabling takes a pending secret.
+	now := time.Now()
+	assert.Error(t, testDB.EnableTwoFactor(user.ID, 100, nil, now))
+	assert.NoError(t, testDB.SetTOTPSecret(user.ID, "JBSWY3DPEHPK3PXP"))
+	assert.NoError(t, testDB.EnableTwoFactor(user.ID, 100, []string{"hash-a", "hash-b"}, now))
+	assert.Error(t, testDB.SetTOTPSecret(user.ID, "OTHERSECRET"))
+
+	tf, err = testDB.GetTwoFactor(user.ID)
+	assert.NoError(t, err)
+	assert.True(t, tf.Enabled())
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", tf.Secret)
+	assert.Equal(t, 2, tf.RecoveryCodesLeft)
+
+	// Codes of the step used while enabling and earlier ones are refused.
+	used, err := testDB.UseTOTPStep(user.ID, 100)
+	assert.NoError(t, err)
+	assert.False(t, used)
+	used, err = testDB.UseTOTPStep(user.ID, 101)
+	assert.NoError(t, err)
+	assert.True(t, used)
+	used, err = testDB.UseTOTPStep(user.ID, 101)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	// Recovery codes work once.
+	used, err = testDB.UseRecoveryCode(user.ID, "hash-a", now)
+	assert.NoError(t, err)
+	assert.True(t, used)
+	used, err = testDB.UseRecoveryCode(user.ID, "hash-a", now)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	assert.NoError(t, testDB.ReplaceRecoveryCodes(user.ID, []string{"hash-c"}))
+	used, err = testDB.UseRecoveryCode(user.ID, "hash-b", now)
+	assert.NoError(t, err)
+	assert.False(t, used)
+	tf, err = testDB.GetTwoFactor(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tf.RecoveryCodesLeft)
+
+	// The last of a run of failures locks it.
+	lockUntil := now.Add(15 * time.Minute)
+	for range 2 {
+		assert.NoError(t, testDB.RecordTwoFactorFailure(user.ID, 3, lockUntil))
+	}
+	tf, err = testDB.GetTwoFactor(user.ID)
+	assert.NoError(t, err)
+	assert.False(t, tf.Locked(now))
+	assert.NoError(t, testDB.RecordTwoFactorFailure(user.ID, 3, lockUntil))
+	tf, err = testDB.GetTwoFactor(user.ID)
+	assert.NoError(t, err)
+	assert.True(t, tf.Locked(now))
+	assert.False(t, tf.Locked(lockUntil.Add(time.Second)))
+
+	assert.NoError(t, testDB.DisableTwoFactor(user.ID))
+	tf, err = testDB.GetTwoFactor(user.ID)
+	assert.NoError(t, err)
+	assert.False(t, tf.Enabled())
+	assert.Empty(t, tf.Secret)
+	assert.Zero(t, tf.RecoveryCodesLeft)
+	assert.False(t, tf.Locked(now))
+}
@@ -12,8 +12,8 @@ import (
 // CreateUser creates a new user in the database
 func (db *DB) CreateUser(user *models.User) error {
 	query := `
-		INSERT INTO users (username, password_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (username, password_hash, email, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
 		RETURNING id, created_at, updated_at`
 
 	now := time.Now()
@@ -21,6 +21,7 @@ func (db *DB) CreateUser(user *models.User) error {
 		query,
 		user.Username,
 		user.PasswordHash,
+		user.Email,
 		now,
 		now,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
@@ -35,7 +36,7 @@ func (db *DB) CreateUser(user *models.User) error {
 // GetUserByID retrieves a user by their ID
 func (db *DB) GetUserByID(id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, created_at, updated_at
+		SELECT id, username, password_hash, COALESCE(email, ''), email_verified_at, created_at, updated_at
 		FROM users
 		WHERE id = $1`
 
@@ -44,6 +45,8 @@ func (db *DB) GetUserByID(id uuid.UUID) (*models.User, error) {
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
+		&user.Email,
+		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -61,7 +64,7 @@ func (db *DB) GetUserByID(id uuid.UUID) (*models.User, error) {
 // GetUserByUsername retrieves a user by their username
 func (db *DB) GetUserByUsername(username string) (*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, created_at, updated_at
+		SELECT id, username, password_hash, COALESCE(email, ''), email_verified_at, created_at, updated_at
 		FROM users
 		WHERE username = $1`
 
@@ -70,6 +73,8 @@ func (db *DB) GetUserByUsername(username string) (*models.User, error) {
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
+		&user.Email,
+		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -84,25 +89,28 @@ func (db *DB) GetUserByUsername(username string) (*models.User, error) {
 	return user, nil
 }
 
-// GetUserByEmail retrieves a user by their email
+// GetUserByEmail retrieves a user by their verified email, whatever its case.
+// It returns nil without an error when no user verified the address.
 func (db *DB) GetUserByEmail(email string) (*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, created_at, updated_at
+		SELECT id, username, password_hash, COALESCE(email, ''), email_verified_at, created_at, updated_at
 		FROM users
-		WHERE email = $1`
+		WHERE LOWER(email) = LOWER($1) AND email_verified_at IS NOT NULL`
 
 	user := &models.User{}
 	err := db.conn.QueryRow(query, email).Scan(
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
+		&user.Email,
+		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -167,7 +175,7 @@ func (db *DB) DeleteUser(id uuid.UUID) error {
 // ListUsers retrieves all users from the database (for admin purposes)
 func (db *DB) ListUsers(limit, offset int) ([]*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, created_at, updated_at
+		SELECT id, username, password_hash, COALESCE(email, ''), email_verified_at, created_at, updated_at
 		FROM users
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2`
@@ -185,6 +193,8 @@ func (db *DB) ListUsers(limit, offset int) ([]*models.User, error) {
 			&user.ID,
 			&user.Username,
 			&user.PasswordHash,
+			&user.Email,
+			&user.EmailVerifiedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -226,3 +236,163 @@ func (db *DB) UserExists(username string) (bool, error) {
 
 	return exists, nil
 }
+
+// GetMileageRate retrieves the per-km rate a user's business trips are reimbursed at
+func (db *DB) GetMileageRate(id uuid.UUID) (float64, error) {
+	var rate float64
+	err := db.conn.QueryRow(`SELECT mileage_rate FROM users WHERE id = $1`, id).Scan(&rate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("user not found")
+		}
+		return 0, fmt.Errorf("failed to get mileage rate: %w", err)
+	}
+
+	return rate, nil
+}
+
+// SetMileageRate updates the per-km rate a user's business trips are reimbursed at
+func (db *DB) SetMileageRate(id uuid.UUID, rate float64) error {
+	result, err := db.conn.Exec(`UPDATE users SET mileage_rate = $2, updated_at = $3 WHERE id = $1`, id, rate, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set mileage rate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// SetPassword changes the user's password at now. All sessions of the user but
+// the kept one are logged out and pending password resets are dropped.
+func (db *DB) SetPassword(id uuid.UUID, passwordHash string, keep uuid.UUID, now time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users
+		SET password_hash = $2, updated_at = $3
+		WHERE id = $1;
+	`, id, passwordHash, now)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	if _, err = tx.Exec(`DELETE FROM password_resets WHERE user_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	if _, err = tx.Exec(`
+		UPDATE sessions SET revoked_at = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL;
+	`, id, keep, now); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePasswordReset stores the hash of a password reset token valid until expiresAt.
+func (db *DB) CreatePasswordReset(id uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO password_resets (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3);
+	`
+
+	if _, err := db.conn.Exec(query, tokenHash, id, expiresAt); err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	return nil
+}
+
+// GetPasswordResetUser retrieves the user of a password reset token that hasn't
+// expired by now, returns false when there is none.
+func (db *DB) GetPasswordResetUser(tokenHash string, now time.Time) (uuid.UUID, bool, error) {
+	query := `
+		SELECT user_id FROM password_resets
+		WHERE token_hash = $1 AND expires_at > $2;
+	`
+
+	var id uuid.UUID
+	err := db.conn.QueryRow(query, tokenHash, now).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, false, nil
+		}
+		return uuid.Nil, false, fmt.Errorf("failed to get password reset: %w", err)
+	}
+
+	return id, true, nil
+}
+
+// SetEmail changes the user's email address, an empty one removes it. The new
+// address is unverified.
+func (db *DB) SetEmail(id uuid.UUID, email string) error {
+	query := `
+		UPDATE users
+		SET email = NULLIF($2, ''), email_verified_at = NULL, updated_at = $3
+		WHERE id = $1;
+	`
+
+	result, err := db.conn.Exec(query, id, email, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the user's email address verified at now, if it still is
+// email. Returns false when the user has another address by now.
+func (db *DB) VerifyEmail(id uuid.UUID, email string, now time.Time) (bool, error) {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, $3)
+		WHERE id = $1 AND email = $2;
+	`
+
+	result, err := db.conn.Exec(query, id, email, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
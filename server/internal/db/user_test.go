@@ -5,6 +5,7 @@ import (
 	"expenser/internal/models"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -523,3 +524,121 @@ func TestUserExists(t *testing.T) {
 		})
 	}
 }
+
+func TestSetPassword(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test SetPassword %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	user := &models.User{Username: "resetme", PasswordHash: "hash123"}
+	assert.NoError(t, testDB.CreateUser(user))
+
+	now := time.Now()
+	newSession := func() *models.Session {
+		s := &models.Session{ID: uuid.New(), UserID: user.ID, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+		assert.NoError(t, testDB.CreateSession(s))
+		return s
+	}
+	current := newSession()
+	other := newSession()
+
+	assert.NoError(t, testDB.CreatePasswordReset(user.ID, "expired", now.Add(-time.Minute)))
+	assert.NoError(t, testDB.CreatePasswordReset(user.ID, "valid", now.Add(time.Hour)))
+
+	_, ok, err := testDB.GetPasswordResetUser("expired", now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	id, ok, err := testDB.GetPasswordResetUser("valid", now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, user.ID, id)
+
+	assert.NoError(t, testDB.SetPassword(user.ID, "newhash", current.ID, now))
+
+	saved, err := testDB.GetUserByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "newhash", saved.PasswordHash)
+
+	// A password change logs out the other sessions.
+	sessions, err := testDB.GetActiveSessions(user.ID, now)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, current.ID, sessions[0].ID)
+
+	revoked, err := testDB.GetSession(other.ID)
+	assert.NoError(t, err)
+	assert.False(t, revoked.Active(now))
+
+	// A password change uses up the pending resets.
+	_, ok, err = testDB.GetPasswordResetUser("valid", now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.Error(t, testDB.SetPassword(uuid.New(), "newhash", uuid.Nil, now))
+}
+
+func TestUserEmail(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test UserEmail %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	user := &models.User{Username: "withemail", PasswordHash: "hash123", Email: "Ivan@example.com"}
+	assert.NoError(t, testDB.CreateUser(user))
+	other := &models.User{Username: "noemail", PasswordHash: "hash123"}
+	assert.NoError(t, testDB.CreateUser(other))
+
+	saved, err := testDB.GetUserByID(other.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, saved.Email)
+
+	// Unverified addresses don't log in.
+	found, err := testDB.GetUserByEmail("ivan@example.com")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	// Nobody else can verify it, nor an address the user changed since.
+	verified, err := testDB.VerifyEmail(other.ID, "Ivan@example.com", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, verified)
+
+	verified, err = testDB.VerifyEmail(user.ID, "Ivan@example.com", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	found, err = testDB.GetUserByEmail("ivan@EXAMPLE.com")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, "Ivan@example.com", found.VerifiedEmail())
+
+	// Another account may claim the address but not verify it too.
+	assert.NoError(t, testDB.SetEmail(other.ID, "ivan@example.com"))
+	_, err = testDB.VerifyEmail(other.ID, "ivan@example.com", time.Now())
+	assert.Error(t, err)
+
+	// A new address starts unverified, an empty one removes it.
+	assert.NoError(t, testDB.SetEmail(user.ID, "new@example.com"))
+	saved, err = testDB.GetUserByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "new@example.com", saved.Email)
+	assert.Nil(t, saved.EmailVerifiedAt)
+
+	assert.NoError(t, testDB.SetEmail(user.ID, ""))
+	saved, err = testDB.GetUserByID(user.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, saved.Email)
+
+	assert.Error(t, testDB.SetEmail(uuid.New(), "x@example.com"))
+}
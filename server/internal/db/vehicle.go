@@ -0,0 +1,161 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// vehicleColumns selects a vehicle with its latest known odometer reading,
+// the hand entered one or a newer one from its car expenses.
+const vehicleColumns = `
+	v.id, v.name, v.plate_number,
+	GREATEST(v.odometer, COALESCE((SELECT MAX(ce.odometer) FROM car_expenses ce WHERE ce.vehicle_id = v.id), 0)),
+	v.odometer_date, v.created_by, v.created_at,
+	v.purchase_price, v.purchase_date, v.depreciation, v.useful_life_years, v.residual_value
+	FROM vehicles v
+`
+
+func scanVehicle(row interface{ Scan(...any) error }, v *models.Vehicle) error {
+	return row.Scan(&v.ID,
+		&v.Name,
+		&v.PlateNumber,
+		&v.Odometer,
+		&v.OdometerDate,
+		&v.CreatedBy,
+		&v.CreatedAt,
+		&v.PurchasePrice,
+		&v.PurchaseDate,
+		&v.Depreciation,
+		&v.UsefulLifeYears,
+		&v.ResidualValue,
+	)
+}
+
+// GetVehicles retrieves all vehicles of a user ordered by name.
+func (db *DB) GetVehicles(userId uuid.UUID) (*[]models.Vehicle, error) {
+	query := `SELECT ` + vehicleColumns + `
+		WHERE v.created_by = $1
+		ORDER BY v.name;
+	`
+
+	rows, err := db.conn.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicles: %v", err)
+	}
+	defer rows.Close()
+
+	var vehicles []models.Vehicle
+	for rows.Next() {
+		var v models.Vehicle
+		if err = scanVehicle(rows, &v); err != nil {
+			return nil, fmt.Errorf("failed to scan vehicles: %v", err)
+		}
+		vehicles = append(vehicles, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicles: %v", err)
+	}
+
+	return &vehicles, nil
+}
+
+// GetVehicleByID retrieves a vehicle by Id, returns nil when it doesn't exist.
+func (db *DB) GetVehicleByID(id int) (*models.Vehicle, error) {
+	query := `SELECT ` + vehicleColumns + `
+		WHERE v.id = $1;
+	`
+
+	var v models.Vehicle
+	err := scanVehicle(db.conn.QueryRow(query, id), &v)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get vehicle: %w", err)
+	}
+
+	return &v, nil
+}
+
+func (db *DB) CreateVehicle(input *models.Vehicle) error {
+	query := `
+		INSERT INTO vehicles (name, plate_number, odometer, odometer_date, created_by,
+			purchase_price, purchase_date, depreciation, useful_life_years, residual_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.Name,
+		input.PlateNumber,
+		input.Odometer,
+		input.OdometerDate,
+		input.CreatedBy,
+		input.PurchasePrice,
+		input.PurchaseDate,
+		input.Depreciation,
+		input.UsefulLifeYears,
+		input.ResidualValue,
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create vehicle: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditVehicle(input *models.Vehicle) error {
+	query := `
+		UPDATE vehicles
+		SET
+			name = $2,
+			plate_number = $3,
+			odometer = $4,
+			odometer_date = $5,
+			purchase_price = $6,
+			purchase_date = $7,
+			depreciation = $8,
+			useful_life_years = $9,
+			residual_value = $10
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.Name,
+		input.PlateNumber,
+		input.Odometer,
+		input.OdometerDate,
+		input.PurchasePrice,
+		input.PurchaseDate,
+		input.Depreciation,
+		input.UsefulLifeYears,
+		input.ResidualValue,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error editing vehicle: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteVehicle removes a vehicle with its service plans, its car expenses are kept unlinked.
+func (db *DB) DeleteVehicle(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM vehicles WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting vehicle: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting vehicle: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
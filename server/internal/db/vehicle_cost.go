@@ -0,0 +1,183 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+)
+
+// GetVehicleValuations retrieves the valuations of a vehicle, oldest first.
+func (db *DB) GetVehicleValuations(vehicleID int) ([]models.VehicleValuation, error) {
+	query := `
+		SELECT id, vehicle_id, valued_on, value, created_at
+		FROM vehicle_valuations
+		WHERE vehicle_id = $1
+		ORDER BY valued_on;
+	`
+
+	rows, err := db.conn.Query(query, vehicleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicle valuations: %v", err)
+	}
+	defer rows.Close()
+
+	var valuations []models.VehicleValuation
+	for rows.Next() {
+		var valuation models.VehicleValuation
+		err = rows.Scan(&valuation.ID,
+			&valuation.VehicleID,
+			&valuation.Date,
+			&valuation.Value,
+			&valuation.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan vehicle valuations: %v", err)
+		}
+		valuations = append(valuations, valuation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicle valuations: %v", err)
+	}
+
+	return valuations, nil
+}
+
+// GetVehicleValuationByID retrieves a valuation by Id, returns nil when it doesn't exist.
+func (db *DB) GetVehicleValuationByID(id int) (*models.VehicleValuation, error) {
+	query := `
+		SELECT id, vehicle_id, valued_on, value, created_at
+		FROM vehicle_valuations
+		WHERE id = $1;
+	`
+
+	var valuation models.VehicleValuation
+	err := db.conn.QueryRow(query, id).Scan(&valuation.ID,
+		&valuation.VehicleID,
+		&valuation.Date,
+		&valuation.Value,
+		&valuation.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get vehicle valuation: %w", err)
+	}
+
+	return &valuation, nil
+}
+
+// SaveVehicleValuation adds a valuation, replacing the one on the same date.
+func (db *DB) SaveVehicleValuation(input *models.VehicleValuation) error {
+	query := `
+		INSERT INTO vehicle_valuations (vehicle_id, valued_on, value)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (vehicle_id, valued_on) DO UPDATE SET value = EXCLUDED.value
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.VehicleID,
+		input.Date,
+		input.Value,
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save vehicle valuation: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteVehicleValuation(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM vehicle_valuations WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting vehicle valuation: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting vehicle valuation: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+// GetVehicleExpensesBetween retrieves a vehicle's car expenses from start to end, both days included.
+func (db *DB) GetVehicleExpensesBetween(vehicleID int, start, end time.Time) ([]models.CarExpense, error) {
+	query := `
+		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.odometer, ce.fuel_litres
+		FROM car_expenses ce
+		JOIN car_expense_types ct ON ce.car_expense_type_id = ct.id
+		WHERE ce.vehicle_id = $1 AND ce.expense_date >= $2 AND ce.expense_date <= $3
+		ORDER BY ce.expense_date, ce.id;
+	`
+
+	rows, err := db.conn.Query(query, vehicleID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicle expenses: %v", err)
+	}
+	defer rows.Close()
+
+	var expenses []models.CarExpense
+	for rows.Next() {
+		exp := models.CarExpense{VehicleID: &vehicleID}
+		err = rows.Scan(&exp.ID,
+			&exp.Type,
+			&exp.Amount,
+			&exp.Date,
+			&exp.Notes,
+			&exp.Odometer,
+			&exp.FuelLitres,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan vehicle expenses: %v", err)
+		}
+		expenses = append(expenses, exp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicle expenses: %v", err)
+	}
+
+	return expenses, nil
+}
+
+// GetOdometerReadings retrieves every odometer reading of a vehicle, the ones on
+// its car expenses and charging sessions and the one entered by hand, oldest first.
+func (db *DB) GetOdometerReadings(vehicleID int) ([]models.OdometerReading, error) {
+	query := `
+		SELECT expense_date, odometer FROM car_expenses
+		WHERE vehicle_id = $1 AND odometer IS NOT NULL
+		UNION ALL
+		SELECT charged_on, odometer FROM charging_sessions
+		WHERE vehicle_id = $1 AND odometer IS NOT NULL AND car_expense_id IS NULL
+		UNION ALL
+		SELECT odometer_date, odometer FROM vehicles
+		WHERE id = $1 AND odometer_date IS NOT NULL
+		ORDER BY 1, 2;
+	`
+
+	rows, err := db.conn.Query(query, vehicleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch odometer readings: %v", err)
+	}
+	defer rows.Close()
+
+	var readings []models.OdometerReading
+	for rows.Next() {
+		var reading models.OdometerReading
+		if err = rows.Scan(&reading.Date, &reading.Km); err != nil {
+			return nil, fmt.Errorf("failed to scan odometer readings: %v", err)
+		}
+		readings = append(readings, reading)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch odometer readings: %v", err)
+	}
+
+	return readings, nil
+}
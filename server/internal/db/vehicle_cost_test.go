@@ -0,0 +1,91 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleOwnershipCost(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Vehicle Ownership Cost %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	price := 20000.0
+	bought := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	vehicle := &models.Vehicle{
+		Name:          "Golf",
+		PlateNumber:   "CB1234AB",
+		PurchasePrice: &price,
+		PurchaseDate:  &bought,
+		Depreciation:  models.DepreciationValuations,
+		CreatedBy:     TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateVehicle(vehicle))
+
+	valuation := &models.VehicleValuation{VehicleID: vehicle.ID, Date: bought.AddDate(1, 0, 0), Value: 17000}
+	assert.NoError(t, testDB.SaveVehicleValuation(valuation))
+
+	// A second valuation on the same date replaces the first.
+	again := &models.VehicleValuation{VehicleID: vehicle.ID, Date: bought.AddDate(1, 0, 0), Value: 16500}
+	assert.NoError(t, testDB.SaveVehicleValuation(again))
+	assert.Equal(t, valuation.ID, again.ID)
+
+	valuations, err := testDB.GetVehicleValuations(vehicle.ID)
+	assert.NoError(t, err)
+	assert.Len(t, valuations, 1)
+	assert.Equal(t, 16500.0, valuations[0].Value)
+
+	first, second := 10000, 12500
+	litres := 42.5
+	fuel := &models.CarExpense{
+		Amount:        110,
+		ExpenseTypeID: 1,
+		Date:          bought.AddDate(0, 2, 0),
+		CreatedBy:     TestUserRegisterModel.ID,
+		VehicleID:     &vehicle.ID,
+		Odometer:      &first,
+		FuelLitres:    &litres,
+	}
+	assert.NoError(t, testDB.CreateCarExpense(fuel))
+
+	later := &models.CarExpense{
+		Amount:        300,
+		ExpenseTypeID: 2,
+		Date:          bought.AddDate(0, 8, 0),
+		CreatedBy:     TestUserRegisterModel.ID,
+		VehicleID:     &vehicle.ID,
+		Odometer:      &second,
+	}
+	assert.NoError(t, testDB.CreateCarExpense(later))
+
+	saved, err := testDB.GetCarExpenseByID(fuel.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, litres, *saved.FuelLitres)
+
+	expenses, err := testDB.GetVehicleExpensesBetween(vehicle.ID, bought, bought.AddDate(0, 6, 0))
+	assert.NoError(t, err)
+	assert.Len(t, expenses, 1)
+	assert.Equal(t, fuel.ID, expenses[0].ID)
+
+	readings, err := testDB.GetOdometerReadings(vehicle.ID)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 2)
+	assert.Equal(t, first, readings[0].Km)
+
+	ok, err := testDB.DeleteVehicleValuation(valuation.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
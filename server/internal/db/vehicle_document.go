@@ -0,0 +1,229 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// vehicleDocumentColumns selects a document with the amount of its linked expense
+// and whether a later document of the same kind replaced it.
+const vehicleDocumentColumns = `
+	d.id, d.vehicle_id, v.name, d.kind, d.reference, d.starts_on, d.expires_on,
+	d.car_expense_id, ce.amount, d.remind_days,
+	EXISTS (
+		SELECT 1 FROM vehicle_documents later
+		WHERE later.vehicle_id = d.vehicle_id AND later.kind = d.kind AND later.expires_on > d.expires_on
+	),
+	d.created_by, d.created_at
+	FROM vehicle_documents d
+	JOIN vehicles v ON v.id = d.vehicle_id
+	LEFT JOIN car_expenses ce ON ce.id = d.car_expense_id
+`
+
+func scanVehicleDocument(row interface{ Scan(...any) error }, doc *models.VehicleDocument) error {
+	var remindDays pq.Int64Array
+
+	err := row.Scan(&doc.ID,
+		&doc.VehicleID,
+		&doc.Vehicle,
+		&doc.Kind,
+		&doc.Reference,
+		&doc.StartsOn,
+		&doc.ExpiresOn,
+		&doc.ExpenseID,
+		&doc.Cost,
+		&remindDays,
+		&doc.Renewed,
+		&doc.CreatedBy,
+		&doc.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	doc.RemindDays = make([]int, 0, len(remindDays))
+	for _, day := range remindDays {
+		doc.RemindDays = append(doc.RemindDays, int(day))
+	}
+
+	return nil
+}
+
+func (db *DB) queryVehicleDocuments(query string, args ...any) ([]models.VehicleDocument, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicle documents: %v", err)
+	}
+	defer rows.Close()
+
+	var docs []models.VehicleDocument
+	for rows.Next() {
+		var doc models.VehicleDocument
+		if err = scanVehicleDocument(rows, &doc); err != nil {
+			return nil, fmt.Errorf("failed to scan vehicle documents: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicle documents: %v", err)
+	}
+
+	return docs, nil
+}
+
+// GetVehicleDocuments retrieves the documents of all vehicles of a user, soonest expiry first.
+func (db *DB) GetVehicleDocuments(userId uuid.UUID) ([]models.VehicleDocument, error) {
+	query := `SELECT ` + vehicleDocumentColumns + `
+		WHERE d.created_by = $1
+		ORDER BY d.expires_on, v.name, d.kind;
+	`
+	return db.queryVehicleDocuments(query, userId)
+}
+
+// GetAllCurrentVehicleDocuments retrieves every user's documents that were not
+// renewed yet, for the reminders worker.
+func (db *DB) GetAllCurrentVehicleDocuments() ([]models.VehicleDocument, error) {
+	query := `SELECT ` + vehicleDocumentColumns + `
+		WHERE NOT EXISTS (
+			SELECT 1 FROM vehicle_documents later
+			WHERE later.vehicle_id = d.vehicle_id AND later.kind = d.kind AND later.expires_on > d.expires_on
+		)
+		ORDER BY d.created_by, d.expires_on;
+	`
+	return db.queryVehicleDocuments(query)
+}
+
+// GetVehicleDocumentByID retrieves a document by Id, returns nil when it doesn't exist.
+func (db *DB) GetVehicleDocumentByID(id int) (*models.VehicleDocument, error) {
+	query := `SELECT ` + vehicleDocumentColumns + `
+		WHERE d.id = $1;
+	`
+
+	var doc models.VehicleDocument
+	err := scanVehicleDocument(db.conn.QueryRow(query, id), &doc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get vehicle document: %w", err)
+	}
+
+	return &doc, nil
+}
+
+func (db *DB) CreateVehicleDocument(input *models.VehicleDocument) error {
+	query := `
+		INSERT INTO vehicle_documents (vehicle_id, kind, reference, starts_on, expires_on, car_expense_id, remind_days, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.VehicleID,
+		input.Kind,
+		input.Reference,
+		input.StartsOn,
+		input.ExpiresOn,
+		input.ExpenseID,
+		pq.Array(input.RemindDays),
+		input.CreatedBy,
+	).Scan(&input.ID, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create vehicle document: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditVehicleDocument(input *models.VehicleDocument) error {
+	query := `
+		UPDATE vehicle_documents
+		SET
+			vehicle_id = $2,
+			kind = $3,
+			reference = $4,
+			starts_on = $5,
+			expires_on = $6,
+			car_expense_id = $7,
+			remind_days = $8
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.VehicleID,
+		input.Kind,
+		input.Reference,
+		input.StartsOn,
+		input.ExpiresOn,
+		input.ExpenseID,
+		pq.Array(input.RemindDays),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error editing vehicle document: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteVehicleDocument(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM vehicle_documents WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting vehicle document: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting vehicle document: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+// GetCarExpensesSince retrieves the user's car expenses from since on, newest first,
+// plus the expense with includeID when given, for linking documents to what they cost.
+func (db *DB) GetCarExpensesSince(userId uuid.UUID, since time.Time, includeID *int) (*[]models.CarExpense, error) {
+	query := `
+		SELECT ce.id, ct.name, ce.amount, ce.expense_date, ce.notes, ce.vehicle_id
+		FROM car_expenses ce
+		JOIN car_expense_types ct ON ce.car_expense_type_id = ct.id
+		WHERE ce.created_by = $1 AND (ce.expense_date >= $2 OR ce.id = $3)
+		ORDER BY ce.expense_date DESC, ce.id DESC;
+	`
+
+	rows, err := db.conn.Query(query, userId, since, includeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch car expenses: %v", err)
+	}
+	defer rows.Close()
+
+	var expenses []models.CarExpense
+	for rows.Next() {
+		var exp models.CarExpense
+		err = rows.Scan(&exp.ID,
+			&exp.Type,
+			&exp.Amount,
+			&exp.Date,
+			&exp.Notes,
+			&exp.VehicleID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan car expenses: %v", err)
+		}
+		expenses = append(expenses, exp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch car expenses: %v", err)
+	}
+
+	return &expenses, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleDocumentRenewal(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Vehicle Document Renewal %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	vehicle := &models.Vehicle{Name: "Golf", CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateVehicle(vehicle))
+
+	premium := &models.CarExpense{
+		Amount:        320.50,
+		ExpenseTypeID: 3,
+		Date:          time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+		CreatedBy:     TestUserRegisterModel.ID,
+		VehicleID:     &vehicle.ID,
+	}
+	assert.NoError(t, testDB.CreateCarExpense(premium))
+
+	first := &models.VehicleDocument{
+		VehicleID:  vehicle.ID,
+		Kind:       models.DocumentInsurance,
+		Reference:  "BG/11/1",
+		StartsOn:   time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresOn:  time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC),
+		ExpenseID:  &premium.ID,
+		RemindDays: []int{30, 7},
+		CreatedBy:  TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateVehicleDocument(first))
+
+	saved, err := testDB.GetVehicleDocumentByID(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 320.50, *saved.Cost)
+	assert.Equal(t, []int{30, 7}, saved.RemindDays)
+	assert.False(t, saved.Renewed)
+
+	renewal := &models.VehicleDocument{
+		VehicleID:  vehicle.ID,
+		Kind:       models.DocumentInsurance,
+		StartsOn:   time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresOn:  time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC),
+		RemindDays: models.DefaultRemindDays,
+		CreatedBy:  TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateVehicleDocument(renewal))
+
+	docs, err := testDB.GetVehicleDocuments(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+	assert.True(t, docs[0].Renewed)
+	assert.False(t, docs[1].Renewed)
+
+	current, err := testDB.GetAllCurrentVehicleDocuments()
+	assert.NoError(t, err)
+	assert.Len(t, current, 1)
+	assert.Equal(t, renewal.ID, current[0].ID)
+
+	deleted, err := testDB.DeleteCarExpense(premium.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	saved, err = testDB.GetVehicleDocumentByID(first.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, saved.ExpenseID)
+	assert.Nil(t, saved.Cost)
+}
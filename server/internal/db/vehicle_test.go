@@ -0,0 +1,131 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServicePlanStatus(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Service Plan Status %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	vehicle := &models.Vehicle{Name: "Golf", PlateNumber: "CB1234AB", CreatedBy: TestUserRegisterModel.ID}
+	assert.NoError(t, testDB.CreateVehicle(vehicle))
+
+	km, months := 10000, 12
+	plan := &models.ServicePlan{
+		VehicleID:      vehicle.ID,
+		Name:           "Oil change",
+		ExpenseTypeID:  1,
+		IntervalKm:     &km,
+		IntervalMonths: &months,
+		CreatedBy:      TestUserRegisterModel.ID,
+	}
+	assert.NoError(t, testDB.CreateServicePlan(plan))
+
+	saved, err := testDB.GetServicePlanByID(plan.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, saved.LastDate)
+	assert.Equal(t, models.ServiceNoHistory, saved.Status(time.Now()).State)
+
+	now := time.Now()
+	odometer, later := 100000, 109500
+	service := &models.CarExpense{
+		Amount:        120,
+		ExpenseTypeID: 1,
+		Date:          now.AddDate(0, -2, 0),
+		CreatedBy:     TestUserRegisterModel.ID,
+		VehicleID:     &vehicle.ID,
+		Odometer:      &odometer,
+	}
+	assert.NoError(t, testDB.CreateCarExpense(service))
+
+	other := &models.CarExpense{
+		Amount:        60,
+		ExpenseTypeID: 2,
+		Date:          now,
+		CreatedBy:     TestUserRegisterModel.ID,
+		VehicleID:     &vehicle.ID,
+		Odometer:      &later,
+	}
+	assert.NoError(t, testDB.CreateCarExpense(other))
+
+	saved, err = testDB.GetServicePlanByID(plan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, service.ID, *saved.LastExpenseID)
+	assert.Equal(t, odometer, *saved.LastOdometer)
+	assert.Equal(t, later, saved.CurrentOdometer)
+
+	status := saved.Status(now)
+	assert.Equal(t, models.ServiceDueSoon, status.State)
+	assert.Equal(t, 500, status.KmLeft())
+
+	got, err := testDB.GetVehicleByID(vehicle.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, later, got.Odometer)
+
+	deleted, err := testDB.DeleteVehicle(vehicle.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	plans, err := testDB.GetServicePlans(TestUserRegisterModel.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, plans)
+}
+
+func TestCreateNotificationOnce(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Create Notification %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	notification := models.Notification{
+		UserID:  TestUserRegisterModel.ID,
+		Kind:    models.NotificationServiceDue,
+		Key:     "service:1:2:due_soon",
+		Title:   "Golf Oil change: Due soon",
+		Message: "Oil change of Golf is due 110000 km.",
+		Link:    "/car",
+	}
+
+	created, err := testDB.CreateNotification(&notification)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	again := notification
+	created, err = testDB.CreateNotification(&again)
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	notifications, err := testDB.GetNotifications(TestUserRegisterModel.ID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, *notifications, 1)
+	assert.False(t, (*notifications)[0].IsRead())
+
+	assert.NoError(t, testDB.MarkNotificationsRead(TestUserRegisterModel.ID))
+
+	notifications, err = testDB.GetNotifications(TestUserRegisterModel.ID, 10)
+	assert.NoError(t, err)
+	assert.True(t, (*notifications)[0].IsRead())
+}
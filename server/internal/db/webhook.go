@@ -0,0 +1,351 @@
+package database
+
+import (
+	"database/sql"
+	"expenser/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// INFO: ENDPOINTS
+
+const webhookEndpointColumns = `
+	id, user_id, name, url, secret, events, closed_through, created_at
+	FROM webhook_endpoints
+`
+
+func scanWebhookEndpoint(row interface{ Scan(...any) error }, e *models.WebhookEndpoint) error {
+	var events pq.StringArray
+
+	err := row.Scan(&e.ID,
+		&e.UserID,
+		&e.Name,
+		&e.URL,
+		&e.Secret,
+		&events,
+		&e.ClosedThrough,
+		&e.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	e.Events = make([]models.WebhookEvent, 0, len(events))
+	for _, event := range events {
+		e.Events = append(e.Events, models.WebhookEvent(event))
+	}
+
+	return nil
+}
+
+func webhookEvents(events []models.WebhookEvent) pq.StringArray {
+	array := make(pq.StringArray, 0, len(events))
+	for _, event := range events {
+		array = append(array, string(event))
+	}
+	return array
+}
+
+func (db *DB) queryWebhookEndpoints(query string, args ...any) ([]models.WebhookEndpoint, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook endpoints: %v", err)
+	}
+	defer rows.Close()
+
+	var endpoints []models.WebhookEndpoint
+	for rows.Next() {
+		var e models.WebhookEndpoint
+		if err = scanWebhookEndpoint(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoints: %v", err)
+		}
+		endpoints = append(endpoints, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook endpoints: %v", err)
+	}
+
+	return endpoints, nil
+}
+
+// GetWebhookEndpoints retrieves the user's webhook endpoints in the order they were added.
+func (db *DB) GetWebhookEndpoints(userId uuid.UUID) ([]models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + `
+		WHERE user_id = $1
+		ORDER BY id;
+	`
+	return db.queryWebhookEndpoints(query, userId)
+}
+
+// GetSubscribedWebhookEndpoints retrieves the user's webhook endpoints subscribed to the event.
+func (db *DB) GetSubscribedWebhookEndpoints(userId uuid.UUID, event models.WebhookEvent) ([]models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + `
+		WHERE user_id = $1 AND $2 = ANY(events)
+		ORDER BY id;
+	`
+	return db.queryWebhookEndpoints(query, userId, event)
+}
+
+// GetWebhookEndpointsToClose retrieves every endpoint subscribed to month closes
+// that wasn't posted the close of month yet, for the webhooks worker.
+func (db *DB) GetWebhookEndpointsToClose(month time.Time) ([]models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + `
+		WHERE $1 = ANY(events) AND closed_through < $2
+		ORDER BY user_id, id;
+	`
+	return db.queryWebhookEndpoints(query, models.WebhookMonthClosed, month)
+}
+
+// GetWebhookEndpointByID retrieves a webhook endpoint by Id, returns nil when it doesn't exist.
+func (db *DB) GetWebhookEndpointByID(id int) (*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + `
+		WHERE id = $1;
+	`
+
+	var e models.WebhookEndpoint
+	err := scanWebhookEndpoint(db.conn.QueryRow(query, id), &e)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	return &e, nil
+}
+
+func (db *DB) CreateWebhookEndpoint(input *models.WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (user_id, name, url, secret, events)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, closed_through, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.UserID,
+		input.Name,
+		input.URL,
+		input.Secret,
+		webhookEvents(input.Events),
+	).Scan(&input.ID, &input.ClosedThrough, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) EditWebhookEndpoint(input *models.WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints
+		SET
+			name = $2,
+			url = $3,
+			secret = $4,
+			events = $5
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.Name,
+		input.URL,
+		input.Secret,
+		webhookEvents(input.Events),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error editing webhook endpoint: %v", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteWebhookEndpoint(id int) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting webhook endpoint: %v", err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting webhook endpoint: %v", err)
+	}
+
+	return rowCount > 0, nil
+}
+
+// MarkMonthClosed records that the endpoint was posted the close of month.
+func (db *DB) MarkMonthClosed(endpointID int, month time.Time) error {
+	_, err := db.conn.Exec(`UPDATE webhook_endpoints SET closed_through = $2 WHERE id = $1`, endpointID, month)
+	if err != nil {
+		return fmt.Errorf("failed to mark month closed: %w", err)
+	}
+
+	return nil
+}
+
+// INFO: DELIVERIES
+
+const webhookDeliveryColumns = `
+	d.id, d.endpoint_id, e.user_id, e.name, e.url, e.secret, d.event, d.payload, d.status,
+	d.attempts, d.next_attempt_at, d.status_code, d.error, d.created_at, d.delivered_at
+`
+
+func scanWebhookDelivery(row interface{ Scan(...any) error }, d *models.WebhookDelivery) error {
+	var payload string
+
+	err := row.Scan(&d.ID,
+		&d.EndpointID,
+		&d.UserID,
+		&d.Endpoint,
+		&d.URL,
+		&d.Secret,
+		&d.Event,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.StatusCode,
+		&d.Error,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	d.Payload = []byte(payload)
+	return nil
+}
+
+func (db *DB) queryWebhookDeliveries(query string, args ...any) ([]models.WebhookDelivery, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err = scanWebhookDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook deliveries: %v", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %v", err)
+	}
+
+	return deliveries, nil
+}
+
+// CreateWebhookDelivery queues an event for its endpoint, to be attempted right away.
+func (db *DB) CreateWebhookDelivery(input *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (endpoint_id, event, payload)
+		VALUES ($1, $2, $3)
+		RETURNING id, status, next_attempt_at, created_at;
+	`
+
+	err := db.conn.QueryRow(query,
+		input.EndpointID,
+		input.Event,
+		string(input.Payload),
+	).Scan(&input.ID, &input.Status, &input.NextAttemptAt, &input.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries retrieves the latest deliveries to the user's endpoints, newest first.
+func (db *DB) GetWebhookDeliveries(userId uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		WHERE e.user_id = $1
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $2;
+	`
+	return db.queryWebhookDeliveries(query, userId, limit)
+}
+
+// GetWebhookDeliveryByID retrieves a delivery by Id, returns nil when it doesn't exist.
+func (db *DB) GetWebhookDeliveryByID(id int) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		WHERE d.id = $1;
+	`
+
+	var d models.WebhookDelivery
+	err := scanWebhookDelivery(db.conn.QueryRow(query, id), &d)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return &d, nil
+}
+
+// ClaimDueWebhookDeliveries retrieves up to limit pending deliveries due by now and
+// holds them off until lease, so a delivery isn't attempted twice at the same time.
+// Recording the attempt sets when the next one is due.
+func (db *DB) ClaimDueWebhookDeliveries(now, lease time.Time, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $2
+		FROM webhook_endpoints e
+		WHERE e.id = d.endpoint_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns + `;
+	`
+	return db.queryWebhookDeliveries(query, now, lease, limit)
+}
+
+// RecordWebhookAttempt saves the outcome of the latest attempt of a delivery.
+func (db *DB) RecordWebhookAttempt(input *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET
+			status = $2,
+			attempts = $3,
+			next_attempt_at = $4,
+			status_code = $5,
+			error = $6,
+			delivered_at = $7
+		WHERE id = $1;
+	`
+
+	_, err := db.conn.Exec(query,
+		input.ID,
+		input.Status,
+		input.Attempts,
+		input.NextAttemptAt,
+		input.StatusCode,
+		input.Error,
+		input.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"expenser/internal/config"
+	"expenser/internal/models"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDeliveries(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Panicf("Couldn't load config in test Webhook Deliveries %v", err)
+		t.FailNow()
+	}
+
+	testDB := InitTestDB(cfg)
+	defer testDB.Close()
+
+	ResetTestDB(testDB)
+	testDB.CreateUser(TestUserRegisterModel)
+
+	e := &models.WebhookEndpoint{
+		UserID: TestUserRegisterModel.ID,
+		Name:   "Sheet",
+		URL:    "https://example.com/hook",
+		Secret: "s3cret",
+		Events: []models.WebhookEvent{models.WebhookExpenseCreated, models.WebhookMonthClosed},
+	}
+	assert.NoError(t, testDB.CreateWebhookEndpoint(e))
+	assert.NotZero(t, e.ID)
+
+	subscribed, err := testDB.GetSubscribedWebhookEndpoints(TestUserRegisterModel.ID, models.WebhookExpenseDeleted)
+	assert.NoError(t, err)
+	assert.Empty(t, subscribed)
+
+	// A new endpoint isn't posted the close of the months before it was added.
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	toClose, err := testDB.GetWebhookEndpointsToClose(month.AddDate(0, -1, 0))
+	assert.NoError(t, err)
+	assert.Empty(t, toClose)
+
+	toClose, err = testDB.GetWebhookEndpointsToClose(month)
+	assert.NoError(t, err)
+	assert.Len(t, toClose, 1)
+	assert.NoError(t, testDB.MarkMonthClosed(e.ID, month))
+	toClose, err = testDB.GetWebhookEndpointsToClose(month)
+	assert.NoError(t, err)
+	assert.Empty(t, toClose)
+
+	d := &models.WebhookDelivery{EndpointID: e.ID, Event: models.WebhookExpenseCreated, Payload: []byte(`{"event":"expense.created"}`)}
+	assert.NoError(t, testDB.CreateWebhookDelivery(d))
+	assert.Equal(t, models.DeliveryPending, d.Status)
+
+	claimed, err := testDB.ClaimDueWebhookDeliveries(now.Add(time.Second), now.Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, e.URL, claimed[0].URL)
+	assert.Equal(t, e.Secret, claimed[0].Secret)
+	assert.Equal(t, d.Payload, claimed[0].Payload)
+
+	// A claimed delivery isn't claimed again until its lease ran out.
+	again, err := testDB.ClaimDueWebhookDeliveries(now.Add(time.Second), now.Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, again)
+
+	code := 503
+	claimed[0].Attempts = 1
+	claimed[0].StatusCode = &code
+	claimed[0].Error = "503 Service Unavailable"
+	claimed[0].NextAttemptAt = now.Add(30 * time.Second)
+	assert.NoError(t, testDB.RecordWebhookAttempt(&claimed[0]))
+
+	saved, err := testDB.GetWebhookDeliveryByID(d.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, saved.Attempts)
+	assert.Equal(t, "503", saved.StatusCodeLabel())
+	assert.Equal(t, TestUserRegisterModel.ID, saved.UserID)
+
+	deliveries, err := testDB.GetWebhookDeliveries(TestUserRegisterModel.ID, 50)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	deleted, err := testDB.DeleteWebhookEndpoint(e.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deliveries, err = testDB.GetWebhookDeliveries(TestUserRegisterModel.ID, 50)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AccountHandler struct {
+	DB *database.DB
+}
+
+func NewAccountHandler(db *database.DB) *AccountHandler {
+	return &AccountHandler{
+		DB: db,
+	}
+}
+
+// AccountsData is the data for the accounts page.
+type AccountsData struct {
+	Accounts     *[]models.Account
+	TotalBalance float64
+}
+
+// accountSelect builds the account dropdown data for the expense forms.
+func accountSelect(db *database.DB, userID uuid.UUID, selected *int) (*models.AccountSelect, error) {
+	accounts, err := db.GetAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccountSelect{
+		Accounts: accounts,
+		Selected: selected,
+	}, nil
+}
+
+// parseAccountID reads the optional "accountID" form value and makes sure
+// the account belongs to the user. An empty value means no account.
+func parseAccountID(c *gin.Context, db *database.DB, userID uuid.UUID) (*int, error) {
+	value := c.Request.PostFormValue("accountID")
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account")
+	}
+
+	account, err := db.GetAccountByID(id)
+	if err != nil || account == nil || account.CreatedBy != userID {
+		return nil, fmt.Errorf("invalid account")
+	}
+
+	return &id, nil
+}
+
+func (h *AccountHandler) GetAccounts(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	accounts, err := h.DB.GetAccounts(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	pageData := &AccountsData{
+		Accounts: accounts,
+	}
+	for _, acc := range *accounts {
+		pageData.TotalBalance += acc.Balance
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Accounts, pageData)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Accounts,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// AccountFormData is the data for the create and edit account forms.
+type AccountFormData struct {
+	Account *models.Account
+	Kinds   []models.AccountKind
+}
+
+func (h *AccountHandler) GetCreateAccountForm(c *gin.Context) {
+	c.HTML(http.StatusOK, utilities.Templates.Components.AccountForm, &AccountFormData{
+		Kinds: models.AccountKinds,
+	})
+}
+
+func parseAccountForm(c *gin.Context) (*models.Account, error) {
+	name := strings.TrimSpace(c.Request.PostFormValue("name"))
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("invalid name")
+	}
+
+	kind := models.AccountKind(c.Request.PostFormValue("kind"))
+	if !kind.Valid() {
+		return nil, fmt.Errorf("invalid account kind")
+	}
+
+	opening := 0.0
+	if value := c.Request.PostFormValue("openingBalance"); value != "" {
+		var err error
+		opening, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid opening balance")
+		}
+	}
+
+	return &models.Account{
+		Name:           name,
+		Kind:           kind,
+		OpeningBalance: opening,
+	}, nil
+}
+
+// CreateAccount handles the HTTP POST request to add a new account
+// and returns its row for the accounts table.
+func (h *AccountHandler) CreateAccount(c *gin.Context) {
+	account, err := parseAccountForm(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	account.CreatedBy, _ = userIDstr.(uuid.UUID)
+
+	if err := h.DB.CreateAccount(account); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create account, the name may already be in use.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.SaveAccount, gin.H{
+		"Account": account,
+		"Modal": &models.ModalContent{
+			Title:   "Successful account creation.",
+			Message: fmt.Sprintf("%s: %.2f BGN", account.Name, account.Balance),
+		},
+	})
+}
+
+// ownAccount loads the account from the id path parameter and makes sure
+// it belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *AccountHandler) ownAccount(c *gin.Context) *models.Account {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	account, err := h.DB.GetAccountByID(id)
+	if err != nil || account == nil || account.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Account not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return account
+}
+
+func (h *AccountHandler) GetEditAccountForm(c *gin.Context) {
+	account := h.ownAccount(c)
+	if account == nil {
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.AccountForm, &AccountFormData{
+		Account: account,
+		Kinds:   models.AccountKinds,
+	})
+}
+
+func (h *AccountHandler) EditAccount(c *gin.Context) {
+	existing := h.ownAccount(c)
+	if existing == nil {
+		return
+	}
+
+	account, err := parseAccountForm(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	account.ID = existing.ID
+
+	if err := h.DB.EditAccount(account); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	updated, err := h.DB.GetAccountByID(account.ID)
+	if err != nil || updated == nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.SaveAccount, gin.H{
+		"Account": updated,
+		"Modal": &models.ModalContent{
+			Title:   "Successful account update.",
+			Message: fmt.Sprintf("%s: %.2f BGN", updated.Name, updated.Balance),
+		},
+	})
+}
+
+func (h *AccountHandler) GetDeleteConfirm(c *gin.Context) {
+	account := h.ownAccount(c)
+	if account == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/accounts/%v", account.ID)),
+		Target:   fmt.Sprintf("#acc-%v", account.ID),
+		Message:  fmt.Sprintf("Deleting %s keeps its expenses but unlinks them and removes its transfers.", account.Name),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	account := h.ownAccount(c)
+	if account == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteAccount(account.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully deleted account!",
+		Message: fmt.Sprintf("Account %s deleted!", account.Name),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
+
+func (h *AccountHandler) GetTransferForm(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	accounts, err := h.DB.GetAccounts(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.TransferForm, accounts)
+}
+
+// CreateTransfer handles the HTTP POST request to move money between two
+// of the user's accounts. It responds with the refreshed accounts table.
+func (h *AccountHandler) CreateTransfer(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	fromID, err := strconv.Atoi(c.Request.PostFormValue("fromAccountID"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on source account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	toID, err := strconv.Atoi(c.Request.PostFormValue("toAccountID"))
+	if err != nil || toID == fromID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on destination account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	for _, id := range []int{fromID, toID} {
+		account, err := h.DB.GetAccountByID(id)
+		if err != nil || account == nil || account.CreatedBy != userID {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "404: Account not found.",
+			}
+			c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+			return
+		}
+	}
+
+	amount, err := strconv.ParseFloat(c.Request.PostFormValue("amount"), 64)
+	if err != nil || amount <= 0 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Amount invalid, must be a positive number",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	date, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("date"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	transfer := &models.Transfer{
+		FromAccountID: fromID,
+		ToAccountID:   toID,
+		Amount:        amount,
+		Date:          date,
+		Notes:         c.Request.PostFormValue("notes"),
+		CreatedBy:     userID,
+	}
+
+	if err := h.DB.CreateTransfer(transfer); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create transfer.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	accounts, err := h.DB.GetAccounts(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.CreateTransfer, gin.H{
+		"Accounts": accounts,
+		"Modal": &models.ModalContent{
+			Title:   "Successful transfer.",
+			Message: fmt.Sprintf("%.2f BGN from %s to %s", transfer.Amount, transfer.FromAccount, transfer.ToAccount),
+		},
+	})
+}
+
+// GetStatement renders the movements of an account for a date range,
+// defaulting to the current month, with a running balance and the latest reconciliation.
+func (h *AccountHandler) GetStatement(c *gin.Context) {
+	account := h.ownAccount(c)
+	if account == nil {
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, -1)
+
+	if value := c.Query("from"); value != "" {
+		d, err := time.ParseInLocation(utilities.DateFormats.Input, value, time.Local)
+		if err != nil {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "400: Bad Request on start date.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+		from = d
+	}
+
+	if value := c.Query("to"); value != "" {
+		d, err := time.ParseInLocation(utilities.DateFormats.Input, value, time.Local)
+		if err != nil || d.Before(from) {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "400: Bad Request on end date.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+		to = d
+	}
+
+	// The end date is inclusive for the user, so query up to the start of the next day.
+	statement, err := h.DB.GetAccountStatement(account, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account statement.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	statement.To = to
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.AccountStatement, statement)
+}
+
+// Reconcile stores the closing balance from a bank statement and compares it
+// with the balance of the recorded entries on the same date.
+func (h *AccountHandler) Reconcile(c *gin.Context) {
+	account := h.ownAccount(c)
+	if account == nil {
+		return
+	}
+
+	date, err := time.ParseInLocation(utilities.DateFormats.Input, c.Request.PostFormValue("statementDate"), time.Local)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on statement date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	balance, err := strconv.ParseFloat(c.Request.PostFormValue("statementBalance"), 64)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on statement balance.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	rec := &models.Reconciliation{
+		AccountID:        account.ID,
+		StatementDate:    date,
+		StatementBalance: balance,
+	}
+
+	if err := h.DB.CreateReconciliation(rec); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't reconcile account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Components.Reconciliation, rec)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"expenser/internal/anomaly"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AnomalyHandler struct {
+	DB *database.DB
+}
+
+func NewAnomalyHandler(db *database.DB) *AnomalyHandler {
+	return &AnomalyHandler{
+		DB: db,
+	}
+}
+
+// expenseAnomaly checks a newly created or imported expense against the user's
+// history, returning nil when it is normal. A failed check is only logged, it
+// doesn't fail creating the expense.
+func expenseAnomaly(db *database.DB, userID uuid.UUID, exp *models.CategorisedExpense) *models.ExpenseAnomaly {
+	found, err := anomaly.NewDetector(db).Check(userID, exp)
+	if err != nil {
+		log.Printf("anomaly check of %s expense %d: %v", exp.Tracker, exp.ID, err)
+		return nil
+	}
+	return found
+}
+
+// anomalyWarning checks a new expense like expenseAnomaly and returns the warning
+// for the create response modal, empty when the expense is normal.
+func anomalyWarning(db *database.DB, userID uuid.UUID, exp *models.CategorisedExpense) string {
+	if found := expenseAnomaly(db, userID, exp); found != nil {
+		return found.Warning()
+	}
+	return ""
+}
+
+// DismissAnomaly hides an anomaly from the dashboard once the user has looked at it.
+func (h *AnomalyHandler) DismissAnomaly(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Header("HX-Reswap", "none")
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	ok, err := h.DB.DismissExpenseAnomaly(id, userID)
+	if err != nil || !ok {
+		c.Header("HX-Reswap", "none")
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Anomaly not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -1,14 +1,23 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	database "expenser/internal/db"
 	"expenser/internal/models"
+	"expenser/internal/notify"
+	"expenser/internal/passkey"
 	"expenser/internal/services"
+	"expenser/internal/sso"
 	"expenser/internal/utilities"
+	"log"
 	"net/http"
-	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -16,13 +25,22 @@ import (
 type AuthHandler struct {
 	DB          *database.DB
 	AuthService *services.AuthService
+	Mailer      *notify.Mailer
+	Passkeys    *passkey.Service // Passkeys is nil when the base URL can't be a WebAuthn origin.
+	SSO         *sso.Provider    // SSO is nil without an OpenID Connect provider.
 }
 
+// passwordResetTTL is how long an emailed password reset link works.
+const passwordResetTTL = time.Hour
+
 // NewAuthHandler creates a new APIHandler instance
-func NewAuthHandler(db *database.DB, authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(db *database.DB, authService *services.AuthService, mailer *notify.Mailer, passkeys *passkey.Service, provider *sso.Provider) *AuthHandler {
 	return &AuthHandler{
 		DB:          db,
 		AuthService: authService,
+		Mailer:      mailer,
+		Passkeys:    passkeys,
+		SSO:         provider,
 	}
 }
 
@@ -30,11 +48,12 @@ func (h *AuthHandler) GetRegister(c *gin.Context) {
 	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
 
 	if isHtmxRequest {
-		c.HTML(http.StatusOK, utilities.Templates.Pages.Register, gin.H{})
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Register, h.loginPage(""))
 	} else {
 		rl := &models.RootLayout{
-			TemplateName: utilities.Templates.Pages.Register,
-			HeaderOpts:   &models.HeaderOptions{},
+			TemplateName:    utilities.Templates.Pages.Register,
+			TemplateContent: h.loginPage(""),
+			HeaderOpts:      &models.HeaderOptions{},
 		}
 		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
 	}
@@ -42,6 +61,10 @@ func (h *AuthHandler) GetRegister(c *gin.Context) {
 
 // APIRegister handles user registration via API
 func (h *AuthHandler) Register(c *gin.Context) {
+	if h.rejectPasswords(c) {
+		return
+	}
+
 	var regData models.UserRegistration
 
 	if err := c.ShouldBind(&regData); err != nil {
@@ -79,6 +102,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	user := &models.User{
 		Username:     regData.Username,
 		PasswordHash: string(hashedPassword),
+		Email:        strings.TrimSpace(regData.Email),
 	}
 
 	if err := h.DB.CreateUser(user); err != nil {
@@ -90,8 +114,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if user.Email != "" && h.Mailer.Enabled() {
+		if err := sendEmailVerification(h.AuthService, h.Mailer, user); err != nil {
+			log.Printf("email verification of %s: %v", user.Username, err)
+		}
+	}
+
 	// Generate JWT token
-	token, err := h.AuthService.GenerateToken(user)
+	token, err := newSession(h.DB, h.AuthService, c, user)
 	if err != nil {
 		content := &models.ModalContent{
 			Title:   "Failed to generate authentication token!",
@@ -109,11 +139,12 @@ func (h *AuthHandler) GetLogin(c *gin.Context) {
 	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
 
 	if isHtmxRequest {
-		c.HTML(http.StatusOK, utilities.Templates.Pages.Login, gin.H{})
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Login, h.loginPage(""))
 	} else {
 		rl := &models.RootLayout{
-			TemplateName: utilities.Templates.Pages.Login,
-			HeaderOpts:   &models.HeaderOptions{},
+			TemplateName:    utilities.Templates.Pages.Login,
+			TemplateContent: h.loginPage(""),
+			HeaderOpts:      &models.HeaderOptions{},
 		}
 		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
 	}
@@ -121,6 +152,10 @@ func (h *AuthHandler) GetLogin(c *gin.Context) {
 
 // APILogin handles user login via API
 func (h *AuthHandler) Login(c *gin.Context) {
+	if h.rejectPasswords(c) {
+		return
+	}
+
 	var loginData models.UserLogin
 
 	if err := c.ShouldBind(&loginData); err != nil {
@@ -133,7 +168,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Get user from database
-	user, err := h.DB.GetUserByUsername(loginData.Username)
+	user, err := h.findUser(loginData.Username)
 	if err != nil {
 		content := &models.ModalContent{
 			Title:   "Invalid credentials!",
@@ -153,8 +188,45 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	h.logIn(c, user)
+}
+
+// logIn logs in the user whose password was right. With two-factor
+// authentication on, they are sent to its second step instead.
+func (h *AuthHandler) logIn(c *gin.Context, user *models.User) {
+	twoFactor, err := h.DB.GetTwoFactor(user.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if twoFactor.Enabled() {
+		pending, err := h.AuthService.GenerateTwoFactorToken(user)
+		if err != nil {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "500: Failed to get authentication token.",
+			}
+			c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+			return
+		}
+
+		h.AuthService.SetTwoFactorCookie(pending, c)
+		redirect(c, "/login/two-factor")
+		return
+	}
+
+	h.startSession(c, user)
+}
+
+// startSession logs the user in on this device once every step is passed.
+func (h *AuthHandler) startSession(c *gin.Context, user *models.User) {
 	// Generate JWT token
-	token, err := h.AuthService.GenerateToken(user)
+	token, err := newSession(h.DB, h.AuthService, c, user)
 	if err != nil {
 		content := &models.ModalContent{
 			Title:   "Something went wrong!",
@@ -165,30 +237,320 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	h.AuthService.SetCookie(token, c)
-	c.Header("HX-Redirect", "/")
-	c.Status(http.StatusOK)
+	redirect(c, "/")
 }
 
-func (h *AuthHandler) Logout(c *gin.Context) {
-	domain := os.Getenv("LAN_DOMAIN")
+// newSession starts a session of the user on the requesting device and returns
+// its token.
+func newSession(db *database.DB, as *services.AuthService, c *gin.Context, user *models.User) (*services.Token, error) {
+	id := uuid.New()
+	token, err := as.GenerateToken(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         id,
+		UserID:     user.ID,
+		UserAgent:  c.Request.UserAgent(),
+		IP:         c.ClientIP(),
+		LastSeenAt: now,
+		ExpiresAt:  token.ExpiresAt,
+	}
+	if err := db.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// redirect sends the browser to the path, through htmx for its requests.
+func redirect(c *gin.Context, path string) {
+	if c.Request.Header.Get("HX-Request") == "true" {
+		c.Header("HX-Redirect", path)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Redirect(http.StatusFound, path)
+}
+
+func (h *AuthHandler) loginPage(errorMessage string) *models.LoginPage {
+	page := &models.LoginPage{
+		PasswordsEnabled: !h.SSO.PasswordsDisabled(),
+		Error:            errorMessage,
+	}
+	if h.SSO != nil {
+		page.SSOName = h.SSO.Config.ProviderName
+	}
+	return page
+}
+
+// rejectPasswords responds that logging in with a password is off, reporting
+// whether it did.
+func (h *AuthHandler) rejectPasswords(c *gin.Context) bool {
+	if !h.SSO.PasswordsDisabled() {
+		return false
+	}
+
+	content := &models.ModalContent{
+		Title:   "Passwords are turned off!",
+		Message: "403: Log in with " + h.SSO.Config.ProviderName + " or a passkey instead.",
+	}
+	c.HTML(http.StatusForbidden, utilities.Templates.Components.ModalError, content)
+	return true
+}
 
-	if domain == "" {
-		domain = "localhost"
+// findUser retrieves the user with the verified email when the login looks
+// like one, or else the username. The email goes first so nobody can take
+// another user's address as their username and catch their password resets.
+func (h *AuthHandler) findUser(login string) (*models.User, error) {
+	if strings.Contains(login, "@") {
+		user, err := h.DB.GetUserByEmail(login)
+		if err != nil || user != nil {
+			return user, err
+		}
 	}
 
-	secure := true
-	httpOnly := true
+	return h.DB.GetUserByUsername(login)
+}
 
-	c.SetCookie(
-		"auth_token",
-		"",
-		-1,
-		"/",
-		domain,
-		secure,
-		httpOnly,
-	)
+func (h *AuthHandler) Logout(c *gin.Context) {
+	cookie, _ := c.Cookie("auth_token")
+	if token, err := h.AuthService.ValidateToken(cookie); err == nil {
+		if _, err := h.DB.RevokeSession(token.Claims.UserID, token.Claims.SessionID(), time.Now()); err != nil {
+			log.Printf("logout of %s: %v", token.Claims.UserID, err)
+		}
+	}
 
+	h.AuthService.ClearCookie(c)
 	c.Header("HX-Redirect", "/")
 	c.Status(http.StatusOK)
 }
+
+// newResetToken returns a random password reset token, to email, and its hash, to store.
+func newResetToken() (string, string) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	return token, hashResetToken(token)
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (h *AuthHandler) GetForgotPassword(c *gin.Context) {
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.ForgotPassword, gin.H{})
+	} else {
+		rl := &models.RootLayout{
+			TemplateName: utilities.Templates.Pages.ForgotPassword,
+			HeaderOpts:   &models.HeaderOptions{},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// ForgotPassword handles the HTTP POST request to email a password reset link to
+// the account. The reply is the same whether or not the account exists and has
+// an address, so it doesn't tell which accounts do.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	if h.rejectPasswords(c) {
+		return
+	}
+
+	if !h.Mailer.Enabled() {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "503: Passwords can't be reset by email, no mail server is configured.",
+		}
+		c.HTML(http.StatusServiceUnavailable, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	username := strings.TrimSpace(c.Request.PostFormValue("username"))
+	if username == "" {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Invalid request data.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.sendPasswordReset(username); err != nil {
+		log.Printf("password reset of %s: %v", username, err)
+	}
+
+	content := &models.ModalContent{
+		Title:   "Check your email!",
+		Message: "If the account has a verified email address, a link to reset its password was sent to it. The link works for an hour.",
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
+
+// sendPasswordReset emails the user a new password reset link, when they have a
+// verified address. Unverified ones might not be theirs.
+func (h *AuthHandler) sendPasswordReset(login string) error {
+	user, err := h.findUser(login)
+	if err != nil || user.VerifiedEmail() == "" {
+		return nil
+	}
+
+	token, hash := newResetToken()
+	if err := h.DB.CreatePasswordReset(user.ID, hash, time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+
+	return h.Mailer.SendPasswordReset(user.VerifiedEmail(), user.Username, token)
+}
+
+// GetResetPassword renders the page a password reset link opens.
+func (h *AuthHandler) GetResetPassword(c *gin.Context) {
+	token := c.Query("token")
+	_, valid, err := h.DB.GetPasswordResetUser(hashResetToken(token), time.Now())
+	if err != nil {
+		log.Printf("password reset: %v", err)
+	}
+
+	data := &models.ResetPasswordPage{
+		Token: token,
+		Valid: valid,
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.ResetPassword, data)
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.ResetPassword,
+			TemplateContent: data,
+			HeaderOpts:      &models.HeaderOptions{},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// ResetPassword handles the HTTP POST request setting a new password with a
+// reset token. All sessions of the user are logged out and they are logged in
+// anew, through the second step when they have two-factor authentication on.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	if h.rejectPasswords(c) {
+		return
+	}
+
+	var reset models.PasswordReset
+	if err := c.ShouldBind(&reset); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: The new password needs at least 6 characters and must match its confirmation.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userID, valid, err := h.DB.GetPasswordResetUser(hashResetToken(reset.Token), time.Now())
+	if err != nil || !valid {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: This reset link is invalid or expired, ask for a new one.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(reset.Password), bcrypt.DefaultCost)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Failed to process password!",
+			Message: "500: Internal server error.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	now := time.Now()
+	if err := h.DB.SetPassword(userID, string(hashedPassword), uuid.Nil, now); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't reset password.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.logIn(c, user)
+}
+
+// sendEmailVerification emails the user a signed link verifying their current address.
+func sendEmailVerification(as *services.AuthService, mailer *notify.Mailer, user *models.User) error {
+	token, err := as.GenerateEmailToken(user)
+	if err != nil {
+		return err
+	}
+
+	return mailer.SendEmailVerification(user.Email, user.Username, token)
+}
+
+// VerifyEmail handles the HTTP GET request of an email verification link. It
+// works without logging in, the signed token tells the user and the address.
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	data := &models.EmailVerificationPage{}
+
+	claims, err := h.AuthService.ValidateEmailToken(c.Query("token"))
+	if err == nil {
+		data.Email = claims.Email
+		data.Verified, data.Taken, err = h.verifyEmail(claims)
+		if err != nil {
+			log.Printf("email verification of %s: %v", claims.UserID, err)
+		}
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.VerifyEmail, data)
+	} else {
+		cookie, _ := c.Cookie("auth_token")
+		session, _ := h.AuthService.ValidateToken(cookie)
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.VerifyEmail,
+			TemplateContent: data,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: session != nil,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// verifyEmail verifies the address of the claims, unless another account
+// verified it first.
+func (h *AuthHandler) verifyEmail(claims *services.JWTClaims) (bool, bool, error) {
+	owner, err := h.DB.GetUserByEmail(claims.Email)
+	if err != nil {
+		return false, false, err
+	}
+	if owner != nil && owner.ID != claims.UserID {
+		return false, true, nil
+	}
+
+	verified, err := h.DB.VerifyEmail(claims.UserID, claims.Email, time.Now())
+	return verified, false, err
+}
@@ -16,9 +16,11 @@ import (
 
 type CarData struct {
 	Name           string
-	MonthlyExpense *models.MonthlyExpense // MonthlyExpense summarizes the total spending for the current month.
-	HighestExpense *models.HighestExpense // HighestExpense identifies the single largest expense in the current month.
-	RecentExpenses *[]models.CarExpense   // RecentExpenses lists individual expenses for the current month.
+	MonthlyExpense *models.MonthlyExpense  // MonthlyExpense summarizes the total spending for the current month.
+	HighestExpense *models.HighestExpense  // HighestExpense identifies the single largest expense in the current month.
+	RecentExpenses *[]models.CarExpense    // RecentExpenses lists individual expenses for the current month.
+	ServiceDue     []models.ServiceStatus  // ServiceDue lists the planned services that are due soon, overdue or never done.
+	Anomalies      []models.ExpenseAnomaly // Anomalies lists the unusual expenses not dismissed yet.
 }
 
 type CarHandler struct {
@@ -31,6 +33,22 @@ func NewCarHandler(db *database.DB) *CarHandler {
 	}
 }
 
+// serviceDue returns the user's planned services that need attention.
+func (h *CarHandler) serviceDue(userID uuid.UUID) ([]models.ServiceStatus, error) {
+	plans, err := h.DB.GetServicePlans(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []models.ServiceStatus
+	for _, status := range models.ServiceStatuses(plans, time.Now()) {
+		if status.NeedsAttention() {
+			due = append(due, status)
+		}
+	}
+	return due, nil
+}
+
 func (h *CarHandler) GetHome(c *gin.Context) {
 	dateNow := time.Now()
 	month := dateNow.Month()
@@ -57,6 +75,18 @@ func (h *CarHandler) GetHome(c *gin.Context) {
 		return
 	}
 
+	serviceDue, err := h.serviceDue(userID)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	anomalies, err := h.DB.GetExpenseAnomalies(userID, models.TrackerCar)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.Modal, err)
+		return
+	}
+
 	pageData := &CarData{
 		Name: "current",
 		MonthlyExpense: &models.MonthlyExpense{
@@ -68,6 +98,8 @@ func (h *CarHandler) GetHome(c *gin.Context) {
 			Type:   utilType,
 		},
 		RecentExpenses: recentExpenses,
+		ServiceDue:     serviceDue,
+		Anomalies:      anomalies,
 	}
 
 	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
@@ -125,6 +157,26 @@ func (h *CarHandler) GetCurrentMonth(c *gin.Context) {
 		return
 	}
 
+	serviceDue, err := h.serviceDue(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching service plans.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	anomalies, err := h.DB.GetExpenseAnomalies(userID, models.TrackerCar)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching unusual expenses.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
 	pageData := &CarData{
 		Name: "current",
 		MonthlyExpense: &models.MonthlyExpense{
@@ -136,6 +188,8 @@ func (h *CarHandler) GetCurrentMonth(c *gin.Context) {
 			Type:   utilType,
 		},
 		RecentExpenses: recentExpenses,
+		ServiceDue:     serviceDue,
+		Anomalies:      anomalies,
 	}
 
 	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
@@ -155,6 +209,12 @@ func (h *CarHandler) GetCurrentMonth(c *gin.Context) {
 	}
 }
 
+type CreateCarFormData struct {
+	Types    *[]models.CarExpenseType
+	Accounts *models.AccountSelect
+	Vehicles *models.VehicleSelect
+}
+
 func (h *CarHandler) GetCreateCarForm(c *gin.Context) {
 	expTypes, err := h.DB.GetCarExpenseTypes()
 	if err != nil {
@@ -162,7 +222,28 @@ func (h *CarHandler) GetCreateCarForm(c *gin.Context) {
 		return
 	}
 
-	c.HTML(http.StatusOK, utilities.Templates.Components.CreateCarExpForm, expTypes)
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	accounts, err := accountSelect(h.DB, userID, nil)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, nil)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	formData := &CreateCarFormData{
+		Types:    expTypes,
+		Accounts: accounts,
+		Vehicles: vehicles,
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.CreateCarExpForm, formData)
 }
 
 // CreateExpResponse is the data structure returned to the client
@@ -204,12 +285,40 @@ func (h *CarHandler) CreateCarExpense(c *gin.Context) {
 	userIDstr, _ := c.Get("user_id")
 	userID, _ := userIDstr.(uuid.UUID)
 
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	vehicleID, err := parseVehicleID(c, h.DB, userID)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	odometer, err := parseOptionalInt(c.Request.PostFormValue("odometer"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	fuelLitres, err := parseOptionalFloat(c.Request.PostFormValue("fuelLitres"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
 	newExpense := &models.CarExpense{
 		Amount:        amount,
 		ExpenseTypeID: expTypeID,
 		Date:          date,
 		Notes:         notes,
 		CreatedBy:     userID,
+		AccountID:     accountID,
+		VehicleID:     vehicleID,
+		Odometer:      odometer,
+		FuelLitres:    fuelLitres,
 	}
 
 	err = h.DB.CreateCarExpense(newExpense)
@@ -218,6 +327,18 @@ func (h *CarHandler) CreateCarExpense(c *gin.Context) {
 		return
 	}
 
+	created := &models.CategorisedExpense{
+		ID:      newExpense.ID,
+		Tracker: models.TrackerCar,
+		TypeID:  newExpense.ExpenseTypeID,
+		Type:    newExpense.Type,
+		Amount:  newExpense.Amount,
+		Date:    newExpense.Date,
+		Notes:   newExpense.Notes,
+	}
+	warning := anomalyWarning(h.DB, userID, created)
+	expenseEvent(h.DB, userID, models.WebhookExpenseCreated, created)
+
 	timeNow := time.Now()
 
 	highestExp, expType, err := h.DB.GetHighestCarExpenseForMonth(timeNow.Month(), userID)
@@ -247,6 +368,7 @@ func (h *CarHandler) CreateCarExpense(c *gin.Context) {
 		Modal: &models.ModalContent{
 			Title:   "Successful expense creation.",
 			Message: fmt.Sprintf("%s: %v BGN", newExpense.Type, newExpense.Amount),
+			Warning: warning,
 		},
 	}
 
@@ -273,8 +395,10 @@ func (h *CarHandler) GetCarExpenseById(c *gin.Context) {
 // INFO: UPDATE
 
 type EditCarFormData struct {
-	Expense *models.CarExpense
-	Types   *[]models.CarExpenseType
+	Expense  *models.CarExpense
+	Types    *[]models.CarExpenseType
+	Accounts *models.AccountSelect
+	Vehicles *models.VehicleSelect
 }
 
 // GetEditCarForm renders the HTML form pre-filled with existing expense data
@@ -287,21 +411,47 @@ func (h *CarHandler) GetEditCarForm(c *gin.Context) {
 		return
 	}
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
 	exp, err := h.DB.GetCarExpenseByID(id)
 	if err != nil {
 		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
 		return
 	}
 
+	if exp == nil || exp.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Expense not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
 	expTypes, err := h.DB.GetCarExpenseTypes()
 	if err != nil {
 		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
 		return
 	}
 
+	accounts, err := accountSelect(h.DB, userID, exp.AccountID)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, exp.VehicleID)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
 	formData := &EditCarFormData{
-		Expense: exp,
-		Types:   expTypes,
+		Expense:  exp,
+		Types:    expTypes,
+		Accounts: accounts,
+		Vehicles: vehicles,
 	}
 
 	c.HTML(http.StatusOK, utilities.Templates.Components.EditCarExpForm, formData)
@@ -319,6 +469,13 @@ func (h *CarHandler) EditCarExpenseById(c *gin.Context) {
 		return
 	}
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	if h.ownExpense(c, id, userID) == nil {
+		return
+	}
+
 	expTypeID, err := strconv.Atoi(c.Request.PostFormValue("typeID"))
 	if err != nil {
 		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
@@ -338,12 +495,41 @@ func (h *CarHandler) EditCarExpenseById(c *gin.Context) {
 	}
 
 	notes := c.Request.PostFormValue("notes")
+
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	vehicleID, err := parseVehicleID(c, h.DB, userID)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	odometer, err := parseOptionalInt(c.Request.PostFormValue("odometer"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
+	fuelLitres, err := parseOptionalFloat(c.Request.PostFormValue("fuelLitres"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
 	editExpense := &models.CarExpense{
 		ID:            id,
 		Amount:        amount,
 		ExpenseTypeID: expTypeID,
 		Date:          date,
 		Notes:         notes,
+		AccountID:     accountID,
+		VehicleID:     vehicleID,
+		Odometer:      odometer,
+		FuelLitres:    fuelLitres,
 	}
 
 	err = h.DB.EditCarExpense(editExpense)
@@ -352,10 +538,10 @@ func (h *CarHandler) EditCarExpenseById(c *gin.Context) {
 		return
 	}
 
-	timeNow := time.Now()
+	edited, _ := h.DB.GetCarExpenseByID(id)
+	carExpenseEvent(h.DB, userID, models.WebhookExpenseUpdated, edited)
 
-	userIDstr, _ := c.Get("user_id")
-	userID, _ := userIDstr.(uuid.UUID)
+	timeNow := time.Now()
 
 	highestExp, expType, err := h.DB.GetHighestCarExpenseForMonth(timeNow.Month(), userID)
 	if err != nil {
@@ -426,6 +612,14 @@ func (h *CarHandler) DeleteCarExp(c *gin.Context) {
 		return
 	}
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	deleted := h.ownExpense(c, id, userID)
+	if deleted == nil {
+		return
+	}
+
 	res, err := h.DB.DeleteCarExpense(id)
 	if err != nil {
 		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
@@ -441,8 +635,7 @@ func (h *CarHandler) DeleteCarExp(c *gin.Context) {
 	timeNow := time.Now()
 	month := timeNow.Month()
 
-	userIDstr, _ := c.Get("user_id")
-	userID, _ := userIDstr.(uuid.UUID)
+	carExpenseEvent(h.DB, userID, models.WebhookExpenseDeleted, deleted)
 
 	monthlyExpense, err := h.DB.GetTotalCarExpenseForMonth(month, userID)
 	if err != nil {
@@ -475,3 +668,19 @@ func (h *CarHandler) DeleteCarExp(c *gin.Context) {
 
 	c.HTML(http.StatusOK, utilities.Templates.Responses.DeleteCarExp, pageData)
 }
+
+// ownExpense loads the expense and makes sure it belongs to the user. It renders
+// the error itself and returns nil on failure.
+func (h *CarHandler) ownExpense(c *gin.Context, id int, userID uuid.UUID) *models.CarExpense {
+	exp, err := h.DB.GetCarExpenseByID(id)
+	if err != nil || exp == nil || exp.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Expense not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return exp
+}
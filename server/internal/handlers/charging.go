@@ -0,0 +1,506 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/tco"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// chargingBillsDays is how far back the charging form offers electricity bills to attribute a session to.
+const chargingBillsDays = 120
+
+type ChargingHandler struct {
+	DB *database.DB
+}
+
+func NewChargingHandler(db *database.DB) *ChargingHandler {
+	return &ChargingHandler{
+		DB: db,
+	}
+}
+
+// ChargingData is the data for the charging sessions of a period and their stats by vehicle.
+type ChargingData struct {
+	From     time.Time
+	To       time.Time
+	Sessions []models.ChargingSession
+	Stats    []models.ChargingStats
+}
+
+// ChargingFormData is the data for the create and edit charging session forms.
+type ChargingFormData struct {
+	Session   *models.ChargingSession // Session is nil for a new one.
+	Vehicles  *models.VehicleSelect
+	Accounts  *models.AccountSelect
+	Locations []models.ChargingLocation
+	Bills     []models.HouseExpense
+}
+
+// IsBillSelected reports whether the edited session is attributed to the bill with the given id.
+func (d *ChargingFormData) IsBillSelected(id int) bool {
+	return d.Session != nil && d.Session.HomeExpenseID != nil && *d.Session.HomeExpenseID == id
+}
+
+func (h *ChargingHandler) charging(c *gin.Context, userID uuid.UUID) (*ChargingData, error) {
+	from, to, err := currentYearPeriod(c)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := h.DB.GetChargingSessions(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byVehicle := make(map[int][]models.ChargingSession)
+	for _, session := range sessions {
+		byVehicle[session.VehicleID] = append(byVehicle[session.VehicleID], session)
+	}
+
+	var stats []models.ChargingStats
+	for vehicleID, vehicleSessions := range byVehicle {
+		readings, err := h.DB.GetOdometerReadings(vehicleID)
+		if err != nil {
+			return nil, err
+		}
+		km := tco.Distance(readings, from, to)
+		stats = append(stats, models.SummarizeCharging(vehicleSessions[0].Vehicle, vehicleSessions, km))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Vehicle < stats[j].Vehicle })
+
+	return &ChargingData{
+		From:     from,
+		To:       to,
+		Sessions: sessions,
+		Stats:    stats,
+	}, nil
+}
+
+// GetCharging renders the charging sessions of the car page with the consumption by vehicle.
+func (h *ChargingHandler) GetCharging(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	data, err := h.charging(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.Charging, data)
+}
+
+func (h *ChargingHandler) chargingFormData(userID uuid.UUID, session *models.ChargingSession) (*ChargingFormData, error) {
+	var selectedVehicle, selectedAccount, billID *int
+	if session != nil {
+		selectedVehicle = &session.VehicleID
+		selectedAccount = session.AccountID
+		billID = session.HomeExpenseID
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, selectedVehicle)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := accountSelect(h.DB, userID, selectedAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	bills, err := h.DB.GetElectricityBills(userID, time.Now().AddDate(0, 0, -chargingBillsDays), billID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChargingFormData{
+		Session:   session,
+		Vehicles:  vehicles,
+		Accounts:  accounts,
+		Locations: models.ChargingLocations,
+		Bills:     bills,
+	}, nil
+}
+
+func (h *ChargingHandler) GetCreateChargingForm(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	formData, err := h.chargingFormData(userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.ChargingForm, formData)
+}
+
+// parseChargingForm reads a charging session. The cost is taken as entered or
+// else worked out from the price per kWh of the tariff.
+func (h *ChargingHandler) parseChargingForm(c *gin.Context, userID uuid.UUID) (*models.ChargingSession, error) {
+	vehicleID, err := parseVehicleID(c, h.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if vehicleID == nil {
+		return nil, fmt.Errorf("choose a vehicle")
+	}
+
+	date, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("date"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date")
+	}
+
+	kwh, err := strconv.ParseFloat(c.Request.PostFormValue("kwh"), 64)
+	if err != nil || kwh <= 0 {
+		return nil, fmt.Errorf("invalid kWh")
+	}
+
+	duration, err := parseOptionalInt(c.Request.PostFormValue("durationMinutes"))
+	if err != nil || (duration != nil && *duration == 0) {
+		return nil, fmt.Errorf("invalid duration")
+	}
+
+	location := models.ChargingLocation(c.Request.PostFormValue("location"))
+	if !location.Valid() {
+		return nil, fmt.Errorf("invalid location")
+	}
+
+	tariff := strings.TrimSpace(c.Request.PostFormValue("tariff"))
+	if len(tariff) > 100 {
+		return nil, fmt.Errorf("invalid tariff")
+	}
+
+	cost, err := parseOptionalFloat(c.Request.PostFormValue("cost"))
+	if err != nil {
+		return nil, err
+	}
+	if cost == nil {
+		price, err := parseOptionalFloat(c.Request.PostFormValue("pricePerKWh"))
+		if err != nil {
+			return nil, err
+		}
+		if price == nil {
+			return nil, fmt.Errorf("enter the cost or the price per kWh")
+		}
+		total := math.Round(kwh*(*price)*100) / 100
+		cost = &total
+	}
+
+	odometer, err := parseOptionalInt(c.Request.PostFormValue("odometer"))
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.ChargingSession{
+		VehicleID:       *vehicleID,
+		Date:            date,
+		KWh:             kwh,
+		DurationMinutes: duration,
+		Location:        location,
+		Tariff:          tariff,
+		Cost:            *cost,
+		Odometer:        odometer,
+		OnHouseBill:     c.Request.PostFormValue("onHouseBill") == "true",
+		AccountID:       accountID,
+		CreatedBy:       userID,
+	}
+
+	if session.OnHouseBill && location != models.ChargingHome {
+		return nil, fmt.Errorf("only home sessions can be on the house bill")
+	}
+
+	if value := c.Request.PostFormValue("homeExpenseID"); value != "" && session.OnHouseBill {
+		billID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid electricity bill")
+		}
+
+		bill, err := h.DB.GetHouseExpenseByID(billID)
+		if err != nil || bill == nil || bill.CreatedBy != userID || bill.UtilityType != "Electricity" {
+			return nil, fmt.Errorf("invalid electricity bill")
+		}
+		session.HomeExpenseID = &billID
+	}
+
+	return session, nil
+}
+
+// chargingSaved responds with the refreshed sessions and stats of the period shown.
+func (h *ChargingHandler) chargingSaved(c *gin.Context, status int, userID uuid.UUID, modal *models.ModalContent) {
+	data, err := h.charging(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching charging sessions.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(status, utilities.Templates.Responses.SaveCharging, gin.H{
+		"Charging": data,
+		"Modal":    modal,
+	})
+}
+
+// savedMessage describes where the cost of a saved session went.
+func savedMessage(session *models.ChargingSession) string {
+	if session.OnHouseBill {
+		return fmt.Sprintf("%.2f kWh charged, paid with the house electricity bill.", session.KWh)
+	}
+	return fmt.Sprintf("%.2f kWh charged, added as a %.2f lv car expense.", session.KWh, session.Cost)
+}
+
+// chargingExpenseSaved queues the webhooks of the car expense of a saved session,
+// like the expense forms do, and checks a new expense for an anomaly. before is
+// the session's expense as it was, nil when it had none. It returns the warning
+// for the response modal, empty when there is none.
+func (h *ChargingHandler) chargingExpenseSaved(session *models.ChargingSession, before *models.CarExpense) string {
+	if session.ExpenseID == nil {
+		carExpenseEvent(h.DB, session.CreatedBy, models.WebhookExpenseDeleted, before)
+		return ""
+	}
+
+	exp, err := h.DB.GetCarExpenseByID(*session.ExpenseID)
+	if err != nil || exp == nil {
+		log.Printf("charging expense %d of session %d: %v", *session.ExpenseID, session.ID, err)
+		return ""
+	}
+
+	if before != nil && before.ID == exp.ID {
+		carExpenseEvent(h.DB, session.CreatedBy, models.WebhookExpenseUpdated, exp)
+		return ""
+	}
+
+	created := &models.CategorisedExpense{
+		ID:      exp.ID,
+		Tracker: models.TrackerCar,
+		TypeID:  exp.ExpenseTypeID,
+		Type:    exp.Type,
+		Amount:  exp.Amount,
+		Date:    exp.Date,
+		Notes:   exp.Notes,
+	}
+	warning := anomalyWarning(h.DB, session.CreatedBy, created)
+	expenseEvent(h.DB, session.CreatedBy, models.WebhookExpenseCreated, created)
+	return warning
+}
+
+// chargingExpense loads the car expense of a session as it is stored, nil when it
+// has none.
+func (h *ChargingHandler) chargingExpense(session *models.ChargingSession) *models.CarExpense {
+	if session.ExpenseID == nil {
+		return nil
+	}
+
+	exp, err := h.DB.GetCarExpenseByID(*session.ExpenseID)
+	if err != nil {
+		log.Printf("charging expense %d of session %d: %v", *session.ExpenseID, session.ID, err)
+	}
+	return exp
+}
+
+// CreateCharging handles the HTTP POST request to log a charging session.
+func (h *ChargingHandler) CreateCharging(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	session, err := h.parseChargingForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateChargingSession(session); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create charging session.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.chargingSaved(c, http.StatusCreated, userID, &models.ModalContent{
+		Title:   "Successful charging session creation.",
+		Message: savedMessage(session),
+		Warning: h.chargingExpenseSaved(session, nil),
+	})
+}
+
+// ownChargingSession loads the session from the id path parameter and makes sure it
+// belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *ChargingHandler) ownChargingSession(c *gin.Context) *models.ChargingSession {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	session, err := h.DB.GetChargingSessionByID(id)
+	if err != nil || session == nil || session.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Charging session not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return session
+}
+
+func (h *ChargingHandler) GetEditChargingForm(c *gin.Context) {
+	session := h.ownChargingSession(c)
+	if session == nil {
+		return
+	}
+
+	formData, err := h.chargingFormData(session.CreatedBy, session)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.ChargingForm, formData)
+}
+
+func (h *ChargingHandler) EditCharging(c *gin.Context) {
+	existing := h.ownChargingSession(c)
+	if existing == nil {
+		return
+	}
+
+	session, err := h.parseChargingForm(c, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	session.ID = existing.ID
+	session.ExpenseID = existing.ExpenseID
+	before := h.chargingExpense(existing)
+
+	if err := h.DB.EditChargingSession(session); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update charging session.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.chargingSaved(c, http.StatusOK, session.CreatedBy, &models.ModalContent{
+		Title:   "Successful charging session update.",
+		Message: savedMessage(session),
+		Warning: h.chargingExpenseSaved(session, before),
+	})
+}
+
+// GetDeleteChargingConfirm asks to confirm deleting a session, carrying the period
+// shown over to the delete request like the trip log does.
+func (h *ChargingHandler) GetDeleteChargingConfirm(c *gin.Context) {
+	session := h.ownChargingSession(c)
+	if session == nil {
+		return
+	}
+
+	from, to, err := currentYearPeriod(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	message := fmt.Sprintf("Please confirm if you want to delete the charging session of %s.", session.Date.Format("02.01.2006"))
+	if session.ExpenseID != nil {
+		message = fmt.Sprintf("Please confirm if you want to delete the charging session of %s and its car expense.", session.Date.Format("02.01.2006"))
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/car/charging/%v?%s", session.ID, periodQuery(from, to))),
+		Target:   fmt.Sprintf("#charge-%v", session.ID),
+		Message:  message,
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *ChargingHandler) DeleteCharging(c *gin.Context) {
+	session := h.ownChargingSession(c)
+	if session == nil {
+		return
+	}
+
+	deleted := h.chargingExpense(session)
+
+	res, err := h.DB.DeleteChargingSession(session.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete charging session.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	carExpenseEvent(h.DB, session.CreatedBy, models.WebhookExpenseDeleted, deleted)
+
+	h.chargingSaved(c, http.StatusOK, session.CreatedBy, &models.ModalContent{
+		Title:   "Successfully deleted charging session!",
+		Message: fmt.Sprintf("Charging session of %s deleted!", session.Date.Format("02.01.2006")),
+	})
+}
@@ -3,7 +3,10 @@ package handlers
 import (
 	database "expenser/internal/db"
 	"expenser/internal/models"
+	"expenser/internal/svgchart"
 	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
 	"net/http"
 	"strconv"
 	"time"
@@ -26,11 +29,16 @@ func (ch *ChartHandler) HouseRoot(c *gin.Context) {
 	dateNow := time.Now()
 	year := dateNow.Year()
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
 	types, _ := ch.DB.GetHouseUtilityTypes()
+	charts, _ := ch.houseCharts(userID, 0, year, chartTheme(c))
 	chartData := gin.H{
-		"Type":  "house",
-		"Year":  year,
-		"Types": types,
+		"Type":   "house",
+		"Year":   year,
+		"Types":  types,
+		"Charts": charts,
 	}
 	c.HTML(http.StatusOK, utilities.Templates.Components.Chart, chartData)
 }
@@ -65,11 +73,16 @@ func (ch *ChartHandler) CarRoot(c *gin.Context) {
 	dateNow := time.Now()
 	year := dateNow.Year()
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
 	types, _ := ch.DB.GetCarExpenseTypes()
+	charts, _ := ch.carCharts(userID, 0, year, chartTheme(c))
 	chartData := gin.H{
-		"Type":  "car",
-		"Year":  year,
-		"Types": types,
+		"Type":   "car",
+		"Year":   year,
+		"Types":  types,
+		"Charts": charts,
 	}
 	c.HTML(http.StatusOK, utilities.Templates.Components.Chart, chartData)
 }
@@ -99,3 +112,136 @@ func (ch *ChartHandler) CarSearch(c *gin.Context) {
 
 	c.JSON(http.StatusOK, exp)
 }
+
+// chartTheme is the theme last picked with the theme-toggle, which main.js keeps
+// in a cookie so the server rendered charts match it.
+func chartTheme(c *gin.Context) svgchart.Theme {
+	theme, _ := c.Cookie("theme")
+	return svgchart.ThemeNamed(theme)
+}
+
+// expenseCharts draws a year of expenses: the total and share of each type, or the
+// monthly totals when the expenses are all of one type.
+func expenseCharts(title string, oneType bool, year int, entries []svgchart.Entry, theme svgchart.Theme) []template.HTML {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if oneType {
+		points := svgchart.ByMonth(entries, year)
+		for i := range points {
+			points[i].Color = svgchart.TypeColors[entries[0].Label]
+		}
+		chart := &svgchart.Chart{
+			ID:     "chart-months",
+			Title:  fmt.Sprintf("%s by Month, %d", entries[0].Label, year),
+			Unit:   "BGN",
+			Theme:  theme,
+			Points: points,
+		}
+		return []template.HTML{chart.HTML(svgchart.KindLine)}
+	}
+
+	totals := &svgchart.Chart{
+		ID:     "chart-totals",
+		Title:  fmt.Sprintf("%s, %d", title, year),
+		Unit:   "BGN",
+		Theme:  theme,
+		Points: svgchart.ByLabel(entries),
+	}
+	shares := *totals
+	shares.ID = "chart-shares"
+	shares.Title = fmt.Sprintf("Share by Type, %d", year)
+	return []template.HTML{totals.HTML(svgchart.KindBar), shares.HTML(svgchart.KindPie)}
+}
+
+func (ch *ChartHandler) houseCharts(userID uuid.UUID, typeID, year int, theme svgchart.Theme) ([]template.HTML, error) {
+	var exp *[]models.HouseExpense
+	var err error
+
+	if typeID > 0 {
+		exp, err = ch.DB.GetHouseExpenseTypeForYear(typeID, year, userID)
+	} else {
+		exp, err = ch.DB.GetHouseExpensesForYear(year, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []svgchart.Entry
+	for _, e := range *exp {
+		entries = append(entries, svgchart.Entry{Label: e.UtilityType, Date: e.ExpenseDate, Amount: e.Amount})
+	}
+	return expenseCharts("Home Expenses by Utility Type", typeID > 0, year, entries, theme), nil
+}
+
+func (ch *ChartHandler) carCharts(userID uuid.UUID, typeID, year int, theme svgchart.Theme) ([]template.HTML, error) {
+	var exp *[]models.CarExpense
+	var err error
+
+	if typeID > 0 {
+		exp, err = ch.DB.GetCarExpenseTypeForYear(typeID, year, userID)
+	} else {
+		exp, err = ch.DB.GetCarExpensesForYear(year, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []svgchart.Entry
+	for _, e := range *exp {
+		entries = append(entries, svgchart.Entry{Label: e.Type, Date: e.Date, Amount: e.Amount})
+	}
+	return expenseCharts("Car Expenses by Type", typeID > 0, year, entries, theme), nil
+}
+
+// chartQuery reads the expense type and year of the chart search, the year
+// defaults to the current one.
+func chartQuery(c *gin.Context) (int, int) {
+	typeID, _ := strconv.Atoi(c.Query("type"))
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil {
+		year = time.Now().Year()
+	}
+	return typeID, year
+}
+
+// HouseSVG renders the home expense charts of the chart search as SVG.
+func (ch *ChartHandler) HouseSVG(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	typeID, year := chartQuery(c)
+	charts, err := ch.houseCharts(userID, typeID, year, chartTheme(c))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching expenses.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.SVGChart, gin.H{"Charts": charts})
+}
+
+// CarSVG renders the car expense charts of the chart search as SVG.
+func (ch *ChartHandler) CarSVG(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	typeID, year := chartQuery(c)
+	charts, err := ch.carCharts(userID, typeID, year, chartTheme(c))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching expenses.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.SVGChart, gin.H{"Charts": charts})
+}
@@ -18,9 +18,11 @@ import (
 // to render the main home page view, including monthly summaries and recent expenses.
 type HouseData struct {
 	Name           string
-	MonthlyExpense *models.MonthlyExpense // MonthlyExpense summarizes the total spending for the current month.
-	HighestExpense *models.HighestExpense // HighestExpense identifies the single largest expense in the current month.
-	RecentExpenses *[]models.HouseExpense // RecentExpenses lists individual expenses for the current month.
+	MonthlyExpense *models.MonthlyExpense  // MonthlyExpense summarizes the total spending for the current month.
+	HighestExpense *models.HighestExpense  // HighestExpense identifies the single largest expense in the current month.
+	RecentExpenses *[]models.HouseExpense  // RecentExpenses lists individual expenses for the current month.
+	BillCheck      []models.BillEstimate   // BillCheck lists the recent bills that deviate significantly from their estimate.
+	Anomalies      []models.ExpenseAnomaly // Anomalies lists the unusual expenses not dismissed yet.
 }
 
 // HouseHandler provides HTTP handlers for managing home-related expenses.
@@ -54,7 +56,31 @@ func (h *HouseHandler) GetCreateHouseForm(c *gin.Context) {
 		return
 	}
 
-	c.HTML(http.StatusOK, utilities.Templates.Components.CreateHouseExpForm, expTypes)
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	accounts, err := accountSelect(h.DB, userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	formData := &CreateFormData{
+		Types:    expTypes,
+		Accounts: accounts,
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.CreateHouseExpForm, formData)
+}
+
+type CreateFormData struct {
+	Types    *[]models.HomeUtilityType
+	Accounts *models.AccountSelect
 }
 
 // CreateHouseExpense handles the HTTP POST request to create a new home expense.
@@ -98,12 +124,23 @@ func (h *HouseHandler) CreateHouseExpense(c *gin.Context) {
 
 	notes := c.Request.PostFormValue("notes")
 
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
 	newExpense := &models.HouseExpense{
 		CreatedBy:     userID,
 		Amount:        amount,
 		UtilityTypeID: utilTypeID,
 		ExpenseDate:   date,
 		Notes:         notes,
+		AccountID:     accountID,
 	}
 
 	err = h.DB.CreateHouseExpense(newExpense)
@@ -116,6 +153,18 @@ func (h *HouseHandler) CreateHouseExpense(c *gin.Context) {
 		return
 	}
 
+	created := &models.CategorisedExpense{
+		ID:      newExpense.ID,
+		Tracker: models.TrackerHouse,
+		TypeID:  newExpense.UtilityTypeID,
+		Type:    newExpense.UtilityType,
+		Amount:  newExpense.Amount,
+		Date:    newExpense.ExpenseDate,
+		Notes:   newExpense.Notes,
+	}
+	warning := anomalyWarning(h.DB, userID, created)
+	expenseEvent(h.DB, userID, models.WebhookExpenseCreated, created)
+
 	timeNow := time.Now()
 
 	highestExp, expType, err := h.DB.GetHighestHouseExpenseForMonth(timeNow.Month(), userID)
@@ -139,6 +188,10 @@ func (h *HouseHandler) CreateHouseExpense(c *gin.Context) {
 	}
 
 	if newExpense.ExpenseDate.Month() != timeNow.Month() {
+		if warning != "" {
+			expenseSaved(c, models.TrackerHouse, newExpense.UtilityType, newExpense.Amount, warning)
+			return
+		}
 		c.HTML(http.StatusCreated, utilities.Templates.Components.Dialog, gin.H{})
 		return
 	}
@@ -158,6 +211,7 @@ func (h *HouseHandler) CreateHouseExpense(c *gin.Context) {
 		Modal: &models.ModalContent{
 			Title:   "Successful expense creation.",
 			Message: fmt.Sprintf("%s: %v BGN", newExpense.UtilityType, newExpense.Amount),
+			Warning: warning,
 		},
 	}
 
@@ -209,6 +263,26 @@ func (h *HouseHandler) GetCurrentMonth(c *gin.Context) {
 		return
 	}
 
+	flagged, err := billCheck(h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching bill estimates.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	anomalies, err := h.DB.GetExpenseAnomalies(userID, models.TrackerHouse)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching unusual expenses.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
 	pageData := &HouseData{
 		Name: "current",
 		MonthlyExpense: &models.MonthlyExpense{
@@ -220,6 +294,8 @@ func (h *HouseHandler) GetCurrentMonth(c *gin.Context) {
 			Type:   utilType,
 		},
 		RecentExpenses: recentExpenses,
+		BillCheck:      flagged,
+		Anomalies:      anomalies,
 	}
 
 	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
@@ -283,6 +359,26 @@ func (h *HouseHandler) GetHome(c *gin.Context) {
 		return
 	}
 
+	flagged, err := billCheck(h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching bill estimates.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	anomalies, err := h.DB.GetExpenseAnomalies(userID, models.TrackerHouse)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching unusual expenses.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
 	pageData := &HouseData{
 		Name: "current",
 		MonthlyExpense: &models.MonthlyExpense{
@@ -294,6 +390,8 @@ func (h *HouseHandler) GetHome(c *gin.Context) {
 			Type:   utilType,
 		},
 		RecentExpenses: recentExpenses,
+		BillCheck:      flagged,
+		Anomalies:      anomalies,
 	}
 
 	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
@@ -346,8 +444,9 @@ func (h *HouseHandler) GetExpenseById(c *gin.Context) {
 // INFO: UPDATE
 
 type EditFormData struct {
-	Expense *models.HouseExpense
-	Types   *[]models.HomeUtilityType
+	Expense  *models.HouseExpense
+	Types    *[]models.HomeUtilityType
+	Accounts *models.AccountSelect
 }
 
 // GetEditHouseForm renders the HTML form pre-filled with existing expense data
@@ -364,21 +463,40 @@ func (h *HouseHandler) GetEditHouseForm(c *gin.Context) {
 		return
 	}
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
 	exp, err := h.DB.GetHouseExpenseByID(id)
 	if err != nil {
 		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
 		return
 	}
 
+	if exp == nil || exp.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Expense not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
 	expTypes, err := h.DB.GetHouseUtilityTypes()
 	if err != nil {
 		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
 		return
 	}
 
+	accounts, err := accountSelect(h.DB, userID, exp.AccountID)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
+		return
+	}
+
 	formData := &EditFormData{
-		Expense: exp,
-		Types:   expTypes,
+		Expense:  exp,
+		Types:    expTypes,
+		Accounts: accounts,
 	}
 
 	c.HTML(http.StatusOK, utilities.Templates.Components.EditHouseExpForm, formData)
@@ -396,6 +514,13 @@ func (h *HouseHandler) EditHouseExpenseById(c *gin.Context) {
 		return
 	}
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	if h.ownExpense(c, id, userID) == nil {
+		return
+	}
+
 	utilTypeID, err := strconv.Atoi(c.Request.PostFormValue("typeID"))
 	if err != nil {
 		c.HTML(http.StatusBadRequest, utilities.Templates.Components.Modal, err)
@@ -417,12 +542,23 @@ func (h *HouseHandler) EditHouseExpenseById(c *gin.Context) {
 	}
 	notes := c.Request.PostFormValue("notes")
 
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
 	editExpense := &models.HouseExpense{
 		ID:            id,
 		Amount:        amount,
 		UtilityTypeID: utilTypeID,
 		ExpenseDate:   date,
 		Notes:         notes,
+		AccountID:     accountID,
 	}
 
 	err = h.DB.EditHouseExpense(editExpense)
@@ -435,9 +571,10 @@ func (h *HouseHandler) EditHouseExpenseById(c *gin.Context) {
 		return
 	}
 
+	edited, _ := h.DB.GetHouseExpenseByID(id)
+	houseExpenseEvent(h.DB, userID, models.WebhookExpenseUpdated, edited)
+
 	timeNow := time.Now()
-	userIDstr, _ := c.Get("user_id")
-	userID, _ := userIDstr.(uuid.UUID)
 
 	highestExp, expType, err := h.DB.GetHighestHouseExpenseForMonth(timeNow.Month(), userID)
 	if err != nil {
@@ -512,6 +649,14 @@ func (h *HouseHandler) DeleteHouseExp(c *gin.Context) {
 		return
 	}
 
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	deleted := h.ownExpense(c, id, userID)
+	if deleted == nil {
+		return
+	}
+
 	res, err := h.DB.DeleteHouseExpense(id)
 	if err != nil {
 		content := &models.ModalContent{
@@ -536,8 +681,8 @@ func (h *HouseHandler) DeleteHouseExp(c *gin.Context) {
 
 	timeNow := time.Now()
 	month := timeNow.Month()
-	userIDstr, _ := c.Get("user_id")
-	userID, _ := userIDstr.(uuid.UUID)
+
+	houseExpenseEvent(h.DB, userID, models.WebhookExpenseDeleted, deleted)
 
 	monthlyExpense, err := h.DB.GetTotalHouseExpenseForMonth(timeNow, userID)
 	if err != nil {
@@ -570,3 +715,19 @@ func (h *HouseHandler) DeleteHouseExp(c *gin.Context) {
 
 	c.HTML(http.StatusOK, utilities.Templates.Responses.DeleteHouseExp, pageData)
 }
+
+// ownExpense loads the expense and makes sure it belongs to the user. It renders
+// the error itself and returns nil on failure.
+func (h *HouseHandler) ownExpense(c *gin.Context, id int, userID uuid.UUID) *models.HouseExpense {
+	exp, err := h.DB.GetHouseExpenseByID(id)
+	if err != nil || exp == nil || exp.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Expense not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return exp
+}
@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"errors"
+	"expenser/internal/bankimport"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxStatementSize limits uploaded statement files to 5 MB.
+const maxStatementSize = 5 << 20
+
+type ImportHandler struct {
+	DB *database.DB
+}
+
+func NewImportHandler(db *database.DB) *ImportHandler {
+	return &ImportHandler{
+		DB: db,
+	}
+}
+
+// ImportPageData is the data for the import page.
+type ImportPageData struct {
+	Accounts *models.AccountSelect
+}
+
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	accounts, err := accountSelect(h.DB, userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	pageData := &ImportPageData{
+		Accounts: accounts,
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Import, pageData)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Import,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// buildImportRows applies the first matching rule to every transaction and
+// flags the ones already imported, either earlier or further up in the same file.
+func buildImportRows(txs []models.BankTransaction, rules []models.ExpenseRule, accountID *int, imported map[string]bool) ([]models.ImportRow, int) {
+	rows := make([]models.ImportRow, 0, len(txs))
+	seen := make(map[string]bool)
+	duplicates := 0
+
+	for _, tx := range txs {
+		row := models.ImportRow{
+			BankTransaction: tx,
+			Duplicate:       imported[tx.Ref] || seen[tx.Ref],
+		}
+		seen[tx.Ref] = true
+
+		if row.Duplicate {
+			duplicates++
+		} else {
+			subject := &models.RuleSubject{
+				Notes:     tx.Description,
+				Amount:    tx.Amount,
+				Date:      tx.Date,
+				AccountID: accountID,
+			}
+			if rule := models.FirstMatchingRule(rules, subject); rule != nil {
+				row.Tracker = rule.Tracker
+				row.ExpenseTypeID = rule.ExpenseTypeID
+				row.Tags = rule.Tags
+				row.VehicleID = rule.VehicleID
+				row.Vehicle = rule.Vehicle
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, duplicates
+}
+
+// PreviewImport handles the statement upload. Nothing is saved yet,
+// the parsed debits are returned for review before committing.
+func (h *ImportHandler) PreviewImport(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	fileHeader, err := c.FormFile("statement")
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, no statement file.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if fileHeader.Size > maxStatementSize {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, the statement file is larger than 5 MB.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, couldn't read the statement file.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxStatementSize))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, couldn't read the statement file.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	format, txs, err := bankimport.Parse(data)
+	if err != nil {
+		message := fmt.Sprintf("400: Couldn't read the %s statement.", format)
+		if errors.Is(err, bankimport.ErrNoTransactions) {
+			message = fmt.Sprintf("400: The %s statement has no booked debits.", format)
+		}
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: message,
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	rules, err := h.DB.GetExpenseRules(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rules.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	refs := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		refs = append(refs, tx.Ref)
+	}
+
+	imported, err := h.DB.GetImportedRefs(userID, refs)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error checking imported transactions.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	var account *models.Account
+	if accountID != nil {
+		account, err = h.DB.GetAccountByID(*accountID)
+		if err != nil {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "500: Error fetching account.",
+			}
+			c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+			return
+		}
+	}
+
+	rows, duplicates := buildImportRows(txs, *rules, accountID, imported)
+
+	preview := &models.ImportPreview{
+		FileName:   fileHeader.Filename,
+		Format:     string(format),
+		Rows:       rows,
+		Duplicates: duplicates,
+		HouseTypes: types.HouseTypes,
+		CarTypes:   types.CarTypes,
+		Account:    account,
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.ImportPreview, preview)
+}
+
+// parseImportRows reads the reviewed preview back. Every row posts its ref, date,
+// amount, description, tags, vehicle and target, rows with an empty target are skipped.
+// The vehicle must be one of the user's and is dropped from rows not booked as car expenses.
+func parseImportRows(c *gin.Context, types *ExpenseTypes, vehicles *[]models.Vehicle) ([]models.ImportRow, int, error) {
+	refs := c.PostFormArray("ref")
+	dates := c.PostFormArray("date")
+	amounts := c.PostFormArray("amount")
+	descriptions := c.PostFormArray("description")
+	tags := c.PostFormArray("tags")
+	targets := c.PostFormArray("target")
+	vehicleIDs := c.PostFormArray("vehicle")
+
+	if len(dates) != len(refs) || len(amounts) != len(refs) || len(descriptions) != len(refs) ||
+		len(tags) != len(refs) || len(targets) != len(refs) || len(vehicleIDs) != len(refs) {
+		return nil, 0, fmt.Errorf("incomplete import rows")
+	}
+
+	var rows []models.ImportRow
+	skipped := 0
+	for i, ref := range refs {
+		if targets[i] == "" {
+			skipped++
+			continue
+		}
+
+		tracker, typeID, err := types.parseTarget(targets[i])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		date, err := time.ParseInLocation(utilities.DateFormats.Input, dates[i], time.Local)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid date")
+		}
+
+		amount, err := strconv.ParseFloat(amounts[i], 64)
+		if err != nil || amount <= 0 {
+			return nil, 0, fmt.Errorf("invalid amount")
+		}
+
+		ref = strings.TrimSpace(ref)
+		if ref == "" || len(ref) > 255 {
+			return nil, 0, fmt.Errorf("invalid bank reference")
+		}
+
+		var vehicleID *int
+		if vehicleIDs[i] != "" && tracker == models.TrackerCar {
+			id, err := strconv.Atoi(vehicleIDs[i])
+			if err != nil || !hasVehicle(vehicles, id) {
+				return nil, 0, fmt.Errorf("invalid vehicle")
+			}
+			vehicleID = &id
+		}
+
+		rows = append(rows, models.ImportRow{
+			BankTransaction: models.BankTransaction{
+				Ref:         ref,
+				Date:        date,
+				Amount:      amount,
+				Description: descriptions[i],
+			},
+			Tracker:       tracker,
+			ExpenseTypeID: typeID,
+			Tags:          strings.Join(models.SplitTags(tags[i]), ","),
+			VehicleID:     vehicleID,
+		})
+	}
+
+	return rows, skipped, nil
+}
+
+// hasVehicle reports whether the vehicle with the given id is in the list.
+func hasVehicle(vehicles *[]models.Vehicle, id int) bool {
+	for _, v := range *vehicles {
+		if v.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitImport books the reviewed rows as expenses.
+func (h *ImportHandler) CommitImport(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	vehicles, err := h.DB.GetVehicles(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicles.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	rows, skipped, err := parseImportRows(c, types, vehicles)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	imported, err := h.DB.ImportTransactions(userID, accountID, rows)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't import the statement, nothing was saved.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	skipped += len(rows) - imported
+
+	var anomalies []models.ExpenseAnomaly
+	for _, row := range rows {
+		if row.ExpenseID == 0 {
+			continue
+		}
+
+		created := &models.CategorisedExpense{
+			ID:      row.ExpenseID,
+			Tracker: row.Tracker,
+			TypeID:  row.ExpenseTypeID,
+			Type:    types.typeName(row.Tracker, row.ExpenseTypeID),
+			Amount:  row.Amount,
+			Date:    row.Date,
+			Notes:   row.Description,
+		}
+		expenseEvent(h.DB, userID, models.WebhookExpenseCreated, created)
+
+		found := expenseAnomaly(h.DB, userID, created)
+		if found != nil {
+			anomalies = append(anomalies, *found)
+		}
+	}
+
+	result := &models.ImportResult{
+		Imported:  imported,
+		Skipped:   skipped,
+		Anomalies: anomalies,
+		Modal: &models.ModalContent{
+			Title:   "Successful import.",
+			Message: fmt.Sprintf("Imported %d expenses, skipped %d transactions.", imported, skipped),
+		},
+	}
+	if len(anomalies) > 0 {
+		result.Modal.Warning = fmt.Sprintf("%d of the imported expenses look unusual compared with earlier ones.", len(anomalies))
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.ImportResult, result)
+}
@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type IncomeData struct {
+	Name          string
+	MonthlyIncome *models.MonthlyExpense // MonthlyIncome summarizes the total income for the current month.
+	HighestIncome *models.HighestExpense // HighestIncome identifies the largest income source in the current month.
+	RecentIncomes *[]models.Income       // RecentIncomes lists individual incomes for the current month.
+}
+
+type IncomeHandler struct {
+	DB *database.DB
+}
+
+func NewIncomeHandler(db *database.DB) *IncomeHandler {
+	return &IncomeHandler{
+		DB: db,
+	}
+}
+
+func (h *IncomeHandler) getIncomeData(userID uuid.UUID) (*IncomeData, *models.ModalContent) {
+	dateNow := time.Now()
+
+	highestIncome, incType, err := h.DB.GetHighestIncomeForMonth(dateNow, userID)
+	if err != nil {
+		return nil, &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching highest income.",
+		}
+	}
+
+	monthlyIncome, err := h.DB.GetTotalIncomeForMonth(dateNow, userID)
+	if err != nil {
+		return nil, &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching total income.",
+		}
+	}
+
+	recentIncomes, err := h.DB.GetIncomesForMonth(dateNow.Month(), dateNow.Year(), userID)
+	if err != nil {
+		return nil, &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching recent incomes.",
+		}
+	}
+
+	return &IncomeData{
+		Name: "current",
+		MonthlyIncome: &models.MonthlyExpense{
+			Amount: monthlyIncome,
+			Month:  dateNow.Month().String(),
+		},
+		HighestIncome: &models.HighestExpense{
+			Amount: highestIncome,
+			Type:   incType,
+		},
+		RecentIncomes: recentIncomes,
+	}, nil
+}
+
+func (h *IncomeHandler) GetHome(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	pageData, errContent := h.getIncomeData(userID)
+	if errContent != nil {
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, errContent)
+		return
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Income, pageData)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Income,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+func (h *IncomeHandler) GetCurrentMonth(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	pageData, errContent := h.getIncomeData(userID)
+	if errContent != nil {
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, errContent)
+		return
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Components.IncomeCurrent, pageData)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Income,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+func (h *IncomeHandler) GetCreateIncomeForm(c *gin.Context) {
+	incTypes, err := h.DB.GetIncomeTypes()
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching income types.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	accounts, err := accountSelect(h.DB, userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	formData := &CreateIncomeFormData{
+		Types:    incTypes,
+		Accounts: accounts,
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.CreateIncomeForm, formData)
+}
+
+type CreateIncomeFormData struct {
+	Types    *[]models.IncomeType
+	Accounts *models.AccountSelect
+}
+
+// parseIncomeForm reads the income type, date, amount and notes
+// shared by the create and edit income forms.
+func parseIncomeForm(c *gin.Context) (*models.Income, error) {
+	incTypeID, err := strconv.Atoi(c.Request.PostFormValue("typeID"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid income type")
+	}
+
+	date, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("date"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date")
+	}
+
+	amount, err := strconv.ParseFloat(c.Request.PostFormValue("amount"), 64)
+	if err != nil || amount < 0 {
+		return nil, fmt.Errorf("invalid amount")
+	}
+
+	return &models.Income{
+		IncomeTypeID: incTypeID,
+		Amount:       amount,
+		Date:         date,
+		Notes:        c.Request.PostFormValue("notes"),
+	}, nil
+}
+
+// incomeSummary builds the out of band total and highest cards
+// returned after every income change.
+func (h *IncomeHandler) incomeSummary(userID uuid.UUID) (*models.MonthlyExpense, *models.HighestExpense, error) {
+	timeNow := time.Now()
+
+	highestInc, incType, err := h.DB.GetHighestIncomeForMonth(timeNow, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	monthlyTotal, err := h.DB.GetTotalIncomeForMonth(timeNow, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.MonthlyExpense{
+		Amount: monthlyTotal,
+		Month:  timeNow.Month().String(),
+		IsOOB:  true,
+	}, &models.HighestExpense{
+		Amount: highestInc,
+		Type:   incType,
+		IsOOB:  true,
+	}, nil
+}
+
+// CreateIncome handles the HTTP POST request to record a new income.
+// It validates the form, saves the income and returns the new row
+// together with the refreshed monthly summary cards.
+func (h *IncomeHandler) CreateIncome(c *gin.Context) {
+	newIncome, err := parseIncomeForm(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+	newIncome.CreatedBy = userID
+
+	newIncome.AccountID, err = parseAccountID(c, h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	err = h.DB.CreateIncome(newIncome)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create income.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	monthly, highest, err := h.incomeSummary(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching income summary.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	resp := &models.IncomeResponse{
+		Income:        newIncome,
+		MonthlyIncome: monthly,
+		HighestIncome: highest,
+		Modal: &models.ModalContent{
+			Title:   "Successful income creation.",
+			Message: fmt.Sprintf("%s: %v BGN", newIncome.Type, newIncome.Amount),
+		},
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.CreateIncome, resp)
+}
+
+// ownIncome loads the income from the id path parameter and makes sure
+// it belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *IncomeHandler) ownIncome(c *gin.Context) *models.Income {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	income, err := h.DB.GetIncomeByID(id)
+	if err != nil || income == nil || income.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Income not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return income
+}
+
+type EditIncomeFormData struct {
+	Income   *models.Income
+	Types    *[]models.IncomeType
+	Accounts *models.AccountSelect
+}
+
+// GetEditIncomeForm renders the HTML form pre-filled with existing income data.
+func (h *IncomeHandler) GetEditIncomeForm(c *gin.Context) {
+	income := h.ownIncome(c)
+	if income == nil {
+		return
+	}
+
+	incTypes, err := h.DB.GetIncomeTypes()
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching income types.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	accounts, err := accountSelect(h.DB, income.CreatedBy, income.AccountID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	formData := &EditIncomeFormData{
+		Income:   income,
+		Types:    incTypes,
+		Accounts: accounts,
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.EditIncomeForm, formData)
+}
+
+// EditIncomeById handles the HTTP PUT request to update an existing income
+// and returns the updated row with the refreshed monthly summary cards.
+func (h *IncomeHandler) EditIncomeById(c *gin.Context) {
+	existing := h.ownIncome(c)
+	if existing == nil {
+		return
+	}
+
+	editIncome, err := parseIncomeForm(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	editIncome.ID = existing.ID
+
+	editIncome.AccountID, err = parseAccountID(c, h.DB, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	err = h.DB.EditIncome(editIncome)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update income.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	monthly, highest, err := h.incomeSummary(existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching income summary.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	resp := &models.IncomeResponse{
+		Income:        editIncome,
+		MonthlyIncome: monthly,
+		HighestIncome: highest,
+		Modal: &models.ModalContent{
+			Title:   "Successful income update.",
+			Message: fmt.Sprintf("%s: %v BGN", editIncome.Type, editIncome.Amount),
+		},
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.CreateIncome, resp)
+}
+
+func (h *IncomeHandler) GetDeleteConfirm(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/income/entries/%v", id)),
+		Target:   fmt.Sprintf("#inc-%v", id),
+		Message:  fmt.Sprintf("Please confirm if you want to delete income with ID: %v", id),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+// DeleteIncome handles the HTTP DELETE request to remove an income by its ID
+// and returns the refreshed monthly summary cards.
+func (h *IncomeHandler) DeleteIncome(c *gin.Context) {
+	income := h.ownIncome(c)
+	if income == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteIncome(income.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete income.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	monthly, highest, err := h.incomeSummary(income.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching income summary.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	resp := &models.IncomeResponse{
+		MonthlyIncome: monthly,
+		HighestIncome: highest,
+		Modal: &models.ModalContent{
+			Title:   "Successfully deleted income!",
+			Message: fmt.Sprintf("Income with ID: %v deleted!", income.ID),
+		},
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.DeleteIncome, resp)
+}
+
+// GetCashFlow renders income against total expenses for every month
+// of the requested year, defaulting to the current one. A full page load
+// gets the income page instead, like the other income sections.
+func (h *IncomeHandler) GetCashFlow(c *gin.Context) {
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		y, err := strconv.Atoi(yearStr)
+		if err != nil {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "400: Bad Request on year.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+		year = y
+	}
+
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if !isHtmxRequest {
+		pageData, errContent := h.getIncomeData(userID)
+		if errContent != nil {
+			c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, errContent)
+			return
+		}
+
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Income,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+		return
+	}
+
+	cashFlow, err := h.DB.GetCashFlowForYear(year, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching cash flow.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.CashFlow, cashFlow)
+}
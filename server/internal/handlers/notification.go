@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"expenser/internal/config"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/notify"
+	"expenser/internal/utilities"
+	"net/http"
+	"net/mail"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// notificationsShown is how many of the latest notifications the page lists.
+const notificationsShown = 50
+
+type NotificationHandler struct {
+	DB         *database.DB
+	Mailer     *notify.Mailer
+	Dispatcher *notify.Dispatcher
+	Telegram   config.Telegram
+}
+
+func NewNotificationHandler(db *database.DB, mailer *notify.Mailer, telegram config.Telegram) *NotificationHandler {
+	return &NotificationHandler{
+		DB:         db,
+		Mailer:     mailer,
+		Dispatcher: notify.NewDispatcher(db, mailer),
+		Telegram:   telegram,
+	}
+}
+
+// GetNotifications lists the user's latest notifications and marks them as read.
+// Unread ones are still highlighted on this visit.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	notifications, err := h.DB.GetNotifications(userID, notificationsShown)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching notifications.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	settings, err := h.DB.GetNotificationSettings(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching notification settings.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	channels, err := h.DB.GetNotificationChannels(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching notification channels.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	telegram, err := h.telegram(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching Telegram link.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.MarkNotificationsRead(userID); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error updating notifications.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data := &models.NotificationsData{
+		Notifications: notifications,
+		Settings:      settings,
+		EmailEnabled:  h.Mailer.Enabled(),
+		Channels:      channels,
+		Telegram:      telegram,
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Notifications, data)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Notifications,
+			TemplateContent: data,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// bindNotificationSettings reads the settings form, an address of the form or
+// the account is required for anything that is emailed.
+func bindNotificationSettings(c *gin.Context, settings *models.NotificationSettings) string {
+	if err := c.ShouldBind(settings); err != nil {
+		return "400: Bad Request."
+	}
+
+	if settings.Email != "" {
+		address, err := mail.ParseAddress(settings.Email)
+		if err != nil {
+			return "400: Bad Request, invalid email address."
+		}
+		settings.Email = address.Address
+	}
+
+	if settings.Address() == "" && (settings.EmailNotifications || settings.MonthlyDigest) {
+		return "400: Bad Request, an email address is required."
+	}
+	return ""
+}
+
+// SaveSettings handles the HTTP PUT request to change what the user is emailed.
+func (h *NotificationHandler) SaveSettings(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	current, err := h.DB.GetNotificationSettings(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching notification settings.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	settings := &models.NotificationSettings{UserID: userID, AccountEmail: current.AccountEmail}
+
+	if msg := bindNotificationSettings(c, settings); msg != "" {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: msg,
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.SaveNotificationSettings(settings); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error saving notification settings.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully saved notification settings!",
+		Message: "Notification settings saved!",
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
+
+// SendTestEmail handles the HTTP POST request to email a test message to the
+// address in the settings form, before the user saves it, or else the account's.
+func (h *NotificationHandler) SendTestEmail(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	current, err := h.DB.GetNotificationSettings(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching notification settings.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	settings := &models.NotificationSettings{UserID: userID, AccountEmail: current.AccountEmail}
+
+	msg := bindNotificationSettings(c, settings)
+	if msg == "" && settings.Address() == "" {
+		msg = "400: Bad Request, an email address is required."
+	}
+	if msg != "" {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: msg,
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.Mailer.SendTest(settings.Address()); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "502: Couldn't send the test email, " + err.Error() + ".",
+		}
+		c.HTML(http.StatusBadGateway, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully sent test email!",
+		Message: "Test email sent to " + settings.Address() + "!",
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"expenser/internal/models"
+	"expenser/internal/netguard"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NotificationChannelFormData is the data for the create and edit push channel forms.
+type NotificationChannelFormData struct {
+	Channel      *models.NotificationChannel // Channel is nil for a new one.
+	ChannelKinds []models.ChannelKind
+	Events       []models.NotificationKind
+}
+
+// IsSubscribed reports whether the event is checked, all of them are for a new channel.
+func (d *NotificationChannelFormData) IsSubscribed(kind models.NotificationKind) bool {
+	return d.Channel == nil || d.Channel.Subscribed(kind)
+}
+
+// channelsSaved responds with the refreshed push channels.
+func (h *NotificationHandler) channelsSaved(c *gin.Context, status int, userID uuid.UUID, modal *models.ModalContent) {
+	channels, err := h.DB.GetNotificationChannels(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching notification channels.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(status, utilities.Templates.Responses.SaveNotificationChannel, gin.H{
+		"Channels": channels,
+		"Modal":    modal,
+	})
+}
+
+func (h *NotificationHandler) channelForm(c *gin.Context, ch *models.NotificationChannel) {
+	c.HTML(http.StatusOK, utilities.Templates.Components.NotificationChannelForm, &NotificationChannelFormData{
+		Channel:      ch,
+		ChannelKinds: models.ChannelKinds,
+		Events:       models.NotificationKinds,
+	})
+}
+
+func (h *NotificationHandler) GetCreateChannelForm(c *gin.Context) {
+	h.channelForm(c, nil)
+}
+
+// parseHTTPURL checks a webhook or ntfy address. Addresses on the server's own
+// network are refused here when it's obvious and when connecting otherwise,
+// unless the admin allowed them with OUTBOUND_ALLOWED_HOSTS.
+func parseHTTPURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid URL")
+	}
+	if !netguard.AllowedHost(u.Hostname()) {
+		return "", fmt.Errorf("the URL can't point to a local or private network unless the server admin allows it")
+	}
+	return u.String(), nil
+}
+
+// parseChannelForm reads a push channel. What is required depends on the kind, see
+// models.NotificationChannel. An empty secret keeps the one of existing, when editing.
+func parseChannelForm(c *gin.Context, userID uuid.UUID, existing *models.NotificationChannel) (*models.NotificationChannel, error) {
+	ch := &models.NotificationChannel{
+		UserID: userID,
+		Kind:   models.ChannelKind(c.Request.PostFormValue("kind")),
+		Name:   strings.TrimSpace(c.Request.PostFormValue("name")),
+		Secret: strings.TrimSpace(c.Request.PostFormValue("secret")),
+		ChatID: strings.TrimSpace(c.Request.PostFormValue("chatID")),
+	}
+
+	if !ch.Kind.Valid() {
+		return nil, fmt.Errorf("invalid channel")
+	}
+	if ch.Name == "" || len(ch.Name) > 100 {
+		return nil, fmt.Errorf("invalid name")
+	}
+	if ch.Secret == "" && existing != nil && existing.Kind == ch.Kind {
+		ch.Secret = existing.Secret
+	}
+
+	switch ch.Kind {
+	case models.ChannelWebhook, models.ChannelNtfy:
+		u, err := parseHTTPURL(c.Request.PostFormValue("url"))
+		if err != nil {
+			return nil, err
+		}
+		ch.URL = u
+		ch.ChatID = ""
+		if ch.Kind == models.ChannelWebhook && ch.Secret == "" {
+			return nil, fmt.Errorf("a signing secret is required")
+		}
+	case models.ChannelTelegram:
+		if ch.Secret == "" || ch.ChatID == "" {
+			return nil, fmt.Errorf("a bot token and chat ID are required")
+		}
+	}
+
+	c.Request.ParseForm()
+	for _, event := range c.Request.PostForm["events"] {
+		kind := models.NotificationKind(event)
+		if !kind.Valid() {
+			return nil, fmt.Errorf("invalid event")
+		}
+		ch.Events = append(ch.Events, kind)
+	}
+	if len(ch.Events) == 0 {
+		return nil, fmt.Errorf("pick at least one event")
+	}
+
+	return ch, nil
+}
+
+// CreateChannel handles the HTTP POST request to add a push channel.
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	ch, err := parseChannelForm(c, userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateNotificationChannel(ch); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create notification channel.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.channelsSaved(c, http.StatusCreated, userID, &models.ModalContent{
+		Title:   "Successful notification channel creation.",
+		Message: fmt.Sprintf("Notifications are pushed to %s over %s!", ch.Name, ch.Kind.Label()),
+	})
+}
+
+// ownChannel loads the push channel from the id path parameter and makes sure it
+// belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *NotificationHandler) ownChannel(c *gin.Context) *models.NotificationChannel {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	ch, err := h.DB.GetNotificationChannelByID(id)
+	if err != nil || ch == nil || ch.UserID != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Notification channel not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return ch
+}
+
+func (h *NotificationHandler) GetEditChannelForm(c *gin.Context) {
+	ch := h.ownChannel(c)
+	if ch == nil {
+		return
+	}
+
+	h.channelForm(c, ch)
+}
+
+func (h *NotificationHandler) EditChannel(c *gin.Context) {
+	existing := h.ownChannel(c)
+	if existing == nil {
+		return
+	}
+
+	ch, err := parseChannelForm(c, existing.UserID, existing)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	ch.ID = existing.ID
+
+	if err := h.DB.EditNotificationChannel(ch); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update notification channel.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.channelsSaved(c, http.StatusOK, ch.UserID, &models.ModalContent{
+		Title:   "Successful notification channel update.",
+		Message: fmt.Sprintf("Notification channel %s updated!", ch.Name),
+	})
+}
+
+func (h *NotificationHandler) GetDeleteChannelConfirm(c *gin.Context) {
+	ch := h.ownChannel(c)
+	if ch == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/notifications/channels/%v", ch.ID)),
+		Target:   fmt.Sprintf("#channel-%v", ch.ID),
+		Message:  fmt.Sprintf("Please confirm if you want to stop pushing notifications to %s.", ch.Name),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	ch := h.ownChannel(c)
+	if ch == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteNotificationChannel(ch.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete notification channel.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	h.channelsSaved(c, http.StatusOK, ch.UserID, &models.ModalContent{
+		Title:   "Successfully deleted notification channel!",
+		Message: fmt.Sprintf("Notification channel %s deleted!", ch.Name),
+	})
+}
+
+// SendTestPush handles the HTTP POST request to push a test notification to the
+// channel in the form, before the user saves it. Editing a channel sends its id
+// along, for the secret left empty to be the saved one.
+func (h *NotificationHandler) SendTestPush(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	var existing *models.NotificationChannel
+	if id, err := strconv.Atoi(c.Request.PostFormValue("id")); err == nil {
+		existing, _ = h.DB.GetNotificationChannelByID(id)
+		if existing != nil && existing.UserID != userID {
+			existing = nil
+		}
+	}
+
+	ch, err := parseChannelForm(c, userID, existing)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.Dispatcher.SendTest(c.Request.Context(), ch); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "502: Couldn't push the test notification, " + err.Error() + ".",
+		}
+		c.HTML(http.StatusBadGateway, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully pushed test notification!",
+		Message: "Test notification pushed to " + ch.Name + "!",
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"errors"
+	"expenser/internal/models"
+	"expenser/internal/passkey"
+	"expenser/internal/utilities"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// passkeysUnavailable responds that passkeys can't be used, when the base URL
+// isn't a valid WebAuthn origin.
+func passkeysUnavailable(c *gin.Context) {
+	content := &models.ModalContent{
+		Title:   "Something went wrong!",
+		Message: "503: Passkeys aren't available on this server.",
+	}
+	c.HTML(http.StatusServiceUnavailable, utilities.Templates.Components.ModalError, content)
+}
+
+// BeginPasskeyLogin starts logging in with a passkey. It responds with the
+// options for the browser's passkey prompt as JSON.
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	if h.Passkeys == nil {
+		passkeysUnavailable(c)
+		return
+	}
+
+	options, ceremonyID, err := h.Passkeys.BeginLogin()
+	if errors.Is(err, passkey.ErrBusy) {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "503: Too many passkey logins are waiting, try again in a few minutes.",
+		}
+		c.HTML(http.StatusServiceUnavailable, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	if err != nil {
+		log.Printf("passkey login: %v", err)
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't start the passkey login.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.AuthService.SetPasskeyCookie(ceremonyID, passkey.CeremonyTTL, c)
+	c.JSON(http.StatusOK, options)
+}
+
+// PasskeyLogin handles the HTTP POST request of the browser's answer to the
+// passkey prompt. A passkey proves both who the user is and that they are
+// present, so it logs in without a two-factor code.
+func (h *AuthHandler) PasskeyLogin(c *gin.Context) {
+	if h.Passkeys == nil {
+		passkeysUnavailable(c)
+		return
+	}
+
+	var input models.PasskeyResponse
+	if err := c.ShouldBind(&input); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Invalid request data.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	ceremonyID, _ := c.Cookie("passkey_ceremony")
+	h.AuthService.SetPasskeyCookie("", 0, c)
+
+	user, used, err := h.Passkeys.FinishLogin(ceremonyID, input.Credential, func(id uuid.UUID) (*passkey.User, error) {
+		user, err := h.DB.GetUserByID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		passkeys, err := h.DB.GetPasskeys(id)
+		if err != nil {
+			return nil, err
+		}
+
+		return &passkey.User{User: user, Passkeys: passkeys}, nil
+	})
+	if err != nil {
+		log.Printf("passkey login: %v", err)
+		content := &models.ModalContent{
+			Title:   "Invalid passkey!",
+			Message: "401: The passkey wasn't accepted, try again or log in with your password.",
+		}
+		c.HTML(http.StatusUnauthorized, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.UsePasskey(used.ID, used.Credential, time.Now()); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error saving passkey.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.startSession(c, user.User)
+}
+
+func (h *SettingsHandler) passkeyUser(userID uuid.UUID) (*passkey.User, error) {
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := h.DB.GetPasskeys(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &passkey.User{User: user, Passkeys: passkeys}, nil
+}
+
+// BeginPasskey starts adding a passkey to the user. It responds with the options
+// for the browser's passkey prompt as JSON.
+func (h *SettingsHandler) BeginPasskey(c *gin.Context) {
+	if h.Passkeys == nil {
+		passkeysUnavailable(c)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	user, err := h.passkeyUser(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	options, ceremonyID, err := h.Passkeys.BeginRegistration(user)
+	if err != nil {
+		log.Printf("passkey registration: %v", err)
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't start adding the passkey.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.AuthService.SetPasskeyCookie(ceremonyID, passkey.CeremonyTTL, c)
+	c.JSON(http.StatusOK, options)
+}
+
+// AddPasskey handles the HTTP POST request of the browser's answer to the
+// passkey prompt, saving the new passkey under its name.
+func (h *SettingsHandler) AddPasskey(c *gin.Context) {
+	if h.Passkeys == nil {
+		passkeysUnavailable(c)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	var input models.PasskeyResponse
+	if err := c.ShouldBind(&input); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Invalid request data.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if input.Name == "" {
+		input.Name = "Passkey"
+	}
+
+	ceremonyID, _ := c.Cookie("passkey_ceremony")
+	h.AuthService.SetPasskeyCookie("", 0, c)
+
+	user, err := h.passkeyUser(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	p, err := h.Passkeys.FinishRegistration(user, ceremonyID, input.Credential, input.Name)
+	if err != nil {
+		log.Printf("passkey registration of %s: %v", userID, err)
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: The passkey wasn't accepted, try again.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreatePasskey(p); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't save the passkey, it may already be added.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.passkeysSaved(c, userID, &models.ModalContent{
+		Title:   "Successfully added passkey!",
+		Message: fmt.Sprintf("Passkey %s added, you can log in with it now.", p.Name),
+	})
+}
+
+// RenamePasskey handles the HTTP PUT request to rename a passkey of the user.
+func (h *SettingsHandler) RenamePasskey(c *gin.Context) {
+	p := h.ownPasskey(c)
+	if p == nil {
+		return
+	}
+
+	var input models.Passkey
+	if err := c.ShouldBind(&input); err != nil || input.Name == "" {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Invalid request data.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.RenamePasskey(p.ID, input.Name); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't rename passkey.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.passkeysSaved(c, p.UserID, &models.ModalContent{
+		Title:   "Successfully renamed passkey!",
+		Message: fmt.Sprintf("Passkey %s renamed to %s.", p.Name, input.Name),
+	})
+}
+
+// DeletePasskey handles the HTTP DELETE request to remove a passkey of the user.
+func (h *SettingsHandler) DeletePasskey(c *gin.Context) {
+	p := h.ownPasskey(c)
+	if p == nil {
+		return
+	}
+
+	res, err := h.DB.DeletePasskey(p.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete passkey.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	h.passkeysSaved(c, p.UserID, &models.ModalContent{
+		Title:   "Successfully deleted passkey!",
+		Message: fmt.Sprintf("Passkey %s deleted, it can't log in anymore.", p.Name),
+	})
+}
+
+func (h *SettingsHandler) ownPasskey(c *gin.Context) *models.Passkey {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	p, err := h.DB.GetPasskeyByID(id)
+	if err != nil || p == nil || p.UserID != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Passkey not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return p
+}
+
+func (h *SettingsHandler) passkeysSaved(c *gin.Context, userID uuid.UUID, modal *models.ModalContent) {
+	passkeys, err := h.DB.GetPasskeys(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching passkeys.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.SavePasskeys, gin.H{
+		"Passkeys": passkeys,
+		"Modal":    modal,
+	})
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"errors"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/quickadd"
+	"expenser/internal/utilities"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type QuickAddHandler struct {
+	DB *database.DB
+}
+
+func NewQuickAddHandler(db *database.DB) *QuickAddHandler {
+	return &QuickAddHandler{
+		DB: db,
+	}
+}
+
+// ParseQuickAdd handles the HTTP POST request with a free text phrase like
+// "fuel 85.40 yesterday shell". Nothing is saved yet, the interpretation is
+// returned for confirmation. The user's rules fill in the type when the phrase
+// names none and add their tags.
+func (h *QuickAddHandler) ParseQuickAdd(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	text := c.Request.PostFormValue("text")
+	if len(text) > 255 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, the text is limited to 255 characters.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	entry, err := quickadd.Parse(text, time.Now(), &quickadd.Types{House: *types.HouseTypes, Car: *types.CarTypes})
+	if err != nil {
+		message := "400: Bad Request, nothing to add."
+		if errors.Is(err, quickadd.ErrNoAmount) {
+			message = fmt.Sprintf("400: Couldn't find an amount in %q.", text)
+		}
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: message,
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	rules, err := h.DB.GetExpenseRules(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rules.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	subject := &models.RuleSubject{
+		Notes:  entry.Notes,
+		Amount: entry.Amount,
+		Date:   entry.Date,
+	}
+	if rule := models.FirstMatchingRule(*rules, subject); rule != nil {
+		entry.ApplyRule(rule)
+	}
+
+	accounts, err := accountSelect(h.DB, userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, entry.VehicleID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicles.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	confirm := &models.QuickAddConfirm{
+		Entry:      entry,
+		Page:       models.Tracker(c.Request.PostFormValue("page")),
+		HouseTypes: types.HouseTypes,
+		CarTypes:   types.CarTypes,
+		Accounts:   accounts,
+		Vehicles:   vehicles,
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.QuickAddConfirm, confirm)
+}
+
+// ConfirmQuickAdd handles the HTTP POST request saving a confirmed phrase.
+func (h *QuickAddHandler) ConfirmQuickAdd(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	tracker, typeID, err := types.parseTarget(c.Request.PostFormValue("target"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, choose an expense type.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	date, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("date"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	amount, err := strconv.ParseFloat(c.Request.PostFormValue("amount"), 64)
+	if err != nil || amount <= 0 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on amount.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	notes := c.Request.PostFormValue("notes")
+	tags := strings.Join(models.SplitTags(c.Request.PostFormValue("tags")), ",")
+	if len(notes) > 255 || len(tags) > 255 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, notes and tags are limited to 255 characters.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	onPage := isCurrentPage(c, tracker, date)
+
+	if tracker == models.TrackerCar {
+		vehicleID, err := parseVehicleID(c, h.DB, userID)
+		if err != nil {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "400: Bad Request on vehicle.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+
+		h.createCarExpense(c, &models.CarExpense{
+			ExpenseTypeID: typeID,
+			Amount:        amount,
+			Date:          date,
+			Notes:         notes,
+			Tags:          tags,
+			CreatedBy:     userID,
+			AccountID:     accountID,
+			VehicleID:     vehicleID,
+		}, onPage)
+		return
+	}
+
+	h.createHouseExpense(c, &models.HouseExpense{
+		UtilityTypeID: typeID,
+		Amount:        amount,
+		ExpenseDate:   date,
+		Notes:         notes,
+		Tags:          tags,
+		CreatedBy:     userID,
+		AccountID:     accountID,
+	}, onPage)
+}
+
+// isCurrentPage reports whether an expense of the tracker and date shows up on
+// the page the form was posted from, its "page" field naming the tracker.
+func isCurrentPage(c *gin.Context, tracker models.Tracker, date time.Time) bool {
+	timeNow := time.Now()
+	return models.Tracker(c.Request.PostFormValue("page")) == tracker &&
+		date.Month() == timeNow.Month() && date.Year() == timeNow.Year()
+}
+
+// expenseSaved tells the user where the expense went when the current page isn't updated.
+func expenseSaved(c *gin.Context, tracker models.Tracker, typeName string, amount float64, warning string) {
+	c.Header("HX-Reswap", "none")
+	content := &models.ModalContent{
+		Title:   "Successful expense creation.",
+		Message: fmt.Sprintf("%s %s: %v BGN", tracker.Label(), typeName, amount),
+		Warning: warning,
+	}
+	c.HTML(http.StatusCreated, utilities.Templates.Components.ModalSuccess, content)
+}
+
+func (h *QuickAddHandler) createCarExpense(c *gin.Context, exp *models.CarExpense, onPage bool) {
+	if err := h.DB.CreateCarExpense(exp); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error creating new car expense.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	carExpenseCreated(c, h.DB, exp, onPage)
+}
+
+// carExpenseCreated responds to a new car expense. On the car page showing the
+// expense's month the row and monthly summary are updated like after the regular
+// add form, otherwise only a success message is shown.
+func carExpenseCreated(c *gin.Context, db *database.DB, exp *models.CarExpense, onPage bool) {
+	created := &models.CategorisedExpense{
+		ID:      exp.ID,
+		Tracker: models.TrackerCar,
+		TypeID:  exp.ExpenseTypeID,
+		Type:    exp.Type,
+		Amount:  exp.Amount,
+		Date:    exp.Date,
+		Notes:   exp.Notes,
+	}
+	warning := anomalyWarning(db, exp.CreatedBy, created)
+	expenseEvent(db, exp.CreatedBy, models.WebhookExpenseCreated, created)
+
+	if !onPage {
+		expenseSaved(c, models.TrackerCar, exp.Type, exp.Amount, warning)
+		return
+	}
+
+	timeNow := time.Now()
+
+	highestExp, expType, err := db.GetHighestCarExpenseForMonth(timeNow.Month(), exp.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching highest car expense.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	monthlyTotal, err := db.GetTotalCarExpenseForMonth(timeNow.Month(), exp.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching total car expense.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	resp := &models.CarExpResponse{
+		Expense: exp,
+		HighestExpense: &models.HighestExpense{
+			Amount: highestExp,
+			Type:   expType,
+			IsOOB:  true,
+		},
+		MonthlyExpense: &models.MonthlyExpense{
+			Amount: monthlyTotal,
+			Month:  timeNow.Month().String(),
+			IsOOB:  true,
+		},
+		Modal: &models.ModalContent{
+			Title:   "Successful expense creation.",
+			Message: fmt.Sprintf("%s: %v BGN", exp.Type, exp.Amount),
+			Warning: warning,
+		},
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.CreateCarExp, resp)
+}
+
+func (h *QuickAddHandler) createHouseExpense(c *gin.Context, exp *models.HouseExpense, onPage bool) {
+	if err := h.DB.CreateHouseExpense(exp); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error creating new house expense.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	houseExpenseCreated(c, h.DB, exp, onPage)
+}
+
+// houseExpenseCreated is carExpenseCreated for house expenses.
+func houseExpenseCreated(c *gin.Context, db *database.DB, exp *models.HouseExpense, onPage bool) {
+	created := &models.CategorisedExpense{
+		ID:      exp.ID,
+		Tracker: models.TrackerHouse,
+		TypeID:  exp.UtilityTypeID,
+		Type:    exp.UtilityType,
+		Amount:  exp.Amount,
+		Date:    exp.ExpenseDate,
+		Notes:   exp.Notes,
+	}
+	warning := anomalyWarning(db, exp.CreatedBy, created)
+	expenseEvent(db, exp.CreatedBy, models.WebhookExpenseCreated, created)
+
+	if !onPage {
+		expenseSaved(c, models.TrackerHouse, exp.UtilityType, exp.Amount, warning)
+		return
+	}
+
+	timeNow := time.Now()
+
+	highestExp, expType, err := db.GetHighestHouseExpenseForMonth(timeNow.Month(), exp.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching highest house expense.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	monthlyTotal, err := db.GetTotalHouseExpenseForMonth(timeNow, exp.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching total house expense.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	resp := &models.HouseExpResponse{
+		Expense: exp,
+		HighestExpense: &models.HighestExpense{
+			Amount: highestExp,
+			Type:   expType,
+			IsOOB:  true,
+		},
+		MonthlyExpense: &models.MonthlyExpense{
+			Amount: monthlyTotal,
+			Month:  timeNow.Month().String(),
+			IsOOB:  true,
+		},
+		Modal: &models.ModalContent{
+			Title:   "Successful expense creation.",
+			Message: fmt.Sprintf("%s: %v BGN", exp.UtilityType, exp.Amount),
+			Warning: warning,
+		},
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.CreateHouseExp, resp)
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/receipt"
+	"expenser/internal/utilities"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxReceiptPhotoSize limits uploaded receipt photos to 10 MB.
+const maxReceiptPhotoSize = 10 << 20
+
+type ReceiptHandler struct {
+	DB *database.DB
+}
+
+func NewReceiptHandler(db *database.DB) *ReceiptHandler {
+	return &ReceiptHandler{
+		DB: db,
+	}
+}
+
+// GetReceiptScanForm renders the form taking a receipt photo or its decoded QR text.
+func (h *ReceiptHandler) GetReceiptScanForm(c *gin.Context) {
+	c.HTML(http.StatusOK, utilities.Templates.Components.ReceiptScanForm, models.Tracker(c.Query("page")))
+}
+
+// readReceiptQR returns the QR text posted as text or, when empty, decoded from the uploaded photo.
+func readReceiptQR(c *gin.Context) (string, error) {
+	if text := strings.TrimSpace(c.Request.PostFormValue("qr")); text != "" {
+		return text, nil
+	}
+
+	fileHeader, err := c.FormFile("photo")
+	if err != nil {
+		return "", fmt.Errorf("paste the QR code text or upload a photo of the receipt")
+	}
+
+	if fileHeader.Size > maxReceiptPhotoSize {
+		return "", fmt.Errorf("the photo is larger than 10 MB")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("couldn't read the photo")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxReceiptPhotoSize))
+	if err != nil {
+		return "", fmt.Errorf("couldn't read the photo")
+	}
+
+	return receipt.Decode(data)
+}
+
+// ReadReceipt handles the HTTP POST request with a receipt photo or QR text.
+// Nothing is saved yet, a new expense form pre-filled with the receipt's
+// amount and date is returned. Receipts already imported are rejected.
+func (h *ReceiptHandler) ReadReceipt(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	text, err := readReceiptQR(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	fiscal, err := receipt.Parse(text)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	imported, err := h.DB.IsReceiptImported(userID, fiscal)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error checking imported receipts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if imported {
+		content := &models.ModalContent{
+			Title:   "Already imported.",
+			Message: fmt.Sprintf("409: Receipt %s of fiscal device %s is already booked.", fiscal.ReceiptNumber, fiscal.DeviceNumber),
+		}
+		c.HTML(http.StatusConflict, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	rules, err := h.DB.GetExpenseRules(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rules.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	accounts, err := accountSelect(h.DB, userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching accounts.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	form := &models.ReceiptForm{
+		QR:         text,
+		Receipt:    fiscal,
+		Page:       models.Tracker(c.Request.PostFormValue("page")),
+		HouseTypes: types.HouseTypes,
+		CarTypes:   types.CarTypes,
+		Accounts:   accounts,
+	}
+
+	subject := &models.RuleSubject{
+		Amount: fiscal.Amount,
+		Date:   fiscal.IssuedAt,
+	}
+	if rule := models.FirstMatchingRule(*rules, subject); rule != nil {
+		form.Target = rule.Target()
+		form.Tags = rule.Tags
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.ReceiptForm, form)
+}
+
+// ImportReceipt handles the HTTP POST request booking a reviewed receipt
+// as a house or car expense.
+func (h *ReceiptHandler) ImportReceipt(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	fiscal, err := receipt.Parse(c.Request.PostFormValue("qr"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	tracker, typeID, err := types.parseTarget(c.Request.PostFormValue("target"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, choose an expense type.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	date, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("date"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	amount, err := strconv.ParseFloat(c.Request.PostFormValue("amount"), 64)
+	if err != nil || amount <= 0 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on amount.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	accountID, err := parseAccountID(c, h.DB, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on account.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	notes := c.Request.PostFormValue("notes")
+	tags := strings.Join(models.SplitTags(c.Request.PostFormValue("tags")), ",")
+	if len(notes) > 255 || len(tags) > 255 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, notes and tags are limited to 255 characters.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	exp := &models.CategorisedExpense{
+		Tracker:   tracker,
+		TypeID:    typeID,
+		Amount:    amount,
+		Date:      date,
+		Notes:     notes,
+		Tags:      tags,
+		AccountID: accountID,
+	}
+
+	imported, err := h.DB.ImportReceipt(userID, fiscal, exp)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't import the receipt.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !imported {
+		content := &models.ModalContent{
+			Title:   "Already imported.",
+			Message: fmt.Sprintf("409: Receipt %s of fiscal device %s is already booked.", fiscal.ReceiptNumber, fiscal.DeviceNumber),
+		}
+		c.HTML(http.StatusConflict, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	onPage := isCurrentPage(c, tracker, date)
+
+	if tracker == models.TrackerCar {
+		carExpenseCreated(c, h.DB, &models.CarExpense{
+			ID:            exp.ID,
+			ExpenseTypeID: exp.TypeID,
+			Type:          exp.Type,
+			Amount:        exp.Amount,
+			Date:          exp.Date,
+			Notes:         exp.Notes,
+			Tags:          exp.Tags,
+			CreatedBy:     userID,
+			AccountID:     exp.AccountID,
+		}, onPage)
+		return
+	}
+
+	houseExpenseCreated(c, h.DB, &models.HouseExpense{
+		ID:            exp.ID,
+		UtilityTypeID: exp.TypeID,
+		UtilityType:   exp.Type,
+		Amount:        exp.Amount,
+		ExpenseDate:   exp.Date,
+		Notes:         exp.Notes,
+		Tags:          exp.Tags,
+		CreatedBy:     userID,
+		AccountID:     exp.AccountID,
+	}, onPage)
+}
@@ -4,7 +4,11 @@ import (
 	"expenser/internal/config"
 	database "expenser/internal/db"
 	"expenser/internal/middleware"
+	"expenser/internal/notify"
+	"expenser/internal/passkey"
 	"expenser/internal/services"
+	"expenser/internal/sso"
+	"log"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,19 +21,42 @@ func RegisterRoutes(router *gin.Engine, db *database.DB, cfg *config.Config) {
 	router.NoRoute(rootHandler.NotFound)
 	router.GET("/", rootHandler.GetRoot)
 
-	authHandler := NewAuthHandler(db, as)
+	mailer := notify.NewMailer(db, cfg)
+	passkeys, err := passkey.New(cfg.BaseURL)
+	if err != nil {
+		log.Printf("Passkeys disabled: %v", err)
+	}
+
+	var provider *sso.Provider
+	if cfg.OIDC.Enabled() {
+		provider = sso.New(cfg.OIDC, cfg.BaseURL)
+	}
+
+	authHandler := NewAuthHandler(db, as, mailer, passkeys, provider)
 
 	router.GET("/login", authHandler.GetLogin)
 	router.POST("/login", authHandler.Login)
+	router.GET("/login/two-factor", authHandler.GetTwoFactorLogin)
+	router.POST("/login/two-factor", authHandler.TwoFactorLogin)
+	router.POST("/login/passkey/begin", authHandler.BeginPasskeyLogin)
+	router.POST("/login/passkey", authHandler.PasskeyLogin)
+	router.GET("/login/oidc", authHandler.BeginSSO)
+	router.GET("/login/oidc/callback", authHandler.SSOCallback)
 	router.GET("/logout", authHandler.Logout)
 	router.GET("/register", authHandler.GetRegister)
 	router.POST("/register", authHandler.Register)
+	router.GET("/password/forgot", authHandler.GetForgotPassword)
+	router.POST("/password/forgot", authHandler.ForgotPassword)
+	router.GET("/password/reset", authHandler.GetResetPassword)
+	router.POST("/password/reset", authHandler.ResetPassword)
+	router.GET("/email/verify", authHandler.VerifyEmail)
 
-	am := middleware.NewAuthMiddleware(as)
+	am := middleware.NewAuthMiddleware(as, db)
 	chartHandler := NewChartHandler(db)
 	searchHandler := NewSearchHandler(db)
 
 	houseHandler := NewHouseHandler(db)
+	tariffHandler := NewTariffHandler(db)
 	protectedHouse := router.Group("/house")
 	{
 		protectedHouse.Use(am.AuthMiddleware())
@@ -40,15 +67,31 @@ func RegisterRoutes(router *gin.Engine, db *database.DB, cfg *config.Config) {
 		protectedHouse.POST("/search", searchHandler.GetResultsHouse)
 		protectedHouse.GET("/chart", chartHandler.HouseRoot)
 		protectedHouse.GET("/chart/search", chartHandler.HouseSearch)
+		protectedHouse.GET("/chart/svg", chartHandler.HouseSVG)
 		protectedHouse.GET("/expenses/new", houseHandler.GetCreateHouseForm)
 		protectedHouse.POST("/expenses", houseHandler.CreateHouseExpense)
 		protectedHouse.GET("/expenses/edit/:id", houseHandler.GetEditHouseForm)
 		protectedHouse.PUT("/expenses/:id", houseHandler.EditHouseExpenseById)
 		protectedHouse.GET("/expenses/delete/:id", houseHandler.GetDeleteConfirm)
 		protectedHouse.DELETE("/expenses/:id", houseHandler.DeleteHouseExp)
+		protectedHouse.GET("/tariffs", tariffHandler.GetTariffs)
+		protectedHouse.GET("/tariffs/new", tariffHandler.GetCreateTariffForm)
+		protectedHouse.POST("/tariffs", tariffHandler.CreateTariff)
+		protectedHouse.GET("/tariffs/edit/:id", tariffHandler.GetEditTariffForm)
+		protectedHouse.PUT("/tariffs/:id", tariffHandler.EditTariff)
+		protectedHouse.GET("/tariffs/delete/:id", tariffHandler.GetDeleteTariffConfirm)
+		protectedHouse.DELETE("/tariffs/:id", tariffHandler.DeleteTariff)
+		protectedHouse.GET("/readings/new", tariffHandler.GetCreateMeterReadingForm)
+		protectedHouse.POST("/readings", tariffHandler.CreateMeterReading)
+		protectedHouse.GET("/readings/edit/:id", tariffHandler.GetEditMeterReadingForm)
+		protectedHouse.PUT("/readings/:id", tariffHandler.EditMeterReading)
+		protectedHouse.GET("/readings/delete/:id", tariffHandler.GetDeleteMeterReadingConfirm)
+		protectedHouse.DELETE("/readings/:id", tariffHandler.DeleteMeterReading)
 	}
 
 	carHandler := NewCarHandler(db)
+	tripHandler := NewTripHandler(db)
+	chargingHandler := NewChargingHandler(db)
 	protectedCar := router.Group("/car")
 	{
 		protectedCar.Use(am.AuthMiddleware())
@@ -59,11 +102,217 @@ func RegisterRoutes(router *gin.Engine, db *database.DB, cfg *config.Config) {
 		protectedCar.POST("/search", searchHandler.GetResultsCar)
 		protectedCar.GET("/chart", chartHandler.CarRoot)
 		protectedCar.GET("/chart/search", chartHandler.CarSearch)
+		protectedCar.GET("/chart/svg", chartHandler.CarSVG)
 		protectedCar.GET("/expenses/new", carHandler.GetCreateCarForm)
 		protectedCar.POST("/expenses", carHandler.CreateCarExpense)
 		protectedCar.GET("/expenses/edit/:id", carHandler.GetEditCarForm)
 		protectedCar.PUT("/expenses/:id", carHandler.EditCarExpenseById)
 		protectedCar.GET("/expenses/delete/:id", carHandler.GetDeleteConfirm)
 		protectedCar.DELETE("/expenses/:id", carHandler.DeleteCarExp)
+		protectedCar.GET("/trips", tripHandler.GetTrips)
+		protectedCar.GET("/trips/new", tripHandler.GetCreateTripForm)
+		protectedCar.POST("/trips", tripHandler.CreateTrip)
+		protectedCar.GET("/trips/edit/:id", tripHandler.GetEditTripForm)
+		protectedCar.PUT("/trips/:id", tripHandler.EditTrip)
+		protectedCar.GET("/trips/delete/:id", tripHandler.GetDeleteConfirm)
+		protectedCar.DELETE("/trips/:id", tripHandler.DeleteTrip)
+		protectedCar.GET("/trips/reimbursement", tripHandler.GetReimbursement)
+		protectedCar.PUT("/trips/rate", tripHandler.SetMileageRate)
+		protectedCar.GET("/charging", chargingHandler.GetCharging)
+		protectedCar.GET("/charging/new", chargingHandler.GetCreateChargingForm)
+		protectedCar.POST("/charging", chargingHandler.CreateCharging)
+		protectedCar.GET("/charging/edit/:id", chargingHandler.GetEditChargingForm)
+		protectedCar.PUT("/charging/:id", chargingHandler.EditCharging)
+		protectedCar.GET("/charging/delete/:id", chargingHandler.GetDeleteChargingConfirm)
+		protectedCar.DELETE("/charging/:id", chargingHandler.DeleteCharging)
+	}
+
+	quickAddHandler := NewQuickAddHandler(db)
+	protectedQuickAdd := router.Group("/quick-add")
+	{
+		protectedQuickAdd.Use(am.AuthMiddleware())
+
+		protectedQuickAdd.POST("", quickAddHandler.ParseQuickAdd)
+		protectedQuickAdd.POST("/confirm", quickAddHandler.ConfirmQuickAdd)
+	}
+
+	receiptHandler := NewReceiptHandler(db)
+	protectedReceipts := router.Group("/receipts")
+	{
+		protectedReceipts.Use(am.AuthMiddleware())
+
+		protectedReceipts.GET("/new", receiptHandler.GetReceiptScanForm)
+		protectedReceipts.POST("", receiptHandler.ReadReceipt)
+		protectedReceipts.POST("/confirm", receiptHandler.ImportReceipt)
+	}
+
+	incomeHandler := NewIncomeHandler(db)
+	protectedIncome := router.Group("/income")
+	{
+		protectedIncome.Use(am.AuthMiddleware())
+
+		protectedIncome.GET("", incomeHandler.GetHome)
+		protectedIncome.GET("/current", incomeHandler.GetCurrentMonth)
+		protectedIncome.GET("/search", searchHandler.GetSearch)
+		protectedIncome.POST("/search", searchHandler.GetResultsIncome)
+		protectedIncome.GET("/cash-flow", incomeHandler.GetCashFlow)
+		protectedIncome.GET("/entries/new", incomeHandler.GetCreateIncomeForm)
+		protectedIncome.POST("/entries", incomeHandler.CreateIncome)
+		protectedIncome.GET("/entries/edit/:id", incomeHandler.GetEditIncomeForm)
+		protectedIncome.PUT("/entries/:id", incomeHandler.EditIncomeById)
+		protectedIncome.GET("/entries/delete/:id", incomeHandler.GetDeleteConfirm)
+		protectedIncome.DELETE("/entries/:id", incomeHandler.DeleteIncome)
+	}
+
+	accountHandler := NewAccountHandler(db)
+	protectedAccounts := router.Group("/accounts")
+	{
+		protectedAccounts.Use(am.AuthMiddleware())
+
+		protectedAccounts.GET("", accountHandler.GetAccounts)
+		protectedAccounts.GET("/new", accountHandler.GetCreateAccountForm)
+		protectedAccounts.POST("", accountHandler.CreateAccount)
+		protectedAccounts.GET("/edit/:id", accountHandler.GetEditAccountForm)
+		protectedAccounts.PUT("/:id", accountHandler.EditAccount)
+		protectedAccounts.GET("/delete/:id", accountHandler.GetDeleteConfirm)
+		protectedAccounts.DELETE("/:id", accountHandler.DeleteAccount)
+		protectedAccounts.GET("/:id/statement", accountHandler.GetStatement)
+		protectedAccounts.POST("/:id/reconcile", accountHandler.Reconcile)
+		protectedAccounts.GET("/transfers/new", accountHandler.GetTransferForm)
+		protectedAccounts.POST("/transfers", accountHandler.CreateTransfer)
+	}
+
+	importHandler := NewImportHandler(db)
+	protectedImport := router.Group("/import")
+	{
+		protectedImport.Use(am.AuthMiddleware())
+
+		protectedImport.GET("", importHandler.GetImport)
+		protectedImport.POST("/preview", importHandler.PreviewImport)
+		protectedImport.POST("/commit", importHandler.CommitImport)
+	}
+
+	ruleHandler := NewRuleHandler(db)
+	protectedRules := router.Group("/rules")
+	{
+		protectedRules.Use(am.AuthMiddleware())
+
+		protectedRules.GET("", ruleHandler.GetRules)
+		protectedRules.GET("/new", ruleHandler.GetCreateRuleForm)
+		protectedRules.POST("", ruleHandler.CreateRule)
+		protectedRules.POST("/test", ruleHandler.TestRule)
+		protectedRules.GET("/reapply", ruleHandler.GetReapplyConfirm)
+		protectedRules.POST("/reapply", ruleHandler.ReapplyRules)
+		protectedRules.GET("/edit/:id", ruleHandler.GetEditRuleForm)
+		protectedRules.PUT("/:id", ruleHandler.EditRule)
+		protectedRules.POST("/:id/move/:direction", ruleHandler.MoveRule)
+		protectedRules.GET("/delete/:id", ruleHandler.GetDeleteConfirm)
+		protectedRules.DELETE("/:id", ruleHandler.DeleteRule)
+	}
+
+	vehicleHandler := NewVehicleHandler(db)
+	protectedVehicles := router.Group("/vehicles")
+	{
+		protectedVehicles.Use(am.AuthMiddleware())
+
+		protectedVehicles.GET("", vehicleHandler.GetVehicles)
+		protectedVehicles.GET("/new", vehicleHandler.GetCreateVehicleForm)
+		protectedVehicles.POST("", vehicleHandler.CreateVehicle)
+		protectedVehicles.GET("/edit/:id", vehicleHandler.GetEditVehicleForm)
+		protectedVehicles.PUT("/:id", vehicleHandler.EditVehicle)
+		protectedVehicles.GET("/delete/:id", vehicleHandler.GetDeleteConfirm)
+		protectedVehicles.DELETE("/:id", vehicleHandler.DeleteVehicle)
+		protectedVehicles.GET("/services/new", vehicleHandler.GetCreateServicePlanForm)
+		protectedVehicles.POST("/services", vehicleHandler.CreateServicePlan)
+		protectedVehicles.GET("/services/edit/:id", vehicleHandler.GetEditServicePlanForm)
+		protectedVehicles.PUT("/services/:id", vehicleHandler.EditServicePlan)
+		protectedVehicles.GET("/services/delete/:id", vehicleHandler.GetDeleteServicePlanConfirm)
+		protectedVehicles.DELETE("/services/:id", vehicleHandler.DeleteServicePlan)
+		protectedVehicles.GET("/documents/new", vehicleHandler.GetCreateDocumentForm)
+		protectedVehicles.POST("/documents", vehicleHandler.CreateDocument)
+		protectedVehicles.GET("/documents/calendar", vehicleHandler.GetRenewalCalendar)
+		protectedVehicles.GET("/documents/edit/:id", vehicleHandler.GetEditDocumentForm)
+		protectedVehicles.PUT("/documents/:id", vehicleHandler.EditDocument)
+		protectedVehicles.GET("/documents/delete/:id", vehicleHandler.GetDeleteDocumentConfirm)
+		protectedVehicles.DELETE("/documents/:id", vehicleHandler.DeleteDocument)
+		protectedVehicles.GET("/costs", vehicleHandler.GetOwnershipCost)
+		protectedVehicles.GET("/costs/report", vehicleHandler.GetOwnershipCostReport)
+		protectedVehicles.GET("/costs/export", vehicleHandler.ExportOwnershipCost)
+		protectedVehicles.POST("/:id/valuations", vehicleHandler.SaveValuation)
+		protectedVehicles.DELETE("/valuations/:id", vehicleHandler.DeleteValuation)
+	}
+
+	anomalyHandler := NewAnomalyHandler(db)
+	protectedAnomalies := router.Group("/anomalies")
+	{
+		protectedAnomalies.Use(am.AuthMiddleware())
+
+		protectedAnomalies.POST("/:id/dismiss", anomalyHandler.DismissAnomaly)
+	}
+
+	statementHandler := NewStatementHandler(db)
+	protectedStatements := router.Group("/statements")
+	{
+		protectedStatements.Use(am.AuthMiddleware())
+
+		protectedStatements.GET("/pdf", statementHandler.GetStatementPDF)
+	}
+
+	notificationHandler := NewNotificationHandler(db, mailer, cfg.Telegram)
+	protectedNotifications := router.Group("/notifications")
+	{
+		protectedNotifications.Use(am.AuthMiddleware())
+
+		protectedNotifications.GET("", notificationHandler.GetNotifications)
+		protectedNotifications.PUT("/settings", notificationHandler.SaveSettings)
+		protectedNotifications.POST("/settings/test", notificationHandler.SendTestEmail)
+		protectedNotifications.GET("/channels/new", notificationHandler.GetCreateChannelForm)
+		protectedNotifications.POST("/channels", notificationHandler.CreateChannel)
+		protectedNotifications.POST("/channels/test", notificationHandler.SendTestPush)
+		protectedNotifications.GET("/channels/edit/:id", notificationHandler.GetEditChannelForm)
+		protectedNotifications.PUT("/channels/:id", notificationHandler.EditChannel)
+		protectedNotifications.GET("/channels/delete/:id", notificationHandler.GetDeleteChannelConfirm)
+		protectedNotifications.DELETE("/channels/:id", notificationHandler.DeleteChannel)
+		protectedNotifications.POST("/telegram", notificationHandler.LinkTelegram)
+		protectedNotifications.DELETE("/telegram", notificationHandler.UnlinkTelegram)
+	}
+
+	settingsHandler := NewSettingsHandler(db, as, mailer, passkeys, provider)
+	protectedSettings := router.Group("/settings")
+	{
+		protectedSettings.Use(am.AuthMiddleware())
+
+		protectedSettings.GET("", settingsHandler.GetSettings)
+		protectedSettings.PUT("/username", settingsHandler.ChangeUsername)
+		protectedSettings.PUT("/password", settingsHandler.ChangePassword)
+		protectedSettings.PUT("/email", settingsHandler.ChangeEmail)
+		protectedSettings.POST("/email/verify", settingsHandler.ResendVerification)
+		protectedSettings.POST("/two-factor", settingsHandler.StartTwoFactor)
+		protectedSettings.POST("/two-factor/enable", settingsHandler.EnableTwoFactor)
+		protectedSettings.DELETE("/two-factor", settingsHandler.DisableTwoFactor)
+		protectedSettings.POST("/two-factor/recovery-codes", settingsHandler.RegenerateRecoveryCodes)
+		protectedSettings.POST("/passkeys/begin", settingsHandler.BeginPasskey)
+		protectedSettings.POST("/passkeys", settingsHandler.AddPasskey)
+		protectedSettings.PUT("/passkeys/:id", settingsHandler.RenamePasskey)
+		protectedSettings.DELETE("/passkeys/:id", settingsHandler.DeletePasskey)
+		protectedSettings.GET("/sessions", settingsHandler.GetSessions)
+		protectedSettings.DELETE("/sessions", settingsHandler.SignOutEverywhere)
+		protectedSettings.DELETE("/sessions/:id", settingsHandler.RevokeSession)
+	}
+
+	webhookHandler := NewWebhookHandler(db)
+	protectedWebhooks := router.Group("/webhooks")
+	{
+		protectedWebhooks.Use(am.AuthMiddleware())
+
+		protectedWebhooks.GET("", webhookHandler.GetWebhooks)
+		protectedWebhooks.GET("/endpoints/new", webhookHandler.GetCreateEndpointForm)
+		protectedWebhooks.POST("/endpoints", webhookHandler.CreateEndpoint)
+		protectedWebhooks.GET("/endpoints/edit/:id", webhookHandler.GetEditEndpointForm)
+		protectedWebhooks.PUT("/endpoints/:id", webhookHandler.EditEndpoint)
+		protectedWebhooks.GET("/endpoints/delete/:id", webhookHandler.GetDeleteEndpointConfirm)
+		protectedWebhooks.DELETE("/endpoints/:id", webhookHandler.DeleteEndpoint)
+		protectedWebhooks.GET("/deliveries", webhookHandler.GetDeliveries)
+		protectedWebhooks.POST("/deliveries/:id/redeliver", webhookHandler.Redeliver)
 	}
 }
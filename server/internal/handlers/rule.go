@@ -0,0 +1,620 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RuleHandler struct {
+	DB *database.DB
+}
+
+func NewRuleHandler(db *database.DB) *RuleHandler {
+	return &RuleHandler{
+		DB: db,
+	}
+}
+
+// ExpenseTypes holds the house and car expense types offered as rule and import targets.
+type ExpenseTypes struct {
+	HouseTypes *[]models.HomeUtilityType
+	CarTypes   *[]models.CarExpenseType
+}
+
+func getExpenseTypes(db *database.DB) (*ExpenseTypes, error) {
+	houseTypes, err := db.GetHouseUtilityTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	carTypes, err := db.GetCarExpenseTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExpenseTypes{
+		HouseTypes: houseTypes,
+		CarTypes:   carTypes,
+	}, nil
+}
+
+// parseTarget reads a "tracker:typeID" value and checks the type exists in that tracker.
+func (t *ExpenseTypes) parseTarget(value string) (models.Tracker, int, error) {
+	trackerStr, idStr, ok := strings.Cut(value, ":")
+	tracker := models.Tracker(trackerStr)
+	if !ok || !tracker.Valid() {
+		return "", 0, fmt.Errorf("invalid tracker")
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid expense type")
+	}
+
+	if tracker == models.TrackerHouse {
+		for _, typ := range *t.HouseTypes {
+			if typ.ID == id {
+				return tracker, id, nil
+			}
+		}
+	} else {
+		for _, typ := range *t.CarTypes {
+			if typ.ID == id {
+				return tracker, id, nil
+			}
+		}
+	}
+
+	return "", 0, fmt.Errorf("invalid expense type")
+}
+
+// typeName returns the name of an expense type of a tracker.
+func (t *ExpenseTypes) typeName(tracker models.Tracker, id int) string {
+	if tracker == models.TrackerHouse {
+		for _, typ := range *t.HouseTypes {
+			if typ.ID == id {
+				return typ.Name
+			}
+		}
+	} else {
+		for _, typ := range *t.CarTypes {
+			if typ.ID == id {
+				return typ.Name
+			}
+		}
+	}
+	return ""
+}
+
+// RulesPageData is the data for the rules page.
+type RulesPageData struct {
+	Rules *[]models.ExpenseRule
+}
+
+func (h *RuleHandler) GetRules(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	rules, err := h.DB.GetExpenseRules(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rules.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	pageData := &RulesPageData{
+		Rules: rules,
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Rules, pageData)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Rules,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// RuleFormData is the data for the create and edit rule forms.
+type RuleFormData struct {
+	Rule     *models.ExpenseRule
+	Types    *ExpenseTypes
+	Accounts *models.AccountSelect
+	Vehicles *models.VehicleSelect
+	Weekdays []time.Weekday
+}
+
+func (h *RuleHandler) ruleFormData(userID uuid.UUID, rule *models.ExpenseRule) (*RuleFormData, error) {
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	var selectedAccount, selectedVehicle *int
+	if rule != nil {
+		selectedAccount = rule.AccountID
+		selectedVehicle = rule.VehicleID
+	}
+
+	accounts, err := accountSelect(h.DB, userID, selectedAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, selectedVehicle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RuleFormData{
+		Rule:     rule,
+		Types:    types,
+		Accounts: accounts,
+		Vehicles: vehicles,
+		Weekdays: models.Weekdays,
+	}, nil
+}
+
+func (h *RuleHandler) GetCreateRuleForm(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	formData, err := h.ruleFormData(userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.RuleForm, formData)
+}
+
+// parseOptionalAmount reads an optional amount form value, empty means no limit.
+func parseOptionalAmount(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return nil, fmt.Errorf("invalid amount")
+	}
+
+	return &amount, nil
+}
+
+// parseRuleForm reads and validates the rule conditions and actions from the form.
+func (h *RuleHandler) parseRuleForm(c *gin.Context, userID uuid.UUID) (*models.ExpenseRule, error) {
+	rule := &models.ExpenseRule{
+		MatchText:  strings.TrimSpace(c.Request.PostFormValue("matchText")),
+		MatchRegex: strings.TrimSpace(c.Request.PostFormValue("matchRegex")),
+		Tags:       strings.Join(models.SplitTags(c.Request.PostFormValue("tags")), ","),
+		CreatedBy:  userID,
+	}
+
+	if len(rule.MatchText) > 255 || len(rule.MatchRegex) > 255 || len(rule.Tags) > 255 {
+		return nil, fmt.Errorf("text fields are limited to 255 characters")
+	}
+
+	var err error
+	if rule.AmountMin, err = parseOptionalAmount(c.Request.PostFormValue("amountMin")); err != nil {
+		return nil, err
+	}
+	if rule.AmountMax, err = parseOptionalAmount(c.Request.PostFormValue("amountMax")); err != nil {
+		return nil, err
+	}
+
+	if value := c.Request.PostFormValue("weekday"); value != "" {
+		day, err := strconv.Atoi(value)
+		if err != nil || day < 0 || day > 6 {
+			return nil, fmt.Errorf("invalid weekday")
+		}
+		weekday := time.Weekday(day)
+		rule.Weekday = &weekday
+	}
+
+	if rule.AccountID, err = parseAccountID(c, h.DB, userID); err != nil {
+		return nil, err
+	}
+
+	types, err := getExpenseTypes(h.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule.Tracker, rule.ExpenseTypeID, err = types.parseTarget(c.Request.PostFormValue("target")); err != nil {
+		return nil, err
+	}
+	rule.ExpenseType = types.typeName(rule.Tracker, rule.ExpenseTypeID)
+
+	if rule.VehicleID, err = parseVehicleID(c, h.DB, userID); err != nil {
+		return nil, err
+	}
+	if rule.VehicleID != nil {
+		vehicle, err := h.DB.GetVehicleByID(*rule.VehicleID)
+		if err != nil {
+			return nil, err
+		}
+		rule.Vehicle = vehicle.Name
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// CreateRule handles the HTTP POST request to add a rule at the end of the user's rules.
+func (h *RuleHandler) CreateRule(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	rule, err := h.parseRuleForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateExpenseRule(rule); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't create rule.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	created, err := h.DB.GetExpenseRuleByID(rule.ID)
+	if err != nil || created == nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rule.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusCreated, utilities.Templates.Responses.SaveRule, gin.H{
+		"Rule": created,
+		"Modal": &models.ModalContent{
+			Title:   "Successful rule creation.",
+			Message: fmt.Sprintf("Matching expenses are booked as %s %s.", created.Tracker.Label(), created.ExpenseType),
+		},
+	})
+}
+
+func (h *RuleHandler) GetEditRuleForm(c *gin.Context) {
+	rule := h.ownRule(c)
+	if rule == nil {
+		return
+	}
+
+	formData, err := h.ruleFormData(rule.CreatedBy, rule)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.RuleForm, formData)
+}
+
+func (h *RuleHandler) EditRule(c *gin.Context) {
+	existing := h.ownRule(c)
+	if existing == nil {
+		return
+	}
+
+	rule, err := h.parseRuleForm(c, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	rule.ID = existing.ID
+
+	if err := h.DB.EditExpenseRule(rule); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't update rule.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	updated, err := h.DB.GetExpenseRuleByID(rule.ID)
+	if err != nil || updated == nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rule.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.SaveRule, gin.H{
+		"Rule": updated,
+		"Modal": &models.ModalContent{
+			Title:   "Successful rule update.",
+			Message: fmt.Sprintf("Matching expenses are booked as %s %s.", updated.Tracker.Label(), updated.ExpenseType),
+		},
+	})
+}
+
+// MoveRule moves a rule one place up or down and returns the reordered rules.
+func (h *RuleHandler) MoveRule(c *gin.Context) {
+	rule := h.ownRule(c)
+	if rule == nil {
+		return
+	}
+
+	direction := c.Param("direction")
+	if direction != "up" && direction != "down" {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on direction.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if _, err := h.DB.MoveExpenseRule(rule, direction == "up"); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't move rule.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	rules, err := h.DB.GetExpenseRules(rule.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rules.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.RuleRows, rules)
+}
+
+// TestRule previews the rule in the form against the user's existing expenses
+// without saving anything.
+func (h *RuleHandler) TestRule(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	rule, err := h.parseRuleForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	expenses, err := h.DB.GetCategorisedExpenses(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching expenses.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	result := &models.RuleTestResult{}
+	for i := range expenses {
+		if rule.Matches(expenses[i].Subject()) {
+			result.Matched++
+		}
+	}
+	result.Changes = models.PlanRuleChanges([]models.ExpenseRule{*rule}, expenses)
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.RuleTestResult, result)
+}
+
+func (h *RuleHandler) GetReapplyConfirm(c *gin.Context) {
+	content := &models.ModalConfirmContent{
+		Title:    "Re-apply all rules to existing expenses?",
+		Method:   "POST",
+		Endpoint: template.URL("/rules/reapply"),
+		Target:   "#rules-reapply-result",
+		Message:  "Every house and car expense matching a rule gets that rule's tracker, type, tags and vehicle. Expenses linked to a charging session, trip, document or odometer reading stay in their tracker. This can't be undone.",
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+// ReapplyRules runs the user's rules over every existing expense and saves the changes.
+func (h *RuleHandler) ReapplyRules(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	rules, err := h.DB.GetExpenseRules(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching rules.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	expenses, err := h.DB.GetCategorisedExpenses(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching expenses.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	changes := models.PlanRuleChanges(*rules, expenses)
+	if err := h.DB.ApplyRuleChanges(userID, changes); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't apply rules, nothing was changed.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	for i := range changes {
+		change := &changes[i]
+		if change.Blocked != "" {
+			continue
+		}
+
+		if change.MovedTo == 0 {
+			expenseEvent(h.DB, userID, models.WebhookExpenseUpdated, &models.CategorisedExpense{
+				ID:      change.Expense.ID,
+				Tracker: change.Tracker,
+				TypeID:  change.TypeID,
+				Type:    change.Type,
+				Amount:  change.Expense.Amount,
+				Date:    change.Expense.Date,
+				Notes:   change.Expense.Notes,
+			})
+			continue
+		}
+
+		// A moved expense gets a new id in the other tracker.
+		expenseEvent(h.DB, userID, models.WebhookExpenseDeleted, &change.Expense)
+		expenseEvent(h.DB, userID, models.WebhookExpenseCreated, &models.CategorisedExpense{
+			ID:      change.MovedTo,
+			Tracker: change.Tracker,
+			TypeID:  change.TypeID,
+			Type:    change.Type,
+			Amount:  change.Expense.Amount,
+			Date:    change.Expense.Date,
+			Notes:   change.Expense.Notes,
+		})
+	}
+
+	updated := models.CountApplicable(changes)
+	message := fmt.Sprintf("%d of %d expenses were updated.", updated, len(expenses))
+	if blocked := len(changes) - updated; blocked > 0 {
+		message += fmt.Sprintf(" %d weren't moved to the other tracker, they are linked to a charging session, trip, document or reading.", blocked)
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.ReapplyRules, gin.H{
+		"Updated": updated,
+		"Total":   len(expenses),
+		"Modal": &models.ModalContent{
+			Title:   "Rules applied.",
+			Message: message,
+		},
+	})
+}
+
+// ownRule loads the rule from the id path parameter and makes sure
+// it belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *RuleHandler) ownRule(c *gin.Context) *models.ExpenseRule {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	rule, err := h.DB.GetExpenseRuleByID(id)
+	if err != nil || rule == nil || rule.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Rule not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return rule
+}
+
+func (h *RuleHandler) GetDeleteConfirm(c *gin.Context) {
+	rule := h.ownRule(c)
+	if rule == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/rules/%v", rule.ID)),
+		Target:   fmt.Sprintf("#rule-%v", rule.ID),
+		Message:  fmt.Sprintf("Rule %d (%s) will no longer be applied. Expenses it categorised are kept.", rule.Position, rule.Conditions()),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+	rule := h.ownRule(c)
+	if rule == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteExpenseRule(rule.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete rule.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully deleted rule!",
+		Message: fmt.Sprintf("Rule %d deleted!", rule.Position),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
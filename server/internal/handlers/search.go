@@ -26,10 +26,12 @@ func NewSearchHandler(db *database.DB) *SearchHandler {
 func (h *SearchHandler) GetSearch(c *gin.Context) {
 	path := c.Request.URL.Path
 	isCar := strings.Contains(path, "car")
+	isIncome := strings.Contains(path, "income")
 
 	c.HTML(http.StatusOK, utilities.Templates.Components.Search, gin.H{
 		"CurrentMonth": time.Now().Format("2006-01"),
 		"IsCar":        isCar,
+		"IsIncome":     isIncome,
 	})
 }
 
@@ -96,3 +98,44 @@ func (h *SearchHandler) GetResultsCar(c *gin.Context) {
 	}
 	c.HTML(http.StatusOK, utilities.Templates.Components.SearchResultsCar, results)
 }
+
+func (h *SearchHandler) GetResultsIncome(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	date, err := time.Parse(utilities.DateFormats.MonthOnly, c.Request.PostFormValue("date"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request on date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	incomes, err := h.DB.GetIncomesForMonth(date.Month(), date.Year(), userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching incomes.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	total, err := h.DB.GetTotalIncomeForMonth(date, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching total income.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	results := gin.H{
+		"Incomes": incomes,
+		"Total":   total,
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.SearchResultsIncome, results)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetSessions renders the page of the user's active sessions.
+func (h *SettingsHandler) GetSessions(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	sessions, err := h.DB.GetActiveSessions(userID, time.Now())
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching sessions.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data := &models.SessionsPage{
+		Sessions:  sessions,
+		CurrentID: currentSession(c),
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Sessions, data)
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Sessions,
+			TemplateContent: data,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// RevokeSession handles the HTTP DELETE request to log out a session of the
+// user. Revoking the current session logs this device out.
+func (h *SettingsHandler) RevokeSession(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	revoked, err := h.DB.RevokeSession(userID, id, time.Now())
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't log out session.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !revoked {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Session not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if id == currentSession(c) {
+		h.AuthService.ClearCookie(c)
+		redirect(c, "/login")
+		return
+	}
+
+	h.sessionsSaved(c, userID, &models.ModalContent{
+		Title:   "Successfully logged out session!",
+		Message: "The device has to log in again.",
+	})
+}
+
+// SignOutEverywhere handles the HTTP DELETE request to log out every session
+// of the user, this one included.
+func (h *SettingsHandler) SignOutEverywhere(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	if err := h.DB.RevokeSessions(userID, uuid.Nil, time.Now()); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't log out sessions.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.AuthService.ClearCookie(c)
+	redirect(c, "/login")
+}
+
+func (h *SettingsHandler) sessionsSaved(c *gin.Context, userID uuid.UUID, modal *models.ModalContent) {
+	sessions, err := h.DB.GetActiveSessions(userID, time.Now())
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching sessions.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.SaveSessions, gin.H{
+		"Sessions": &models.SessionsPage{Sessions: sessions, CurrentID: currentSession(c)},
+		"Modal":    modal,
+	})
+}
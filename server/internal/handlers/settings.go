@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/notify"
+	"expenser/internal/passkey"
+	"expenser/internal/services"
+	"expenser/internal/sso"
+	"expenser/internal/utilities"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SettingsHandler handles the account settings of the logged in user.
+type SettingsHandler struct {
+	DB          *database.DB
+	AuthService *services.AuthService
+	Mailer      *notify.Mailer
+	Passkeys    *passkey.Service
+	SSO         *sso.Provider
+}
+
+func NewSettingsHandler(db *database.DB, authService *services.AuthService, mailer *notify.Mailer, passkeys *passkey.Service, provider *sso.Provider) *SettingsHandler {
+	return &SettingsHandler{
+		DB:          db,
+		AuthService: authService,
+		Mailer:      mailer,
+		Passkeys:    passkeys,
+		SSO:         provider,
+	}
+}
+
+// GetSettings renders the account settings page.
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	twoFactor, err := h.twoFactorData(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	passkeys, err := h.DB.GetPasskeys(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data := &models.SettingsData{
+		User:             user,
+		EmailEnabled:     h.Mailer.Enabled(),
+		PasswordsEnabled: !h.SSO.PasswordsDisabled(),
+		TwoFactor:        twoFactor,
+		Passkeys:         passkeys,
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Settings, data)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Settings,
+			TemplateContent: data,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// renewSession issues the current session a new token, for it to carry the
+// changed username or to outlive a password change.
+func (h *SettingsHandler) renewSession(c *gin.Context, user *models.User) error {
+	sessionID := currentSession(c)
+	token, err := h.AuthService.GenerateToken(user, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := h.DB.RenewSession(sessionID, token.ExpiresAt); err != nil {
+		return err
+	}
+
+	h.AuthService.SetCookie(token, c)
+	return nil
+}
+
+// currentSession returns the session of the request, set by the auth middleware.
+func currentSession(c *gin.Context) uuid.UUID {
+	sessionIDstr, _ := c.Get("session_id")
+	sessionID, _ := sessionIDstr.(uuid.UUID)
+	return sessionID
+}
+
+// ChangeUsername handles the HTTP PUT request to rename the logged in user.
+func (h *SettingsHandler) ChangeUsername(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	username := strings.TrimSpace(c.Request.PostFormValue("username"))
+	if len(username) < 3 || len(username) > 50 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, a username is 3 to 50 characters.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if username != user.Username {
+		exists, err := h.DB.UserExists(username)
+		if err != nil || exists {
+			content := &models.ModalContent{
+				Title:   "Username already exists!",
+				Message: "400: Invalid request data.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+	}
+
+	user.Username = username
+	if err := h.DB.UpdateUser(user); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't change username.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.renewSession(c, user); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Failed to get authentication token.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully changed username!",
+		Message: fmt.Sprintf("You log in as %s from now on.", user.Username),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
+
+// ChangePassword handles the HTTP PUT request to change the logged in user's
+// password. All other sessions of the user are logged out, this one carries on.
+func (h *SettingsHandler) ChangePassword(c *gin.Context) {
+	if h.SSO.PasswordsDisabled() {
+		content := &models.ModalContent{
+			Title:   "Passwords are turned off!",
+			Message: "403: You log in with " + h.SSO.Config.ProviderName + " or a passkey instead.",
+		}
+		c.HTML(http.StatusForbidden, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	var change models.PasswordChange
+	if err := c.ShouldBind(&change); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: The new password needs at least 6 characters and must match its confirmation.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(change.CurrentPassword)); err != nil {
+		content := &models.ModalContent{
+			Title:   "Invalid credentials!",
+			Message: "401: The current password is wrong.",
+		}
+		c.HTML(http.StatusUnauthorized, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(change.Password), bcrypt.DefaultCost)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Failed to process password!",
+			Message: "500: Internal server error.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	now := time.Now()
+	if err := h.DB.SetPassword(user.ID, string(hashedPassword), currentSession(c), now); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't change password.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.renewSession(c, user); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Failed to get authentication token.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully changed password!",
+		Message: "Your password is changed and your other sessions are logged out.",
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
+
+// ChangeEmail handles the HTTP PUT request to change the logged in user's email
+// address, an empty one removes it, which takes the current password or, without
+// one, a code. A new address is emailed a verification link.
+func (h *SettingsHandler) ChangeEmail(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	email := strings.TrimSpace(c.Request.PostFormValue("email"))
+	if email != "" {
+		address, err := mail.ParseAddress(email)
+		if err != nil || len(address.Address) > 254 {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "400: Bad Request, invalid email address.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+		email = address.Address
+	}
+
+	if !h.reauthenticate(c, userID) {
+		return
+	}
+
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if email == user.Email {
+		h.emailSaved(c, user, &models.ModalContent{
+			Title:   "Nothing to change!",
+			Message: "That is already the email address of your account.",
+		})
+		return
+	}
+
+	if err := h.DB.SetEmail(user.ID, email); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't change email address.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	user.Email = email
+	user.EmailVerifiedAt = nil
+
+	// The session no longer carries the old verified address.
+	if err := h.renewSession(c, user); err != nil {
+		log.Printf("session renewal of %s: %v", user.Username, err)
+	}
+
+	if email == "" {
+		h.emailSaved(c, user, &models.ModalContent{
+			Title:   "Successfully removed email address!",
+			Message: "Your account has no email address anymore.",
+		})
+		return
+	}
+
+	h.sendVerification(c, user)
+}
+
+// ResendVerification handles the HTTP POST request to email the logged in user
+// a new link verifying their address.
+func (h *SettingsHandler) ResendVerification(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if user.Email == "" || user.VerifiedEmail() != "" {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, there is no address to verify.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.sendVerification(c, user)
+}
+
+// sendVerification emails the user a link verifying their address and replies
+// whether it was sent.
+func (h *SettingsHandler) sendVerification(c *gin.Context, user *models.User) {
+	if !h.Mailer.Enabled() {
+		h.emailSaved(c, user, &models.ModalContent{
+			Title:   "Saved email address!",
+			Message: user.Email + " is the email address of your account.",
+			Warning: "No mail server is configured, so it can't be verified until one is.",
+		})
+		return
+	}
+
+	if err := sendEmailVerification(h.AuthService, h.Mailer, user); err != nil {
+		log.Printf("email verification of %s: %v", user.Username, err)
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "502: Couldn't send the verification email, try again later.",
+		}
+		c.HTML(http.StatusBadGateway, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.emailSaved(c, user, &models.ModalContent{
+		Title:   "Check your email!",
+		Message: "A link to verify " + user.Email + " was sent to it. The link works for a day.",
+	})
+}
+
+// emailSaved replies with the email settings of the user and the modal.
+func (h *SettingsHandler) emailSaved(c *gin.Context, user *models.User, modal *models.ModalContent) {
+	// Without it the form only leaves out the code field.
+	twoFactor, err := h.twoFactorData(user.ID)
+	if err != nil {
+		log.Printf("two-factor settings of %s: %v", user.Username, err)
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.SaveEmail, gin.H{
+		"Settings": &models.SettingsData{
+			User:         user,
+			EmailEnabled: h.Mailer.Enabled(),
+			TwoFactor:    twoFactor,
+		},
+		"Modal": modal,
+	})
+}
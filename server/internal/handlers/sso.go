@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"expenser/internal/models"
+	"expenser/internal/sso"
+	"expenser/internal/utilities"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BeginSSO sends the browser to the OpenID Connect provider to log in.
+func (h *AuthHandler) BeginSSO(c *gin.Context) {
+	if h.SSO == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	url, state, err := h.SSO.Begin(c.Request.Context())
+	if errors.Is(err, sso.ErrBusy) {
+		h.ssoFailed(c, http.StatusServiceUnavailable, "Too many logins are waiting for "+h.SSO.Config.ProviderName+", try again in a few minutes.")
+		return
+	}
+	if err != nil {
+		log.Printf("single sign-on: %v", err)
+		h.ssoFailed(c, http.StatusBadGateway, h.SSO.Config.ProviderName+" can't be reached right now, try again later.")
+		return
+	}
+
+	h.AuthService.SetSSOCookie(state, sso.LoginTTL, c)
+	redirect(c, url)
+}
+
+// SSOCallback handles the provider redirecting back after the user logged in
+// there. The user is the one linked to their identity at the provider, else the
+// one with their verified email, else a new one when sign-ups are allowed.
+func (h *AuthHandler) SSOCallback(c *gin.Context) {
+	if h.SSO == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	state, _ := c.Cookie("sso_state")
+	h.AuthService.SetSSOCookie("", 0, c)
+
+	if reason := c.Query("error"); reason != "" {
+		log.Printf("single sign-on refused: %s %s", reason, c.Query("error_description"))
+		h.ssoFailed(c, http.StatusUnauthorized, h.SSO.Config.ProviderName+" didn't log you in.")
+		return
+	}
+
+	identity, err := h.SSO.Finish(c.Request.Context(), state, c.Query("state"), c.Query("code"))
+	if err != nil {
+		log.Printf("single sign-on: %v", err)
+		h.ssoFailed(c, http.StatusUnauthorized, "The login with "+h.SSO.Config.ProviderName+" failed or expired, try again.")
+		return
+	}
+
+	user, err := h.ssoUser(identity)
+	if err != nil {
+		log.Printf("single sign-on of %s at %s: %v", identity.Subject, identity.Issuer, err)
+		h.ssoFailed(c, http.StatusInternalServerError, "Couldn't get your account, try again.")
+		return
+	}
+
+	if user == nil {
+		h.ssoFailed(c, http.StatusForbidden, "There is no account for you yet, ask the administrator to create one.")
+		return
+	}
+
+	h.logIn(c, user)
+}
+
+// ssoUser returns the user the identity logs in as, linking or creating it on
+// its first login. It is nil when there is none and sign-ups are off.
+func (h *AuthHandler) ssoUser(identity *sso.Identity) (*models.User, error) {
+	user, err := h.DB.GetUserByOIDCIdentity(identity.Issuer, identity.Subject)
+	if err != nil || user != nil {
+		return user, err
+	}
+
+	// Both sides have to have verified the address, else anyone could take over
+	// an account by giving its address to the provider.
+	if identity.EmailVerified {
+		user, err := h.DB.GetUserByEmail(identity.Email)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			if err := h.DB.LinkOIDCIdentity(user, identity.Issuer, identity.Subject); err != nil {
+				return nil, err
+			}
+			return user, nil
+		}
+	}
+
+	if !h.SSO.Config.AllowSignup {
+		return nil, nil
+	}
+
+	username, err := h.freeUsername(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user has no password, bcrypt never matches an empty hash.
+	user = &models.User{Username: username}
+	if len(identity.Email) <= 254 {
+		user.Email = identity.Email
+	}
+
+	if err := h.DB.CreateOIDCUser(user, identity.Issuer, identity.Subject, identity.EmailVerified, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// freeUsername returns a username for a new user of the provider, their
+// username there, or a numbered one when it's taken.
+func (h *AuthHandler) freeUsername(identity *sso.Identity) (string, error) {
+	base := identity.Username
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	base = strings.TrimSpace(base)
+	if len([]rune(base)) > 45 {
+		base = string([]rune(base)[:45])
+	}
+	if len([]rune(base)) < 3 {
+		base = "user"
+	}
+
+	username := base
+	for i := 2; ; i++ {
+		exists, err := h.DB.UserExists(username)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return username, nil
+		}
+		if i > 1000 {
+			return "", fmt.Errorf("no free username for %s", base)
+		}
+		username = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// ssoFailed renders the login page with why single sign-on failed.
+func (h *AuthHandler) ssoFailed(c *gin.Context, status int, message string) {
+	rl := &models.RootLayout{
+		TemplateName:    utilities.Templates.Pages.Login,
+		TemplateContent: h.loginPage(message),
+		HeaderOpts:      &models.HeaderOptions{},
+	}
+	c.HTML(status, utilities.Templates.Root, rl)
+}
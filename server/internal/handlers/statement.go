@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/statement"
+	"expenser/internal/utilities"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StatementHandler struct {
+	DB *database.DB
+}
+
+func NewStatementHandler(db *database.DB) *StatementHandler {
+	return &StatementHandler{
+		DB: db,
+	}
+}
+
+// statementTrackers returns the trackers a statement covers: "house", "car" or
+// "combined" for both.
+func statementTrackers(tracker string) ([]models.Tracker, bool) {
+	if tracker == "combined" {
+		return []models.Tracker{models.TrackerHouse, models.TrackerCar}, true
+	}
+
+	t := models.Tracker(tracker)
+	if !t.Valid() {
+		return nil, false
+	}
+	return []models.Tracker{t}, true
+}
+
+// GetStatementPDF downloads the statement of the month or year picked on the
+// search page as PDF, for one tracker or both combined.
+func (h *StatementHandler) GetStatementPDF(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	date, err := time.Parse(utilities.DateFormats.MonthOnly, c.Query("date"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "400: Bad Request on date.")
+		return
+	}
+
+	period := models.StatementPeriod(c.DefaultQuery("period", string(models.StatementMonth)))
+	trackers, ok := statementTrackers(c.Query("tracker"))
+	if !period.Valid() || !ok {
+		c.String(http.StatusBadRequest, "400: Bad Request.")
+		return
+	}
+
+	lines, err := statement.Lines(h.DB, userID, period, date, trackers)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "500: Error fetching expenses.")
+		return
+	}
+
+	s := statement.Build(period, date, trackers, lines)
+
+	var buf bytes.Buffer
+	if err := statement.WritePDF(&buf, s); err != nil {
+		c.String(http.StatusInternalServerError, "500: Error generating statement.")
+		return
+	}
+
+	name := s.From.Format(utilities.DateFormats.MonthOnly)
+	if period == models.StatementYear {
+		name = s.From.Format("2006")
+	}
+	filename := fmt.Sprintf("statement-%s-%s.pdf", c.Query("tracker"), name)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
@@ -0,0 +1,592 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/tariff"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// billCheckDays is how far back the house dashboard shows bills deviating from their estimate.
+const billCheckDays = 90
+
+type TariffHandler struct {
+	DB *database.DB
+}
+
+func NewTariffHandler(db *database.DB) *TariffHandler {
+	return &TariffHandler{
+		DB: db,
+	}
+}
+
+// TariffsData is the data for the tariffs section of the house page: the tariff
+// schedules, the meter readings and the bills estimated from them.
+type TariffsData struct {
+	Tariffs   []models.UtilityTariff
+	Readings  []models.MeterReading
+	Estimates []models.BillEstimate
+}
+
+// DeviationPercent returns how far a bill may be off its estimate before it is flagged.
+func (d *TariffsData) DeviationPercent() int {
+	return models.BillDeviationPercent
+}
+
+// TariffFormData is the data for the create and edit tariff forms.
+type TariffFormData struct {
+	Tariff *models.UtilityTariff // Tariff is nil for a new one.
+	Types  []models.HomeUtilityType
+}
+
+// MeterReadingFormData is the data for the create and edit meter reading forms.
+type MeterReadingFormData struct {
+	Reading *models.MeterReading // Reading is nil for a new one.
+	Types   []models.HomeUtilityType
+}
+
+// billEstimates estimates the bills of all the user's meter readings and matches them
+// to the bills that arrived.
+func billEstimates(db *database.DB, userID uuid.UUID) ([]models.UtilityTariff, []models.MeterReading, []models.BillEstimate, error) {
+	tariffs, err := db.GetUtilityTariffs(userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	readings, err := db.GetMeterReadings(userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var since time.Time
+	if len(readings) > 0 {
+		since = readings[len(readings)-1].Date
+	}
+
+	bills, err := db.GetMeteredBills(userID, since)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return tariffs, readings, tariff.Estimates(tariffs, readings, bills), nil
+}
+
+// billCheck returns the user's recent bills that deviate significantly from their estimate.
+func billCheck(db *database.DB, userID uuid.UUID) ([]models.BillEstimate, error) {
+	_, _, estimates, err := billEstimates(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return tariff.Flagged(estimates, time.Now().AddDate(0, 0, -billCheckDays)), nil
+}
+
+func (h *TariffHandler) tariffs(userID uuid.UUID) (*TariffsData, error) {
+	tariffs, readings, estimates, err := billEstimates(h.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TariffsData{
+		Tariffs:   tariffs,
+		Readings:  readings,
+		Estimates: estimates,
+	}, nil
+}
+
+// meteredUtilityTypes returns the utility types billed by tariff.
+func (h *TariffHandler) meteredUtilityTypes() ([]models.HomeUtilityType, error) {
+	types, err := h.DB.GetHouseUtilityTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var metered []models.HomeUtilityType
+	for _, t := range *types {
+		if models.IsMeteredUtility(t.Name) {
+			metered = append(metered, t)
+		}
+	}
+	return metered, nil
+}
+
+// parseMeteredUtilityType reads the utility type of the tariff and reading forms.
+func (h *TariffHandler) parseMeteredUtilityType(c *gin.Context) (int, error) {
+	typeID, err := strconv.Atoi(c.Request.PostFormValue("typeID"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid utility")
+	}
+
+	types, err := h.meteredUtilityTypes()
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range types {
+		if t.ID == typeID {
+			return typeID, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid utility")
+}
+
+// GetTariffs renders the tariffs section of the house page with the estimated bills.
+func (h *TariffHandler) GetTariffs(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	data, err := h.tariffs(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching tariffs.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.Tariffs, data)
+}
+
+// tariffsSaved responds with the refreshed tariffs, readings and estimates.
+func (h *TariffHandler) tariffsSaved(c *gin.Context, status int, userID uuid.UUID, modal *models.ModalContent) {
+	data, err := h.tariffs(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching tariffs.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(status, utilities.Templates.Responses.SaveTariff, gin.H{
+		"Tariffs": data,
+		"Modal":   modal,
+	})
+}
+
+// INFO: TARIFFS
+
+func (h *TariffHandler) tariffForm(c *gin.Context, t *models.UtilityTariff) {
+	types, err := h.meteredUtilityTypes()
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.TariffForm, &TariffFormData{
+		Tariff: t,
+		Types:  types,
+	})
+}
+
+func (h *TariffHandler) GetCreateTariffForm(c *gin.Context) {
+	h.tariffForm(c, nil)
+}
+
+// parseTariffForm reads a tariff. Prices are entered without VAT, the night price
+// is left empty for single zone tariffs.
+func (h *TariffHandler) parseTariffForm(c *gin.Context, userID uuid.UUID) (*models.UtilityTariff, error) {
+	typeID, err := h.parseMeteredUtilityType(c)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(c.Request.PostFormValue("name"))
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("invalid name")
+	}
+
+	effectiveFrom, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("effectiveFrom"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid effective date")
+	}
+
+	fixedFee, err := parseOptionalFloat(c.Request.PostFormValue("fixedFee"))
+	if err != nil {
+		return nil, err
+	}
+	if fixedFee == nil {
+		zero := 0.0
+		fixedFee = &zero
+	}
+
+	unitPrice, err := strconv.ParseFloat(c.Request.PostFormValue("unitPrice"), 64)
+	if err != nil || unitPrice < 0 {
+		return nil, fmt.Errorf("invalid unit price")
+	}
+
+	nightPrice, err := parseOptionalFloat(c.Request.PostFormValue("nightPrice"))
+	if err != nil {
+		return nil, err
+	}
+
+	vat, err := strconv.ParseFloat(c.Request.PostFormValue("vatPercent"), 64)
+	if err != nil || vat < 0 || vat > 100 {
+		return nil, fmt.Errorf("invalid VAT")
+	}
+
+	return &models.UtilityTariff{
+		UtilityTypeID: typeID,
+		Name:          name,
+		EffectiveFrom: effectiveFrom,
+		FixedFee:      *fixedFee,
+		UnitPrice:     unitPrice,
+		NightPrice:    nightPrice,
+		VATPercent:    vat,
+		CreatedBy:     userID,
+	}, nil
+}
+
+// CreateTariff handles the HTTP POST request to add a tariff to a utility's schedule.
+func (h *TariffHandler) CreateTariff(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	t, err := h.parseTariffForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateUtilityTariff(t); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create tariff, the utility may already have one effective from that date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.tariffsSaved(c, http.StatusCreated, userID, &models.ModalContent{
+		Title:   "Successful tariff creation.",
+		Message: fmt.Sprintf("Tariff %s is in effect from %s!", t.Name, t.EffectiveFrom.Format("02.01.2006")),
+	})
+}
+
+// ownTariff loads the tariff from the id path parameter and makes sure it belongs
+// to the current user. It renders the error itself and returns nil on failure.
+func (h *TariffHandler) ownTariff(c *gin.Context) *models.UtilityTariff {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	t, err := h.DB.GetUtilityTariffByID(id)
+	if err != nil || t == nil || t.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Tariff not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return t
+}
+
+func (h *TariffHandler) GetEditTariffForm(c *gin.Context) {
+	t := h.ownTariff(c)
+	if t == nil {
+		return
+	}
+
+	h.tariffForm(c, t)
+}
+
+func (h *TariffHandler) EditTariff(c *gin.Context) {
+	existing := h.ownTariff(c)
+	if existing == nil {
+		return
+	}
+
+	t, err := h.parseTariffForm(c, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	t.ID = existing.ID
+
+	if err := h.DB.EditUtilityTariff(t); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update tariff, the utility may already have one effective from that date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.tariffsSaved(c, http.StatusOK, t.CreatedBy, &models.ModalContent{
+		Title:   "Successful tariff update.",
+		Message: fmt.Sprintf("Tariff %s updated!", t.Name),
+	})
+}
+
+func (h *TariffHandler) GetDeleteTariffConfirm(c *gin.Context) {
+	t := h.ownTariff(c)
+	if t == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/house/tariffs/%v", t.ID)),
+		Target:   fmt.Sprintf("#tariff-%v", t.ID),
+		Message:  fmt.Sprintf("Please confirm if you want to delete the %s tariff %s.", t.UtilityType, t.Name),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *TariffHandler) DeleteTariff(c *gin.Context) {
+	t := h.ownTariff(c)
+	if t == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteUtilityTariff(t.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete tariff.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	h.tariffsSaved(c, http.StatusOK, t.CreatedBy, &models.ModalContent{
+		Title:   "Successfully deleted tariff!",
+		Message: fmt.Sprintf("Tariff %s deleted!", t.Name),
+	})
+}
+
+// INFO: METER READINGS
+
+func (h *TariffHandler) meterReadingForm(c *gin.Context, reading *models.MeterReading) {
+	types, err := h.meteredUtilityTypes()
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.MeterReadingForm, &MeterReadingFormData{
+		Reading: reading,
+		Types:   types,
+	})
+}
+
+func (h *TariffHandler) GetCreateMeterReadingForm(c *gin.Context) {
+	h.meterReadingForm(c, nil)
+}
+
+// parseMeterReadingForm reads a meter reading, the night register is left empty
+// for single register meters.
+func (h *TariffHandler) parseMeterReadingForm(c *gin.Context, userID uuid.UUID) (*models.MeterReading, error) {
+	typeID, err := h.parseMeteredUtilityType(c)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("date"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date")
+	}
+
+	value, err := strconv.ParseFloat(c.Request.PostFormValue("value"), 64)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid reading")
+	}
+
+	nightValue, err := parseOptionalFloat(c.Request.PostFormValue("nightValue"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MeterReading{
+		UtilityTypeID: typeID,
+		Date:          date,
+		Value:         value,
+		NightValue:    nightValue,
+		CreatedBy:     userID,
+	}, nil
+}
+
+// CreateMeterReading handles the HTTP POST request to record a meter reading.
+func (h *TariffHandler) CreateMeterReading(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	reading, err := h.parseMeterReadingForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateMeterReading(reading); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create meter reading, the meter may already have one on that date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.tariffsSaved(c, http.StatusCreated, userID, &models.ModalContent{
+		Title:   "Successful meter reading creation.",
+		Message: fmt.Sprintf("Meter reading of %s recorded!", reading.Date.Format("02.01.2006")),
+	})
+}
+
+// ownMeterReading loads the reading from the id path parameter and makes sure it
+// belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *TariffHandler) ownMeterReading(c *gin.Context) *models.MeterReading {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	reading, err := h.DB.GetMeterReadingByID(id)
+	if err != nil || reading == nil || reading.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Meter reading not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return reading
+}
+
+func (h *TariffHandler) GetEditMeterReadingForm(c *gin.Context) {
+	reading := h.ownMeterReading(c)
+	if reading == nil {
+		return
+	}
+
+	h.meterReadingForm(c, reading)
+}
+
+func (h *TariffHandler) EditMeterReading(c *gin.Context) {
+	existing := h.ownMeterReading(c)
+	if existing == nil {
+		return
+	}
+
+	reading, err := h.parseMeterReadingForm(c, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	reading.ID = existing.ID
+
+	if err := h.DB.EditMeterReading(reading); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update meter reading, the meter may already have one on that date.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.tariffsSaved(c, http.StatusOK, reading.CreatedBy, &models.ModalContent{
+		Title:   "Successful meter reading update.",
+		Message: fmt.Sprintf("Meter reading of %s updated!", reading.Date.Format("02.01.2006")),
+	})
+}
+
+func (h *TariffHandler) GetDeleteMeterReadingConfirm(c *gin.Context) {
+	reading := h.ownMeterReading(c)
+	if reading == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/house/readings/%v", reading.ID)),
+		Target:   fmt.Sprintf("#reading-%v", reading.ID),
+		Message:  fmt.Sprintf("Please confirm if you want to delete the %s reading of %s.", reading.UtilityType, reading.Date.Format("02.01.2006")),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *TariffHandler) DeleteMeterReading(c *gin.Context) {
+	reading := h.ownMeterReading(c)
+	if reading == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteMeterReading(reading.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete meter reading.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	h.tariffsSaved(c, http.StatusOK, reading.CreatedBy, &models.ModalContent{
+		Title:   "Successfully deleted meter reading!",
+		Message: fmt.Sprintf("Meter reading of %s deleted!", reading.Date.Format("02.01.2006")),
+	})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"expenser/internal/models"
+	"expenser/internal/telegram"
+	"expenser/internal/utilities"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// telegram returns the Telegram bot section of the user, nil when no bot is configured.
+func (h *NotificationHandler) telegram(userID uuid.UUID) (*models.TelegramData, error) {
+	if !h.Telegram.Enabled() {
+		return nil, nil
+	}
+
+	link, err := h.DB.GetTelegramLink(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TelegramData{
+		BotName: h.Telegram.BotName,
+		Link:    link,
+	}, nil
+}
+
+// telegramSaved responds with the refreshed Telegram bot section.
+func (h *NotificationHandler) telegramSaved(c *gin.Context, userID uuid.UUID) {
+	data, err := h.telegram(userID)
+	if err != nil {
+		c.Header("HX-Reswap", "none")
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching Telegram link.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.TelegramLink, data)
+}
+
+// LinkTelegram handles the HTTP POST request for a new code to link a Telegram
+// chat with. The chat linked so far stays linked until the code is sent to the bot.
+func (h *NotificationHandler) LinkTelegram(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	if !h.Telegram.Enabled() {
+		c.Header("HX-Reswap", "none")
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: No Telegram bot is configured.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.SetTelegramLinkCode(userID, telegram.NewLinkCode(), time.Now().Add(telegram.LinkCodeTTL)); err != nil {
+		c.Header("HX-Reswap", "none")
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't create a Telegram link code.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.telegramSaved(c, userID)
+}
+
+// UnlinkTelegram handles the HTTP DELETE request to unlink the user's Telegram chat.
+func (h *NotificationHandler) UnlinkTelegram(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	if _, err := h.DB.DeleteTelegramLink(userID); err != nil {
+		c.Header("HX-Reswap", "none")
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't unlink Telegram.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.telegramSaved(c, userID)
+}
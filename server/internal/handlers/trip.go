@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// tripExpensesDays is how far back the trip form offers car expenses to link.
+const tripExpensesDays = 90
+
+type TripHandler struct {
+	DB *database.DB
+}
+
+func NewTripHandler(db *database.DB) *TripHandler {
+	return &TripHandler{
+		DB: db,
+	}
+}
+
+// TripLogData is the data for the trip log and its mileage summary.
+type TripLogData struct {
+	From    time.Time
+	To      time.Time
+	Trips   []models.Trip
+	Summary models.MileageSummary
+}
+
+// TripFormData is the data for the create and edit trip forms.
+type TripFormData struct {
+	Trip     *models.Trip // Trip is nil for a new one.
+	Vehicles *models.VehicleSelect
+	Expenses []models.CarExpense
+}
+
+// IsExpenseSelected reports whether the edited trip is linked to the expense with the given id.
+func (d *TripFormData) IsExpenseSelected(id int) bool {
+	return d.Trip != nil && d.Trip.HasExpense(id)
+}
+
+// currentYearPeriod reads the from and to dates, by default the current year up to the end of the month.
+func currentYearPeriod(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, -1)
+
+	return parsePeriod(c, from, to)
+}
+
+// periodQuery returns the period as query parameters, to carry it over to later requests.
+func periodQuery(from, to time.Time) string {
+	query := url.Values{}
+	query.Set("from", from.Format(utilities.DateFormats.Input))
+	query.Set("to", to.Format(utilities.DateFormats.Input))
+	return query.Encode()
+}
+
+func (h *TripHandler) tripLog(c *gin.Context, userID uuid.UUID) (*TripLogData, error) {
+	from, to, err := currentYearPeriod(c)
+	if err != nil {
+		return nil, err
+	}
+
+	trips, err := h.DB.GetTrips(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TripLogData{
+		From:    from,
+		To:      to,
+		Trips:   trips,
+		Summary: models.SummarizeMileage(trips),
+	}, nil
+}
+
+// GetTrips renders the trip log of the car page with the mileage summary of the period.
+func (h *TripHandler) GetTrips(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	data, err := h.tripLog(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.TripLog, data)
+}
+
+func (h *TripHandler) tripFormData(userID uuid.UUID, trip *models.Trip) (*TripFormData, error) {
+	var selectedVehicle, tripID *int
+	if trip != nil {
+		selectedVehicle = trip.VehicleID
+		tripID = &trip.ID
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, selectedVehicle)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := h.DB.GetTripExpenseCandidates(userID, time.Now().AddDate(0, 0, -tripExpensesDays), tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TripFormData{
+		Trip:     trip,
+		Vehicles: vehicles,
+		Expenses: expenses,
+	}, nil
+}
+
+func (h *TripHandler) GetCreateTripForm(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	formData, err := h.tripFormData(userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.TripForm, formData)
+}
+
+// parseTripForm reads a trip and the ids of the expenses to link to it.
+func (h *TripHandler) parseTripForm(c *gin.Context, userID uuid.UUID) (*models.Trip, []int, error) {
+	date, err := time.Parse(utilities.DateFormats.Input, c.Request.PostFormValue("date"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid date")
+	}
+
+	vehicleID, err := parseVehicleID(c, h.DB, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	startOdometer, err := parseOptionalInt(c.Request.PostFormValue("startOdometer"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endOdometer, err := parseOptionalInt(c.Request.PostFormValue("endOdometer"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	distance, err := parseOptionalInt(c.Request.PostFormValue("distance"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	km, err := models.TripDistance(startOdometer, endOdometer, distance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	purpose := strings.TrimSpace(c.Request.PostFormValue("purpose"))
+	if purpose == "" || len(purpose) > 255 {
+		return nil, nil, fmt.Errorf("invalid purpose")
+	}
+
+	var expenseIDs []int
+	for _, value := range c.Request.PostForm["expenseIDs"] {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid expense")
+		}
+		expenseIDs = append(expenseIDs, id)
+	}
+
+	trip := &models.Trip{
+		VehicleID:     vehicleID,
+		Date:          date,
+		StartOdometer: startOdometer,
+		EndOdometer:   endOdometer,
+		Distance:      km,
+		Purpose:       purpose,
+		Business:      c.Request.PostFormValue("business") == "true",
+		CreatedBy:     userID,
+	}
+
+	return trip, expenseIDs, nil
+}
+
+// tripSaved responds with the refreshed trip log of the period shown, as a trip
+// moves in the list and changes the summary.
+func (h *TripHandler) tripSaved(c *gin.Context, status int, userID uuid.UUID, modal *models.ModalContent) {
+	data, err := h.tripLog(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching trips.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(status, utilities.Templates.Responses.SaveTrip, gin.H{
+		"Log":   data,
+		"Modal": modal,
+	})
+}
+
+// CreateTrip handles the HTTP POST request to log a trip.
+func (h *TripHandler) CreateTrip(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	trip, expenseIDs, err := h.parseTripForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateTrip(trip, expenseIDs); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create trip.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.tripSaved(c, http.StatusCreated, userID, &models.ModalContent{
+		Title:   "Successful trip creation.",
+		Message: fmt.Sprintf("%s trip of %d km logged!", trip.Kind(), trip.Distance),
+	})
+}
+
+// ownTrip loads the trip from the id path parameter and makes sure it belongs
+// to the current user. It renders the error itself and returns nil on failure.
+func (h *TripHandler) ownTrip(c *gin.Context) *models.Trip {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	trip, err := h.DB.GetTripByID(id)
+	if err != nil || trip == nil || trip.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Trip not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return trip
+}
+
+func (h *TripHandler) GetEditTripForm(c *gin.Context) {
+	trip := h.ownTrip(c)
+	if trip == nil {
+		return
+	}
+
+	formData, err := h.tripFormData(trip.CreatedBy, trip)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.TripForm, formData)
+}
+
+func (h *TripHandler) EditTrip(c *gin.Context) {
+	existing := h.ownTrip(c)
+	if existing == nil {
+		return
+	}
+
+	trip, expenseIDs, err := h.parseTripForm(c, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	trip.ID = existing.ID
+
+	if err := h.DB.EditTrip(trip, expenseIDs); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update trip.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.tripSaved(c, http.StatusOK, trip.CreatedBy, &models.ModalContent{
+		Title:   "Successful trip update.",
+		Message: fmt.Sprintf("%s trip of %d km updated!", trip.Kind(), trip.Distance),
+	})
+}
+
+// GetDeleteConfirm asks to confirm deleting a trip. The period of the trip log is
+// carried over to the delete request, which responds with the refreshed log.
+func (h *TripHandler) GetDeleteConfirm(c *gin.Context) {
+	trip := h.ownTrip(c)
+	if trip == nil {
+		return
+	}
+
+	from, to, err := currentYearPeriod(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/car/trips/%v?%s", trip.ID, periodQuery(from, to))),
+		Target:   fmt.Sprintf("#trip-%v", trip.ID),
+		Message:  fmt.Sprintf("Please confirm if you want to delete the trip of %s: %s.", trip.Date.Format("02.01.2006"), trip.Purpose),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *TripHandler) DeleteTrip(c *gin.Context) {
+	trip := h.ownTrip(c)
+	if trip == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteTrip(trip.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete trip.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	h.tripSaved(c, http.StatusOK, trip.CreatedBy, &models.ModalContent{
+		Title:   "Successfully deleted trip!",
+		Message: fmt.Sprintf("Trip of %s deleted!", trip.Date.Format("02.01.2006")),
+	})
+}
+
+// GetReimbursement renders the reimbursement report of the business trips in the
+// period at the user's per-km rate.
+func (h *TripHandler) GetReimbursement(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	from, to, err := currentYearPeriod(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	rate, err := h.DB.GetMileageRate(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching the per-km rate.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	trips, err := h.DB.GetTrips(userID, from, to)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching trips.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	report := models.BuildReimbursement(trips, rate, from, to)
+	c.HTML(http.StatusOK, utilities.Templates.Components.Reimbursement, report)
+}
+
+// SetMileageRate handles the HTTP PUT request to change the per-km rate business
+// trips are reimbursed at, then renders the reimbursement report again.
+func (h *TripHandler) SetMileageRate(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	rate, err := strconv.ParseFloat(c.Request.PostFormValue("rate"), 64)
+	if err != nil || rate < 0 || rate >= 1000 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, invalid per-km rate.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.SetMileageRate(userID, rate); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't save the per-km rate.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.GetReimbursement(c)
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"encoding/base64"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/totp"
+	"expenser/internal/utilities"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	twoFactorIssuer = "Expenser" // twoFactorIssuer labels the account in authenticator apps.
+
+	// After twoFactorMaxFailures wrong codes in a row, the second step of logging
+	// in refuses codes for twoFactorLockout.
+	twoFactorMaxFailures = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+// INFO: LOGIN
+
+// GetTwoFactorLogin renders the second step of logging in, asking for a code.
+func (h *AuthHandler) GetTwoFactorLogin(c *gin.Context) {
+	pending, _ := c.Cookie("two_factor_token")
+	if _, err := h.AuthService.ValidateTwoFactorToken(pending); err != nil {
+		c.Redirect(http.StatusSeeOther, "/login")
+		return
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.TwoFactorLogin, gin.H{})
+	} else {
+		rl := &models.RootLayout{
+			TemplateName: utilities.Templates.Pages.TwoFactorLogin,
+			HeaderOpts:   &models.HeaderOptions{},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// TwoFactorLogin handles the HTTP POST request of the second step of logging
+// in, with a TOTP code or else a recovery code, which is used up.
+func (h *AuthHandler) TwoFactorLogin(c *gin.Context) {
+	pending, _ := c.Cookie("two_factor_token")
+	claims, err := h.AuthService.ValidateTwoFactorToken(pending)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "401: The login expired, log in again.",
+		}
+		c.HTML(http.StatusUnauthorized, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	var input models.TwoFactorLogin
+	if err := c.ShouldBind(&input); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Invalid request data.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	now := time.Now()
+	twoFactor, err := h.DB.GetTwoFactor(claims.UserID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if twoFactor.Locked(now) {
+		content := &models.ModalContent{
+			Title:   "Too many wrong codes!",
+			Message: "429: Wait a few minutes before trying again.",
+		}
+		c.HTML(http.StatusTooManyRequests, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	ok, err := checkSecondFactor(h.DB, claims.UserID, twoFactor, input.Code, now)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't check the code.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !ok {
+		if err := h.DB.RecordTwoFactorFailure(claims.UserID, twoFactorMaxFailures, now.Add(twoFactorLockout)); err != nil {
+			log.Printf("two-factor failure of %s: %v", claims.UserID, err)
+		}
+
+		content := &models.ModalContent{
+			Title:   "Invalid code!",
+			Message: "401: The code is wrong or was already used.",
+		}
+		c.HTML(http.StatusUnauthorized, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	user, err := h.DB.GetUserByID(claims.UserID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.AuthService.SetTwoFactorCookie("", c)
+	h.startSession(c, user)
+}
+
+// checkSecondFactor checks the code as a TOTP code of the user, or else as one
+// of their recovery codes, and uses it up.
+func checkSecondFactor(db *database.DB, userID uuid.UUID, twoFactor *models.TwoFactor, code string, now time.Time) (bool, error) {
+	if !twoFactor.Enabled() {
+		return false, nil
+	}
+
+	if step, ok := totp.Validate(twoFactor.Secret, code, now); ok {
+		return db.UseTOTPStep(userID, step)
+	}
+
+	return db.UseRecoveryCode(userID, totp.HashRecoveryCode(code), now)
+}
+
+// INFO: SETTINGS
+
+// twoFactorData gathers the two-factor section of the user's settings.
+func (h *SettingsHandler) twoFactorData(userID uuid.UUID) (*models.TwoFactorData, error) {
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := h.DB.GetTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorData{TwoFactor: twoFactor, HasPassword: user.HasPassword()}, nil
+}
+
+// twoFactorSaved replies with the two-factor section and the modal, if any.
+func (h *SettingsHandler) twoFactorSaved(c *gin.Context, data *models.TwoFactorData, modal *models.ModalContent) {
+	c.HTML(http.StatusOK, utilities.Templates.Responses.SaveTwoFactor, gin.H{
+		"TwoFactor": data,
+		"Modal":     modal,
+	})
+}
+
+// StartTwoFactor handles the HTTP POST request to set up two-factor
+// authentication, giving the user a new secret to add to their authenticator.
+func (h *SettingsHandler) StartTwoFactor(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+	usernameStr, _ := c.Get("username")
+	username, _ := usernameStr.(string)
+
+	secret := totp.NewSecret()
+	if err := h.DB.SetTOTPSecret(userID, secret); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Two-factor authentication is already on.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	qr, err := totp.QRCode(totp.URI(twoFactorIssuer, username, secret))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't generate the QR code.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data, err := h.twoFactorData(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data.Enrolment = &models.TwoFactorEnrolment{
+		Secret: secret,
+		QRCode: template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(qr)),
+	}
+	h.twoFactorSaved(c, data, nil)
+}
+
+// EnableTwoFactor handles the HTTP POST request turning two-factor
+// authentication on with a first code of the new secret. The recovery codes
+// are shown once.
+func (h *SettingsHandler) EnableTwoFactor(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	twoFactor, err := h.DB.GetTwoFactor(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if twoFactor.Enabled() || twoFactor.Secret == "" {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: There is no two-factor authentication being set up.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	now := time.Now()
+	step, ok := totp.Validate(twoFactor.Secret, c.Request.PostFormValue("code"), now)
+	if !ok {
+		content := &models.ModalContent{
+			Title:   "Invalid code!",
+			Message: "400: The code is wrong, check the time of your device and try the next one.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	codes, hashes := totp.NewRecoveryCodes()
+	if err := h.DB.EnableTwoFactor(userID, step, hashes, now); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't turn on two-factor authentication.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data, err := h.twoFactorData(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data.RecoveryCodes = codes
+	h.twoFactorSaved(c, data, &models.ModalContent{
+		Title:   "Successfully turned on two-factor authentication!",
+		Message: "Logging in asks for a code from your authenticator from now on.",
+		Warning: "Save your recovery codes somewhere safe, they are shown only once.",
+	})
+}
+
+// reauthenticate makes the logged in user confirm a change with their current
+// password. Users without one, created by single sign-on, give a code from
+// their authenticator or a recovery code instead when two-factor authentication
+// is on, else their session is all there is to check. It replies with an error
+// when the check fails.
+func (h *SettingsHandler) reauthenticate(c *gin.Context, userID uuid.UUID) bool {
+	user, err := h.DB.GetUserByID(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return false
+	}
+
+	if user.HasPassword() {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(c.Request.PostFormValue("current_password"))); err != nil {
+			content := &models.ModalContent{
+				Title:   "Invalid credentials!",
+				Message: "401: The current password is wrong.",
+			}
+			c.HTML(http.StatusUnauthorized, utilities.Templates.Components.ModalError, content)
+			return false
+		}
+		return true
+	}
+
+	twoFactor, err := h.DB.GetTwoFactor(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return false
+	}
+
+	if !twoFactor.Enabled() {
+		return true
+	}
+
+	now := time.Now()
+	if twoFactor.Locked(now) {
+		content := &models.ModalContent{
+			Title:   "Too many wrong codes!",
+			Message: "429: Wait a few minutes before trying again.",
+		}
+		c.HTML(http.StatusTooManyRequests, utilities.Templates.Components.ModalError, content)
+		return false
+	}
+
+	ok, err := checkSecondFactor(h.DB, userID, twoFactor, c.Request.PostFormValue("code"), now)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't check the code.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return false
+	}
+
+	if !ok {
+		if err := h.DB.RecordTwoFactorFailure(userID, twoFactorMaxFailures, now.Add(twoFactorLockout)); err != nil {
+			log.Printf("two-factor failure of %s: %v", userID, err)
+		}
+
+		content := &models.ModalContent{
+			Title:   "Invalid code!",
+			Message: "401: The code is wrong or was already used.",
+		}
+		c.HTML(http.StatusUnauthorized, utilities.Templates.Components.ModalError, content)
+		return false
+	}
+
+	return true
+}
+
+// DisableTwoFactor handles the HTTP DELETE request turning two-factor
+// authentication off, which takes the current password or, without one, a code.
+func (h *SettingsHandler) DisableTwoFactor(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	if !h.reauthenticate(c, userID) {
+		return
+	}
+
+	if err := h.DB.DisableTwoFactor(userID); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't turn off two-factor authentication.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data, err := h.twoFactorData(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.twoFactorSaved(c, data, &models.ModalContent{
+		Title:   "Successfully turned off two-factor authentication!",
+		Message: "Logging in only asks for your password from now on.",
+	})
+}
+
+// RegenerateRecoveryCodes handles the HTTP POST request replacing the user's
+// recovery codes, which takes the current password or, without one, a code. The
+// new ones are shown once.
+func (h *SettingsHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	if !h.reauthenticate(c, userID) {
+		return
+	}
+
+	data, err := h.twoFactorData(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching account.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !data.TwoFactor.Enabled() {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Two-factor authentication is off.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	codes, hashes := totp.NewRecoveryCodes()
+	if err := h.DB.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't replace the recovery codes.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	data.TwoFactor.RecoveryCodesLeft = len(codes)
+	data.RecoveryCodes = codes
+	h.twoFactorSaved(c, data, &models.ModalContent{
+		Title:   "Successfully replaced recovery codes!",
+		Message: "Your old recovery codes don't work anymore.",
+		Warning: "Save your recovery codes somewhere safe, they are shown only once.",
+	})
+}
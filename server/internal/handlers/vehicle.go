@@ -0,0 +1,725 @@
+package handlers
+
+import (
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VehicleHandler struct {
+	DB *database.DB
+}
+
+func NewVehicleHandler(db *database.DB) *VehicleHandler {
+	return &VehicleHandler{
+		DB: db,
+	}
+}
+
+// vehicleSelect builds the vehicle dropdown data of the car expense forms.
+func vehicleSelect(db *database.DB, userID uuid.UUID, selected *int) (*models.VehicleSelect, error) {
+	vehicles, err := db.GetVehicles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.VehicleSelect{
+		Vehicles: vehicles,
+		Selected: selected,
+	}, nil
+}
+
+// parseVehicleID reads the optional "vehicleID" form value and makes sure
+// the vehicle belongs to the user. An empty value means no vehicle.
+func parseVehicleID(c *gin.Context, db *database.DB, userID uuid.UUID) (*int, error) {
+	value := c.Request.PostFormValue("vehicleID")
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vehicle")
+	}
+
+	vehicle, err := db.GetVehicleByID(id)
+	if err != nil || vehicle == nil || vehicle.CreatedBy != userID {
+		return nil, fmt.Errorf("invalid vehicle")
+	}
+
+	return &id, nil
+}
+
+// parseOptionalInt reads an optional positive whole number form value, empty means not set.
+func parseOptionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return nil, fmt.Errorf("invalid number %q", value)
+	}
+
+	return &number, nil
+}
+
+// parseOptionalFloat reads an optional positive amount form value, empty means not set.
+func parseOptionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return nil, fmt.Errorf("invalid amount %q", value)
+	}
+
+	return &number, nil
+}
+
+// VehiclesData is the data for the vehicles page.
+type VehiclesData struct {
+	Vehicles  *[]models.Vehicle
+	Plans     []models.ServiceStatus
+	Documents []models.DocumentStatus
+}
+
+func (h *VehicleHandler) servicePlanStatuses(userID uuid.UUID) ([]models.ServiceStatus, error) {
+	plans, err := h.DB.GetServicePlans(userID)
+	if err != nil {
+		return nil, err
+	}
+	return models.ServiceStatuses(plans, time.Now()), nil
+}
+
+func (h *VehicleHandler) GetVehicles(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	vehicles, err := h.DB.GetVehicles(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicles.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	plans, err := h.servicePlanStatuses(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching service plans.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	docs, err := h.documentStatuses(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicle documents.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	pageData := &VehiclesData{
+		Vehicles:  vehicles,
+		Plans:     plans,
+		Documents: docs,
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Vehicles, pageData)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Vehicles,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// VehicleFormData is the data for the create and edit vehicle forms.
+type VehicleFormData struct {
+	Vehicle *models.Vehicle
+	Methods []models.DepreciationMethod
+}
+
+func (h *VehicleHandler) GetCreateVehicleForm(c *gin.Context) {
+	c.HTML(http.StatusOK, utilities.Templates.Components.VehicleForm, &VehicleFormData{
+		Methods: models.DepreciationMethods,
+	})
+}
+
+func parseVehicleForm(c *gin.Context) (*models.Vehicle, error) {
+	name := strings.TrimSpace(c.Request.PostFormValue("name"))
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("invalid name")
+	}
+
+	plate := strings.ToUpper(strings.TrimSpace(c.Request.PostFormValue("plateNumber")))
+	if len(plate) > 20 {
+		return nil, fmt.Errorf("invalid plate number")
+	}
+
+	vehicle := &models.Vehicle{
+		Name:        name,
+		PlateNumber: plate,
+	}
+
+	odometer, err := parseOptionalInt(c.Request.PostFormValue("odometer"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid odometer")
+	}
+	if odometer != nil {
+		vehicle.Odometer = *odometer
+		today := time.Now()
+		vehicle.OdometerDate = &today
+	}
+
+	if err := parsePurchase(c, vehicle); err != nil {
+		return nil, err
+	}
+
+	return vehicle, nil
+}
+
+// parsePurchase reads the optional purchase price and how the vehicle depreciates.
+func parsePurchase(c *gin.Context, vehicle *models.Vehicle) error {
+	vehicle.Depreciation = models.DepreciationMethod(c.Request.PostFormValue("depreciation"))
+	if !vehicle.Depreciation.Valid() {
+		return fmt.Errorf("invalid depreciation method")
+	}
+
+	price, err := parseOptionalFloat(c.Request.PostFormValue("purchasePrice"))
+	if err != nil {
+		return fmt.Errorf("invalid purchase price")
+	}
+	if price == nil {
+		return nil
+	}
+	vehicle.PurchasePrice = price
+
+	purchaseDate, err := time.Parse("2006-01-02", c.Request.PostFormValue("purchaseDate"))
+	if err != nil {
+		return fmt.Errorf("the purchase date is required with the purchase price")
+	}
+	vehicle.PurchaseDate = &purchaseDate
+
+	if vehicle.UsefulLifeYears, err = parseOptionalInt(c.Request.PostFormValue("usefulLifeYears")); err != nil {
+		return fmt.Errorf("invalid useful life")
+	}
+	if vehicle.UsefulLifeYears != nil && *vehicle.UsefulLifeYears == 0 {
+		return fmt.Errorf("the useful life must be above zero")
+	}
+	if vehicle.Depreciation == models.DepreciationStraightLine && vehicle.UsefulLifeYears == nil {
+		return fmt.Errorf("straight-line depreciation needs the useful life")
+	}
+
+	residual, err := parseOptionalFloat(c.Request.PostFormValue("residualValue"))
+	if err != nil || (residual != nil && *residual > *price) {
+		return fmt.Errorf("invalid residual value")
+	}
+	if residual != nil {
+		vehicle.ResidualValue = *residual
+	}
+
+	return nil
+}
+
+// vehicleSaved responds with the vehicle's row and the refreshed service plans and
+// documents, which show the vehicle's name and depend on its odometer.
+func (h *VehicleHandler) vehicleSaved(c *gin.Context, status int, vehicleID int, modal *models.ModalContent) {
+	vehicle, err := h.DB.GetVehicleByID(vehicleID)
+	if err != nil || vehicle == nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicle.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	plans, err := h.servicePlanStatuses(vehicle.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching service plans.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	docs, err := h.documentStatuses(vehicle.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicle documents.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(status, utilities.Templates.Responses.SaveVehicle, gin.H{
+		"Vehicle":   vehicle,
+		"Plans":     plans,
+		"Documents": docs,
+		"Modal":     modal,
+	})
+}
+
+// CreateVehicle handles the HTTP POST request to add a new vehicle.
+func (h *VehicleHandler) CreateVehicle(c *gin.Context) {
+	vehicle, err := parseVehicleForm(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	vehicle.CreatedBy, _ = userIDstr.(uuid.UUID)
+
+	if err := h.DB.CreateVehicle(vehicle); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create vehicle, the name may already be in use.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.vehicleSaved(c, http.StatusCreated, vehicle.ID, &models.ModalContent{
+		Title:   "Successful vehicle creation.",
+		Message: fmt.Sprintf("%s: %d km", vehicle.Label(), vehicle.Odometer),
+	})
+}
+
+// ownVehicle loads the vehicle from the id path parameter and makes sure
+// it belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *VehicleHandler) ownVehicle(c *gin.Context) *models.Vehicle {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	vehicle, err := h.DB.GetVehicleByID(id)
+	if err != nil || vehicle == nil || vehicle.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Vehicle not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return vehicle
+}
+
+func (h *VehicleHandler) GetEditVehicleForm(c *gin.Context) {
+	vehicle := h.ownVehicle(c)
+	if vehicle == nil {
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.VehicleForm, &VehicleFormData{
+		Vehicle: vehicle,
+		Methods: models.DepreciationMethods,
+	})
+}
+
+func (h *VehicleHandler) EditVehicle(c *gin.Context) {
+	existing := h.ownVehicle(c)
+	if existing == nil {
+		return
+	}
+
+	vehicle, err := parseVehicleForm(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	vehicle.ID = existing.ID
+
+	// Keep the date of the last reading when the odometer wasn't changed.
+	if vehicle.OdometerDate == nil || vehicle.Odometer == existing.Odometer {
+		vehicle.Odometer = existing.Odometer
+		vehicle.OdometerDate = existing.OdometerDate
+	}
+
+	if err := h.DB.EditVehicle(vehicle); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update vehicle, the name may already be in use.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.vehicleSaved(c, http.StatusOK, vehicle.ID, &models.ModalContent{
+		Title:   "Successful vehicle update.",
+		Message: fmt.Sprintf("%s: %d km", vehicle.Label(), vehicle.Odometer),
+	})
+}
+
+func (h *VehicleHandler) GetDeleteConfirm(c *gin.Context) {
+	vehicle := h.ownVehicle(c)
+	if vehicle == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/vehicles/%v", vehicle.ID)),
+		Target:   fmt.Sprintf("#veh-%v", vehicle.ID),
+		Message:  fmt.Sprintf("Deleting %s removes its service plans and documents and unlinks its car expenses.", vehicle.Name),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *VehicleHandler) DeleteVehicle(c *gin.Context) {
+	vehicle := h.ownVehicle(c)
+	if vehicle == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteVehicle(vehicle.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete vehicle.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	plans, err := h.servicePlanStatuses(vehicle.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching service plans.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	docs, err := h.documentStatuses(vehicle.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicle documents.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.DeleteVehicle, gin.H{
+		"Plans":     plans,
+		"Documents": docs,
+		"Modal": &models.ModalContent{
+			Title:   "Successfully deleted vehicle!",
+			Message: fmt.Sprintf("Vehicle %s deleted!", vehicle.Name),
+		},
+	})
+}
+
+// ServicePlanFormData is the data for the create and edit service plan forms.
+type ServicePlanFormData struct {
+	Plan     *models.ServicePlan
+	Vehicles *models.VehicleSelect
+	Types    *[]models.CarExpenseType
+}
+
+func (h *VehicleHandler) servicePlanFormData(userID uuid.UUID, plan *models.ServicePlan) (*ServicePlanFormData, error) {
+	var selected *int
+	if plan != nil {
+		selected = &plan.VehicleID
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := h.DB.GetCarExpenseTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServicePlanFormData{
+		Plan:     plan,
+		Vehicles: vehicles,
+		Types:    types,
+	}, nil
+}
+
+func (h *VehicleHandler) GetCreateServicePlanForm(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	formData, err := h.servicePlanFormData(userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.ServicePlanForm, formData)
+}
+
+func (h *VehicleHandler) parseServicePlanForm(c *gin.Context, userID uuid.UUID) (*models.ServicePlan, error) {
+	vehicleID, err := parseVehicleID(c, h.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if vehicleID == nil {
+		return nil, fmt.Errorf("choose a vehicle")
+	}
+
+	name := strings.TrimSpace(c.Request.PostFormValue("name"))
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("invalid name")
+	}
+
+	typeID, err := strconv.Atoi(c.Request.PostFormValue("typeID"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid expense type")
+	}
+
+	plan := &models.ServicePlan{
+		VehicleID:     *vehicleID,
+		Name:          name,
+		ExpenseTypeID: typeID,
+		CreatedBy:     userID,
+	}
+
+	if plan.IntervalKm, err = parseOptionalInt(c.Request.PostFormValue("intervalKm")); err != nil {
+		return nil, fmt.Errorf("invalid km interval")
+	}
+	if plan.IntervalMonths, err = parseOptionalInt(c.Request.PostFormValue("intervalMonths")); err != nil {
+		return nil, fmt.Errorf("invalid months interval")
+	}
+
+	if plan.IntervalKm == nil && plan.IntervalMonths == nil {
+		return nil, fmt.Errorf("set an interval in km, months or both")
+	}
+	if (plan.IntervalKm != nil && *plan.IntervalKm == 0) || (plan.IntervalMonths != nil && *plan.IntervalMonths == 0) {
+		return nil, fmt.Errorf("intervals must be above zero")
+	}
+
+	return plan, nil
+}
+
+// servicePlanSaved responds with the plan's row showing its fresh status.
+func (h *VehicleHandler) servicePlanSaved(c *gin.Context, status int, planID int, title string) {
+	plan, err := h.DB.GetServicePlanByID(planID)
+	if err != nil || plan == nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching service plan.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	planStatus := plan.Status(time.Now())
+	c.HTML(status, utilities.Templates.Responses.SaveServicePlan, gin.H{
+		"Status": &planStatus,
+		"Modal": &models.ModalContent{
+			Title:   title,
+			Message: fmt.Sprintf("%s of %s: %s", plan.Name, plan.Vehicle, planStatus.State.Label()),
+		},
+	})
+}
+
+// CreateServicePlan handles the HTTP POST request to add a service plan to a vehicle.
+func (h *VehicleHandler) CreateServicePlan(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	plan, err := h.parseServicePlanForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateServicePlan(plan); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create service plan.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.servicePlanSaved(c, http.StatusCreated, plan.ID, "Successful service plan creation.")
+}
+
+// ownServicePlan loads the plan from the id path parameter and makes sure
+// it belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *VehicleHandler) ownServicePlan(c *gin.Context) *models.ServicePlan {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	plan, err := h.DB.GetServicePlanByID(id)
+	if err != nil || plan == nil || plan.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Service plan not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return plan
+}
+
+func (h *VehicleHandler) GetEditServicePlanForm(c *gin.Context) {
+	plan := h.ownServicePlan(c)
+	if plan == nil {
+		return
+	}
+
+	formData, err := h.servicePlanFormData(plan.CreatedBy, plan)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.ServicePlanForm, formData)
+}
+
+func (h *VehicleHandler) EditServicePlan(c *gin.Context) {
+	existing := h.ownServicePlan(c)
+	if existing == nil {
+		return
+	}
+
+	plan, err := h.parseServicePlanForm(c, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	plan.ID = existing.ID
+
+	if err := h.DB.EditServicePlan(plan); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update service plan.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.servicePlanSaved(c, http.StatusOK, plan.ID, "Successful service plan update.")
+}
+
+func (h *VehicleHandler) GetDeleteServicePlanConfirm(c *gin.Context) {
+	plan := h.ownServicePlan(c)
+	if plan == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/vehicles/services/%v", plan.ID)),
+		Target:   fmt.Sprintf("#plan-%v", plan.ID),
+		Message:  fmt.Sprintf("Please confirm if you want to delete the %s plan of %s.", plan.Name, plan.Vehicle),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *VehicleHandler) DeleteServicePlan(c *gin.Context) {
+	plan := h.ownServicePlan(c)
+	if plan == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteServicePlan(plan.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete service plan.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	content := &models.ModalContent{
+		Title:   "Successfully deleted service plan!",
+		Message: fmt.Sprintf("%s of %s deleted!", plan.Name, plan.Vehicle),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalSuccess, content)
+}
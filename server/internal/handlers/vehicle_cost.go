@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"expenser/internal/models"
+	"expenser/internal/tco"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OwnershipCostData is the data for the ownership cost page and report.
+type OwnershipCostData struct {
+	Vehicles   *models.VehicleSelect
+	From       time.Time
+	To         time.Time
+	Report     *models.OwnershipCost
+	Valuations []models.VehicleValuation
+}
+
+// ExportURL returns the link to the report as CSV.
+func (d *OwnershipCostData) ExportURL() template.URL {
+	query := url.Values{}
+	query.Set("vehicleID", strconv.Itoa(d.Report.Vehicle.ID))
+	query.Set("from", d.From.Format("2006-01-02"))
+	query.Set("to", d.To.Format("2006-01-02"))
+	return template.URL("/vehicles/costs/export?" + query.Encode())
+}
+
+// costVehicle loads the vehicle from the vehicleID value, or the user's first
+// vehicle when none is given. It returns nil, nil when the user has no vehicles.
+func (h *VehicleHandler) costVehicle(c *gin.Context, userID uuid.UUID) (*models.Vehicle, error) {
+	value := c.Request.FormValue("vehicleID")
+	if value == "" {
+		vehicles, err := h.DB.GetVehicles(userID)
+		if err != nil || len(*vehicles) == 0 {
+			return nil, err
+		}
+		return &(*vehicles)[0], nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vehicle")
+	}
+
+	vehicle, err := h.DB.GetVehicleByID(id)
+	if err != nil || vehicle == nil || vehicle.CreatedBy != userID {
+		return nil, fmt.Errorf("invalid vehicle")
+	}
+
+	return vehicle, nil
+}
+
+// parsePeriod reads the from and to dates of a report, keeping the given
+// defaults for the ones that are not set.
+func parsePeriod(c *gin.Context, from, to time.Time) (time.Time, time.Time, error) {
+	var err error
+	if value := c.Request.FormValue("from"); value != "" {
+		if from, err = time.Parse(utilities.DateFormats.Input, value); err != nil {
+			return from, to, fmt.Errorf("invalid from date")
+		}
+	}
+	if value := c.Request.FormValue("to"); value != "" {
+		if to, err = time.Parse(utilities.DateFormats.Input, value); err != nil {
+			return from, to, fmt.Errorf("invalid to date")
+		}
+	}
+
+	if to.Before(from) {
+		return from, to, fmt.Errorf("the period ends before it starts")
+	}
+
+	return from, to, nil
+}
+
+// costPeriod reads the from and to dates. By default the period is the current
+// year up to today, starting at the purchase when the vehicle was bought this year.
+func costPeriod(c *gin.Context, vehicle *models.Vehicle) (time.Time, time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	if vehicle.PurchaseDate != nil && vehicle.PurchaseDate.After(from) && !vehicle.PurchaseDate.After(today) {
+		from = *vehicle.PurchaseDate
+	}
+
+	return parsePeriod(c, from, today)
+}
+
+// ownershipCost computes the report of the requested vehicle and period.
+// It returns nil data without an error when the user has no vehicles.
+func (h *VehicleHandler) ownershipCost(c *gin.Context) (*OwnershipCostData, error) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	vehicle, err := h.costVehicle(c, userID)
+	if err != nil || vehicle == nil {
+		return nil, err
+	}
+
+	from, to, err := costPeriod(c, vehicle)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, &vehicle.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	valuations, err := h.DB.GetVehicleValuations(vehicle.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := h.DB.GetVehicleExpensesBetween(vehicle.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	readings, err := h.DB.GetOdometerReadings(vehicle.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := tco.Compute(&tco.Input{
+		Vehicle:    *vehicle,
+		Valuations: valuations,
+		Expenses:   expenses,
+		Readings:   readings,
+		From:       from,
+		To:         to,
+	})
+
+	return &OwnershipCostData{
+		Vehicles:   vehicles,
+		From:       from,
+		To:         to,
+		Report:     report,
+		Valuations: valuations,
+	}, nil
+}
+
+// GetOwnershipCost renders the ownership cost page of a vehicle.
+func (h *VehicleHandler) GetOwnershipCost(c *gin.Context) {
+	_, exists := c.Get("user_id")
+
+	pageData, err := h.ownershipCost(c)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.OwnershipCost, pageData)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.OwnershipCost,
+			TemplateContent: pageData,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// GetOwnershipCostReport renders the report for the vehicle and period picked on the page.
+func (h *VehicleHandler) GetOwnershipCostReport(c *gin.Context) {
+	data, err := h.ownershipCost(c)
+	if err != nil || data == nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.OwnershipCostReport, data)
+}
+
+// ExportOwnershipCost downloads the report for the vehicle and period as CSV.
+func (h *VehicleHandler) ExportOwnershipCost(c *gin.Context) {
+	data, err := h.ownershipCost(c)
+	if err != nil || data == nil {
+		c.String(http.StatusBadRequest, "400: Bad Request, %v.", err)
+		return
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '"' || r == '/' || r == '\\' {
+			return '-'
+		}
+		return r
+	}, data.Report.Vehicle.Name)
+
+	filename := fmt.Sprintf("ownership-cost-%s-%s-%s.csv", name, data.From.Format("2006-01-02"), data.To.Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if err := tco.WriteCSV(c.Writer, data.Report); err != nil {
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+// SaveValuation handles the HTTP POST request to enter what a vehicle is worth
+// on a date, then renders the report again.
+func (h *VehicleHandler) SaveValuation(c *gin.Context) {
+	vehicle := h.ownVehicle(c)
+	if vehicle == nil {
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", c.Request.PostFormValue("valuedOn"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, invalid valuation date.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	value, err := strconv.ParseFloat(c.Request.PostFormValue("value"), 64)
+	if err != nil || value < 0 {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request, invalid value.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	valuation := &models.VehicleValuation{VehicleID: vehicle.ID, Date: date, Value: value}
+	if err := h.DB.SaveVehicleValuation(valuation); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't save valuation.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.GetOwnershipCostReport(c)
+}
+
+// DeleteValuation handles the HTTP DELETE request to remove a valuation, then renders the report again.
+func (h *VehicleHandler) DeleteValuation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	valuation, err := h.DB.GetVehicleValuationByID(id)
+	var vehicle *models.Vehicle
+	if err == nil && valuation != nil {
+		vehicle, err = h.DB.GetVehicleByID(valuation.VehicleID)
+	}
+	if err != nil || vehicle == nil || vehicle.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Valuation not found.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if _, err := h.DB.DeleteVehicleValuation(valuation.ID); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete valuation.",
+		}
+		c.Header("HX-Reswap", "none")
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.GetOwnershipCostReport(c)
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// documentExpensesMonths is how far back the document form offers car expenses to link.
+const documentExpensesMonths = 13
+
+func (h *VehicleHandler) documentStatuses(userID uuid.UUID) ([]models.DocumentStatus, error) {
+	docs, err := h.DB.GetVehicleDocuments(userID)
+	if err != nil {
+		return nil, err
+	}
+	return models.DocumentStatuses(docs, time.Now()), nil
+}
+
+// DocumentFormData is the data for the create, renew and edit document forms.
+type DocumentFormData struct {
+	Document *models.VehicleDocument // Document is prefilled when editing or renewing, nil for a new one.
+	IsEdit   bool
+	Vehicles *models.VehicleSelect
+	Kinds    []models.DocumentKind
+	Expenses *[]models.CarExpense
+}
+
+// IsExpenseSelected reports whether the edited document is linked to the expense with the given id.
+func (d *DocumentFormData) IsExpenseSelected(id int) bool {
+	return d.IsEdit && d.Document.ExpenseID != nil && *d.Document.ExpenseID == id
+}
+
+// RemindDays returns the lead times to prefill, the defaults for a new document.
+func (d *DocumentFormData) RemindDays() string {
+	if d.Document != nil {
+		return d.Document.RemindDaysValue()
+	}
+	return models.VehicleDocument{RemindDays: models.DefaultRemindDays}.RemindDaysValue()
+}
+
+func (h *VehicleHandler) documentFormData(userID uuid.UUID, doc *models.VehicleDocument, isEdit bool) (*DocumentFormData, error) {
+	var selectedVehicle, expenseID *int
+	if doc != nil {
+		selectedVehicle = &doc.VehicleID
+		expenseID = doc.ExpenseID
+	}
+
+	vehicles, err := vehicleSelect(h.DB, userID, selectedVehicle)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := h.DB.GetCarExpensesSince(userID, time.Now().AddDate(0, -documentExpensesMonths, 0), expenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DocumentFormData{
+		Document: doc,
+		IsEdit:   isEdit,
+		Vehicles: vehicles,
+		Kinds:    models.DocumentKinds,
+		Expenses: expenses,
+	}, nil
+}
+
+// GetCreateDocumentForm renders the form for a new document. With a renew query
+// parameter it's prefilled as the renewal of that document, starting the day after it expires.
+func (h *VehicleHandler) GetCreateDocumentForm(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	var doc *models.VehicleDocument
+	if renew := c.Query("renew"); renew != "" {
+		id, err := strconv.Atoi(renew)
+		if err != nil {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "400: Bad Request. Couldn't get ID.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+
+		previous, err := h.DB.GetVehicleDocumentByID(id)
+		if err != nil || previous == nil || previous.CreatedBy != userID {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "404: Document not found.",
+			}
+			c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+			return
+		}
+
+		startsOn := previous.ExpiresOn.AddDate(0, 0, 1)
+		doc = &models.VehicleDocument{
+			VehicleID:  previous.VehicleID,
+			Kind:       previous.Kind,
+			StartsOn:   startsOn,
+			ExpiresOn:  startsOn.AddDate(0, 0, int(previous.ExpiresOn.Sub(previous.StartsOn).Hours()/24)),
+			RemindDays: previous.RemindDays,
+		}
+	}
+
+	formData, err := h.documentFormData(userID, doc, false)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.DocumentForm, formData)
+}
+
+func (h *VehicleHandler) parseDocumentForm(c *gin.Context, userID uuid.UUID) (*models.VehicleDocument, error) {
+	vehicleID, err := parseVehicleID(c, h.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if vehicleID == nil {
+		return nil, fmt.Errorf("choose a vehicle")
+	}
+
+	kind := models.DocumentKind(c.Request.PostFormValue("kind"))
+	if !kind.Valid() {
+		return nil, fmt.Errorf("invalid document kind")
+	}
+
+	reference := strings.TrimSpace(c.Request.PostFormValue("reference"))
+	if len(reference) > 100 {
+		return nil, fmt.Errorf("invalid reference")
+	}
+
+	startsOn, err := time.Parse("2006-01-02", c.Request.PostFormValue("startsOn"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date")
+	}
+
+	expiresOn, err := time.Parse("2006-01-02", c.Request.PostFormValue("expiresOn"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiry date")
+	}
+	if expiresOn.Before(startsOn) {
+		return nil, fmt.Errorf("the document expires before it starts")
+	}
+
+	remindDays, err := models.ParseRemindDays(c.Request.PostFormValue("remindDays"))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &models.VehicleDocument{
+		VehicleID:  *vehicleID,
+		Kind:       kind,
+		Reference:  reference,
+		StartsOn:   startsOn,
+		ExpiresOn:  expiresOn,
+		RemindDays: remindDays,
+		CreatedBy:  userID,
+	}
+
+	if value := c.Request.PostFormValue("expenseID"); value != "" {
+		expenseID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expense")
+		}
+
+		exp, err := h.DB.GetCarExpenseByID(expenseID)
+		if err != nil || exp == nil || exp.CreatedBy != userID {
+			return nil, fmt.Errorf("invalid expense")
+		}
+		doc.ExpenseID = &expenseID
+	}
+
+	return doc, nil
+}
+
+// documentSaved responds with the refreshed documents list, as saving one
+// can mark an earlier document of the same kind as renewed or move it in the list.
+func (h *VehicleHandler) documentSaved(c *gin.Context, status int, doc *models.VehicleDocument, title string) {
+	docs, err := h.documentStatuses(doc.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicle documents.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(status, utilities.Templates.Responses.SaveDocument, gin.H{
+		"Documents": docs,
+		"Modal": &models.ModalContent{
+			Title:   title,
+			Message: fmt.Sprintf("%s valid until %s", doc.Kind.Label(), doc.ExpiresOn.Format("02.01.2006")),
+		},
+	})
+}
+
+// CreateDocument handles the HTTP POST request to add a document, or a renewal, to a vehicle.
+func (h *VehicleHandler) CreateDocument(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	doc, err := h.parseDocumentForm(c, userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateVehicleDocument(doc); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create document.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.documentSaved(c, http.StatusCreated, doc, "Successful document creation.")
+}
+
+// ownDocument loads the document from the id path parameter and makes sure
+// it belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *VehicleHandler) ownDocument(c *gin.Context) *models.VehicleDocument {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	doc, err := h.DB.GetVehicleDocumentByID(id)
+	if err != nil || doc == nil || doc.CreatedBy != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Document not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return doc
+}
+
+func (h *VehicleHandler) GetEditDocumentForm(c *gin.Context) {
+	doc := h.ownDocument(c)
+	if doc == nil {
+		return
+	}
+
+	formData, err := h.documentFormData(doc.CreatedBy, doc, true)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Internal Server Error :(",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.DocumentForm, formData)
+}
+
+func (h *VehicleHandler) EditDocument(c *gin.Context) {
+	existing := h.ownDocument(c)
+	if existing == nil {
+		return
+	}
+
+	doc, err := h.parseDocumentForm(c, existing.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	doc.ID = existing.ID
+
+	if err := h.DB.EditVehicleDocument(doc); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update document.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.documentSaved(c, http.StatusOK, doc, "Successful document update.")
+}
+
+func (h *VehicleHandler) GetDeleteDocumentConfirm(c *gin.Context) {
+	doc := h.ownDocument(c)
+	if doc == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/vehicles/documents/%v", doc.ID)),
+		Target:   fmt.Sprintf("#doc-%v", doc.ID),
+		Message:  fmt.Sprintf("Please confirm if you want to delete the %s of %s.", doc.Kind.Label(), doc.Vehicle),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *VehicleHandler) DeleteDocument(c *gin.Context) {
+	doc := h.ownDocument(c)
+	if doc == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteVehicleDocument(doc.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete document.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	docs, err := h.documentStatuses(doc.CreatedBy)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicle documents.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Responses.SaveDocument, gin.H{
+		"Documents": docs,
+		"Modal": &models.ModalContent{
+			Title:   "Successfully deleted document!",
+			Message: fmt.Sprintf("%s of %s deleted!", doc.Kind.Label(), doc.Vehicle),
+		},
+	})
+}
+
+// GetRenewalCalendar renders the documents expiring in a year by month,
+// the current year unless a year query parameter is given.
+func (h *VehicleHandler) GetRenewalCalendar(c *gin.Context) {
+	now := time.Now()
+	year := now.Year()
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1900 || parsed > 9999 {
+			content := &models.ModalContent{
+				Title:   "Something went wrong!",
+				Message: "400: Bad Request, invalid year.",
+			}
+			c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+			return
+		}
+		year = parsed
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	docs, err := h.DB.GetVehicleDocuments(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching vehicle documents.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.RenewalCalendar, models.BuildRenewalCalendar(docs, year, now))
+}
@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/utilities"
+	"expenser/internal/webhook"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// deliveriesShown is how many of the latest deliveries the webhooks page lists.
+const deliveriesShown = 50
+
+type WebhookHandler struct {
+	DB *database.DB
+}
+
+func NewWebhookHandler(db *database.DB) *WebhookHandler {
+	return &WebhookHandler{
+		DB: db,
+	}
+}
+
+// WebhookEndpointFormData is the data for the create and edit webhook endpoint forms.
+type WebhookEndpointFormData struct {
+	Endpoint *models.WebhookEndpoint // Endpoint is nil for a new one.
+	Events   []models.WebhookEvent
+}
+
+// IsSubscribed reports whether the event is checked, all of them are for a new endpoint.
+func (d *WebhookEndpointFormData) IsSubscribed(event models.WebhookEvent) bool {
+	return d.Endpoint == nil || d.Endpoint.Subscribed(event)
+}
+
+// expenseEvent queues the webhooks of an expense event. A failure is only logged,
+// it doesn't fail saving the expense.
+func expenseEvent(db *database.DB, userID uuid.UUID, event models.WebhookEvent, exp *models.CategorisedExpense) {
+	if err := webhook.Enqueue(db, userID, event, webhook.Expense(exp)); err != nil {
+		log.Printf("webhooks: %s of %s expense %d: %v", event, exp.Tracker, exp.ID, err)
+	}
+}
+
+// houseExpenseEvent is expenseEvent for a house expense loaded from the database,
+// nothing is queued when it wasn't found.
+func houseExpenseEvent(db *database.DB, userID uuid.UUID, event models.WebhookEvent, exp *models.HouseExpense) {
+	if exp == nil {
+		return
+	}
+	expenseEvent(db, userID, event, &models.CategorisedExpense{
+		ID:      exp.ID,
+		Tracker: models.TrackerHouse,
+		TypeID:  exp.UtilityTypeID,
+		Type:    exp.UtilityType,
+		Amount:  exp.Amount,
+		Date:    exp.ExpenseDate,
+		Notes:   exp.Notes,
+	})
+}
+
+// carExpenseEvent is houseExpenseEvent for car expenses.
+func carExpenseEvent(db *database.DB, userID uuid.UUID, event models.WebhookEvent, exp *models.CarExpense) {
+	if exp == nil {
+		return
+	}
+	expenseEvent(db, userID, event, &models.CategorisedExpense{
+		ID:      exp.ID,
+		Tracker: models.TrackerCar,
+		TypeID:  exp.ExpenseTypeID,
+		Type:    exp.Type,
+		Amount:  exp.Amount,
+		Date:    exp.Date,
+		Notes:   exp.Notes,
+	})
+}
+
+func (h *WebhookHandler) webhooks(userID uuid.UUID) (*models.WebhooksData, error) {
+	endpoints, err := h.DB.GetWebhookEndpoints(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := h.DB.GetWebhookDeliveries(userID, deliveriesShown)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhooksData{
+		Endpoints:  endpoints,
+		Deliveries: deliveries,
+	}, nil
+}
+
+// GetWebhooks renders the webhooks page with the endpoints and the delivery log.
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	userIDstr, exists := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	data, err := h.webhooks(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching webhooks.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	isHtmxRequest := c.Request.Header.Get("HX-Request") == "true"
+
+	if isHtmxRequest {
+		c.HTML(http.StatusOK, utilities.Templates.Pages.Webhooks, data)
+		return
+	} else {
+		rl := &models.RootLayout{
+			TemplateName:    utilities.Templates.Pages.Webhooks,
+			TemplateContent: data,
+			HeaderOpts: &models.HeaderOptions{
+				IsLoggedIn: exists,
+			},
+		}
+		c.HTML(http.StatusOK, utilities.Templates.Root, rl)
+	}
+}
+
+// GetDeliveries renders the delivery log, for refreshing it.
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	deliveries, err := h.DB.GetWebhookDeliveries(userID, deliveriesShown)
+	if err != nil {
+		c.Header("HX-Reswap", "none")
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching webhook deliveries.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(http.StatusOK, utilities.Templates.Components.WebhookDeliveries, deliveries)
+}
+
+// webhooksSaved responds with the refreshed endpoints and delivery log.
+func (h *WebhookHandler) webhooksSaved(c *gin.Context, status int, userID uuid.UUID, modal *models.ModalContent) {
+	data, err := h.webhooks(userID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Error fetching webhooks.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	c.HTML(status, utilities.Templates.Responses.SaveWebhook, gin.H{
+		"Webhooks": data,
+		"Modal":    modal,
+	})
+}
+
+// INFO: ENDPOINTS
+
+func (h *WebhookHandler) endpointForm(c *gin.Context, e *models.WebhookEndpoint) {
+	c.HTML(http.StatusOK, utilities.Templates.Components.WebhookEndpointForm, &WebhookEndpointFormData{
+		Endpoint: e,
+		Events:   models.WebhookEvents,
+	})
+}
+
+func (h *WebhookHandler) GetCreateEndpointForm(c *gin.Context) {
+	h.endpointForm(c, nil)
+}
+
+// newWebhookSecret returns a random signing secret for an endpoint added without one.
+func newWebhookSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// parseEndpointForm reads a webhook endpoint. An empty secret keeps the one of
+// existing when editing, and is generated for a new endpoint.
+func parseEndpointForm(c *gin.Context, userID uuid.UUID, existing *models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	e := &models.WebhookEndpoint{
+		UserID: userID,
+		Name:   strings.TrimSpace(c.Request.PostFormValue("name")),
+		Secret: strings.TrimSpace(c.Request.PostFormValue("secret")),
+	}
+
+	if e.Name == "" || len(e.Name) > 100 {
+		return nil, fmt.Errorf("invalid name")
+	}
+
+	u, err := parseHTTPURL(c.Request.PostFormValue("url"))
+	if err != nil {
+		return nil, err
+	}
+	e.URL = u
+
+	if e.Secret == "" {
+		e.Secret = newWebhookSecret()
+		if existing != nil {
+			e.Secret = existing.Secret
+		}
+	}
+
+	c.Request.ParseForm()
+	for _, value := range c.Request.PostForm["events"] {
+		event := models.WebhookEvent(value)
+		if !event.Valid() {
+			return nil, fmt.Errorf("invalid event")
+		}
+		e.Events = append(e.Events, event)
+	}
+	if len(e.Events) == 0 {
+		return nil, fmt.Errorf("pick at least one event")
+	}
+
+	return e, nil
+}
+
+// CreateEndpoint handles the HTTP POST request to register a webhook endpoint.
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	e, err := parseEndpointForm(c, userID, nil)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if err := h.DB.CreateWebhookEndpoint(e); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't create webhook endpoint.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.webhooksSaved(c, http.StatusCreated, userID, &models.ModalContent{
+		Title:   "Successful webhook endpoint creation.",
+		Message: fmt.Sprintf("Webhook %s added, its payloads are signed with the secret %s.", e.Name, e.Secret),
+		Warning: "Keep the secret, it isn't shown again.",
+	})
+}
+
+// ownEndpoint loads the webhook endpoint from the id path parameter and makes sure
+// it belongs to the current user. It renders the error itself and returns nil on failure.
+func (h *WebhookHandler) ownEndpoint(c *gin.Context) *models.WebhookEndpoint {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	e, err := h.DB.GetWebhookEndpointByID(id)
+	if err != nil || e == nil || e.UserID != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Webhook endpoint not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return nil
+	}
+
+	return e
+}
+
+func (h *WebhookHandler) GetEditEndpointForm(c *gin.Context) {
+	e := h.ownEndpoint(c)
+	if e == nil {
+		return
+	}
+
+	h.endpointForm(c, e)
+}
+
+func (h *WebhookHandler) EditEndpoint(c *gin.Context) {
+	existing := h.ownEndpoint(c)
+	if existing == nil {
+		return
+	}
+
+	e, err := parseEndpointForm(c, existing.UserID, existing)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: fmt.Sprintf("400: Bad Request, %v.", err),
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+	e.ID = existing.ID
+
+	if err := h.DB.EditWebhookEndpoint(e); err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't update webhook endpoint.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.webhooksSaved(c, http.StatusOK, e.UserID, &models.ModalContent{
+		Title:   "Successful webhook endpoint update.",
+		Message: fmt.Sprintf("Webhook %s updated!", e.Name),
+	})
+}
+
+func (h *WebhookHandler) GetDeleteEndpointConfirm(c *gin.Context) {
+	e := h.ownEndpoint(c)
+	if e == nil {
+		return
+	}
+
+	content := &models.ModalConfirmContent{
+		Title:    "Are you sure you want to delete this?",
+		Method:   "DELETE",
+		Endpoint: template.URL(fmt.Sprintf("/webhooks/endpoints/%v", e.ID)),
+		Target:   fmt.Sprintf("#endpoint-%v", e.ID),
+		Message:  fmt.Sprintf("Please confirm if you want to delete the webhook %s and its delivery log.", e.Name),
+	}
+	c.HTML(http.StatusOK, utilities.Templates.Components.ModalConfirm, content)
+}
+
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	e := h.ownEndpoint(c)
+	if e == nil {
+		return
+	}
+
+	res, err := h.DB.DeleteWebhookEndpoint(e.ID)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Couldn't delete webhook endpoint.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	if !res {
+		c.HTML(http.StatusNoContent, "", gin.H{})
+		return
+	}
+
+	h.webhooksSaved(c, http.StatusOK, e.UserID, &models.ModalContent{
+		Title:   "Successfully deleted webhook endpoint!",
+		Message: fmt.Sprintf("Webhook %s deleted!", e.Name),
+	})
+}
+
+// INFO: DELIVERIES
+
+// Redeliver handles the HTTP POST request to queue a delivery again. It is posted
+// as a new delivery by the worker, the log shows its outcome once refreshed.
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "400: Bad Request. Couldn't get ID.",
+		}
+		c.HTML(http.StatusBadRequest, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	userIDstr, _ := c.Get("user_id")
+	userID, _ := userIDstr.(uuid.UUID)
+
+	d, err := h.DB.GetWebhookDeliveryByID(id)
+	if err != nil || d == nil || d.UserID != userID {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "404: Webhook delivery not found.",
+		}
+		c.HTML(http.StatusNotFound, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	again, err := webhook.Redeliver(h.DB, d)
+	if err != nil {
+		content := &models.ModalContent{
+			Title:   "Something went wrong!",
+			Message: "500: Couldn't queue the redelivery.",
+		}
+		c.HTML(http.StatusInternalServerError, utilities.Templates.Components.ModalError, content)
+		return
+	}
+
+	h.webhooksSaved(c, http.StatusCreated, userID, &models.ModalContent{
+		Title:   "Successfully queued redelivery!",
+		Message: fmt.Sprintf("Delivery %d of %s to %s is posted again as delivery %d.", d.ID, d.Event, d.Endpoint, again.ID),
+	})
+}
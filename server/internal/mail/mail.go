@@ -0,0 +1,155 @@
+// Package mail sends emails over SMTP, with a plain text and an HTML part
+// rendered from the templates of the email.
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"expenser/internal/config"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Message is an email to send.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport delivers messages.
+type Transport interface {
+	Send(msg *Message) error
+}
+
+// SMTPTransport delivers messages to an SMTP server. It upgrades to TLS when the
+// server offers it and logs in only when it has a username.
+type SMTPTransport struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPTransport(cfg config.SMTP) *SMTPTransport {
+	return &SMTPTransport{
+		Addr:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Host:     cfg.Host,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		From:     cfg.From,
+	}
+}
+
+func (t *SMTPTransport) Send(msg *Message) error {
+	from, err := mail.ParseAddress(t.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	body, err := msg.bytes(from, to)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if t.Username != "" {
+		auth = smtp.PlainAuth("", t.Username, t.Password, t.Host)
+	}
+
+	if err := smtp.SendMail(t.Addr, auth, from.Address, []string{to.Address}, body); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// bytes builds the message as a multipart/alternative MIME email.
+func (msg *Message) bytes(from, to *mail.Address) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Every email has a template file of each kind: name.txt defines "name-subject"
+// and "name-text", name.html defines "name-html".
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/*.html"))
+)
+
+// Render renders the email with the given name for the recipient.
+func Render(name, to string, data any) (*Message, error) {
+	msg := &Message{To: to}
+
+	var subject, text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&subject, name+"-subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	if err := textTemplates.ExecuteTemplate(&text, name+"-text", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+"-html", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.Text = strings.TrimSpace(text.String()) + "\n"
+	msg.HTML = html.String()
+	return msg, nil
+}
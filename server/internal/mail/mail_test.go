@@ -0,0 +1,113 @@
+package mail
+
+import (
+	"bufio"
+	"expenser/internal/config"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTP is a minimal SMTP server standing in for MailHog. It accepts one
+// message and hands over its envelope and data.
+type fakeSMTP struct {
+	listener net.Listener
+	rcpt     chan string
+	data     chan string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &fakeSMTP{listener: listener, rcpt: make(chan string, 1), data: make(chan string, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.rcpt <- strings.TrimSpace(line[len("RCPT TO:"):])
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data <- data.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPTransport(t *testing.T) {
+	server := newFakeSMTP(t)
+	defer server.listener.Close()
+
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	transport := NewSMTPTransport(config.SMTP{Host: host, Port: port, From: "Expenser <expenser@localhost>"})
+
+	msg, err := Render("test", "Иван <ivan@example.com>", struct{ URL string }{"http://localhost:8080"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Expenser test email", msg.Subject)
+	assert.NoError(t, transport.Send(msg))
+
+	assert.Equal(t, "<ivan@example.com>", <-server.rcpt)
+
+	data := <-server.data
+	assert.Contains(t, data, "From: \"Expenser\" <expenser@localhost>\r\n")
+	assert.Contains(t, data, "To: =?utf-8?q?")
+	assert.Contains(t, data, "Subject: Expenser test email\r\n")
+	assert.Contains(t, data, "multipart/alternative")
+	assert.Contains(t, data, "Content-Type: text/plain; charset=utf-8")
+	assert.Contains(t, data, "Content-Type: text/html; charset=utf-8")
+
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(data)))
+	assert.NoError(t, err)
+	assert.Contains(t, string(decoded), `<a href="http://localhost:8080" style="color: #4e79a7">Expenser</a>`)
+}
+
+func TestSendInvalidAddress(t *testing.T) {
+	transport := NewSMTPTransport(config.SMTP{Host: "localhost", Port: "1025", From: "Expenser <expenser@localhost>"})
+	err := transport.Send(&Message{To: "not an address", Subject: "Hi", Text: "Hi"})
+	assert.ErrorContains(t, err, "invalid recipient address")
+}
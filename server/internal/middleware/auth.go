@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	database "expenser/internal/db"
 	"expenser/internal/models"
 	"expenser/internal/services"
 	"expenser/internal/utilities"
+	"log"
 	"net/http"
 	"time"
 
@@ -13,15 +15,43 @@ import (
 // AuthMiddleware handles JWT token operations
 type AuthMiddleware struct {
 	authService *services.AuthService
+	db          *database.DB
 }
 
 // NewAuthMiddleware creates a new Auth middleware instance
-func NewAuthMiddleware(as *services.AuthService) *AuthMiddleware {
+func NewAuthMiddleware(as *services.AuthService, db *database.DB) *AuthMiddleware {
 	return &AuthMiddleware{
 		authService: as,
+		db:          db,
 	}
 }
 
+// sessionTouchInterval is how often the last seen time of a session in use is updated.
+const sessionTouchInterval = time.Minute
+
+// activeSession reports whether the session of the token is still logged in,
+// not revoked from another device or by logging out. Tokens without a session
+// are refused. It records the session being seen from the request.
+func (am *AuthMiddleware) activeSession(token *services.Token, c *gin.Context) bool {
+	session, err := am.db.GetSession(token.Claims.SessionID())
+	if err != nil || session == nil || session.UserID != token.Claims.UserID {
+		return false
+	}
+
+	now := time.Now()
+	if !session.Active(now) {
+		return false
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval || session.IP != c.ClientIP() {
+		if err := am.db.TouchSession(session.ID, c.ClientIP(), now); err != nil {
+			log.Printf("session %s: %v", session.ID, err)
+		}
+	}
+
+	return true
+}
+
 func (am *AuthMiddleware) extractTokenFromCookie(c *gin.Context) (string, error) {
 	token, err := c.Cookie("auth_token")
 	if err != nil {
@@ -30,7 +60,14 @@ func (am *AuthMiddleware) extractTokenFromCookie(c *gin.Context) (string, error)
 	return token, nil
 }
 
+// redirectToLogin sends the browser to the login page, which shows the ways of
+// logging in the server allows.
 func (am *AuthMiddleware) redirectToLogin(c *gin.Context) {
+	if c.Request.Header.Get("HX-Request") != "true" {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
 	c.Header("HX-Redirect", "/login")
 	rl := &models.RootLayout{
 		TemplateName: utilities.Templates.Pages.Login,
@@ -53,7 +90,7 @@ func (am *AuthMiddleware) AuthMiddleware() gin.HandlerFunc {
 		}
 
 		token, err := am.authService.ValidateToken(tokenString)
-		if err != nil {
+		if err != nil || !am.activeSession(token, c) {
 			am.redirectToLogin(c)
 			c.Abort()
 			return
@@ -68,6 +105,7 @@ func (am *AuthMiddleware) AuthMiddleware() gin.HandlerFunc {
 
 		// Store user information in context
 		c.Set("user_id", token.Claims.UserID)
+		c.Set("session_id", token.Claims.SessionID())
 		c.Set("username", token.Claims.Username)
 		c.Set("email", token.Claims.Email)
 		c.Set("user_claims", token.Claims)
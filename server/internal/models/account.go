@@ -0,0 +1,126 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountKind is the kind of money holder an account represents.
+type AccountKind string
+
+const (
+	AccountCash        AccountKind = "cash"
+	AccountDebitCard   AccountKind = "debit_card"
+	AccountCreditCard  AccountKind = "credit_card"
+	AccountBankAccount AccountKind = "bank_account"
+)
+
+// AccountKinds lists every supported kind in the order shown in forms.
+var AccountKinds = []AccountKind{AccountCash, AccountDebitCard, AccountCreditCard, AccountBankAccount}
+
+// Label returns a human readable name for the kind.
+func (k AccountKind) Label() string {
+	switch k {
+	case AccountCash:
+		return "Cash"
+	case AccountDebitCard:
+		return "Debit card"
+	case AccountCreditCard:
+		return "Credit card"
+	case AccountBankAccount:
+		return "Bank account"
+	}
+	return string(k)
+}
+
+// Valid reports whether k is one of the supported kinds.
+func (k AccountKind) Valid() bool {
+	for _, kind := range AccountKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Account is a user defined card, cash envelope or bank account
+// that expenses are paid from and incomes are paid into.
+type Account struct {
+	ID             int
+	Name           string
+	Kind           AccountKind
+	OpeningBalance float64
+	Balance        float64 // Balance is the current book balance, filled in by balance queries.
+	CreatedBy      uuid.UUID
+	CreatedAt      time.Time
+}
+
+// AccountSelect is the data for the account dropdown shared by every expense form.
+type AccountSelect struct {
+	Accounts *[]Account
+	Selected *int
+}
+
+// IsSelected reports whether the account with the given ID is the selected one.
+func (as *AccountSelect) IsSelected(id int) bool {
+	return as.Selected != nil && *as.Selected == id
+}
+
+// Transfer moves money from one account to another.
+type Transfer struct {
+	ID            int
+	FromAccountID int
+	ToAccountID   int
+	FromAccount   string
+	ToAccount     string
+	Amount        float64
+	Date          time.Time
+	Notes         string
+	CreatedBy     uuid.UUID
+	CreatedAt     time.Time
+}
+
+// StatementEntry is a single booked movement on an account statement.
+// Amount is positive for money coming in and negative for money going out.
+type StatementEntry struct {
+	Date        time.Time
+	Source      string // Source is where the entry comes from, e.g. "House", "Car", "Income" or "Transfer".
+	Description string
+	Amount      float64
+	Balance     float64
+}
+
+// AccountStatement lists the movements of an account for a period
+// together with its balance before and after.
+type AccountStatement struct {
+	Account        *Account
+	From           time.Time
+	To             time.Time
+	OpeningBalance float64
+	ClosingBalance float64
+	Entries        []StatementEntry
+	Reconciliation *Reconciliation // Reconciliation is the latest bank reconciliation, if any.
+}
+
+// Reconciliation records the balance on a bank statement against
+// the balance computed from the recorded entries at the same date.
+type Reconciliation struct {
+	ID               int
+	AccountID        int
+	StatementDate    time.Time
+	StatementBalance float64
+	BookBalance      float64
+	CreatedAt        time.Time
+}
+
+// Difference is the amount the bank statement differs from the books.
+func (r *Reconciliation) Difference() float64 {
+	return r.StatementBalance - r.BookBalance
+}
+
+// Reconciled reports whether the books match the bank statement to the cent.
+func (r *Reconciliation) Reconciled() bool {
+	d := r.Difference()
+	return d < 0.005 && d > -0.005
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpenseAnomaly marks a house or car expense that is an outlier against the
+// user's history of the same expense type.
+type ExpenseAnomaly struct {
+	ID        int
+	Tracker   Tracker
+	ExpenseID int
+	Type      string
+	Date      time.Time
+	Amount    float64
+	Expected  float64 // Expected is the typical amount of the type the expense was compared with.
+	Score     float64 // Score is the robust z-score of the expense, negative when it is unusually low.
+	Seasonal  bool    // Seasonal is set when the expense was compared with the same month of earlier years only.
+	BasedOn   int     // BasedOn is the number of past expenses compared with.
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+}
+
+// IsHigh reports whether the expense is unusually high rather than low.
+func (a ExpenseAnomaly) IsHigh() bool {
+	return a.Score > 0
+}
+
+// Ratio returns the amount as a multiple of the expected amount.
+func (a ExpenseAnomaly) Ratio() float64 {
+	if a.Expected == 0 {
+		return 0
+	}
+	return a.Amount / a.Expected
+}
+
+// Warning returns the message shown when the expense is created.
+func (a ExpenseAnomaly) Warning() string {
+	level := "low"
+	if a.IsHigh() {
+		level = "high"
+	}
+
+	season := ""
+	if a.Seasonal {
+		season = " for " + a.Date.Month().String()
+	}
+
+	return fmt.Sprintf("This looks unusually %s: %.2f BGN is %.1f times the %.2f BGN usual%s, going by %d past expenses.",
+		level, a.Amount, a.Ratio(), a.Expected, season, a.BasedOn)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// BankTransaction is a booked debit read from a bank statement file.
+type BankTransaction struct {
+	Ref         string // Ref is the bank reference of the transaction, used to skip it on later imports.
+	Date        time.Time
+	Amount      float64 // Amount is always positive, only debits are imported.
+	Currency    string
+	Description string
+}
+
+// ImportRow is a bank transaction in the import preview together with
+// where it is going to be booked.
+type ImportRow struct {
+	BankTransaction
+	Tracker       Tracker // Tracker is empty when no rule matched and the row is skipped by default.
+	ExpenseTypeID int
+	Tags          string
+	VehicleID     *int // VehicleID is the vehicle of car rows, set by the matching rule.
+	Vehicle       string
+	Duplicate     bool // Duplicate is set when the bank reference was already imported.
+	ExpenseID     int  // ExpenseID is the expense the row was booked as, 0 until imported.
+}
+
+// Target returns the row's tracker and type in the "tracker:typeID" form, empty when unassigned.
+func (r *ImportRow) Target() string {
+	if r.Tracker == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", r.Tracker, r.ExpenseTypeID)
+}
+
+// ImportPreview is the parsed statement shown to the user before anything is saved.
+type ImportPreview struct {
+	FileName   string
+	Format     string
+	Rows       []ImportRow
+	Duplicates int
+	HouseTypes *[]HomeUtilityType
+	CarTypes   *[]CarExpenseType
+	Account    *Account // Account is the account the debits are booked on, nil when not tracked.
+}
+
+// ImportResult summarizes a committed import.
+type ImportResult struct {
+	Imported  int
+	Skipped   int
+	Anomalies []ExpenseAnomaly // Anomalies are the imported expenses that look unusual.
+	Modal     *ModalContent
+}
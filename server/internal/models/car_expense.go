@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,32 @@ type CarExpense struct {
 	Notes         string    `form:"notes"`
 	CreatedAt     time.Time `form:"createdAt"`
 	CreatedBy     uuid.UUID
+	AccountID     *int     // AccountID is the account the expense was paid from, nil when not tracked.
+	Tags          string   // Tags are comma separated, assigned by rules.
+	VehicleID     *int     // VehicleID is the vehicle the expense is for, nil when not tracked.
+	Odometer      *int     // Odometer is the reading in km when the expense was made, nil when not entered.
+	FuelLitres    *float64 // FuelLitres is how much fuel was filled up, nil when not a fill-up.
+}
+
+// OdometerValue returns the odometer reading formatted for a form input.
+func (e CarExpense) OdometerValue() string {
+	if e.Odometer == nil {
+		return ""
+	}
+	return fmt.Sprint(*e.Odometer)
+}
+
+// FuelLitresValue returns the litres formatted for a form input.
+func (e CarExpense) FuelLitresValue() string {
+	if e.FuelLitres == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", *e.FuelLitres)
+}
+
+// TagList returns the expense's tags.
+func (e CarExpense) TagList() []string {
+	return SplitTags(e.Tags)
 }
 
 type CarExpenseType struct {
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChargingExpenseType is the car expense type charging sessions roll up into.
+const ChargingExpenseType = "Charging"
+
+// ChargingLocation is where an electric vehicle was charged.
+type ChargingLocation string
+
+const (
+	ChargingHome   ChargingLocation = "home"
+	ChargingPublic ChargingLocation = "public"
+)
+
+// ChargingLocations lists the locations in the order they are offered in forms.
+var ChargingLocations = []ChargingLocation{ChargingHome, ChargingPublic}
+
+// Label returns the human readable location.
+func (l ChargingLocation) Label() string {
+	switch l {
+	case ChargingHome:
+		return "Home"
+	case ChargingPublic:
+		return "Public"
+	}
+	return string(l)
+}
+
+// Valid reports whether the location is a known one.
+func (l ChargingLocation) Valid() bool {
+	return l == ChargingHome || l == ChargingPublic
+}
+
+// ChargingSession is one charge of an electric vehicle.
+type ChargingSession struct {
+	ID              int
+	VehicleID       int
+	Vehicle         string // Vehicle is the vehicle's name.
+	Date            time.Time
+	KWh             float64
+	DurationMinutes *int // DurationMinutes is nil when not entered.
+	Location        ChargingLocation
+	Tariff          string // Tariff is the name of the tariff or charging network, like "Night" or "Eldrive".
+	Cost            float64
+	Odometer        *int // Odometer is the reading in km when charging, nil when not entered.
+	OnHouseBill     bool // OnHouseBill is set for home sessions already paid with the house electricity bill.
+	ExpenseID       *int // ExpenseID is the car expense the session rolls up into, nil when on the house bill.
+	HomeExpenseID   *int // HomeExpenseID is the electricity bill that paid the session, when known.
+	AccountID       *int // AccountID is the account the car expense is paid from, nil for none.
+	CreatedBy       uuid.UUID
+	CreatedAt       time.Time
+}
+
+// PricePerKWh returns what a kWh cost in the session.
+func (s ChargingSession) PricePerKWh() float64 {
+	return s.Cost / s.KWh
+}
+
+// Duration returns how long the session took for display, or "-" when not entered.
+func (s ChargingSession) Duration() string {
+	if s.DurationMinutes == nil {
+		return "-"
+	}
+
+	hours, minutes := *s.DurationMinutes/60, *s.DurationMinutes%60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", hours, minutes)
+}
+
+// DurationValue returns the duration in minutes formatted for a form input.
+func (s ChargingSession) DurationValue() string {
+	if s.DurationMinutes == nil {
+		return ""
+	}
+	return fmt.Sprint(*s.DurationMinutes)
+}
+
+// OdometerValue returns the odometer reading formatted for a form input.
+func (s ChargingSession) OdometerValue() string {
+	if s.Odometer == nil {
+		return ""
+	}
+	return fmt.Sprint(*s.Odometer)
+}
+
+// ExpenseNotes returns the notes of the car expense the session rolls up into.
+func (s ChargingSession) ExpenseNotes() string {
+	parts := []string{fmt.Sprintf("%.2f kWh", s.KWh), strings.ToLower(s.Location.Label())}
+	if s.Tariff != "" {
+		parts = append(parts, s.Tariff)
+	}
+	return "Charging " + strings.Join(parts, ", ")
+}
+
+// ChargingStats sums up the charging of a vehicle over a period.
+type ChargingStats struct {
+	Vehicle       string
+	Sessions      int
+	KWh           float64
+	HomeKWh       float64
+	Cost          float64
+	HouseBillCost float64 // HouseBillCost is the part of Cost paid with the house electricity bill.
+	Km            int     // Km is the distance driven in the period, 0 when the odometer readings don't tell.
+}
+
+// HomeShare returns the share of the energy charged at home in percent.
+func (s ChargingStats) HomeShare() float64 {
+	if s.KWh == 0 {
+		return 0
+	}
+	return s.HomeKWh / s.KWh * 100
+}
+
+// KWhPer100Km returns the consumption for display, or "-" when the distance is unknown.
+func (s ChargingStats) KWhPer100Km() string {
+	if s.Km == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", s.KWh/float64(s.Km)*100)
+}
+
+// CostPerKm returns the charging cost per km for display, or "-" when the distance is unknown.
+func (s ChargingStats) CostPerKm() string {
+	if s.Km == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", s.Cost/float64(s.Km))
+}
+
+// SummarizeCharging sums up the sessions of a vehicle over a period in which it drove km.
+func SummarizeCharging(vehicle string, sessions []ChargingSession, km int) ChargingStats {
+	stats := ChargingStats{Vehicle: vehicle, Km: km}
+	for _, session := range sessions {
+		stats.Sessions++
+		stats.KWh += session.KWh
+		stats.Cost += session.Cost
+		if session.Location == ChargingHome {
+			stats.HomeKWh += session.KWh
+		}
+		if session.OnHouseBill {
+			stats.HouseBillCost += session.Cost
+		}
+	}
+	return stats
+}
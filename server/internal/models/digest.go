@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// DigestItem is something coming up in the month a digest is sent in.
+type DigestItem struct {
+	Title  string
+	Detail string
+}
+
+// Digest is the monthly email summarising a user's previous month: the house and
+// car totals, the top categories, income against expenses and what's coming up.
+type Digest struct {
+	Username      string
+	Month         time.Time // Month is the first day of the summarised month.
+	HouseTotal    float64
+	CarTotal      float64
+	Total         float64
+	Count         int
+	TopCategories []StatementCategory
+	Income        float64
+	Net           float64 // Net is the income left after the month's expenses, negative when overspent.
+	Upcoming      []DigestItem
+	Link          string // Link is the app's address.
+}
+
+// Overspent reports whether the month's expenses were higher than its income.
+func (d Digest) Overspent() bool {
+	return d.Net < 0
+}
+
+// BudgetStatus describes the month's income against its expenses.
+func (d Digest) BudgetStatus() string {
+	switch {
+	case d.Income == 0:
+		return fmt.Sprintf("No income was recorded, the expenses were %.2f BGN.", d.Total)
+	case d.Overspent():
+		return fmt.Sprintf("Expenses were %.2f BGN over the income of %.2f BGN.", -d.Net, d.Income)
+	}
+	return fmt.Sprintf("%.2f BGN of the income of %.2f BGN was left, %.0f%% saved.", d.Net, d.Income, d.Net/d.Income*100)
+}
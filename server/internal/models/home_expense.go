@@ -15,6 +15,13 @@ type HouseExpense struct {
 	Notes         string    `form:"notes"`
 	CreatedAt     time.Time `form:"createdAt"`
 	CreatedBy     uuid.UUID
+	AccountID     *int   // AccountID is the account the expense was paid from, nil when not tracked.
+	Tags          string // Tags are comma separated, assigned by rules.
+}
+
+// TagList returns the expense's tags.
+func (e HouseExpense) TagList() []string {
+	return SplitTags(e.Tags)
 }
 
 type HomeUtilityType struct {
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Income struct {
+	ID           int       `form:"id"`
+	IncomeTypeID int       `form:"typeID"`
+	Type         string    `form:"type" binding:"required"`
+	Amount       float64   `form:"amount" binding:"required"`
+	Date         time.Time `form:"date" binding:"required"`
+	Notes        string    `form:"notes"`
+	CreatedAt    time.Time `form:"createdAt"`
+	CreatedBy    uuid.UUID
+	AccountID    *int // AccountID is the account the income was paid into, nil when not tracked.
+}
+
+type IncomeType struct {
+	ID   int
+	Name string
+}
+
+// IncomeResponse is the data structure returned to the client
+// after an income has been created, updated or deleted.
+// It includes the affected income and updated summary data.
+type IncomeResponse struct {
+	Income        *Income         // Income is the created or updated income record.
+	MonthlyIncome *MonthlyExpense // MonthlyIncome provides the updated income total for the current month.
+	HighestIncome *HighestExpense // HighestIncome provides the updated largest income source for the current month.
+	Modal         *ModalContent
+}
+
+// CashFlowMonth summarizes the money coming in and going out for one month.
+// Expenses combines house and car expenses. Balance is the running balance
+// at the end of the month, including everything recorded before the period.
+type CashFlowMonth struct {
+	Month       time.Month
+	Income      float64
+	Expenses    float64
+	Net         float64
+	SavingsRate float64 // SavingsRate is Net as a percentage of Income, zero without income.
+	Balance     float64
+}
+
+// CashFlow is the cash-flow overview for a single year.
+type CashFlow struct {
+	Year           int
+	OpeningBalance float64
+	Months         []CashFlowMonth
+	TotalIncome    float64
+	TotalExpenses  float64
+	TotalNet       float64
+	SavingsRate    float64
+}
+
+// MonthBalance is a user's income against their combined house and car expenses
+// of the month starting on Month.
+type MonthBalance struct {
+	UserID   uuid.UUID
+	Month    time.Time
+	Income   float64
+	Expenses float64
+}
+
+// Overspent reports whether the expenses went over the income. Without any
+// income recorded there is nothing to go over.
+func (b MonthBalance) Overspent() bool {
+	return b.Income > 0 && b.Expenses > b.Income
+}
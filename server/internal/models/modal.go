@@ -5,6 +5,7 @@ import "html/template"
 type ModalContent struct {
 	Title   string
 	Message string
+	Warning string // Warning is shown below the message of a success modal, which then stays open until closed.
 }
 
 type ModalConfirmContent struct {
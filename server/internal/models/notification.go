@@ -0,0 +1,160 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationKind is the event a notification is about.
+type NotificationKind string
+
+const (
+	NotificationServiceDue     NotificationKind = "service_due"
+	NotificationDocumentExpiry NotificationKind = "document_expiry"
+	NotificationBillDue        NotificationKind = "bill_due"
+	NotificationBudgetExceeded NotificationKind = "budget_exceeded"
+)
+
+// NotificationKinds are the events a push channel can be subscribed to, in the
+// order they are offered.
+var NotificationKinds = []NotificationKind{
+	NotificationBudgetExceeded,
+	NotificationBillDue,
+	NotificationDocumentExpiry,
+	NotificationServiceDue,
+}
+
+// Label returns the event for display.
+func (k NotificationKind) Label() string {
+	switch k {
+	case NotificationServiceDue:
+		return "Service due"
+	case NotificationDocumentExpiry:
+		return "Document expiring"
+	case NotificationBillDue:
+		return "Bill due"
+	case NotificationBudgetExceeded:
+		return "Budget exceeded"
+	}
+	return string(k)
+}
+
+// Valid reports whether the kind is a known event.
+func (k NotificationKind) Valid() bool {
+	for _, kind := range NotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is an in-app message for a user, like a service reminder.
+type Notification struct {
+	ID        int
+	UserID    uuid.UUID
+	Kind      NotificationKind
+	Key       string // Key identifies the event, the same event is only notified once.
+	Title     string
+	Message   string
+	Link      string // Link is the page the notification is about, empty when none.
+	CreatedAt time.Time
+	ReadAt    *time.Time
+}
+
+// IsRead reports whether the user has seen the notification.
+func (n Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+// NotificationSettings are what a user gets sent outside the app, and where to.
+type NotificationSettings struct {
+	UserID             uuid.UUID
+	Email              string `form:"email"`              // Email overrides the address of the account when not empty.
+	AccountEmail       string `form:"-"`                  // AccountEmail is the verified address of the account, if any.
+	EmailNotifications bool   `form:"emailNotifications"` // EmailNotifications emails every new notification.
+	MonthlyDigest      bool   `form:"monthlyDigest"`      // MonthlyDigest emails a summary of the previous month.
+	LastDigestOn       *time.Time
+}
+
+// Address returns where the user is emailed: the address of the settings,
+// otherwise the one of the account.
+func (s *NotificationSettings) Address() string {
+	if s.Email != "" {
+		return s.Email
+	}
+	return s.AccountEmail
+}
+
+// ChannelKind is the service a push channel delivers notifications through.
+type ChannelKind string
+
+const (
+	ChannelWebhook  ChannelKind = "webhook"
+	ChannelNtfy     ChannelKind = "ntfy"
+	ChannelTelegram ChannelKind = "telegram"
+)
+
+// ChannelKinds are the push services, in the order they are offered.
+var ChannelKinds = []ChannelKind{ChannelWebhook, ChannelNtfy, ChannelTelegram}
+
+// Label returns the service for display.
+func (k ChannelKind) Label() string {
+	switch k {
+	case ChannelWebhook:
+		return "Webhook"
+	case ChannelNtfy:
+		return "ntfy"
+	case ChannelTelegram:
+		return "Telegram"
+	}
+	return string(k)
+}
+
+// Valid reports whether the kind is a known service.
+func (k ChannelKind) Valid() bool {
+	for _, kind := range ChannelKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationChannel is where a user gets pushed the notifications of the events
+// they subscribed it to. What its fields hold depends on the kind:
+//   - webhook: URL is posted a JSON payload signed with Secret.
+//   - ntfy: URL is the topic, Secret the optional access token.
+//   - telegram: Secret is the bot token, ChatID the chat the bot writes to.
+type NotificationChannel struct {
+	ID        int
+	UserID    uuid.UUID
+	Kind      ChannelKind
+	Name      string
+	URL       string
+	Secret    string
+	ChatID    string
+	Events    []NotificationKind
+	CreatedAt time.Time
+}
+
+// Subscribed reports whether the channel is sent notifications of the kind.
+func (ch *NotificationChannel) Subscribed(kind NotificationKind) bool {
+	for _, event := range ch.Events {
+		if event == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationsData is the notifications page: the latest notifications, the
+// settings and the push channels.
+type NotificationsData struct {
+	Notifications *[]Notification
+	Settings      *NotificationSettings
+	EmailEnabled  bool // EmailEnabled is false when no mail server is configured.
+	Channels      []NotificationChannel
+	Telegram      *TelegramData // Telegram is nil when no bot is configured.
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// TypeCost is what a vehicle's car expenses of one type cost over a period.
+type TypeCost struct {
+	Type   string
+	Amount float64
+	Count  int
+}
+
+// OwnershipCost is the total cost of owning a vehicle over a period:
+// its depreciation plus all of its car expenses.
+type OwnershipCost struct {
+	Vehicle Vehicle
+	From    time.Time
+	To      time.Time
+	Months  float64 // Months is the length of the period in average months.
+	Km      int     // Km is the distance driven in the period, 0 when the odometer readings don't tell.
+
+	StartValue   *float64 // StartValue is the vehicle's value on From, nil without a purchase price.
+	EndValue     *float64 // EndValue is the vehicle's value on To, nil without a purchase price.
+	Depreciation float64
+
+	Expenses      []TypeCost // Expenses are the car expenses by type, highest first.
+	ExpensesTotal float64
+
+	FuelLitres  float64
+	FuelCost    float64  // FuelCost is the amount of the expenses with fuel litres.
+	Consumption *float64 // Consumption is in litres per 100 km, nil when the distance is unknown.
+
+	Total        float64
+	CostPerMonth float64
+	CostPerKm    *float64 // CostPerKm is nil when the distance is unknown.
+}
+
+// CostPerKmValue returns the cost per km for display, or "-" when unknown.
+func (c OwnershipCost) CostPerKmValue() string {
+	if c.CostPerKm == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", *c.CostPerKm)
+}
+
+// ConsumptionValue returns the consumption for display, or "-" when unknown.
+func (c OwnershipCost) ConsumptionValue() string {
+	if c.Consumption == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *c.Consumption)
+}
+
+// ValuesRange returns the vehicle's value at the start and end of the period
+// for display, or "" without a purchase price.
+func (c OwnershipCost) ValuesRange() string {
+	if c.StartValue == nil || c.EndValue == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f to %.2f", *c.StartValue, *c.EndValue)
+}
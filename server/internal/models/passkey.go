@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey is a WebAuthn credential a user signs in with instead of a password.
+type Passkey struct {
+	ID           int
+	UserID       uuid.UUID
+	CredentialID []byte
+	Name         string `form:"name" binding:"max=100"`
+	Credential   []byte // Credential is the JSON of the WebAuthn credential: its public key, flags and signature counter.
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+// PasskeyResponse is the browser's answer to a passkey prompt, posted as the
+// JSON of the PublicKeyCredential.
+type PasskeyResponse struct {
+	Credential string `form:"credential" binding:"required"`
+	Name       string `form:"name" binding:"max=100"`
+}
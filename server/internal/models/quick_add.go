@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuickAdd is an expense read from a short free text phrase, shown to the user
+// for confirmation before it is saved.
+type QuickAdd struct {
+	Text          string
+	Tracker       Tracker // Tracker is empty when the phrase names no type and no rule matched.
+	ExpenseTypeID int
+	ExpenseType   string
+	Amount        float64
+	Date          time.Time
+	Notes         string
+	Tags          string
+	VehicleID     *int // VehicleID is the vehicle a car rule books the entry on.
+	Vehicle       string
+	Rule          string // Rule describes the rule that set the type or tags, empty when none matched.
+}
+
+// Target returns the tracker and type in the "tracker:typeID" form, empty when unassigned.
+func (q *QuickAdd) Target() string {
+	if q.Tracker == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", q.Tracker, q.ExpenseTypeID)
+}
+
+// ApplyRule books the entry as the rule says when the phrase named no type
+// and adds the rule's tags. The rule's vehicle is taken when the entry ends up
+// in the rule's tracker.
+func (q *QuickAdd) ApplyRule(rule *ExpenseRule) {
+	if q.Tracker == "" {
+		q.Tracker = rule.Tracker
+		q.ExpenseTypeID = rule.ExpenseTypeID
+		q.ExpenseType = rule.ExpenseType
+	}
+	if rule.VehicleID != nil && q.Tracker == rule.Tracker {
+		q.VehicleID = rule.VehicleID
+		q.Vehicle = rule.Vehicle
+	}
+	q.Tags = MergeTags(q.Tags, rule.Tags)
+	q.Rule = rule.Conditions()
+}
+
+// QuickAddConfirm is the confirmation fragment for a parsed phrase.
+type QuickAddConfirm struct {
+	Entry      *QuickAdd
+	Page       Tracker // Page is the tracker page the phrase was entered on.
+	HouseTypes *[]HomeUtilityType
+	CarTypes   *[]CarExpenseType
+	Accounts   *AccountSelect
+	Vehicles   *VehicleSelect
+}
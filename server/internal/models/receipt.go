@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// FiscalReceipt is what the QR code of a Bulgarian cash register receipt holds.
+// The fiscal device and receipt numbers identify the receipt, it can be imported once.
+type FiscalReceipt struct {
+	DeviceNumber  string
+	ReceiptNumber string
+	IssuedAt      time.Time
+	Amount        float64
+}
+
+// ReceiptForm is the new expense form pre-filled from a receipt.
+type ReceiptForm struct {
+	QR         string // QR is the receipt's QR text, posted back to import it.
+	Receipt    *FiscalReceipt
+	Page       Tracker // Page is the tracker page the receipt was scanned on.
+	Target     string  // Target is the preselected "tracker:typeID", empty when nothing matched.
+	Tags       string
+	HouseTypes *[]HomeUtilityType
+	CarTypes   *[]CarExpenseType
+	Accounts   *AccountSelect
+}
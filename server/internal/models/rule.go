@@ -0,0 +1,341 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tracker is the expense tracker an entry belongs to.
+type Tracker string
+
+const (
+	TrackerHouse Tracker = "house"
+	TrackerCar   Tracker = "car"
+)
+
+func (t Tracker) Valid() bool {
+	return t == TrackerHouse || t == TrackerCar
+}
+
+// Label returns the human readable tracker name.
+func (t Tracker) Label() string {
+	switch t {
+	case TrackerHouse:
+		return "House"
+	case TrackerCar:
+		return "Car"
+	}
+	return string(t)
+}
+
+// Weekdays lists the days offered as a rule condition, starting on Monday.
+var Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// ExpenseRule assigns a tracker, expense type, tags and for car rules a vehicle to
+// expenses matching all of its conditions. Empty conditions match anything. Rules are tried in Position
+// order and the first match wins.
+type ExpenseRule struct {
+	ID       int
+	Position int
+
+	MatchText  string // MatchText must be contained in the notes or bank description, case insensitive.
+	MatchRegex string // MatchRegex is matched against the notes or bank description, case insensitive.
+	AmountMin  *float64
+	AmountMax  *float64
+	Weekday    *time.Weekday
+	AccountID  *int
+	Account    string
+
+	Tracker       Tracker
+	ExpenseTypeID int
+	ExpenseType   string
+	Tags          string // Tags are comma separated and added to the expense's own tags.
+	VehicleID     *int   // VehicleID is the vehicle car expenses are booked on, nil keeps the expense's own.
+	Vehicle       string
+	// There is no property counterpart of VehicleID: properties aren't modelled,
+	// house expenses all belong to the one household.
+
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+
+	regex *regexp.Regexp
+}
+
+// RuleSubject is what rule conditions are checked against, an expense or a bank transaction.
+type RuleSubject struct {
+	Notes     string
+	Amount    float64
+	Date      time.Time
+	AccountID *int
+}
+
+// Validate checks the rule has at least one condition, a valid regex and a sane amount range.
+func (r *ExpenseRule) Validate() error {
+	if r.MatchText == "" && r.MatchRegex == "" && r.AmountMin == nil && r.AmountMax == nil && r.Weekday == nil && r.AccountID == nil {
+		return errors.New("a rule needs at least one condition")
+	}
+
+	if r.MatchRegex != "" {
+		if _, err := regexp.Compile("(?i)" + r.MatchRegex); err != nil {
+			return errors.New("invalid regular expression")
+		}
+	}
+
+	if r.AmountMin != nil && r.AmountMax != nil && *r.AmountMin > *r.AmountMax {
+		return errors.New("minimum amount is above the maximum")
+	}
+
+	if !r.Tracker.Valid() {
+		return errors.New("invalid tracker")
+	}
+
+	if r.VehicleID != nil && r.Tracker != TrackerCar {
+		return errors.New("only car expenses can be booked on a vehicle")
+	}
+
+	return nil
+}
+
+// Matches reports whether every condition of the rule holds for the subject.
+func (r *ExpenseRule) Matches(s *RuleSubject) bool {
+	notes := strings.ToLower(s.Notes)
+
+	if r.MatchText != "" && !strings.Contains(notes, strings.ToLower(r.MatchText)) {
+		return false
+	}
+
+	if r.MatchRegex != "" {
+		if r.regex == nil {
+			re, err := regexp.Compile("(?i)" + r.MatchRegex)
+			if err != nil {
+				return false
+			}
+			r.regex = re
+		}
+		if !r.regex.MatchString(s.Notes) {
+			return false
+		}
+	}
+
+	if r.AmountMin != nil && s.Amount < *r.AmountMin {
+		return false
+	}
+
+	if r.AmountMax != nil && s.Amount > *r.AmountMax {
+		return false
+	}
+
+	if r.Weekday != nil && s.Date.Weekday() != *r.Weekday {
+		return false
+	}
+
+	if r.AccountID != nil && (s.AccountID == nil || *s.AccountID != *r.AccountID) {
+		return false
+	}
+
+	return true
+}
+
+// FirstMatchingRule returns the first of the ordered rules matching the subject, or nil.
+func FirstMatchingRule(rules []ExpenseRule, s *RuleSubject) *ExpenseRule {
+	for i := range rules {
+		if rules[i].Matches(s) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Target returns the rule's tracker and type in the "tracker:typeID" form used by the forms.
+func (r *ExpenseRule) Target() string {
+	return fmt.Sprintf("%s:%d", r.Tracker, r.ExpenseTypeID)
+}
+
+// Conditions describes the rule's conditions for the rules table.
+func (r *ExpenseRule) Conditions() string {
+	var parts []string
+	if r.MatchText != "" {
+		parts = append(parts, fmt.Sprintf("notes contain %q", r.MatchText))
+	}
+	if r.MatchRegex != "" {
+		parts = append(parts, fmt.Sprintf("notes match /%s/", r.MatchRegex))
+	}
+	switch {
+	case r.AmountMin != nil && r.AmountMax != nil:
+		parts = append(parts, fmt.Sprintf("amount %.2f - %.2f", *r.AmountMin, *r.AmountMax))
+	case r.AmountMin != nil:
+		parts = append(parts, fmt.Sprintf("amount from %.2f", *r.AmountMin))
+	case r.AmountMax != nil:
+		parts = append(parts, fmt.Sprintf("amount up to %.2f", *r.AmountMax))
+	}
+	if r.Weekday != nil {
+		parts = append(parts, "on "+r.Weekday.String())
+	}
+	if r.AccountID != nil {
+		parts = append(parts, "paid with "+r.Account)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AmountMinValue returns the minimum amount formatted for a form input.
+func (r *ExpenseRule) AmountMinValue() string {
+	if r.AmountMin == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", *r.AmountMin)
+}
+
+// AmountMaxValue returns the maximum amount formatted for a form input.
+func (r *ExpenseRule) AmountMaxValue() string {
+	if r.AmountMax == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", *r.AmountMax)
+}
+
+// IsWeekday reports whether the rule is limited to the given day.
+func (r *ExpenseRule) IsWeekday(day time.Weekday) bool {
+	return r.Weekday != nil && *r.Weekday == day
+}
+
+// MergeTags adds the tags not already present to a comma separated tag list.
+func MergeTags(tags, add string) string {
+	list := SplitTags(tags)
+	for _, tag := range SplitTags(add) {
+		found := false
+		for _, existing := range list {
+			if strings.EqualFold(existing, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, tag)
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+// SplitTags splits a comma separated tag list, dropping empty tags.
+func SplitTags(tags string) []string {
+	var list []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			list = append(list, tag)
+		}
+	}
+	return list
+}
+
+// RuleChange is an existing expense a rule would recategorise.
+type RuleChange struct {
+	Expense     CategorisedExpense
+	Rule        *ExpenseRule
+	Tracker     Tracker
+	TypeID      int
+	Type        string
+	Tags        string
+	VehicleID   *int
+	Vehicle     string // Vehicle is the name of the rule's vehicle, empty when the rule sets none.
+	TypeChanged bool
+	Blocked     string // Blocked is what keeps the expense from moving to the rule's tracker, the change is then skipped.
+	MovedTo     int    // MovedTo is the id of the expense in the other tracker once the move is applied.
+}
+
+// Moves reports whether the change moves the expense to the other tracker.
+func (c *RuleChange) Moves() bool {
+	return c.Tracker != c.Expense.Tracker
+}
+
+// CountApplicable returns how many of the changes aren't blocked.
+func CountApplicable(changes []RuleChange) int {
+	n := 0
+	for i := range changes {
+		if changes[i].Blocked == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// CategorisedExpense is a house or car expense as seen by the rules engine.
+type CategorisedExpense struct {
+	ID        int
+	Tracker   Tracker
+	TypeID    int
+	Type      string
+	Amount    float64
+	Date      time.Time
+	Notes     string
+	Tags      string
+	AccountID *int
+	VehicleID *int   // VehicleID is always nil for house expenses.
+	Linked    string // Linked names the charging session, trip, document or reading tying the expense to its tracker, empty when nothing does.
+}
+
+// Subject returns the expense as rule input.
+func (e *CategorisedExpense) Subject() *RuleSubject {
+	return &RuleSubject{
+		Notes:     e.Notes,
+		Amount:    e.Amount,
+		Date:      e.Date,
+		AccountID: e.AccountID,
+	}
+}
+
+// PlanRuleChanges runs the ordered rules over existing expenses and returns
+// the expenses whose tracker, type, tags or vehicle would change. Car expenses
+// staying car expenses keep their vehicle unless the rule sets one.
+func PlanRuleChanges(rules []ExpenseRule, expenses []CategorisedExpense) []RuleChange {
+	var changes []RuleChange
+	for _, exp := range expenses {
+		rule := FirstMatchingRule(rules, exp.Subject())
+		if rule == nil {
+			continue
+		}
+
+		change := RuleChange{
+			Expense: exp,
+			Rule:    rule,
+			Tracker: rule.Tracker,
+			TypeID:  rule.ExpenseTypeID,
+			Type:    rule.ExpenseType,
+			Tags:    MergeTags(exp.Tags, rule.Tags),
+		}
+		change.TypeChanged = change.Tracker != exp.Tracker || change.TypeID != exp.TypeID
+		if change.Moves() {
+			change.Blocked = exp.Linked
+		}
+
+		if rule.VehicleID != nil {
+			change.VehicleID = rule.VehicleID
+			change.Vehicle = rule.Vehicle
+		} else if change.Tracker == TrackerCar && exp.Tracker == TrackerCar {
+			change.VehicleID = exp.VehicleID
+		}
+
+		if change.TypeChanged || change.Tags != exp.Tags || !sameID(change.VehicleID, exp.VehicleID) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// sameID reports whether two optional ids are both unset or equal.
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// RuleTestResult lists the existing expenses a rule would change.
+type RuleTestResult struct {
+	Matched int
+	Changes []RuleChange
+}
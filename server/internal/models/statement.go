@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatementPeriod is how long a period a statement covers.
+type StatementPeriod string
+
+const (
+	StatementMonth StatementPeriod = "month"
+	StatementYear  StatementPeriod = "year"
+)
+
+func (p StatementPeriod) Valid() bool {
+	return p == StatementMonth || p == StatementYear
+}
+
+// StatementLine is one expense listed on a statement.
+type StatementLine struct {
+	Tracker Tracker
+	Date    time.Time
+	Type    string
+	Amount  float64
+	Notes   string
+}
+
+// StatementCategory is what the expenses of one type of a tracker add up to on a statement.
+type StatementCategory struct {
+	Tracker Tracker
+	TypeCost
+	Share float64 // Share is the percentage of the statement's total.
+}
+
+// Statement is a printable summary of the expenses of a month or a year, of one
+// tracker or of both combined.
+type Statement struct {
+	Period      StatementPeriod
+	From        time.Time
+	To          time.Time
+	Trackers    []Tracker
+	Lines       []StatementLine     // Lines are all the expenses of the period, oldest first.
+	Categories  []StatementCategory // Categories are the expenses by tracker and type, highest first.
+	Totals      map[Tracker]float64
+	Total       float64
+	GeneratedAt time.Time
+}
+
+// Title names the statement's trackers and period, e.g. "House and Car statement, October 2025".
+func (s *Statement) Title() string {
+	labels := make([]string, len(s.Trackers))
+	for i, t := range s.Trackers {
+		labels[i] = t.Label()
+	}
+
+	period := s.From.Format("January 2006")
+	if s.Period == StatementYear {
+		period = s.From.Format("2006")
+	}
+	return fmt.Sprintf("%s statement, %s", strings.Join(labels, " and "), period)
+}
+
+// Combined reports whether the statement covers more than one tracker.
+func (s *Statement) Combined() bool {
+	return len(s.Trackers) > 1
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MeteredUtilities are the utility types billed by tariff from meter readings.
+var MeteredUtilities = []string{"Electricity", "Water", "Gas"}
+
+// IsMeteredUtility reports whether bills of the utility type follow a tariff.
+func IsMeteredUtility(name string) bool {
+	for _, metered := range MeteredUtilities {
+		if name == metered {
+			return true
+		}
+	}
+	return false
+}
+
+// BillDeviationPercent is how far in percent a bill may be off its estimate before it is flagged.
+const BillDeviationPercent = 15
+
+// UtilityTariff is a published tariff of a utility, in effect from EffectiveFrom until
+// the next tariff of the same utility type. Prices are without VAT.
+type UtilityTariff struct {
+	ID            int
+	UtilityTypeID int
+	UtilityType   string
+	Name          string
+	EffectiveFrom time.Time
+	FixedFee      float64  // FixedFee is charged per month.
+	UnitPrice     float64  // UnitPrice is the price per kWh or cubic meter, of the day zone for two zone tariffs.
+	NightPrice    *float64 // NightPrice is the night zone price per kWh, nil for single zone tariffs.
+	VATPercent    float64
+	CreatedBy     uuid.UUID
+	CreatedAt     time.Time
+}
+
+// NightUnitPrice returns the price of a night unit, the unit price for single zone tariffs.
+func (t UtilityTariff) NightUnitPrice() float64 {
+	if t.NightPrice == nil {
+		return t.UnitPrice
+	}
+	return *t.NightPrice
+}
+
+// NightPriceValue returns the night price formatted for a form input.
+func (t UtilityTariff) NightPriceValue() string {
+	if t.NightPrice == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.5f", *t.NightPrice)
+}
+
+// MeterReading is a reading of a utility meter. Two zone electricity meters have a
+// separate night register.
+type MeterReading struct {
+	ID            int
+	UtilityTypeID int
+	UtilityType   string
+	Date          time.Time
+	Value         float64  // Value is the day register of two zone meters.
+	NightValue    *float64 // NightValue is the night register, nil for single register meters.
+	CreatedBy     uuid.UUID
+	CreatedAt     time.Time
+}
+
+// NightValueValue returns the night register reading formatted for a form input.
+func (r MeterReading) NightValueValue() string {
+	if r.NightValue == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.3f", *r.NightValue)
+}
+
+// BillEstimate is the expected bill for the consumption between two meter readings,
+// with the actual bill once it arrived.
+type BillEstimate struct {
+	UtilityTypeID int
+	UtilityType   string
+	From          time.Time // From is the date of the opening reading.
+	To            time.Time // To is the date of the closing reading.
+	Units         float64   // Units are all units consumed, night ones included.
+	NightUnits    float64
+	Amount        float64
+	Bill          *HouseExpense // Bill is the matching home expense, nil when it hasn't arrived.
+}
+
+// Deviation returns how far the bill is off the estimate in percent, 0 without a bill.
+func (e BillEstimate) Deviation() float64 {
+	if e.Bill == nil || e.Amount == 0 {
+		return 0
+	}
+	return (e.Bill.Amount - e.Amount) / e.Amount * 100
+}
+
+// Flagged reports whether the bill deviates significantly from the estimate.
+func (e BillEstimate) Flagged() bool {
+	return math.Abs(e.Deviation()) > BillDeviationPercent
+}
+
+// DeviationLabel returns the deviation for display, or "-" without a bill.
+func (e BillEstimate) DeviationLabel() string {
+	if e.Bill == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f%%", e.Deviation())
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TelegramLink is the Telegram chat a user adds and queries expenses from
+// through the bot.
+type TelegramLink struct {
+	UserID        uuid.UUID
+	ChatID        *int64 // ChatID is nil until the user sent the bot their link code.
+	LinkCode      string // LinkCode is the one-time code to send the bot, empty once used.
+	CodeExpiresAt *time.Time
+	LinkedAt      *time.Time
+}
+
+// Linked reports whether the user's chat is linked.
+func (l *TelegramLink) Linked() bool {
+	return l != nil && l.ChatID != nil
+}
+
+// CodeValid reports whether the link code can still be sent to the bot.
+func (l *TelegramLink) CodeValid() bool {
+	return l != nil && l.LinkCode != "" && l.CodeExpiresAt != nil && l.CodeExpiresAt.After(time.Now())
+}
+
+// TelegramData is the Telegram bot section of the notifications page.
+type TelegramData struct {
+	BotName string // BotName is the bot's username, for the t.me link. Empty when unknown.
+	Link    *TelegramLink
+}
@@ -0,0 +1,231 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FuelExpenseType is the car expense type of fill-ups. Fuel linked to a business
+// trip is not reimbursed on top of the per-km rate, which already covers it.
+const FuelExpenseType = "Fuel"
+
+// Trip is a journey logged for the mileage log and reimbursements.
+type Trip struct {
+	ID            int
+	VehicleID     *int    // VehicleID is the vehicle driven, nil when not tracked.
+	Vehicle       *string // Vehicle is the name of the vehicle driven.
+	Date          time.Time
+	StartOdometer *int // StartOdometer is nil when the trip was logged with a distance only.
+	EndOdometer   *int
+	Distance      int // Distance is in km, from the odometer readings when both are entered.
+	Purpose       string
+	Business      bool
+	Expenses      []CarExpense // Expenses are the tolls, parking and fuel paid on the trip.
+	CreatedBy     uuid.UUID
+	CreatedAt     time.Time
+}
+
+// TripDistance returns the distance of a trip from its odometer readings when
+// both are entered, or else the entered distance.
+func TripDistance(start, end, distance *int) (int, error) {
+	if start != nil && end != nil {
+		if *end <= *start {
+			return 0, fmt.Errorf("the end odometer must be after the start")
+		}
+		return *end - *start, nil
+	}
+
+	if distance == nil {
+		return 0, fmt.Errorf("enter the distance or both odometer readings")
+	}
+	if *distance <= 0 {
+		return 0, fmt.Errorf("invalid distance")
+	}
+	return *distance, nil
+}
+
+// Kind returns whether the trip was for business or personal.
+func (t Trip) Kind() string {
+	if t.Business {
+		return "Business"
+	}
+	return "Personal"
+}
+
+// VehicleName returns the name of the vehicle driven, or "-" when not tracked.
+func (t Trip) VehicleName() string {
+	if t.Vehicle == nil {
+		return "-"
+	}
+	return *t.Vehicle
+}
+
+// StartOdometerValue returns the start reading formatted for a form input.
+func (t Trip) StartOdometerValue() string {
+	if t.StartOdometer == nil {
+		return ""
+	}
+	return fmt.Sprint(*t.StartOdometer)
+}
+
+// EndOdometerValue returns the end reading formatted for a form input.
+func (t Trip) EndOdometerValue() string {
+	if t.EndOdometer == nil {
+		return ""
+	}
+	return fmt.Sprint(*t.EndOdometer)
+}
+
+// ExpensesTotal returns the amount of all expenses linked to the trip.
+func (t Trip) ExpensesTotal() float64 {
+	var total float64
+	for _, exp := range t.Expenses {
+		total += exp.Amount
+	}
+	return total
+}
+
+// ReimbursableExpenses returns the amount of the linked expenses that are
+// reimbursed on top of the per-km rate, all but fuel.
+func (t Trip) ReimbursableExpenses() float64 {
+	var total float64
+	for _, exp := range t.Expenses {
+		if exp.Type != FuelExpenseType {
+			total += exp.Amount
+		}
+	}
+	return total
+}
+
+// HasExpense reports whether the expense with the given id is linked to the trip.
+func (t Trip) HasExpense(id int) bool {
+	for _, exp := range t.Expenses {
+		if exp.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// MileageMonth is the distance driven in a month, by business and personal trips.
+type MileageMonth struct {
+	Month      time.Time
+	Trips      int
+	BusinessKm int
+	PersonalKm int
+}
+
+// TotalKm returns the distance of all trips in the month.
+func (m MileageMonth) TotalKm() int {
+	return m.BusinessKm + m.PersonalKm
+}
+
+// MileageSummary is the distance driven over a period, month by month.
+type MileageSummary struct {
+	Months     []MileageMonth // Months are the months with trips, oldest first.
+	Trips      int
+	BusinessKm int
+	PersonalKm int
+}
+
+// TotalKm returns the distance of all trips in the period.
+func (s MileageSummary) TotalKm() int {
+	return s.BusinessKm + s.PersonalKm
+}
+
+// BusinessShare returns the share of the distance driven on business in percent.
+func (s MileageSummary) BusinessShare() float64 {
+	if s.TotalKm() == 0 {
+		return 0
+	}
+	return float64(s.BusinessKm) / float64(s.TotalKm()) * 100
+}
+
+// SummarizeMileage sums up the distance of the trips by month.
+func SummarizeMileage(trips []Trip) MileageSummary {
+	var summary MileageSummary
+	byMonth := make(map[time.Time]*MileageMonth)
+
+	for _, trip := range trips {
+		start := time.Date(trip.Date.Year(), trip.Date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		month, ok := byMonth[start]
+		if !ok {
+			month = &MileageMonth{Month: start}
+			byMonth[start] = month
+		}
+
+		month.Trips++
+		summary.Trips++
+		if trip.Business {
+			month.BusinessKm += trip.Distance
+			summary.BusinessKm += trip.Distance
+		} else {
+			month.PersonalKm += trip.Distance
+			summary.PersonalKm += trip.Distance
+		}
+	}
+
+	for _, month := range byMonth {
+		summary.Months = append(summary.Months, *month)
+	}
+	sort.Slice(summary.Months, func(i, j int) bool {
+		return summary.Months[i].Month.Before(summary.Months[j].Month)
+	})
+
+	return summary
+}
+
+// ReimbursementLine is what a business trip is reimbursed.
+type ReimbursementLine struct {
+	Trip      Trip
+	Allowance float64 // Allowance is the distance at the per-km rate.
+	Expenses  float64 // Expenses are the linked expenses reimbursed on top, see Trip.ReimbursableExpenses.
+}
+
+// Total returns the allowance and the expenses of the trip.
+func (l ReimbursementLine) Total() float64 {
+	return l.Allowance + l.Expenses
+}
+
+// Reimbursement is what the business trips of a period are reimbursed at a per-km rate.
+type Reimbursement struct {
+	From      time.Time
+	To        time.Time
+	Rate      float64 // Rate is in lv per km.
+	Lines     []ReimbursementLine
+	Km        int
+	Allowance float64
+	Expenses  float64
+}
+
+// Total returns the allowance and the expenses of all trips.
+func (r Reimbursement) Total() float64 {
+	return r.Allowance + r.Expenses
+}
+
+// BuildReimbursement returns the reimbursement of the business trips among the
+// given ones, in the order they are given.
+func BuildReimbursement(trips []Trip, rate float64, from, to time.Time) *Reimbursement {
+	report := &Reimbursement{From: from, To: to, Rate: rate}
+
+	for _, trip := range trips {
+		if !trip.Business {
+			continue
+		}
+
+		line := ReimbursementLine{
+			Trip:      trip,
+			Allowance: float64(trip.Distance) * rate,
+			Expenses:  trip.ReimbursableExpenses(),
+		}
+		report.Lines = append(report.Lines, line)
+		report.Km += trip.Distance
+		report.Allowance += line.Allowance
+		report.Expenses += line.Expenses
+	}
+
+	return report
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"html/template"
+	"time"
+)
+
+// TwoFactor is the TOTP two-factor authentication of a user.
+type TwoFactor struct {
+	Secret            string     // Secret is empty when the user never started enrolling.
+	EnabledAt         *time.Time // EnabledAt is nil while the secret is pending a first valid code.
+	LastStep          *int64     // LastStep is the time step of the latest code used.
+	LockedUntil       *time.Time // LockedUntil is when codes are accepted again after too many wrong ones.
+	RecoveryCodesLeft int
+}
+
+// Enabled reports whether logging in asks for a code.
+func (t *TwoFactor) Enabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// Locked reports whether codes are refused at now after too many wrong ones.
+func (t *TwoFactor) Locked(now time.Time) bool {
+	return t != nil && t.LockedUntil != nil && t.LockedUntil.After(now)
+}
+
+// TwoFactorData is the two-factor authentication section of the settings page.
+type TwoFactorData struct {
+	TwoFactor     *TwoFactor
+	Enrolment     *TwoFactorEnrolment // Enrolment is set while the user sets up their authenticator.
+	RecoveryCodes []string            // RecoveryCodes are shown once, right after they are generated.
+	HasPassword   bool                // HasPassword is false for users who confirm changes with a code instead.
+}
+
+// TwoFactorEnrolment is the secret a user adds to their authenticator app.
+type TwoFactorEnrolment struct {
+	Secret string
+	QRCode template.URL // QRCode is a data URL of the PNG of the otpauth URI.
+}
+
+// TwoFactorLogin represents the data needed for the second step of logging in,
+// a TOTP code or else a recovery code
+type TwoFactorLogin struct {
+	Code string `form:"code" binding:"required,max=32"`
+}
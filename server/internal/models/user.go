@@ -8,22 +8,82 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID           uuid.UUID
-	Username     string `form:"username" binding:"required,min=3,max=50"`
-	PasswordHash string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID              uuid.UUID
+	Username        string `form:"username" binding:"required,min=3,max=50"`
+	PasswordHash    string
+	Email           string     // Email is the optional address of the account, empty when there is none.
+	EmailVerifiedAt *time.Time // EmailVerifiedAt is nil until the address is verified.
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// VerifiedEmail returns the address of the account once it is verified, otherwise "".
+func (u *User) VerifiedEmail() string {
+	if u.EmailVerifiedAt == nil {
+		return ""
+	}
+	return u.Email
+}
+
+// HasPassword reports whether the user can log in with a password. Users created
+// by single sign-on have none.
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
 }
 
 // UserRegistration represents the data needed for user registration
 type UserRegistration struct {
 	Username        string `form:"username" binding:"required,min=3,max=50"`
+	Email           string `form:"email" binding:"omitempty,email,max=254"`
 	Password        string `form:"password" binding:"required,min=6"`
 	ConfirmPassword string `form:"confirm_password" binding:"required,eqfield=Password"`
 }
 
-// UserLogin represents the data needed for user login
+// UserLogin represents the data needed for user login, Username is the username or the verified email
 type UserLogin struct {
 	Username string `form:"username" binding:"required"`
 	Password string `form:"password" binding:"required"`
 }
+
+// PasswordChange represents the data needed for a logged in user to change their password
+type PasswordChange struct {
+	CurrentPassword string `form:"current_password" binding:"required"`
+	Password        string `form:"password" binding:"required,min=6"`
+	ConfirmPassword string `form:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+// PasswordReset represents the data needed to reset a forgotten password with an emailed token
+type PasswordReset struct {
+	Token           string `form:"token" binding:"required"`
+	Password        string `form:"password" binding:"required,min=6"`
+	ConfirmPassword string `form:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+// SettingsData is the account settings page.
+type SettingsData struct {
+	User             *User
+	EmailEnabled     bool // EmailEnabled is false when no mail server is configured to verify addresses.
+	PasswordsEnabled bool
+	TwoFactor        *TwoFactorData
+	Passkeys         []Passkey
+}
+
+// LoginPage is the login or register page, with the ways of logging in the server allows.
+type LoginPage struct {
+	SSOName          string // SSOName labels the single sign-on button, empty without a provider.
+	PasswordsEnabled bool
+	Error            string // Error is why the latest single sign-on failed.
+}
+
+// ResetPasswordPage is the page a password reset link opens.
+type ResetPasswordPage struct {
+	Token string
+	Valid bool // Valid is false when the token is unknown, used or expired.
+}
+
+// EmailVerificationPage is the page an email verification link opens.
+type EmailVerificationPage struct {
+	Email    string
+	Verified bool // Verified is false when the link is invalid, expired or for an address the account no longer has.
+	Taken    bool // Taken is true when another account verified the address first.
+}
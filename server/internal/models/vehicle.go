@@ -0,0 +1,265 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vehicle is a car the user tracks expenses, services and documents for.
+type Vehicle struct {
+	ID           int
+	Name         string
+	PlateNumber  string
+	Odometer     int        // Odometer is the last reading in km, entered by hand or from a car expense.
+	OdometerDate *time.Time // OdometerDate is when Odometer was read, nil when never entered.
+	CreatedBy    uuid.UUID
+	CreatedAt    time.Time
+
+	PurchasePrice   *float64   // PurchasePrice is nil when unknown, the vehicle then doesn't depreciate.
+	PurchaseDate    *time.Time // PurchaseDate is set together with PurchasePrice.
+	Depreciation    DepreciationMethod
+	UsefulLifeYears *int    // UsefulLifeYears is the straight-line depreciation period.
+	ResidualValue   float64 // ResidualValue is what's left after UsefulLifeYears.
+}
+
+// PurchasePriceValue returns the purchase price formatted for a form input.
+func (v Vehicle) PurchasePriceValue() string {
+	if v.PurchasePrice == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", *v.PurchasePrice)
+}
+
+// UsefulLifeYearsValue returns the useful life formatted for a form input.
+func (v Vehicle) UsefulLifeYearsValue() string {
+	if v.UsefulLifeYears == nil {
+		return ""
+	}
+	return fmt.Sprint(*v.UsefulLifeYears)
+}
+
+// DepreciationMethod is how a vehicle loses value over time.
+type DepreciationMethod string
+
+const (
+	DepreciationStraightLine DepreciationMethod = "straight_line"
+	DepreciationValuations   DepreciationMethod = "valuations"
+)
+
+// DepreciationMethods lists the methods in the order they are offered in forms.
+var DepreciationMethods = []DepreciationMethod{DepreciationStraightLine, DepreciationValuations}
+
+// Label returns the human readable method.
+func (m DepreciationMethod) Label() string {
+	switch m {
+	case DepreciationStraightLine:
+		return "Straight-line"
+	case DepreciationValuations:
+		return "Entered valuations"
+	}
+	return string(m)
+}
+
+// Valid reports whether the method is a known one.
+func (m DepreciationMethod) Valid() bool {
+	return m == DepreciationStraightLine || m == DepreciationValuations
+}
+
+// VehicleValuation is what a vehicle was worth on a date, entered by the user.
+type VehicleValuation struct {
+	ID        int
+	VehicleID int
+	Date      time.Time
+	Value     float64
+	CreatedAt time.Time
+}
+
+// OdometerReading is a vehicle's odometer in km on a date.
+type OdometerReading struct {
+	Date time.Time
+	Km   int
+}
+
+// Label returns the vehicle name with its plate number when known.
+func (v Vehicle) Label() string {
+	if v.PlateNumber == "" {
+		return v.Name
+	}
+	return fmt.Sprintf("%s (%s)", v.Name, v.PlateNumber)
+}
+
+// VehicleSelect is the data for the vehicle dropdown of the car expense forms.
+type VehicleSelect struct {
+	Vehicles *[]Vehicle
+	Selected *int
+}
+
+// IsSelected reports whether the vehicle with the given id is preselected.
+func (s *VehicleSelect) IsSelected(id int) bool {
+	return s.Selected != nil && *s.Selected == id
+}
+
+// Service reminders start this long before a service is due.
+const (
+	ServiceDueSoonDays = 30
+	ServiceDueSoonKm   = 1000
+)
+
+// ServiceState is how close a planned service is to being due.
+type ServiceState string
+
+const (
+	ServiceOK        ServiceState = "ok"
+	ServiceDueSoon   ServiceState = "due_soon"
+	ServiceOverdue   ServiceState = "overdue"
+	ServiceNoHistory ServiceState = "no_history"
+)
+
+// Label returns the human readable state.
+func (s ServiceState) Label() string {
+	switch s {
+	case ServiceOK:
+		return "OK"
+	case ServiceDueSoon:
+		return "Due soon"
+	case ServiceOverdue:
+		return "Overdue"
+	case ServiceNoHistory:
+		return "Never done"
+	}
+	return string(s)
+}
+
+// ServicePlan is a recurring service of a vehicle, like an oil change every
+// 10000 km or 12 months. The last service is the vehicle's latest car expense
+// of the plan's type.
+type ServicePlan struct {
+	ID             int
+	VehicleID      int
+	Vehicle        string
+	Name           string
+	ExpenseTypeID  int
+	ExpenseType    string
+	IntervalKm     *int
+	IntervalMonths *int
+	CreatedBy      uuid.UUID
+	CreatedAt      time.Time
+
+	LastExpenseID   *int       // LastExpenseID is the latest matching car expense, nil when there is none.
+	LastDate        *time.Time // LastDate is the date of the latest matching car expense.
+	LastOdometer    *int       // LastOdometer is the reading on the latest matching expense, nil when not entered.
+	CurrentOdometer int        // CurrentOdometer is the vehicle's latest known reading.
+}
+
+// Interval describes the plan's interval for the plans table.
+func (p ServicePlan) Interval() string {
+	var parts []string
+	if p.IntervalKm != nil {
+		parts = append(parts, fmt.Sprintf("%d km", *p.IntervalKm))
+	}
+	if p.IntervalMonths != nil {
+		parts = append(parts, fmt.Sprintf("%d months", *p.IntervalMonths))
+	}
+	return strings.Join(parts, " or ")
+}
+
+// IntervalKmValue returns the km interval formatted for a form input.
+func (p ServicePlan) IntervalKmValue() string {
+	if p.IntervalKm == nil {
+		return ""
+	}
+	return fmt.Sprint(*p.IntervalKm)
+}
+
+// IntervalMonthsValue returns the months interval formatted for a form input.
+func (p ServicePlan) IntervalMonthsValue() string {
+	if p.IntervalMonths == nil {
+		return ""
+	}
+	return fmt.Sprint(*p.IntervalMonths)
+}
+
+// ServiceStatus is when a planned service is next due.
+type ServiceStatus struct {
+	Plan    ServicePlan
+	State   ServiceState
+	DueDate *time.Time // DueDate is nil when the plan has no months interval or no history.
+	DueKm   *int       // DueKm is nil when the plan has no km interval or the last service has no reading.
+}
+
+// Status computes when the service is next due as of now.
+func (p *ServicePlan) Status(now time.Time) ServiceStatus {
+	status := ServiceStatus{Plan: *p, State: ServiceOK}
+	if p.LastDate == nil {
+		status.State = ServiceNoHistory
+		return status
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if p.IntervalMonths != nil {
+		due := p.LastDate.AddDate(0, *p.IntervalMonths, 0)
+		status.DueDate = &due
+
+		switch {
+		case !today.Before(due):
+			status.State = ServiceOverdue
+		case !today.AddDate(0, 0, ServiceDueSoonDays).Before(due):
+			status.State = ServiceDueSoon
+		}
+	}
+
+	if p.IntervalKm != nil && p.LastOdometer != nil {
+		dueKm := *p.LastOdometer + *p.IntervalKm
+		status.DueKm = &dueKm
+
+		switch {
+		case p.CurrentOdometer >= dueKm:
+			status.State = ServiceOverdue
+		case p.CurrentOdometer+ServiceDueSoonKm >= dueKm && status.State == ServiceOK:
+			status.State = ServiceDueSoon
+		}
+	}
+
+	return status
+}
+
+// NeedsAttention reports whether the service is due soon, overdue or was never done.
+func (s ServiceStatus) NeedsAttention() bool {
+	return s.State != ServiceOK
+}
+
+// KmLeft returns the km until the service is due, negative when overdue.
+func (s ServiceStatus) KmLeft() int {
+	if s.DueKm == nil {
+		return 0
+	}
+	return *s.DueKm - s.Plan.CurrentOdometer
+}
+
+// Due describes when the service is due for the plans table.
+func (s ServiceStatus) Due() string {
+	var parts []string
+	if s.DueDate != nil {
+		parts = append(parts, s.DueDate.Format("02.01.2006"))
+	}
+	if s.DueKm != nil {
+		parts = append(parts, fmt.Sprintf("%d km", *s.DueKm))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " or ")
+}
+
+// ServiceStatuses computes the status of every plan as of now.
+func ServiceStatuses(plans []ServicePlan, now time.Time) []ServiceStatus {
+	statuses := make([]ServiceStatus, 0, len(plans))
+	for i := range plans {
+		statuses = append(statuses, plans[i].Status(now))
+	}
+	return statuses
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentKind is the kind of a vehicle document.
+type DocumentKind string
+
+const (
+	DocumentInsurance  DocumentKind = "insurance"
+	DocumentVignette   DocumentKind = "vignette"
+	DocumentInspection DocumentKind = "inspection"
+	DocumentRoadTax    DocumentKind = "road_tax"
+	DocumentOther      DocumentKind = "other"
+)
+
+// DocumentKinds lists the kinds in the order they are offered in forms.
+var DocumentKinds = []DocumentKind{DocumentInsurance, DocumentVignette, DocumentInspection, DocumentRoadTax, DocumentOther}
+
+// Label returns the human readable kind.
+func (k DocumentKind) Label() string {
+	switch k {
+	case DocumentInsurance:
+		return "Civil liability insurance"
+	case DocumentVignette:
+		return "E-vignette"
+	case DocumentInspection:
+		return "Technical inspection"
+	case DocumentRoadTax:
+		return "Road tax"
+	case DocumentOther:
+		return "Other"
+	}
+	return string(k)
+}
+
+// Valid reports whether the kind is a known one.
+func (k DocumentKind) Valid() bool {
+	for _, kind := range DocumentKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRemindDays are the lead times of a new document, in days before it expires.
+var DefaultRemindDays = []int{30, 7, 1}
+
+// MaxRemindDays limits how many lead times a document can have.
+const MaxRemindDays = 5
+
+// ParseRemindDays reads comma separated lead times in days, like "30, 7, 1".
+// The result is ordered from the longest lead time down without duplicates.
+func ParseRemindDays(value string) ([]int, error) {
+	seen := make(map[int]bool)
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 0 || day > 365 {
+			return nil, fmt.Errorf("invalid reminder days %q", part)
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	if len(days) > MaxRemindDays {
+		return nil, fmt.Errorf("at most %d reminders per document", MaxRemindDays)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days, nil
+}
+
+// VehicleDocument is a dated document of a vehicle that has to be renewed,
+// like the civil liability insurance or the annual technical inspection.
+type VehicleDocument struct {
+	ID         int
+	VehicleID  int
+	Vehicle    string
+	Kind       DocumentKind
+	Reference  string // Reference is the policy or document number, empty when not entered.
+	StartsOn   time.Time
+	ExpiresOn  time.Time // ExpiresOn is the last day the document is valid.
+	ExpenseID  *int      // ExpenseID is the car expense the document was paid with, nil when not linked.
+	Cost       *float64  // Cost is the amount of the linked car expense.
+	RemindDays []int     // RemindDays are the lead times of the reminders, in days before expiry.
+	Renewed    bool      // Renewed is set when the vehicle has a later document of the same kind.
+	CreatedBy  uuid.UUID
+	CreatedAt  time.Time
+}
+
+// RemindDaysValue returns the lead times formatted for a form input.
+func (d VehicleDocument) RemindDaysValue() string {
+	parts := make([]string, 0, len(d.RemindDays))
+	for _, day := range d.RemindDays {
+		parts = append(parts, strconv.Itoa(day))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DaysLeft returns the days from now until the document expires,
+// 0 on its last valid day and negative once expired.
+func (d *VehicleDocument) DaysLeft(now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	expires := time.Date(d.ExpiresOn.Year(), d.ExpiresOn.Month(), d.ExpiresOn.Day(), 0, 0, 0, 0, time.UTC)
+	return int(expires.Sub(today).Hours() / 24)
+}
+
+// DueReminder returns the shortest lead time the document has reached as of now.
+// Expired documents return -1. It reports false when no reminder is due,
+// including for documents that were already renewed.
+func (d *VehicleDocument) DueReminder(now time.Time) (int, bool) {
+	if d.Renewed {
+		return 0, false
+	}
+
+	left := d.DaysLeft(now)
+	if left < 0 {
+		return -1, true
+	}
+
+	lead, found := 0, false
+	for _, day := range d.RemindDays {
+		if left <= day && (!found || day < lead) {
+			lead, found = day, true
+		}
+	}
+	return lead, found
+}
+
+// DocumentState is whether a document is still valid.
+type DocumentState string
+
+const (
+	DocumentValid    DocumentState = "valid"
+	DocumentExpiring DocumentState = "expiring"
+	DocumentExpired  DocumentState = "expired"
+	DocumentRenewed  DocumentState = "renewed"
+)
+
+// Label returns the human readable state.
+func (s DocumentState) Label() string {
+	switch s {
+	case DocumentValid:
+		return "Valid"
+	case DocumentExpiring:
+		return "Expiring"
+	case DocumentExpired:
+		return "Expired"
+	case DocumentRenewed:
+		return "Renewed"
+	}
+	return string(s)
+}
+
+// DocumentStatus is a document with its state as of a date.
+type DocumentStatus struct {
+	Document VehicleDocument
+	State    DocumentState
+	DaysLeft int
+}
+
+// Status computes the document's state as of now. A document is expiring
+// once its longest reminder lead time was reached.
+func (d *VehicleDocument) Status(now time.Time) DocumentStatus {
+	status := DocumentStatus{Document: *d, State: DocumentValid, DaysLeft: d.DaysLeft(now)}
+
+	switch {
+	case d.Renewed:
+		status.State = DocumentRenewed
+	case status.DaysLeft < 0:
+		status.State = DocumentExpired
+	default:
+		if _, due := d.DueReminder(now); due {
+			status.State = DocumentExpiring
+		}
+	}
+
+	return status
+}
+
+// NeedsAttention reports whether the document is expiring or expired.
+func (s DocumentStatus) NeedsAttention() bool {
+	return s.State == DocumentExpiring || s.State == DocumentExpired
+}
+
+// DocumentStatuses computes the status of every document as of now.
+func DocumentStatuses(docs []VehicleDocument, now time.Time) []DocumentStatus {
+	statuses := make([]DocumentStatus, 0, len(docs))
+	for i := range docs {
+		statuses = append(statuses, docs[i].Status(now))
+	}
+	return statuses
+}
+
+// RenewalMonth lists the documents expiring in a month.
+type RenewalMonth struct {
+	Month     time.Month
+	Documents []DocumentStatus
+}
+
+// RenewalCalendar is a year of document renewals, one entry per month.
+type RenewalCalendar struct {
+	Year   int
+	Months []RenewalMonth
+}
+
+// PrevYear returns the year before the calendar's.
+func (c *RenewalCalendar) PrevYear() int {
+	return c.Year - 1
+}
+
+// NextYear returns the year after the calendar's.
+func (c *RenewalCalendar) NextYear() int {
+	return c.Year + 1
+}
+
+// BuildRenewalCalendar places the documents expiring in the year by month,
+// each month ordered by expiry date.
+func BuildRenewalCalendar(docs []VehicleDocument, year int, now time.Time) *RenewalCalendar {
+	calendar := &RenewalCalendar{Year: year, Months: make([]RenewalMonth, 12)}
+	for i := range calendar.Months {
+		calendar.Months[i].Month = time.Month(i + 1)
+	}
+
+	for i := range docs {
+		if docs[i].ExpiresOn.Year() != year {
+			continue
+		}
+		month := &calendar.Months[docs[i].ExpiresOn.Month()-1]
+		month.Documents = append(month.Documents, docs[i].Status(now))
+	}
+
+	for i := range calendar.Months {
+		documents := calendar.Months[i].Documents
+		sort.SliceStable(documents, func(a, b int) bool {
+			return documents[a].Document.ExpiresOn.Before(documents[b].Document.ExpiresOn)
+		})
+	}
+
+	return calendar
+}
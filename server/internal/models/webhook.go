@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvent is what a webhook endpoint is posted about.
+type WebhookEvent string
+
+const (
+	WebhookExpenseCreated WebhookEvent = "expense.created"
+	WebhookExpenseUpdated WebhookEvent = "expense.updated"
+	WebhookExpenseDeleted WebhookEvent = "expense.deleted"
+	WebhookMonthClosed    WebhookEvent = "month.closed"
+)
+
+// WebhookEvents are the events an endpoint can be subscribed to, in the order they are offered.
+var WebhookEvents = []WebhookEvent{
+	WebhookExpenseCreated,
+	WebhookExpenseUpdated,
+	WebhookExpenseDeleted,
+	WebhookMonthClosed,
+}
+
+// Label returns the event for display.
+func (e WebhookEvent) Label() string {
+	switch e {
+	case WebhookExpenseCreated:
+		return "Expense created"
+	case WebhookExpenseUpdated:
+		return "Expense updated"
+	case WebhookExpenseDeleted:
+		return "Expense deleted"
+	case WebhookMonthClosed:
+		return "Month closed"
+	}
+	return string(e)
+}
+
+// Valid reports whether the event is a known one.
+func (e WebhookEvent) Valid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint is a URL a user registered to be posted their expense events,
+// signed with Secret.
+type WebhookEndpoint struct {
+	ID            int
+	UserID        uuid.UUID
+	Name          string
+	URL           string
+	Secret        string
+	Events        []WebhookEvent
+	ClosedThrough time.Time // ClosedThrough is the latest month the endpoint was posted the close of.
+	CreatedAt     time.Time
+}
+
+// Subscribed reports whether the endpoint is posted the event.
+func (e *WebhookEndpoint) Subscribed(event WebhookEvent) bool {
+	for _, subscribed := range e.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is how far a webhook delivery got.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // DeliveryPending is waiting for its first or next attempt.
+	DeliverySucceeded DeliveryStatus = "succeeded" // DeliverySucceeded was accepted with a 2xx status.
+	DeliveryFailed    DeliveryStatus = "failed"    // DeliveryFailed ran out of attempts.
+)
+
+// WebhookDelivery is one event posted to an endpoint, with the outcome of its
+// latest attempt.
+type WebhookDelivery struct {
+	ID            int
+	EndpointID    int
+	UserID        uuid.UUID // UserID is the owner of the endpoint.
+	Endpoint      string    // Endpoint is the name of the endpoint.
+	URL           string
+	Secret        string
+	Event         WebhookEvent
+	Payload       []byte
+	Status        DeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	StatusCode    *int // StatusCode is the reply of the latest attempt, nil when it got none.
+	Error         string
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// StatusCodeLabel returns the status code of the latest attempt for display, or "-".
+func (d WebhookDelivery) StatusCodeLabel() string {
+	if d.StatusCode == nil {
+		return "-"
+	}
+	return strconv.Itoa(*d.StatusCode)
+}
+
+// WebhookExpense is the data of an expense event.
+type WebhookExpense struct {
+	ID      int     `json:"id"`
+	Tracker Tracker `json:"tracker"`
+	Type    string  `json:"type"`
+	Amount  float64 `json:"amount"`
+	Date    string  `json:"date"`
+	Notes   string  `json:"notes"`
+}
+
+// WebhookMonth is the data of a month close event, the totals of the month.
+type WebhookMonth struct {
+	Month      string  `json:"month"`
+	HouseTotal float64 `json:"house_total"`
+	CarTotal   float64 `json:"car_total"`
+	Total      float64 `json:"total"`
+	Income     float64 `json:"income"`
+	Net        float64 `json:"net"`
+	Count      int     `json:"count"`
+}
+
+// WebhooksData is the webhooks page: the endpoints and their latest deliveries.
+type WebhooksData struct {
+	Endpoints  []WebhookEndpoint
+	Deliveries []WebhookDelivery
+}
@@ -0,0 +1,148 @@
+// Package netguard makes HTTP requests to addresses users enter, like webhook
+// and ntfy URLs, without letting them reach the server's own network. The
+// address is checked when connecting, after DNS resolution, so redirects and
+// hostnames resolving to internal addresses are refused as well. Hosts and
+// networks the server admin allows with Allow are reached all the same, e.g. a
+// Home Assistant or ntfy server on the LAN.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrForbidden = errors.New("the address is on a local or private network")
+
+// allowed are the hosts and networks requests may go to even though they are
+// local or private. They are set once, by Allow at start up.
+var allowed struct {
+	hosts    map[string]bool
+	prefixes []netip.Prefix
+}
+
+// Allow lets requests go to the entries even though they are local or private.
+// An entry is a hostname, an IP address or a CIDR network. It replaces the
+// entries of an earlier call, and must be called before any request is made.
+func Allow(entries []string) error {
+	hosts := map[string]bool{}
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry)), ".")
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return fmt.Errorf("invalid network %q", entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(strings.Trim(entry, "[]")); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		if strings.ContainsAny(entry, ":/ ") {
+			return fmt.Errorf("invalid host %q", entry)
+		}
+		hosts[entry] = true
+	}
+
+	allowed.hosts = hosts
+	allowed.prefixes = prefixes
+	return nil
+}
+
+// allowedName reports whether the hostname is one of the allowed hosts.
+func allowedName(host string) bool {
+	return allowed.hosts[strings.TrimSuffix(strings.ToLower(host), ".")]
+}
+
+// Allowed reports whether requests may go to the address. Loopback, private,
+// link-local, multicast and unspecified addresses are refused, unless they are
+// in an allowed network.
+func Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range allowed.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// AllowedHost reports whether a URL host may be requested, as far as can be
+// told without resolving it. It lets forms refuse obvious internal addresses
+// early, the dialer of NewClient has the final say.
+func AllowedHost(host string) bool {
+	if allowedName(host) {
+		return true
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil {
+		return true
+	}
+	return Allowed(addr)
+}
+
+// control refuses connections to addresses that aren't Allowed.
+func control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbidden, address)
+	}
+	if !Allowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbidden, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewClient returns a client that only connects to Allowed addresses, or to
+// allowed hosts whatever they resolve to, and gives up after timeout. It never
+// uses a proxy, that would hide the address.
+func NewClient(timeout time.Duration) *http.Client {
+	guarded := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	trusted := &net.Dialer{Timeout: timeout}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(address); err == nil && allowedName(host) {
+			return trusted.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
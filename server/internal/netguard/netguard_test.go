@@ -0,0 +1,84 @@
+package netguard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946", "100.64.0.1"} {
+		assert.True(t, Allowed(netip.MustParseAddr(addr)), addr)
+	}
+
+	for _, addr := range []string{
+		"127.0.0.1", "::1", "10.0.0.5", "172.16.3.4", "192.168.1.10", "fd00::1",
+		"169.254.169.254", "fe80::1", "0.0.0.0", "::", "224.0.0.1", "::ffff:127.0.0.1",
+	} {
+		assert.False(t, Allowed(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestAllowedHost(t *testing.T) {
+	assert.True(t, AllowedHost("ntfy.sh"))
+	assert.True(t, AllowedHost("93.184.216.34"))
+
+	assert.False(t, AllowedHost("localhost"))
+	assert.False(t, AllowedHost("api.localhost."))
+	assert.False(t, AllowedHost("192.168.1.10"))
+	assert.False(t, AllowedHost("[::1]"))
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Get(server.URL)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// The same check holds for hostnames, after they are resolved.
+	u, _ := url.Parse(server.URL)
+	_, err = NewClient(time.Second).Get("http://localhost:" + u.Port())
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAllow(t *testing.T) {
+	assert.NoError(t, Allow([]string{"homeassistant.local", "192.168.1.0/24", " 127.0.0.1 ", ""}))
+	defer Allow(nil)
+
+	assert.True(t, Allowed(netip.MustParseAddr("192.168.1.10")))
+	assert.True(t, Allowed(netip.MustParseAddr("::ffff:192.168.1.10")))
+	assert.False(t, Allowed(netip.MustParseAddr("192.168.2.10")))
+	assert.True(t, AllowedHost("HomeAssistant.local."))
+	assert.True(t, AllowedHost("127.0.0.1"))
+	assert.False(t, AllowedHost("localhost"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	res, err := NewClient(time.Second).Get(server.URL)
+	assert.NoError(t, err)
+	if err == nil {
+		res.Body.Close()
+	}
+
+	assert.NoError(t, Allow([]string{"localhost"}))
+	u, _ := url.Parse(server.URL)
+	res, err = NewClient(time.Second).Get("http://localhost:" + u.Port())
+	assert.NoError(t, err)
+	if err == nil {
+		res.Body.Close()
+	}
+
+	assert.Error(t, Allow([]string{"10.0.0.0/33"}))
+	assert.Error(t, Allow([]string{"ha.local:8123"}))
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/netguard"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Dispatcher delivers new notifications outside the app: by email when the user
+// opted in, and pushed to every channel they subscribed to the notification's kind.
+type Dispatcher struct {
+	DB          *database.DB
+	Mailer      *Mailer
+	BaseURL     string
+	TelegramAPI string
+	Client      *http.Client
+}
+
+func NewDispatcher(db *database.DB, mailer *Mailer) *Dispatcher {
+	return &Dispatcher{
+		DB:          db,
+		Mailer:      mailer,
+		BaseURL:     mailer.BaseURL,
+		TelegramAPI: TelegramAPI,
+		Client:      netguard.NewClient(pushTimeout),
+	}
+}
+
+// Notifier returns the notifier pushing to the channel.
+func (d *Dispatcher) Notifier(ch *models.NotificationChannel) Notifier {
+	switch ch.Kind {
+	case models.ChannelWebhook:
+		return &WebhookNotifier{URL: ch.URL, Secret: ch.Secret, Client: d.Client}
+	case models.ChannelNtfy:
+		return &NtfyNotifier{URL: ch.URL, Token: ch.Secret, Client: d.Client}
+	case models.ChannelTelegram:
+		return &TelegramNotifier{APIURL: d.TelegramAPI, Token: ch.Secret, ChatID: ch.ChatID, Client: d.Client}
+	}
+	return nil
+}
+
+// Notify emails and pushes a new notification. Every channel is tried, a failed
+// one doesn't hold up the others, and the failures are returned together.
+func (d *Dispatcher) Notify(ctx context.Context, n *models.Notification) error {
+	var errs []error
+	if err := d.Mailer.Notify(n); err != nil {
+		errs = append(errs, fmt.Errorf("email: %w", err))
+	}
+
+	channels, err := d.DB.GetSubscribedChannels(n.UserID, n.Kind)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	for i := range channels {
+		if err := d.Push(ctx, &channels[i], n); err != nil {
+			errs = append(errs, fmt.Errorf("channel %s: %w", channels[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Push sends the notification to one channel, with the link made a full address.
+func (d *Dispatcher) Push(ctx context.Context, ch *models.NotificationChannel, n *models.Notification) error {
+	notifier := d.Notifier(ch)
+	if notifier == nil {
+		return fmt.Errorf("unknown channel kind %q", ch.Kind)
+	}
+
+	pushed := *n
+	if pushed.Link != "" {
+		pushed.Link = d.BaseURL + pushed.Link
+	}
+	return notifier.Notify(ctx, &pushed)
+}
+
+// SendTest pushes a test notification to the channel, so a user can check it
+// before saving.
+func (d *Dispatcher) SendTest(ctx context.Context, ch *models.NotificationChannel) error {
+	kind := models.NotificationDocumentExpiry
+	if len(ch.Events) > 0 {
+		kind = ch.Events[0]
+	}
+
+	return d.Push(ctx, ch, &models.Notification{
+		UserID:    ch.UserID,
+		Kind:      kind,
+		Title:     "Expenser test notification",
+		Message:   fmt.Sprintf("Notifications of %s reach you here.", ch.Name),
+		Link:      "/notifications",
+		CreatedAt: time.Now(),
+	})
+}
@@ -0,0 +1,302 @@
+// Package notify delivers notifications outside the app: an email for every new
+// notification and a monthly digest of the previous month, to the users who
+// opted in to them, and pushes to the webhook, ntfy and Telegram channels users
+// subscribed to the kinds of notifications they want.
+package notify
+
+import (
+	"context"
+	"expenser/internal/config"
+	database "expenser/internal/db"
+	"expenser/internal/mail"
+	"expenser/internal/models"
+	"expenser/internal/statement"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// topCategories is how many of the month's categories the digest lists.
+	topCategories = 5
+	// upcomingDays is how far ahead the digest looks for services and documents coming due.
+	upcomingDays = 30
+)
+
+// Mailer emails notifications and digests. It sends nothing without a transport,
+// when no mail server is configured.
+type Mailer struct {
+	DB        *database.DB
+	Transport mail.Transport
+	BaseURL   string
+}
+
+func NewMailer(db *database.DB, cfg *config.Config) *Mailer {
+	m := &Mailer{
+		DB:      db,
+		BaseURL: cfg.BaseURL,
+	}
+	if cfg.SMTP.Enabled() {
+		m.Transport = mail.NewSMTPTransport(cfg.SMTP)
+	}
+	return m
+}
+
+// Enabled reports whether the mailer can send emails.
+func (m *Mailer) Enabled() bool {
+	return m.Transport != nil
+}
+
+// notificationEmail is a notification with the full address of its link.
+type notificationEmail struct {
+	models.Notification
+	URL string
+}
+
+// Notify emails a new notification to its user, if they opted in to email notifications.
+func (m *Mailer) Notify(n *models.Notification) error {
+	if !m.Enabled() {
+		return nil
+	}
+
+	settings, err := m.DB.GetNotificationSettings(n.UserID)
+	if err != nil {
+		return err
+	}
+	if !settings.EmailNotifications || settings.Address() == "" {
+		return nil
+	}
+
+	msg, err := mail.Render("notification", settings.Address(), notificationEmail{Notification: *n, URL: m.BaseURL + n.Link})
+	if err != nil {
+		return err
+	}
+	return m.Transport.Send(msg)
+}
+
+// SendTest emails a test message to the address, so a user can check their settings.
+func (m *Mailer) SendTest(to string) error {
+	if !m.Enabled() {
+		return fmt.Errorf("no mail server is configured")
+	}
+
+	msg, err := mail.Render("test", to, struct{ URL string }{m.BaseURL})
+	if err != nil {
+		return err
+	}
+	return m.Transport.Send(msg)
+}
+
+// SendPasswordReset emails the user a link to reset their password with the token.
+func (m *Mailer) SendPasswordReset(to, username, token string) error {
+	if !m.Enabled() {
+		return fmt.Errorf("no mail server is configured")
+	}
+
+	data := struct{ Username, URL string }{username, m.BaseURL + "/password/reset?token=" + token}
+	msg, err := mail.Render("password-reset", to, data)
+	if err != nil {
+		return err
+	}
+	return m.Transport.Send(msg)
+}
+
+// SendEmailVerification emails the user a link to verify their address with the token.
+func (m *Mailer) SendEmailVerification(to, username, token string) error {
+	if !m.Enabled() {
+		return fmt.Errorf("no mail server is configured")
+	}
+
+	data := struct{ Username, URL string }{username, m.BaseURL + "/email/verify?token=" + token}
+	msg, err := mail.Render("verify-email", to, data)
+	if err != nil {
+		return err
+	}
+	return m.Transport.Send(msg)
+}
+
+// SendTwoFactorReset tells the user their two-factor authentication was turned
+// off by the administrator.
+func (m *Mailer) SendTwoFactorReset(to, username string) error {
+	if !m.Enabled() {
+		return fmt.Errorf("no mail server is configured")
+	}
+
+	data := struct{ Username, URL string }{username, m.BaseURL + "/settings"}
+	msg, err := mail.Render("two-factor-reset", to, data)
+	if err != nil {
+		return err
+	}
+	return m.Transport.Send(msg)
+}
+
+// Digest gathers the user's digest of the month starting on month, with what's
+// coming up as of now.
+func (m *Mailer) Digest(userID uuid.UUID, month, now time.Time) (*models.Digest, error) {
+	user, err := m.DB.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	trackers := []models.Tracker{models.TrackerHouse, models.TrackerCar}
+	lines, err := statement.Lines(m.DB, userID, models.StatementMonth, month, trackers)
+	if err != nil {
+		return nil, err
+	}
+
+	income, err := m.DB.GetTotalIncomeForMonth(month, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := m.DB.GetServicePlans(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := m.DB.GetVehicleDocuments(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s := statement.Build(models.StatementMonth, month, trackers, lines)
+	digest := BuildDigest(s, income, plans, docs, now)
+	digest.Username = user.Username
+	digest.Link = m.BaseURL
+	return digest, nil
+}
+
+// SendDigests emails the digest of the month before now to every user who opted
+// in and wasn't sent it yet. A failed digest is logged and tried again next time.
+func (m *Mailer) SendDigests(now time.Time) error {
+	if !m.Enabled() {
+		return nil
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+	recipients, err := m.DB.GetDigestRecipients(month)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range recipients {
+		if err := m.sendDigest(&r, month, now); err != nil {
+			log.Printf("digest of %s for user %s: %v", month.Format("2006-01"), r.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (m *Mailer) sendDigest(settings *models.NotificationSettings, month, now time.Time) error {
+	digest, err := m.Digest(settings.UserID, month, now)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mail.Render("digest", settings.Address(), digest)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Transport.Send(msg); err != nil {
+		return err
+	}
+	return m.DB.MarkDigestSent(settings.UserID, month)
+}
+
+// BuildDigest sums up a month's statement for the digest: the tracker totals, the
+// top categories, the income left after the expenses, and what's coming up as of
+// now. The house bills of the month are expected again in the next one.
+func BuildDigest(s *models.Statement, income float64, plans []models.ServicePlan, docs []models.VehicleDocument, now time.Time) *models.Digest {
+	digest := &models.Digest{
+		Month:      s.From,
+		HouseTotal: s.Totals[models.TrackerHouse],
+		CarTotal:   s.Totals[models.TrackerCar],
+		Total:      s.Total,
+		Count:      len(s.Lines),
+		Income:     income,
+		Net:        income - s.Total,
+	}
+
+	digest.TopCategories = s.Categories
+	if len(digest.TopCategories) > topCategories {
+		digest.TopCategories = digest.TopCategories[:topCategories]
+	}
+
+	for _, cat := range s.Categories {
+		if cat.Tracker != models.TrackerHouse {
+			continue
+		}
+		digest.Upcoming = append(digest.Upcoming, models.DigestItem{
+			Title:  cat.Type + " bill",
+			Detail: fmt.Sprintf("%.2f BGN last month", cat.Amount),
+		})
+	}
+
+	for i := range plans {
+		status := plans[i].Status(now)
+		if status.State != models.ServiceDueSoon && status.State != models.ServiceOverdue {
+			continue
+		}
+		digest.Upcoming = append(digest.Upcoming, models.DigestItem{
+			Title:  fmt.Sprintf("%s %s", status.Plan.Vehicle, status.Plan.Name),
+			Detail: fmt.Sprintf("%s, due %s", status.State.Label(), status.Due()),
+		})
+	}
+
+	for i := range docs {
+		doc := &docs[i]
+		if doc.Renewed || doc.DaysLeft(now) > upcomingDays {
+			continue
+		}
+
+		detail := "expires on " + doc.ExpiresOn.Format("02.01.2006")
+		if doc.DaysLeft(now) < 0 {
+			detail = "expired on " + doc.ExpiresOn.Format("02.01.2006")
+		}
+		digest.Upcoming = append(digest.Upcoming, models.DigestItem{
+			Title:  fmt.Sprintf("%s %s", doc.Vehicle, doc.Kind.Label()),
+			Detail: detail,
+		})
+	}
+
+	return digest
+}
+
+// Worker sends the monthly digests in the background.
+type Worker struct {
+	Mailer   *Mailer
+	Interval time.Duration
+}
+
+func NewWorker(mailer *Mailer, interval time.Duration) *Worker {
+	return &Worker{
+		Mailer:   mailer,
+		Interval: interval,
+	}
+}
+
+// Run sends the due digests right away and then every interval until the context
+// is cancelled. It returns at once when no mail server is configured.
+func (w *Worker) Run(ctx context.Context) {
+	if !w.Mailer.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Mailer.SendDigests(time.Now()); err != nil {
+			log.Printf("digests: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
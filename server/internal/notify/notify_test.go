@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"expenser/internal/mail"
+	"expenser/internal/models"
+	"expenser/internal/statement"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildDigest(t *testing.T) {
+	month := day(2025, time.September, 1)
+	lines := []models.StatementLine{
+		{Tracker: models.TrackerHouse, Date: day(2025, time.September, 5), Type: "Electricity", Amount: 84},
+		{Tracker: models.TrackerHouse, Date: day(2025, time.September, 8), Type: "Water", Amount: 26},
+		{Tracker: models.TrackerCar, Date: day(2025, time.September, 12), Type: "Fuel", Amount: 120},
+		{Tracker: models.TrackerCar, Date: day(2025, time.September, 20), Type: "Fuel", Amount: 90},
+	}
+	s := statement.Build(models.StatementMonth, month, []models.Tracker{models.TrackerHouse, models.TrackerCar}, lines)
+
+	now := day(2025, time.October, 1)
+	lastService, months := day(2024, time.October, 20), 12
+	plans := []models.ServicePlan{
+		{Vehicle: "Golf", Name: "Oil change", IntervalMonths: &months, LastDate: &lastService},
+		{Vehicle: "Golf", Name: "Timing belt", IntervalMonths: &months, LastDate: &now},
+	}
+	docs := []models.VehicleDocument{
+		{Vehicle: "Golf", Kind: models.DocumentInsurance, ExpiresOn: day(2025, time.October, 14)},
+		{Vehicle: "Golf", Kind: models.DocumentVignette, ExpiresOn: day(2026, time.March, 1)},
+		{Vehicle: "Golf", Kind: models.DocumentInspection, ExpiresOn: day(2025, time.October, 2), Renewed: true},
+	}
+
+	digest := BuildDigest(s, 1000, plans, docs, now)
+	assert.Equal(t, month, digest.Month)
+	assert.Equal(t, 110.0, digest.HouseTotal)
+	assert.Equal(t, 210.0, digest.CarTotal)
+	assert.Equal(t, 320.0, digest.Total)
+	assert.Equal(t, 4, digest.Count)
+	assert.Equal(t, 680.0, digest.Net)
+	assert.False(t, digest.Overspent())
+	assert.Equal(t, "680.00 BGN of the income of 1000.00 BGN was left, 68% saved.", digest.BudgetStatus())
+
+	assert.Len(t, digest.TopCategories, 3)
+	assert.Equal(t, "Fuel", digest.TopCategories[0].Type)
+
+	assert.Len(t, digest.Upcoming, 4)
+	assert.Equal(t, "Electricity bill", digest.Upcoming[0].Title)
+	assert.Equal(t, "84.00 BGN last month", digest.Upcoming[0].Detail)
+	assert.Equal(t, "Golf Oil change", digest.Upcoming[2].Title)
+	assert.Equal(t, "expires on 14.10.2025", digest.Upcoming[3].Detail)
+
+	digest.Username = "ivan"
+	digest.Link = "http://localhost:8080"
+	msg, err := mail.Render("digest", "ivan@example.com", digest)
+	assert.NoError(t, err)
+	assert.Equal(t, "Your September 2025 expenses: 320.00 BGN", msg.Subject)
+	assert.Contains(t, msg.Text, "- Car Fuel: 210.00 BGN (65.6%)")
+	assert.Contains(t, msg.Text, "- Golf Oil change: Due soon, due 20.10.2025")
+	assert.Contains(t, msg.HTML, "<strong>Water bill</strong>: 26.00 BGN last month")
+}
+
+func TestDigestOverspent(t *testing.T) {
+	digest := &models.Digest{Total: 500, Income: 400, Net: -100}
+	assert.True(t, digest.Overspent())
+	assert.Equal(t, "Expenses were 100.00 BGN over the income of 400.00 BGN.", digest.BudgetStatus())
+
+	digest = &models.Digest{Total: 50}
+	assert.Equal(t, "No income was recorded, the expenses were 50.00 BGN.", digest.BudgetStatus())
+}
+
+// sentMessages is a transport keeping the messages it is given.
+type sentMessages []*mail.Message
+
+func (s *sentMessages) Send(msg *mail.Message) error {
+	*s = append(*s, msg)
+	return nil
+}
+
+func TestSendPasswordReset(t *testing.T) {
+	var sent sentMessages
+	m := &Mailer{Transport: &sent, BaseURL: "https://expenser.lan"}
+
+	assert.NoError(t, m.SendPasswordReset("ivan@example.com", "ivan", "abc123"))
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "ivan@example.com", sent[0].To)
+	assert.Equal(t, "Reset your Expenser password", sent[0].Subject)
+	assert.Contains(t, sent[0].Text, "https://expenser.lan/password/reset?token=abc123")
+	assert.Contains(t, sent[0].HTML, `href="https://expenser.lan/password/reset?token=abc123"`)
+
+	assert.Error(t, (&Mailer{}).SendPasswordReset("ivan@example.com", "ivan", "abc123"))
+}
+
+func TestSendEmailVerification(t *testing.T) {
+	var sent sentMessages
+	m := &Mailer{Transport: &sent, BaseURL: "https://expenser.lan"}
+
+	assert.NoError(t, m.SendEmailVerification("ivan@example.com", "ivan", "a.b-c"))
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "ivan@example.com", sent[0].To)
+	assert.Equal(t, "Verify your Expenser email address", sent[0].Subject)
+	assert.Contains(t, sent[0].Text, "https://expenser.lan/email/verify?token=a.b-c")
+	assert.Contains(t, sent[0].HTML, `href="https://expenser.lan/email/verify?token=a.b-c"`)
+
+	assert.Error(t, (&Mailer{}).SendEmailVerification("ivan@example.com", "ivan", "a.b-c"))
+}
+
+func TestSendTwoFactorReset(t *testing.T) {
+	var sent sentMessages
+	m := &Mailer{Transport: &sent, BaseURL: "https://expenser.lan"}
+
+	assert.NoError(t, m.SendTwoFactorReset("ivan@example.com", "ivan"))
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "ivan@example.com", sent[0].To)
+	assert.Equal(t, "Two-factor authentication of your Expenser account was turned off", sent[0].Subject)
+	assert.Contains(t, sent[0].Text, "account ivan")
+	assert.Contains(t, sent[0].HTML, `href="https://expenser.lan/settings"`)
+}
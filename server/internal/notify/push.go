@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expenser/internal/models"
+	"expenser/internal/netguard"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TelegramAPI is the Telegram Bot API server.
+const TelegramAPI = "https://api.telegram.org"
+
+// pushTimeout is how long a push service gets to accept a notification.
+const pushTimeout = 10 * time.Second
+
+// Notifier pushes a notification to one destination. The link of the
+// notification is a full address.
+type Notifier interface {
+	Notify(ctx context.Context, n *models.Notification) error
+}
+
+// Webhook headers. The signature is the hex HMAC-SHA256 of the timestamp, a dot
+// and the body, keyed with the channel secret, so receivers can check where a
+// payload came from and reject old ones.
+const (
+	EventHeader     = "X-Expenser-Event"
+	TimestampHeader = "X-Expenser-Timestamp"
+	SignatureHeader = "X-Expenser-Signature"
+)
+
+// Sign returns the signature of a webhook body sent at timestamp, in unix seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookPayload is the JSON body a webhook is posted.
+type WebhookPayload struct {
+	Event     models.NotificationKind `json:"event"`
+	Title     string                  `json:"title"`
+	Message   string                  `json:"message"`
+	Link      string                  `json:"link,omitempty"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+// WebhookNotifier posts notifications as signed JSON to a URL.
+type WebhookNotifier struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n *models.Notification) error {
+	body, err := json.Marshal(WebhookPayload{
+		Event:     n.Kind,
+		Title:     n.Title,
+		Message:   n.Message,
+		Link:      n.Link,
+		CreatedAt: n.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(n.Kind))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(w.Secret, timestamp, body))
+
+	_, err = send(w.Client, req)
+	return err
+}
+
+// NtfyNotifier publishes notifications to a topic of an ntfy server. The token
+// is only sent for topics that need one.
+type NtfyNotifier struct {
+	URL    string // URL is the topic, like https://ntfy.sh/my-expenses.
+	Token  string
+	Client *http.Client
+}
+
+func (p *NtfyNotifier) Notify(ctx context.Context, n *models.Notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, strings.NewReader(n.Message))
+	if err != nil {
+		return fmt.Errorf("invalid ntfy URL: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Title", n.Title)
+	req.Header.Set("Tags", string(n.Kind))
+	if n.Link != "" {
+		req.Header.Set("Click", n.Link)
+	}
+	if n.Kind == models.NotificationDocumentExpiry || n.Kind == models.NotificationBudgetExceeded {
+		req.Header.Set("Priority", "high")
+	}
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	_, err = send(p.Client, req)
+	return err
+}
+
+// TelegramNotifier sends notifications as messages of a bot to a chat.
+type TelegramNotifier struct {
+	APIURL string // APIURL is the Bot API server, TelegramAPI unless testing.
+	Token  string
+	ChatID string
+	Client *http.Client
+}
+
+func (t *TelegramNotifier) Notify(ctx context.Context, n *models.Notification) error {
+	text := n.Title + "\n\n" + n.Message
+	if n.Link != "" {
+		text += "\n" + n.Link
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  t.ChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode telegram message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(t.APIURL, "/"), t.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid telegram API URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Telegram explains refused messages in the reply, the Bot API is trusted to
+	// say why.
+	var reply struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	res, err := send(t.Client, req)
+	if jsonErr := json.Unmarshal(res, &reply); jsonErr == nil && !reply.OK && reply.Description != "" {
+		return fmt.Errorf("telegram: %s", reply.Description)
+	}
+	if err != nil {
+		// The token is part of the address, keep it out of the error.
+		if t.Token != "" {
+			return fmt.Errorf("telegram: %s", strings.ReplaceAll(err.Error(), t.Token, "***"))
+		}
+		return fmt.Errorf("telegram: %w", err)
+	}
+	if !reply.OK {
+		return fmt.Errorf("telegram: invalid reply")
+	}
+	return nil
+}
+
+// send makes the request and returns the response body, with an error for any
+// status but 2xx. The error leaves the body out, it is whatever the address the
+// user entered answered.
+func send(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = netguard.NewClient(pushTimeout)
+	}
+	req.Header.Set("User-Agent", "Expenser")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to push notification: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push reply: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return body, fmt.Errorf("push rejected with %s", res.Status)
+	}
+	return body, nil
+}
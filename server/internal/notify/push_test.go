@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"expenser/internal/models"
+	"expenser/internal/netguard"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testNotification() *models.Notification {
+	return &models.Notification{
+		Kind:      models.NotificationDocumentExpiry,
+		Title:     "Golf Civil liability insurance expires tomorrow",
+		Message:   "Civil liability insurance of Golf is valid until 15.10.2025.",
+		Link:      "http://localhost:8080/vehicles",
+		CreatedAt: time.Date(2025, time.October, 14, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+// standIn records the requests of a push service and answers them with status and reply.
+func standIn(t *testing.T, status int, reply string) (*httptest.Server, chan *http.Request, chan []byte) {
+	requests, bodies := make(chan *http.Request, 1), make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests, bodies
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server, requests, bodies := standIn(t, http.StatusNoContent, "")
+
+	notifier := &WebhookNotifier{URL: server.URL + "/hooks/expenser", Secret: "s3cret", Client: server.Client()}
+	assert.NoError(t, notifier.Notify(context.Background(), testNotification()))
+
+	r, body := <-requests, <-bodies
+	assert.Equal(t, "/hooks/expenser", r.URL.Path)
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "document_expiry", r.Header.Get(EventHeader))
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, Sign("s3cret", timestamp, body), r.Header.Get(SignatureHeader))
+	assert.NotEqual(t, Sign("other", timestamp, body), r.Header.Get(SignatureHeader))
+
+	var payload WebhookPayload
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, models.NotificationDocumentExpiry, payload.Event)
+	assert.Equal(t, "Golf Civil liability insurance expires tomorrow", payload.Title)
+	assert.Equal(t, "http://localhost:8080/vehicles", payload.Link)
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(t,
+		"sha256=3eec9d84b6c50b53c43ce996ec40a5c3f02b700800040220c6d06655f9ce9590",
+		Sign("s3cret", 1700000000, []byte(`{"event":"bill_due"}`)))
+}
+
+func TestWebhookRejected(t *testing.T) {
+	server, _, _ := standIn(t, http.StatusGone, "hook removed")
+
+	notifier := &WebhookNotifier{URL: server.URL, Secret: "s3cret", Client: server.Client()}
+	err := notifier.Notify(context.Background(), testNotification())
+	assert.ErrorContains(t, err, "410 Gone")
+	assert.NotContains(t, err.Error(), "hook removed")
+}
+
+func TestWebhookLocalAddress(t *testing.T) {
+	server, _, _ := standIn(t, http.StatusOK, "")
+
+	// Without a client of their own, notifiers don't reach the local network.
+	notifier := &WebhookNotifier{URL: server.URL, Secret: "s3cret"}
+	err := notifier.Notify(context.Background(), testNotification())
+	assert.ErrorIs(t, err, netguard.ErrForbidden)
+
+	// Unless the admin allows the address, e.g. for an ntfy server on the LAN.
+	assert.NoError(t, netguard.Allow([]string{"127.0.0.1"}))
+	defer netguard.Allow(nil)
+	assert.NoError(t, notifier.Notify(context.Background(), testNotification()))
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	server, requests, bodies := standIn(t, http.StatusOK, `{"id":"x1"}`)
+
+	notifier := &NtfyNotifier{URL: server.URL + "/my-expenses", Token: "tk_123", Client: server.Client()}
+	assert.NoError(t, notifier.Notify(context.Background(), testNotification()))
+
+	r, body := <-requests, <-bodies
+	assert.Equal(t, "/my-expenses", r.URL.Path)
+	assert.Equal(t, "Civil liability insurance of Golf is valid until 15.10.2025.", string(body))
+	assert.Equal(t, "Golf Civil liability insurance expires tomorrow", r.Header.Get("Title"))
+	assert.Equal(t, "http://localhost:8080/vehicles", r.Header.Get("Click"))
+	assert.Equal(t, "high", r.Header.Get("Priority"))
+	assert.Equal(t, "Bearer tk_123", r.Header.Get("Authorization"))
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	server, requests, bodies := standIn(t, http.StatusOK, `{"ok":true,"result":{"message_id":7}}`)
+
+	notifier := &TelegramNotifier{APIURL: server.URL, Token: "123:abc", ChatID: "-1001", Client: server.Client()}
+	assert.NoError(t, notifier.Notify(context.Background(), testNotification()))
+
+	r, body := <-requests, <-bodies
+	assert.Equal(t, "/bot123:abc/sendMessage", r.URL.Path)
+
+	var message struct {
+		ChatID string `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &message))
+	assert.Equal(t, "-1001", message.ChatID)
+	assert.Equal(t, "Golf Civil liability insurance expires tomorrow\n\n"+
+		"Civil liability insurance of Golf is valid until 15.10.2025.\nhttp://localhost:8080/vehicles", message.Text)
+}
+
+func TestTelegramRejected(t *testing.T) {
+	server, _, _ := standIn(t, http.StatusBadRequest, `{"ok":false,"description":"Bad Request: chat not found"}`)
+
+	notifier := &TelegramNotifier{APIURL: server.URL, Token: "123:abc", ChatID: "-1001", Client: server.Client()}
+	err := notifier.Notify(context.Background(), testNotification())
+	assert.ErrorContains(t, err, "chat not found")
+	assert.NotContains(t, err.Error(), "123:abc")
+}
+
+func TestDispatcherPush(t *testing.T) {
+	server, requests, _ := standIn(t, http.StatusOK, "")
+
+	d := &Dispatcher{BaseURL: "https://expenser.example.com", Client: server.Client()}
+	ch := &models.NotificationChannel{Kind: models.ChannelNtfy, Name: "Phone", URL: server.URL + "/topic"}
+
+	n := testNotification()
+	n.Link = "/vehicles"
+	assert.NoError(t, d.Push(context.Background(), ch, n))
+	assert.Equal(t, "https://expenser.example.com/vehicles", (<-requests).Header.Get("Click"))
+	assert.Equal(t, "/vehicles", n.Link)
+
+	ch.Kind = "pager"
+	assert.ErrorContains(t, d.Push(context.Background(), ch, n), `unknown channel kind "pager"`)
+}
@@ -0,0 +1,269 @@
+// Package passkey signs users in with WebAuthn passkeys. It runs the
+// registration and authentication ceremonies, remembering each challenge
+// between its start and its finish, and keeps credentials in models.Passkey.
+package passkey
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expenser/internal/models"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+const (
+	// CeremonyTTL is how long a user has to answer the browser's passkey prompt.
+	CeremonyTTL = 5 * time.Minute
+
+	// MaxCeremonies is how many ceremonies can be waiting at once. Logins start
+	// them without an account, so they are capped to keep memory bounded.
+	MaxCeremonies = 1000
+)
+
+var (
+	ErrNoCeremony = errors.New("the passkey prompt expired, try again")
+	ErrCloned     = errors.New("the passkey's signature counter went back, it may be cloned")
+	ErrBusy       = errors.New("too many passkey prompts are waiting, try again later")
+)
+
+// Service runs passkey ceremonies for the app at one address.
+type Service struct {
+	WebAuthn *webauthn.WebAuthn
+
+	mu         sync.Mutex
+	ceremonies map[string]*ceremony
+}
+
+// ceremony is a started registration or login, waiting for the browser's answer.
+type ceremony struct {
+	Session   webauthn.SessionData
+	UserID    uuid.UUID // UserID is the registering user, uuid.Nil for a login.
+	ExpiresAt time.Time
+}
+
+// New returns the service for the app at baseURL. Passkeys are bound to its
+// host name and only work from its origin.
+func New(baseURL string) (*Service, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid base URL %q for passkeys", baseURL)
+	}
+
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: "Expenser",
+		RPOrigins:     []string{u.Scheme + "://" + u.Host},
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: CeremonyTTL},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: CeremonyTTL},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure passkeys: %w", err)
+	}
+
+	return &Service{WebAuthn: w, ceremonies: map[string]*ceremony{}}, nil
+}
+
+// User is a user with their passkeys, as WebAuthn sees them.
+type User struct {
+	*models.User
+	Passkeys []models.Passkey
+}
+
+// WebAuthnID is the user handle authenticators keep, the bytes of the user's ID.
+func (u *User) WebAuthnID() []byte {
+	return u.ID[:]
+}
+
+func (u *User) WebAuthnName() string {
+	return u.Username
+}
+
+func (u *User) WebAuthnDisplayName() string {
+	return u.Username
+}
+
+func (u *User) WebAuthnIcon() string {
+	return ""
+}
+
+// WebAuthnCredentials returns the credentials of the passkeys, skipping any
+// that don't decode.
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Passkeys))
+	for _, p := range u.Passkeys {
+		var c webauthn.Credential
+		if err := json.Unmarshal(p.Credential, &c); err == nil {
+			credentials = append(credentials, c)
+		}
+	}
+	return credentials
+}
+
+// start remembers the ceremony and returns its ID, dropping expired ones. It
+// returns ErrBusy when MaxCeremonies are still waiting.
+func (s *Service) start(session *webauthn.SessionData, userID uuid.UUID) (string, error) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, c := range s.ceremonies {
+		if now.After(c.ExpiresAt) {
+			delete(s.ceremonies, key)
+		}
+	}
+	if len(s.ceremonies) >= MaxCeremonies {
+		return "", ErrBusy
+	}
+
+	s.ceremonies[id] = &ceremony{Session: *session, UserID: userID, ExpiresAt: now.Add(CeremonyTTL)}
+	return id, nil
+}
+
+// take returns the ceremony with the ID and forgets it, so every challenge is answered once.
+func (s *Service) take(id string) (*ceremony, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.ceremonies[id]
+	delete(s.ceremonies, id)
+	if !ok || time.Now().After(c.ExpiresAt) {
+		return nil, ErrNoCeremony
+	}
+	return c, nil
+}
+
+// BeginRegistration starts adding a passkey for the user. It returns the options
+// for navigator.credentials.create and the ID of the ceremony to finish.
+func (s *Service) BeginRegistration(user *User) (*protocol.CredentialCreation, string, error) {
+	var exclusions []protocol.CredentialDescriptor
+	for _, c := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+
+	options, session, err := s.WebAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin passkey registration: %w", err)
+	}
+
+	id, err := s.start(session, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, id, nil
+}
+
+// FinishRegistration checks the browser's JSON response to the ceremony and
+// returns the new passkey of the user with the name.
+func (s *Service) FinishRegistration(user *User, ceremonyID, response, name string) (*models.Passkey, error) {
+	c, err := s.take(ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserID != user.ID {
+		return nil, ErrNoCeremony
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(strings.NewReader(response))
+	if err != nil {
+		return nil, fmt.Errorf("invalid passkey response: %w", err)
+	}
+
+	credential, err := s.WebAuthn.CreateCredential(user, c.Session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("passkey not accepted: %w", err)
+	}
+
+	encoded, err := json.Marshal(credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode passkey: %w", err)
+	}
+
+	return &models.Passkey{
+		UserID:       user.ID,
+		CredentialID: credential.ID,
+		Name:         name,
+		Credential:   encoded,
+	}, nil
+}
+
+// BeginLogin starts a login with any passkey of the app the browser knows. It
+// returns the options for navigator.credentials.get and the ID of the ceremony.
+func (s *Service) BeginLogin() (*protocol.CredentialAssertion, string, error) {
+	options, session, err := s.WebAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin passkey login: %w", err)
+	}
+
+	id, err := s.start(session, uuid.Nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, id, nil
+}
+
+// FindUser retrieves the user with the ID, with their passkeys.
+type FindUser func(id uuid.UUID) (*User, error)
+
+// FinishLogin checks the browser's JSON response to the login ceremony. It
+// returns the user it signs in and their passkey used, with its signature
+// counter updated to store.
+func (s *Service) FinishLogin(ceremonyID, response string, find FindUser) (*User, *models.Passkey, error) {
+	c, err := s.take(ceremonyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.UserID != uuid.Nil {
+		return nil, nil, ErrNoCeremony
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(strings.NewReader(response))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid passkey response: %w", err)
+	}
+
+	var user *User
+	credential, err := s.WebAuthn.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		id, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err = find(id)
+		return user, err
+	}, c.Session, parsed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("passkey not accepted: %w", err)
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, nil, ErrCloned
+	}
+
+	for _, p := range user.Passkeys {
+		if string(p.CredentialID) == string(credential.ID) {
+			if p.Credential, err = json.Marshal(credential); err != nil {
+				return nil, nil, fmt.Errorf("failed to encode passkey: %w", err)
+			}
+			return user, &p, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("passkey not accepted: unknown credential")
+}
@@ -0,0 +1,214 @@
+package passkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"expenser/internal/models"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const origin = "http://localhost:8080"
+
+// authenticator is a software passkey of one user, answering the ceremonies
+// the way a browser would.
+type authenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &authenticator{key: key, credentialID: id}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (a *authenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte("localhost"))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func clientData(kind, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{"type": kind, "challenge": challenge, "origin": origin})
+	return data
+}
+
+// create answers navigator.credentials.create with a "none" attestation.
+func (a *authenticator) create(t *testing.T, challenge string, userHandle []byte) string {
+	a.userHandle = userHandle
+
+	publicKey, err := cbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	attested := make([]byte, 16) // AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(0x45, attested), // UP, UV and AT
+	})
+	require.NoError(t, err)
+
+	response, _ := json.Marshal(map[string]any{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(clientData("webauthn.create", challenge)),
+			"attestationObject": b64(attestation),
+		},
+	})
+	return string(response)
+}
+
+// get answers navigator.credentials.get, signing with the passkey.
+func (a *authenticator) get(t *testing.T, challenge string) string {
+	a.signCount++
+	authData := a.authData(0x05, nil) // UP and UV
+	client := clientData("webauthn.get", challenge)
+
+	clientHash := sha256.Sum256(client)
+	digest := sha256.Sum256(append(authData, clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	response, _ := json.Marshal(map[string]any{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(client),
+			"authenticatorData": b64(authData),
+			"signature":         b64(signature),
+			"userHandle":        b64(a.userHandle),
+		},
+	})
+	return string(response)
+}
+
+func TestPasskey(t *testing.T) {
+	s, err := New(origin)
+	require.NoError(t, err)
+
+	user := &User{User: &models.User{ID: uuid.New(), Username: "ivan"}}
+	a := newAuthenticator(t)
+
+	options, ceremonyID, err := s.BeginRegistration(user)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", options.Response.RelyingParty.ID)
+
+	response := a.create(t, options.Response.Challenge.String(), user.WebAuthnID())
+
+	// Each ceremony is finished once, by the user who started it.
+	_, err = s.FinishRegistration(&User{User: &models.User{ID: uuid.New()}}, ceremonyID, response, "Laptop")
+	assert.ErrorIs(t, err, ErrNoCeremony)
+
+	options, ceremonyID, err = s.BeginRegistration(user)
+	require.NoError(t, err)
+	response = a.create(t, options.Response.Challenge.String(), user.WebAuthnID())
+
+	p, err := s.FinishRegistration(user, ceremonyID, response, "Laptop")
+	require.NoError(t, err)
+	assert.Equal(t, a.credentialID, p.CredentialID)
+	assert.Equal(t, "Laptop", p.Name)
+	user.Passkeys = []models.Passkey{*p}
+
+	_, err = s.FinishRegistration(user, ceremonyID, response, "Laptop")
+	assert.ErrorIs(t, err, ErrNoCeremony)
+
+	// Registering again excludes the passkeys the user already has.
+	options, _, err = s.BeginRegistration(user)
+	require.NoError(t, err)
+	assert.Len(t, options.Response.CredentialExcludeList, 1)
+
+	find := func(id uuid.UUID) (*User, error) {
+		if id != user.ID {
+			return nil, fmt.Errorf("user not found")
+		}
+		return user, nil
+	}
+
+	login, ceremonyID, err := s.BeginLogin()
+	require.NoError(t, err)
+
+	signedIn, used, err := s.FinishLogin(ceremonyID, a.get(t, login.Response.Challenge.String()), find)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, signedIn.ID)
+	assert.Equal(t, p.ID, used.ID)
+	assert.Contains(t, string(used.Credential), `"SignCount":1`)
+	user.Passkeys = []models.Passkey{*used}
+
+	// A passkey of another user doesn't sign in.
+	login, ceremonyID, err = s.BeginLogin()
+	require.NoError(t, err)
+	other := newAuthenticator(t)
+	other.userHandle = a.userHandle
+	_, _, err = s.FinishLogin(ceremonyID, other.get(t, login.Response.Challenge.String()), find)
+	assert.Error(t, err)
+
+	// A signature counter going back is taken for a cloned passkey.
+	login, ceremonyID, err = s.BeginLogin()
+	require.NoError(t, err)
+	a.signCount = 0
+	_, _, err = s.FinishLogin(ceremonyID, a.get(t, login.Response.Challenge.String()), find)
+	assert.ErrorIs(t, err, ErrCloned)
+
+	_, _, err = s.FinishLogin("unknown", "{}", find)
+	assert.ErrorIs(t, err, ErrNoCeremony)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("not a url")
+	assert.Error(t, err)
+}
+
+func TestMaxCeremonies(t *testing.T) {
+	s, err := New("http://localhost:8080")
+	require.NoError(t, err)
+
+	for i := 0; i < MaxCeremonies; i++ {
+		_, _, err := s.BeginLogin()
+		require.NoError(t, err)
+	}
+
+	_, _, err = s.BeginLogin()
+	assert.ErrorIs(t, err, ErrBusy)
+
+	// Expired ceremonies make room again.
+	for _, c := range s.ceremonies {
+		c.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	_, _, err = s.BeginLogin()
+	assert.NoError(t, err)
+}
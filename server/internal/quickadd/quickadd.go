@@ -0,0 +1,329 @@
+// Package quickadd interprets short free text expense phrases such as
+// "fuel 85.40 yesterday shell" or "ток 120 15.03", in English or Bulgarian.
+package quickadd
+
+import (
+	"errors"
+	"expenser/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrEmpty    = errors.New("nothing to add")
+	ErrNoAmount = errors.New("no amount found")
+)
+
+// amountPattern is an amount in lv with up to two decimals, after any currency word.
+var amountPattern = regexp.MustCompile(`^\d+([.,]\d{1,2})?$`)
+
+// typeSynonyms maps the seeded expense type names to the words naming them.
+// The type names themselves always match, the synonyms only when the type exists.
+var typeSynonyms = map[models.Tracker]map[string][]string{
+	models.TrackerCar: {
+		"Fuel":               {"fuel", "petrol", "diesel", "gasoline", "lpg", "гориво", "бензин", "дизел", "нафта", "зареждане"},
+		"Maintenance/Repair": {"service", "repair", "maintenance", "mechanic", "сервиз", "ремонт", "поддръжка", "механик"},
+		"Insurance":          {"insurance", "casco", "застраховка", "гражданска", "каско"},
+		"Car Wash":           {"wash", "carwash", "car wash", "миене", "автомивка"},
+		"Parking/Tolls":      {"parking", "toll", "tolls", "vignette", "паркинг", "винетка", "тол"},
+		"Oil":                {"oil", "масло"},
+		"Tires":              {"tires", "tyres", "гуми"},
+	},
+	models.TrackerHouse: {
+		"Electricity": {"electricity", "power", "ток", "електричество", "електроенергия"},
+		"Water":       {"water", "вода", "вик"},
+		"Gas":         {"gas", "газ"},
+		"Internet":    {"internet", "wifi", "интернет"},
+		"TV":          {"tv", "cable", "телевизия", "кабелна"},
+		"Waste":       {"waste", "garbage", "trash", "смет", "боклук"},
+	},
+}
+
+// trackerWords pick the tracker when no type is named, its "Other" type is used then.
+var trackerWords = map[string]models.Tracker{
+	"car":       models.TrackerCar,
+	"кола":      models.TrackerCar,
+	"колата":    models.TrackerCar,
+	"автомобил": models.TrackerCar,
+	"house":     models.TrackerHouse,
+	"home":      models.TrackerHouse,
+	"къща":      models.TrackerHouse,
+	"дом":       models.TrackerHouse,
+	"вкъщи":     models.TrackerHouse,
+}
+
+// relativeDays maps relative date words to the number of days before today.
+var relativeDays = map[string]int{
+	"today":     0,
+	"днес":      0,
+	"yesterday": 1,
+	"вчера":     1,
+	"завчера":   2,
+}
+
+var weekdayWords = map[string]time.Weekday{
+	"monday":     time.Monday,
+	"tuesday":    time.Tuesday,
+	"wednesday":  time.Wednesday,
+	"thursday":   time.Thursday,
+	"friday":     time.Friday,
+	"saturday":   time.Saturday,
+	"sunday":     time.Sunday,
+	"понеделник": time.Monday,
+	"вторник":    time.Tuesday,
+	"сряда":      time.Wednesday,
+	"четвъртък":  time.Thursday,
+	"петък":      time.Friday,
+	"събота":     time.Saturday,
+	"неделя":     time.Sunday,
+}
+
+// currencyWords are dropped from the text, amounts are always in BGN.
+var currencyWords = []string{"лв.", "лв", "lv", "bgn", "leva", "лева"}
+
+// Types are the expense types a phrase can name.
+type Types struct {
+	House []models.HomeUtilityType
+	Car   []models.CarExpenseType
+}
+
+// typeWord is an expense type named by a word.
+type typeWord struct {
+	tracker models.Tracker
+	id      int
+	name    string
+}
+
+func (t *Types) words() map[string]typeWord {
+	words := make(map[string]typeWord)
+	add := func(tracker models.Tracker, id int, name string) {
+		word := typeWord{tracker: tracker, id: id, name: name}
+		for _, w := range typeSynonyms[tracker][name] {
+			words[w] = word
+		}
+		// "Maintenance/Repair" is named by either half as well.
+		for _, part := range strings.Split(name, "/") {
+			words[strings.ToLower(strings.TrimSpace(part))] = word
+		}
+		words[strings.ToLower(name)] = word
+	}
+
+	// Car types go first so shared words like "gas" end up as house types.
+	for _, typ := range t.Car {
+		add(models.TrackerCar, typ.ID, typ.Name)
+	}
+	for _, typ := range t.House {
+		add(models.TrackerHouse, typ.ID, typ.Name)
+	}
+	return words
+}
+
+// other returns the tracker's "Other" type, or its first type.
+func (t *Types) other(tracker models.Tracker) (int, string) {
+	if tracker == models.TrackerCar {
+		for _, typ := range t.Car {
+			if strings.EqualFold(typ.Name, "Other") {
+				return typ.ID, typ.Name
+			}
+		}
+		if len(t.Car) > 0 {
+			return t.Car[0].ID, t.Car[0].Name
+		}
+		return 0, ""
+	}
+
+	for _, typ := range t.House {
+		if strings.EqualFold(typ.Name, "Other") {
+			return typ.ID, typ.Name
+		}
+	}
+	if len(t.House) > 0 {
+		return t.House[0].ID, t.House[0].Name
+	}
+	return 0, ""
+}
+
+// Parse interprets the phrase. The first number is the amount, a later date-like
+// token or a word such as "yesterday" the date, the first word naming an expense
+// type its type and everything else the notes. Without a date the expense is for
+// today. When no type is named ExpenseTypeID is left zero for rules or the user to fill.
+func Parse(text string, now time.Time, types *Types) (*models.QuickAdd, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmpty
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	entry := &models.QuickAdd{Text: text, Date: today}
+
+	words := types.words()
+	tokens := strings.Fields(text)
+
+	var notes []string
+	var trackerHint models.Tracker
+	hasAmount, hasDate := false, false
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		word := normalize(token)
+
+		if word == "" || isCurrency(word) {
+			continue
+		}
+
+		// Two word type names like "car wash" win over their single words.
+		if entry.ExpenseTypeID == 0 && i+1 < len(tokens) {
+			if typ, ok := words[word+" "+normalize(tokens[i+1])]; ok {
+				entry.Tracker, entry.ExpenseTypeID, entry.ExpenseType = typ.tracker, typ.id, typ.name
+				i++
+				continue
+			}
+		}
+
+		if date, ok := parseFullDate(word, today); ok && !hasDate {
+			entry.Date, hasDate = date, true
+			continue
+		}
+
+		if amount, ok := parseAmount(word); ok && !hasAmount {
+			entry.Amount, hasAmount = amount, true
+			continue
+		}
+
+		if date, ok := parseShortDate(word, today); ok && !hasDate {
+			entry.Date, hasDate = date, true
+			continue
+		}
+
+		if days, ok := relativeDays[word]; ok && !hasDate {
+			entry.Date, hasDate = today.AddDate(0, 0, -days), true
+			continue
+		}
+
+		if day, ok := weekdayWords[word]; ok && !hasDate {
+			entry.Date, hasDate = lastWeekday(today, day), true
+			continue
+		}
+
+		if typ, ok := words[word]; ok && entry.ExpenseTypeID == 0 {
+			entry.Tracker, entry.ExpenseTypeID, entry.ExpenseType = typ.tracker, typ.id, typ.name
+			continue
+		}
+
+		if tracker, ok := trackerWords[word]; ok && trackerHint == "" {
+			trackerHint = tracker
+			continue
+		}
+
+		notes = append(notes, strings.TrimFunc(token, unicode.IsPunct))
+	}
+
+	if !hasAmount {
+		return nil, ErrNoAmount
+	}
+
+	if entry.ExpenseTypeID == 0 && trackerHint != "" {
+		entry.Tracker = trackerHint
+		entry.ExpenseTypeID, entry.ExpenseType = types.other(trackerHint)
+	}
+
+	entry.Notes = strings.Join(notes, " ")
+	return entry, nil
+}
+
+// normalize lower cases the token and drops surrounding punctuation, keeping
+// the dot of "лв." and the separators inside numbers and dates.
+func normalize(token string) string {
+	word := strings.ToLower(token)
+	word = strings.TrimLeftFunc(word, unicode.IsPunct)
+	if strings.HasSuffix(word, "лв.") {
+		return word
+	}
+	return strings.TrimRightFunc(word, unicode.IsPunct)
+}
+
+func isCurrency(word string) bool {
+	for _, c := range currencyWords {
+		if word == c {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAmount reads "85.40", "85,40" or "85.40лв".
+func parseAmount(word string) (float64, bool) {
+	for _, c := range currencyWords {
+		if strings.HasSuffix(word, c) {
+			word = strings.TrimSuffix(word, c)
+			break
+		}
+	}
+
+	// Only whole cents, "1.234" is more likely a date typo than an amount. Words
+	// like "nan", "inf" or "1e3" that ParseFloat takes aren't amounts either.
+	if !amountPattern.MatchString(word) {
+		return 0, false
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(word, ",", ".", 1), 64)
+	if err != nil || amount <= 0 {
+		return 0, false
+	}
+
+	return amount, true
+}
+
+// parseFullDate reads dates that can't be an amount: "15.03.2025", "15.03.25",
+// "15/03" and "2025-03-15".
+func parseFullDate(word string, today time.Time) (time.Time, bool) {
+	for _, layout := range []string{"02.01.2006", "2.1.2006", "02.01.06", "2.1.06", "2006-01-02"} {
+		if date, err := time.ParseInLocation(layout, word, today.Location()); err == nil {
+			return date, true
+		}
+	}
+
+	if strings.Contains(word, "/") {
+		return parseShortDate(strings.ReplaceAll(word, "/", "."), today)
+	}
+
+	return time.Time{}, false
+}
+
+// parseShortDate reads "15.03" as the last 15 March that isn't in the future.
+func parseShortDate(word string, today time.Time) (time.Time, bool) {
+	dayStr, monthStr, ok := strings.Cut(word, ".")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	day, err := strconv.Atoi(dayStr)
+	if err != nil || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	month, err := strconv.Atoi(monthStr)
+	if err != nil || month < 1 || month > 12 {
+		return time.Time{}, false
+	}
+
+	date := time.Date(today.Year(), time.Month(month), day, 0, 0, 0, 0, today.Location())
+	if date.Day() != day {
+		return time.Time{}, false
+	}
+
+	if date.After(today) {
+		date = date.AddDate(-1, 0, 0)
+	}
+
+	return date, true
+}
+
+// lastWeekday returns the most recent given weekday, today included.
+func lastWeekday(today time.Time, day time.Weekday) time.Time {
+	diff := (int(today.Weekday()) - int(day) + 7) % 7
+	return today.AddDate(0, 0, -diff)
+}
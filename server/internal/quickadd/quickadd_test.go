@@ -0,0 +1,85 @@
+package quickadd
+
+import (
+	"expenser/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTypes = &Types{
+	House: []models.HomeUtilityType{
+		{ID: 1, Name: "Electricity"},
+		{ID: 2, Name: "Water"},
+		{ID: 3, Name: "Gas"},
+		{ID: 7, Name: "Other"},
+	},
+	Car: []models.CarExpenseType{
+		{ID: 1, Name: "Fuel"},
+		{ID: 2, Name: "Maintenance/Repair"},
+		{ID: 4, Name: "Car Wash"},
+		{ID: 6, Name: "Other"},
+	},
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, time.March, 20, 18, 30, 0, 0, time.Local)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		tracker models.Tracker
+		typeID  int
+		amount  float64
+		date    time.Time
+		notes   string
+	}{
+		{"english relative date", "fuel 85.40 yesterday shell", models.TrackerCar, 1, 85.40, day(2025, time.March, 19), "shell"},
+		{"bulgarian short date", "ток 120 15.03", models.TrackerHouse, 1, 120, day(2025, time.March, 15), ""},
+		{"short date last year", "вода 30,50 лв 25.12", models.TrackerHouse, 2, 30.50, day(2024, time.December, 25), ""},
+		{"full date", "сервиз 250лв 03.02.2025 смяна на ремък", models.TrackerCar, 2, 250, day(2025, time.February, 3), "смяна на ремък"},
+		{"iso date", "repair 99 2025-01-10", models.TrackerCar, 2, 99, day(2025, time.January, 10), ""},
+		{"two word type", "car wash 12 today", models.TrackerCar, 4, 12, day(2025, time.March, 20), ""},
+		{"type name part", "maintenance 40", models.TrackerCar, 2, 40, day(2025, time.March, 20), ""},
+		{"gas is a house type", "gas 64.10", models.TrackerHouse, 3, 64.10, day(2025, time.March, 20), ""},
+		{"weekday", "бензин 70 понеделник", models.TrackerCar, 1, 70, day(2025, time.March, 17), ""},
+		{"tracker word only", "кола 15 чистачки", models.TrackerCar, 6, 15, day(2025, time.March, 20), "чистачки"},
+		{"no type", "lidl 42.17", "", 0, 42.17, day(2025, time.March, 20), "lidl"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry, err := Parse(test.text, now, testTypes)
+			assert.NoError(t, err)
+			assert.Equal(t, test.tracker, entry.Tracker)
+			assert.Equal(t, test.typeID, entry.ExpenseTypeID)
+			assert.InDelta(t, test.amount, entry.Amount, 0.001)
+			assert.True(t, test.date.Equal(entry.Date), "date %v", entry.Date)
+			assert.Equal(t, test.notes, entry.Notes)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2025, time.March, 20, 0, 0, 0, 0, time.Local)
+
+	_, err := Parse("  ", now, testTypes)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	_, err = Parse("fuel yesterday", now, testTypes)
+	assert.ErrorIs(t, err, ErrNoAmount)
+
+	for _, phrase := range []string{"fuel nan", "fuel inf", "fuel Infinity", "fuel 1e3", "fuel 0x10"} {
+		_, err = Parse(phrase, now, testTypes)
+		assert.ErrorIs(t, err, ErrNoAmount, phrase)
+	}
+
+	// The first number is always the amount, "31.02" is not read as an invalid date.
+	entry, err := Parse("ток 31.02", now, testTypes)
+	assert.NoError(t, err)
+	assert.InDelta(t, 31.02, entry.Amount, 0.001)
+}
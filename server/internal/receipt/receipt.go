@@ -0,0 +1,134 @@
+// Package receipt reads the QR code printed on Bulgarian cash register receipts.
+//
+// Regulation N-18 requires every fiscal receipt to carry a QR code with
+//
+//	<fiscal device number>*<receipt number>*<date>*<time>*<total>
+//
+// for example "DT123456*0012345*2025-03-15*12:34:56*85.40".
+package receipt
+
+import (
+	"bytes"
+	"errors"
+	"expenser/internal/models"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+var (
+	ErrNoQRCode  = errors.New("no QR code found in the image")
+	ErrNotFiscal = errors.New("not a fiscal receipt QR code")
+	ErrBadImage  = errors.New("unsupported image, use a JPEG or PNG photo")
+	ErrTooLarge  = errors.New("the image is too large, use a photo of at most 40 megapixels")
+)
+
+// maxIDLength bounds the fiscal device and receipt numbers, real ones are 8 characters or less.
+const maxIDLength = 32
+
+var (
+	dateLayouts = []string{"2006-01-02", "02.01.2006"}
+	timeLayouts = []string{"15:04:05", "15:04"}
+)
+
+// Parse reads the decoded QR text of a fiscal receipt.
+func Parse(text string) (*models.FiscalReceipt, error) {
+	fields := strings.Split(strings.TrimSpace(text), "*")
+	if len(fields) != 5 {
+		return nil, ErrNotFiscal
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	device, number := fields[0], fields[1]
+	if !validID(device) || !validID(number) {
+		return nil, ErrNotFiscal
+	}
+
+	issuedAt, err := parseDateTime(fields[2], fields[3])
+	if err != nil {
+		return nil, ErrNotFiscal
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(fields[4], ",", ".", 1), 64)
+	if err != nil || amount <= 0 {
+		return nil, ErrNotFiscal
+	}
+
+	return &models.FiscalReceipt{
+		DeviceNumber:  strings.ToUpper(device),
+		ReceiptNumber: number,
+		IssuedAt:      issuedAt,
+		Amount:        amount,
+	}, nil
+}
+
+// validID accepts the letters and digits fiscal device and receipt numbers are made of.
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDateTime(dateStr, timeStr string) (time.Time, error) {
+	for _, dateLayout := range dateLayouts {
+		for _, timeLayout := range timeLayouts {
+			issuedAt, err := time.ParseInLocation(dateLayout+" "+timeLayout, dateStr+" "+timeStr, time.Local)
+			if err == nil {
+				return issuedAt, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid receipt date %q %q", dateStr, timeStr)
+}
+
+// MaxPixels is the largest photo decoded. A small file can claim huge
+// dimensions, so the size is checked before the pixels are allocated.
+const MaxPixels = 40_000_000
+
+// Decode finds the QR code in a JPEG or PNG photo and returns its text.
+func Decode(data []byte) (string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrBadImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxPixels/cfg.Height {
+		return "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrBadImage
+	}
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", ErrBadImage
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, hints)
+	if err != nil {
+		return "", ErrNoQRCode
+	}
+
+	return result.GetText(), nil
+}
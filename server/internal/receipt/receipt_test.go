@@ -0,0 +1,82 @@
+package receipt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		device string
+		number string
+		date   time.Time
+		amount float64
+		err    error
+	}{
+		{"regular", "DT123456*0012345*2025-03-15*12:34:56*85.40", "DT123456", "0012345", time.Date(2025, time.March, 15, 12, 34, 56, 0, time.Local), 85.40, nil},
+		{"spaces and comma", " dt123456 * 0000081 * 2025-01-02 * 08:05:00 * 7,20\n", "DT123456", "0000081", time.Date(2025, time.January, 2, 8, 5, 0, 0, time.Local), 7.20, nil},
+		{"bulgarian date", "50123456*12*15.03.2025*09:10*12.00", "50123456", "12", time.Date(2025, time.March, 15, 9, 10, 0, 0, time.Local), 12, nil},
+		{"url", "https://example.com/receipt", "", "", time.Time{}, 0, ErrNotFiscal},
+		{"missing total", "DT123456*0012345*2025-03-15*12:34:56", "", "", time.Time{}, 0, ErrNotFiscal},
+		{"bad date", "DT123456*0012345*2025-13-15*12:34:56*85.40", "", "", time.Time{}, 0, ErrNotFiscal},
+		{"bad device", "DT-123/456*0012345*2025-03-15*12:34:56*85.40", "", "", time.Time{}, 0, ErrNotFiscal},
+		{"zero total", "DT123456*0012345*2025-03-15*12:34:56*0.00", "", "", time.Time{}, 0, ErrNotFiscal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			receipt, err := Parse(test.text)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.device, receipt.DeviceNumber)
+			assert.Equal(t, test.number, receipt.ReceiptNumber)
+			assert.True(t, test.date.Equal(receipt.IssuedAt), "date %v", receipt.IssuedAt)
+			assert.InDelta(t, test.amount, receipt.Amount, 0.001)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	text := "DT123456*0012345*2025-03-15*12:34:56*85.40"
+
+	matrix, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, 300, 300, nil)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, matrix))
+
+	decoded, err := Decode(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, text, decoded)
+
+	_, err = Decode([]byte("not an image"))
+	assert.ErrorIs(t, err, ErrBadImage)
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+
+	// Claim 10000x10000 pixels in the header, the pixel data is never read.
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := Decode(data)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
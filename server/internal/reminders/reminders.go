@@ -0,0 +1,237 @@
+// Package reminders raises notifications for things coming due, like vehicle
+// services, expiring vehicle documents and utility bills, and for months whose
+// expenses went over the income.
+package reminders
+
+import (
+	"context"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/notify"
+	"expenser/internal/tariff"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Worker checks for due reminders in the background.
+type Worker struct {
+	DB         *database.DB
+	Dispatcher *notify.Dispatcher // Dispatcher emails and pushes the new notifications.
+	Interval   time.Duration
+}
+
+func NewWorker(db *database.DB, dispatcher *notify.Dispatcher, interval time.Duration) *Worker {
+	return &Worker{
+		DB:         db,
+		Dispatcher: dispatcher,
+		Interval:   interval,
+	}
+}
+
+// Run checks right away and then every interval until the context is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Check(ctx, time.Now()); err != nil {
+			log.Printf("reminders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check raises the notifications due as of now. Notifications raised before are skipped.
+func (w *Worker) Check(ctx context.Context, now time.Time) error {
+	plans, err := w.DB.GetAllServicePlans()
+	if err != nil {
+		return err
+	}
+
+	docs, err := w.DB.GetAllCurrentVehicleDocuments()
+	if err != nil {
+		return err
+	}
+
+	bills, err := w.billsDue(now)
+	if err != nil {
+		return err
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	balances, err := w.DB.GetMonthBalances(month)
+	if err != nil {
+		return err
+	}
+
+	notifications := ServiceNotifications(plans, now)
+	notifications = append(notifications, DocumentNotifications(docs, now)...)
+	notifications = append(notifications, bills...)
+	notifications = append(notifications, BudgetNotifications(balances)...)
+
+	for _, n := range notifications {
+		created, err := w.DB.CreateNotification(&n)
+		if err != nil {
+			return err
+		}
+
+		// A failed delivery doesn't hold up the other notifications.
+		if created {
+			if err := w.Dispatcher.Notify(ctx, &n); err != nil {
+				log.Printf("reminders: delivering notification %d: %v", n.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// billsDue returns the bill due notifications of every user with recent meter readings.
+func (w *Worker) billsDue(now time.Time) ([]models.Notification, error) {
+	users, err := w.DB.GetMeterReadingUsers(now.AddDate(0, 0, -billDueDays))
+	if err != nil {
+		return nil, err
+	}
+
+	var notifications []models.Notification
+	for _, userID := range users {
+		tariffs, err := w.DB.GetUtilityTariffs(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		readings, err := w.DB.GetMeterReadings(userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(readings) == 0 {
+			continue
+		}
+
+		bills, err := w.DB.GetMeteredBills(userID, readings[len(readings)-1].Date)
+		if err != nil {
+			return nil, err
+		}
+
+		due := tariff.Due(tariff.Estimates(tariffs, readings, bills), now)
+		notifications = append(notifications, BillNotifications(userID, due)...)
+	}
+	return notifications, nil
+}
+
+// billDueDays is how far back a meter reading may be for its bill to still be due.
+const billDueDays = 45
+
+// ServiceNotifications returns a notification for every planned service due soon
+// or overdue. The key changes with the last service and the state, so a service is
+// notified once when it comes due, once when it gets overdue and again after the
+// next one is recorded.
+func ServiceNotifications(plans []models.ServicePlan, now time.Time) []models.Notification {
+	var notifications []models.Notification
+	for i := range plans {
+		status := plans[i].Status(now)
+		if status.State != models.ServiceDueSoon && status.State != models.ServiceOverdue {
+			continue
+		}
+
+		plan := status.Plan
+		lastID := 0
+		if plan.LastExpenseID != nil {
+			lastID = *plan.LastExpenseID
+		}
+
+		notifications = append(notifications, models.Notification{
+			UserID:  plan.CreatedBy,
+			Kind:    models.NotificationServiceDue,
+			Key:     fmt.Sprintf("service:%d:%d:%s", plan.ID, lastID, status.State),
+			Title:   fmt.Sprintf("%s %s: %s", plan.Vehicle, plan.Name, status.State.Label()),
+			Message: fmt.Sprintf("%s of %s is due %s.", plan.Name, plan.Vehicle, status.Due()),
+			Link:    "/car",
+		})
+	}
+	return notifications
+}
+
+// DocumentNotifications returns a notification for every document that reached one
+// of its reminder lead times or expired. The key holds the expiry date and the lead
+// time, so each lead time is notified once per document and again after it is edited
+// to a new expiry date.
+func DocumentNotifications(docs []models.VehicleDocument, now time.Time) []models.Notification {
+	var notifications []models.Notification
+	for i := range docs {
+		doc := &docs[i]
+		lead, due := doc.DueReminder(now)
+		if !due {
+			continue
+		}
+
+		title := fmt.Sprintf("%s %s expires in %d days", doc.Vehicle, doc.Kind.Label(), doc.DaysLeft(now))
+		switch left := doc.DaysLeft(now); {
+		case left < 0:
+			title = fmt.Sprintf("%s %s has expired", doc.Vehicle, doc.Kind.Label())
+		case left == 0:
+			title = fmt.Sprintf("%s %s expires today", doc.Vehicle, doc.Kind.Label())
+		case left == 1:
+			title = fmt.Sprintf("%s %s expires tomorrow", doc.Vehicle, doc.Kind.Label())
+		}
+
+		notifications = append(notifications, models.Notification{
+			UserID:  doc.CreatedBy,
+			Kind:    models.NotificationDocumentExpiry,
+			Key:     fmt.Sprintf("document:%d:%s:%d", doc.ID, doc.ExpiresOn.Format("2006-01-02"), lead),
+			Title:   title,
+			Message: fmt.Sprintf("%s of %s is valid until %s.", doc.Kind.Label(), doc.Vehicle, doc.ExpiresOn.Format("02.01.2006")),
+			Link:    "/vehicles",
+		})
+	}
+	return notifications
+}
+
+// BillNotifications returns a notification for every bill estimated from the
+// user's meter readings that hasn't arrived yet. The key holds the utility and
+// the closing reading, so each bill is notified once.
+func BillNotifications(userID uuid.UUID, due []models.BillEstimate) []models.Notification {
+	var notifications []models.Notification
+	for _, estimate := range due {
+		notifications = append(notifications, models.Notification{
+			UserID: userID,
+			Kind:   models.NotificationBillDue,
+			Key:    fmt.Sprintf("bill:%d:%s", estimate.UtilityTypeID, estimate.To.Format("2006-01-02")),
+			Title:  fmt.Sprintf("%s bill due, about %.2f BGN", estimate.UtilityType, estimate.Amount),
+			Message: fmt.Sprintf("%s used from %s to %s is estimated at %.2f BGN.",
+				estimate.UtilityType, estimate.From.Format("02.01.2006"), estimate.To.Format("02.01.2006"), estimate.Amount),
+			Link: "/house",
+		})
+	}
+	return notifications
+}
+
+// BudgetNotifications returns a notification for every user whose expenses went
+// over their income this month. The key holds the month, so it is notified once a month.
+func BudgetNotifications(balances []models.MonthBalance) []models.Notification {
+	var notifications []models.Notification
+	for _, b := range balances {
+		if !b.Overspent() {
+			continue
+		}
+
+		notifications = append(notifications, models.Notification{
+			UserID: b.UserID,
+			Kind:   models.NotificationBudgetExceeded,
+			Key:    "budget:" + b.Month.Format("2006-01"),
+			Title:  fmt.Sprintf("%s expenses are over the income", b.Month.Format("January")),
+			Message: fmt.Sprintf("Expenses of %.2f BGN are %.2f BGN over the income of %.2f BGN this month.",
+				b.Expenses, b.Expenses-b.Income, b.Income),
+			Link: "/income/cash-flow",
+		})
+	}
+	return notifications
+}
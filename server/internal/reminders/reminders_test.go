@@ -0,0 +1,128 @@
+package reminders
+
+import (
+	"expenser/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentNotifications(t *testing.T) {
+	now := time.Date(2025, time.March, 20, 9, 0, 0, 0, time.Local)
+	expires := func(days int) time.Time {
+		return time.Date(2025, time.March, 20+days, 0, 0, 0, 0, time.UTC)
+	}
+	doc := func(id, days int) models.VehicleDocument {
+		return models.VehicleDocument{
+			ID:         id,
+			Vehicle:    "Golf",
+			Kind:       models.DocumentInsurance,
+			ExpiresOn:  expires(days),
+			RemindDays: []int{30, 7, 1},
+			CreatedBy:  uuid.New(),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		doc   models.VehicleDocument
+		key   string
+		title string
+	}{
+		{"before the first lead time", doc(1, 31), "", ""},
+		{"first lead time", doc(2, 30), "document:2:2025-04-19:30", "Golf Civil liability insurance expires in 30 days"},
+		{"between lead times", doc(3, 12), "document:3:2025-04-01:30", "Golf Civil liability insurance expires in 12 days"},
+		{"second lead time", doc(4, 5), "document:4:2025-03-25:7", "Golf Civil liability insurance expires in 5 days"},
+		{"last day", doc(5, 0), "document:5:2025-03-20:1", "Golf Civil liability insurance expires today"},
+		{"expired", doc(6, -2), "document:6:2025-03-18:-1", "Golf Civil liability insurance has expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifications := DocumentNotifications([]models.VehicleDocument{tt.doc}, now)
+			if tt.key == "" {
+				assert.Empty(t, notifications)
+				return
+			}
+
+			assert.Len(t, notifications, 1)
+			assert.Equal(t, tt.key, notifications[0].Key)
+			assert.Equal(t, tt.title, notifications[0].Title)
+			assert.Equal(t, models.NotificationDocumentExpiry, notifications[0].Kind)
+			assert.Equal(t, tt.doc.CreatedBy, notifications[0].UserID)
+		})
+	}
+
+	renewed := doc(7, -2)
+	renewed.Renewed = true
+	assert.Empty(t, DocumentNotifications([]models.VehicleDocument{renewed}, now))
+}
+
+func TestServiceNotifications(t *testing.T) {
+	now := time.Date(2025, time.March, 20, 9, 0, 0, 0, time.Local)
+	km, months, lastID, lastKm := 10000, 12, 42, 100000
+	lastDate := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.Local)
+
+	plan := models.ServicePlan{
+		ID:              3,
+		Vehicle:         "Golf",
+		Name:            "Oil change",
+		IntervalKm:      &km,
+		IntervalMonths:  &months,
+		LastExpenseID:   &lastID,
+		LastDate:        &lastDate,
+		LastOdometer:    &lastKm,
+		CurrentOdometer: 104000,
+	}
+
+	notifications := ServiceNotifications([]models.ServicePlan{plan}, now)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, "service:3:42:overdue", notifications[0].Key)
+
+	plan.LastDate = &now
+	plan.CurrentOdometer = 109200
+	notifications = ServiceNotifications([]models.ServicePlan{plan}, now)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, "service:3:42:due_soon", notifications[0].Key)
+
+	plan.CurrentOdometer = 101000
+	assert.Empty(t, ServiceNotifications([]models.ServicePlan{plan}, now))
+}
+
+func TestBillNotifications(t *testing.T) {
+	userID := uuid.New()
+	due := []models.BillEstimate{{
+		UtilityTypeID: 3,
+		UtilityType:   "Electricity",
+		From:          time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC),
+		To:            time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		Amount:        84.2,
+	}}
+
+	notifications := BillNotifications(userID, due)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, userID, notifications[0].UserID)
+	assert.Equal(t, models.NotificationBillDue, notifications[0].Kind)
+	assert.Equal(t, "bill:3:2025-10-01", notifications[0].Key)
+	assert.Equal(t, "Electricity bill due, about 84.20 BGN", notifications[0].Title)
+	assert.Equal(t, "Electricity used from 01.09.2025 to 01.10.2025 is estimated at 84.20 BGN.", notifications[0].Message)
+}
+
+func TestBudgetNotifications(t *testing.T) {
+	month := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	balances := []models.MonthBalance{
+		{UserID: uuid.New(), Month: month, Income: 1000, Expenses: 1250.5},
+		{UserID: uuid.New(), Month: month, Income: 1000, Expenses: 400},
+		{UserID: uuid.New(), Month: month, Expenses: 400},
+	}
+
+	notifications := BudgetNotifications(balances)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, balances[0].UserID, notifications[0].UserID)
+	assert.Equal(t, models.NotificationBudgetExceeded, notifications[0].Kind)
+	assert.Equal(t, "budget:2025-10", notifications[0].Key)
+	assert.Equal(t, "October expenses are over the income", notifications[0].Title)
+	assert.Equal(t, "Expenses of 1250.50 BGN are 250.50 BGN over the income of 1000.00 BGN this month.", notifications[0].Message)
+}
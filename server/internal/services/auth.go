@@ -19,6 +19,29 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
+// SessionID returns the session the token logs in, uuid.Nil for tokens
+// issued before sessions were tracked.
+func (c *JWTClaims) SessionID() uuid.UUID {
+	id, err := uuid.Parse(c.ID)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+// The audiences of the tokens that aren't sessions, so none of them can be used
+// as a session nor a session token as one of them.
+const (
+	emailAudience     = "email-verification" // emailAudience is of the tokens in email verification links.
+	twoFactorAudience = "two-factor"         // twoFactorAudience is of the tokens of a login waiting for its second step.
+)
+
+// EmailTokenTTL is how long an email verification link works.
+const EmailTokenTTL = 24 * time.Hour
+
+// TwoFactorTokenTTL is how long the second step of a login can take.
+const TwoFactorTokenTTL = 5 * time.Minute
+
 type AuthService struct {
 	secretKey       []byte
 	tokenExpiration time.Duration
@@ -51,19 +74,33 @@ func (as *AuthService) SetCookie(t *Token, c *gin.Context) {
 	c.SetCookie("auth_token", t.Value, int(t.Duration.Seconds()), "/", domain, secure, httpOnly)
 }
 
-// GenerateToken creates a new JWT token for the given user
-func (as *AuthService) GenerateToken(user *models.User) (*Token, error) {
+// ClearCookie removes the auth cookie, logging this device out.
+func (as *AuthService) ClearCookie(c *gin.Context) {
+	domain := os.Getenv("LAN_DOMAIN")
+
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	c.SetCookie("auth_token", "", -1, "/", domain, true, true)
+}
+
+// GenerateToken creates a new JWT token for the given user, carrying the ID of
+// their session on this device.
+func (as *AuthService) GenerateToken(user *models.User, sessionID uuid.UUID) (*Token, error) {
 	timeNow := time.Now()
 	expAt := timeNow.Add(as.tokenExpiration)
 	claims := &JWTClaims{
 		UserID:   user.ID,
 		Username: user.Username,
+		Email:    user.VerifiedEmail(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expAt),
 			IssuedAt:  jwt.NewNumericDate(timeNow),
 			NotBefore: jwt.NewNumericDate(timeNow),
 			Issuer:    "expenser-app",
 			Subject:   fmt.Sprintf("user:%s", user.ID),
+			ID:        sessionID.String(),
 		},
 	}
 
@@ -100,6 +137,9 @@ func (as *AuthService) ValidateToken(tokenString string) (*Token, error) {
 	if !ok {
 		return nil, fmt.Errorf("could not get claims from token")
 	}
+	if len(claims.Audience) > 0 {
+		return nil, fmt.Errorf("not a session token")
+	}
 
 	expAt, _ := token.Claims.GetExpirationTime()
 
@@ -110,3 +150,123 @@ func (as *AuthService) ValidateToken(tokenString string) (*Token, error) {
 		ExpiresAt: time.Unix(expAt.Unix(), 0),
 	}, nil
 }
+
+// signPurposeToken signs the claims as a token for the audience, valid for ttl.
+func (as *AuthService) signPurposeToken(claims *JWTClaims, audience string, ttl time.Duration) (string, error) {
+	timeNow := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(timeNow.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(timeNow),
+		Issuer:    "expenser-app",
+		Subject:   fmt.Sprintf("user:%s", claims.UserID),
+		Audience:  jwt.ClaimStrings{audience},
+	}
+
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(as.secretKey)
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate %s token %w", audience, err)
+	}
+
+	return signedToken, nil
+}
+
+// validatePurposeToken validates a token for the audience and returns its claims.
+func (as *AuthService) validatePurposeToken(tokenString, audience string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return as.secretKey, nil
+	}, jwt.WithAudience(audience))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// GenerateEmailToken creates the signed token of a link verifying the user's
+// current email address.
+func (as *AuthService) GenerateEmailToken(user *models.User) (string, error) {
+	return as.signPurposeToken(&JWTClaims{UserID: user.ID, Email: user.Email}, emailAudience, EmailTokenTTL)
+}
+
+// ValidateEmailToken validates the token of an email verification link and
+// returns the user and address it verifies.
+func (as *AuthService) ValidateEmailToken(tokenString string) (*JWTClaims, error) {
+	claims, err := as.validatePurposeToken(tokenString, emailAudience)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Email == "" {
+		return nil, fmt.Errorf("no email in token")
+	}
+
+	return claims, nil
+}
+
+// GenerateTwoFactorToken creates the token of a login of the user whose
+// password was right, waiting for their second step.
+func (as *AuthService) GenerateTwoFactorToken(user *models.User) (string, error) {
+	return as.signPurposeToken(&JWTClaims{UserID: user.ID, Username: user.Username}, twoFactorAudience, TwoFactorTokenTTL)
+}
+
+// ValidateTwoFactorToken validates the token of a login waiting for its second
+// step and returns the user it is of.
+func (as *AuthService) ValidateTwoFactorToken(tokenString string) (*JWTClaims, error) {
+	return as.validatePurposeToken(tokenString, twoFactorAudience)
+}
+
+// SetTwoFactorCookie keeps the token of a login waiting for its second step, an
+// empty token removes it.
+func (as *AuthService) SetTwoFactorCookie(tokenString string, c *gin.Context) {
+	domain := os.Getenv("LAN_DOMAIN")
+
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	maxAge := int(TwoFactorTokenTTL.Seconds())
+	if tokenString == "" {
+		maxAge = -1
+	}
+
+	c.SetCookie("two_factor_token", tokenString, maxAge, "/login", domain, true, true)
+}
+
+// SetPasskeyCookie keeps the ID of the passkey ceremony the browser is prompting
+// for until the ceremony times out after ttl, an empty ID removes it.
+func (as *AuthService) SetPasskeyCookie(ceremonyID string, ttl time.Duration, c *gin.Context) {
+	domain := os.Getenv("LAN_DOMAIN")
+
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	maxAge := int(ttl.Seconds())
+	if ceremonyID == "" {
+		maxAge = -1
+	}
+
+	c.SetCookie("passkey_ceremony", ceremonyID, maxAge, "/", domain, true, true)
+}
+
+// SetSSOCookie keeps the state of the single sign-on the user is logging in
+// with at the provider until the login times out after ttl, an empty state
+// removes it.
+func (as *AuthService) SetSSOCookie(state string, ttl time.Duration, c *gin.Context) {
+	domain := os.Getenv("LAN_DOMAIN")
+
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	maxAge := int(ttl.Seconds())
+	if state == "" {
+		maxAge = -1
+	}
+
+	c.SetCookie("sso_state", state, maxAge, "/login/oidc", domain, true, true)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"expenser/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailToken(t *testing.T) {
+	as := NewAuthService("test-secret", time.Hour)
+	user := &models.User{ID: uuid.New(), Username: "ivan", Email: "ivan@example.com"}
+
+	emailToken, err := as.GenerateEmailToken(user)
+	assert.NoError(t, err)
+
+	claims, err := as.ValidateEmailToken(emailToken)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, "ivan@example.com", claims.Email)
+
+	// Verification links aren't sessions and sessions don't verify addresses.
+	_, err = as.ValidateToken(emailToken)
+	assert.Error(t, err)
+
+	session, err := as.GenerateToken(user, uuid.New())
+	assert.NoError(t, err)
+	_, err = as.ValidateEmailToken(session.Value)
+	assert.Error(t, err)
+
+	// Another secret didn't sign it.
+	_, err = NewAuthService("other-secret", time.Hour).ValidateEmailToken(emailToken)
+	assert.Error(t, err)
+}
+
+func TestSessionEmail(t *testing.T) {
+	as := NewAuthService("test-secret", time.Hour)
+	user := &models.User{ID: uuid.New(), Username: "ivan", Email: "ivan@example.com"}
+
+	// Only a verified address is carried by the session.
+	token, err := as.GenerateToken(user, uuid.New())
+	assert.NoError(t, err)
+	assert.Empty(t, token.Claims.Email)
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	token, err = as.GenerateToken(user, uuid.New())
+	assert.NoError(t, err)
+
+	validated, err := as.ValidateToken(token.Value)
+	assert.NoError(t, err)
+	assert.Equal(t, "ivan@example.com", validated.Claims.Email)
+}
+
+func TestTwoFactorToken(t *testing.T) {
+	as := NewAuthService("test-secret", time.Hour)
+	user := &models.User{ID: uuid.New(), Username: "ivan"}
+
+	pending, err := as.GenerateTwoFactorToken(user)
+	assert.NoError(t, err)
+
+	claims, err := as.ValidateTwoFactorToken(pending)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	// A login waiting for its second step isn't logged in yet.
+	_, err = as.ValidateToken(pending)
+	assert.Error(t, err)
+	_, err = as.ValidateEmailToken(pending)
+	assert.Error(t, err)
+
+	session, err := as.GenerateToken(user, uuid.New())
+	assert.NoError(t, err)
+	_, err = as.ValidateTwoFactorToken(session.Value)
+	assert.Error(t, err)
+}
+
+func TestSessionID(t *testing.T) {
+	as := NewAuthService("test-secret", time.Hour)
+	user := &models.User{ID: uuid.New(), Username: "ivan"}
+	sessionID := uuid.New()
+
+	token, err := as.GenerateToken(user, sessionID)
+	assert.NoError(t, err)
+
+	validated, err := as.ValidateToken(token.Value)
+	assert.NoError(t, err)
+	assert.Equal(t, sessionID, validated.Claims.SessionID())
+
+	// Tokens issued before sessions were tracked have none.
+	assert.Equal(t, uuid.Nil, (&JWTClaims{UserID: user.ID}).SessionID())
+}
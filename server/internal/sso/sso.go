@@ -0,0 +1,216 @@
+// Package sso logs users in with an OpenID Connect provider, using the
+// authorization code flow with PKCE. The provider is discovered on first use,
+// so the app starts even while the provider is down.
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expenser/internal/config"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// LoginTTL is how long a user has to log in at the provider.
+	LoginTTL = 10 * time.Minute
+
+	// MaxLogins is how many logins can be waiting for the provider at once.
+	// Anyone can start one, so they are capped to keep memory bounded.
+	MaxLogins = 1000
+)
+
+var (
+	ErrNoLogin = errors.New("the login expired, try again")
+	ErrBusy    = errors.New("too many logins are waiting for the provider, try again later")
+)
+
+// Provider is the OpenID Connect provider of the app.
+type Provider struct {
+	Config      config.OIDC
+	RedirectURL string
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+	logins   map[string]*login
+}
+
+// login is a started login, waiting for the provider to redirect back.
+type login struct {
+	Verifier  string
+	Nonce     string
+	ExpiresAt time.Time
+}
+
+// Identity is the user the provider logged in.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+}
+
+// New returns the provider of the configuration, redirecting back to the app at baseURL.
+func New(cfg config.OIDC, baseURL string) *Provider {
+	return &Provider{
+		Config:      cfg,
+		RedirectURL: baseURL + "/login/oidc/callback",
+		logins:      map[string]*login{},
+	}
+}
+
+// PasswordsDisabled reports whether users can only log in with the provider
+// or a passkey. Passwords are allowed without a provider.
+func (p *Provider) PasswordsDisabled() bool {
+	return p != nil && p.Config.DisablePasswords
+}
+
+// discover returns the provider's endpoints and keys, fetching them once.
+func (p *Provider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		provider, err := oidc.NewProvider(ctx, p.Config.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OpenID provider: %w", err)
+		}
+		p.provider = provider
+	}
+
+	return p.provider, nil
+}
+
+func (p *Provider) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.Config.ClientID,
+		ClientSecret: p.Config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.RedirectURL,
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Begin starts a login. It returns the provider's URL to send the user to and
+// the state to keep in their browser until they come back, or ErrBusy when
+// MaxLogins are still waiting.
+func (p *Provider) Begin(ctx context.Context) (string, string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state := randomString()
+	l := &login{
+		Verifier:  oauth2.GenerateVerifier(),
+		Nonce:     randomString(),
+		ExpiresAt: time.Now().Add(LoginTTL),
+	}
+
+	p.mu.Lock()
+	for key, started := range p.logins {
+		if time.Now().After(started.ExpiresAt) {
+			delete(p.logins, key)
+		}
+	}
+	if len(p.logins) >= MaxLogins {
+		p.mu.Unlock()
+		return "", "", ErrBusy
+	}
+	p.logins[state] = l
+	p.mu.Unlock()
+
+	url := p.oauth2Config(provider).AuthCodeURL(state, oauth2.S256ChallengeOption(l.Verifier), oidc.Nonce(l.Nonce))
+	return url, state, nil
+}
+
+// take returns the login of the state and forgets it, so every code is exchanged once.
+func (p *Provider) take(state string) (*login, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l, ok := p.logins[state]
+	delete(p.logins, state)
+	if !ok || time.Now().After(l.ExpiresAt) {
+		return nil, ErrNoLogin
+	}
+	return l, nil
+}
+
+// claims are the ID token claims of the user. Some providers send email_verified
+// as a string.
+type claims struct {
+	Email             string          `json:"email"`
+	EmailVerified     json.RawMessage `json:"email_verified"`
+	PreferredUsername string          `json:"preferred_username"`
+	Name              string          `json:"name"`
+}
+
+// Finish exchanges the code the provider redirected back with for the user's
+// identity. state is the one the browser kept, callbackState the one in the redirect.
+func (p *Provider) Finish(ctx context.Context, state, callbackState, code string) (*Identity, error) {
+	if state == "" || state != callbackState {
+		return nil, ErrNoLogin
+	}
+
+	l, err := p.take(state)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(l.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("no ID token in the token response")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.Config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if idToken.Nonce != l.Nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce doesn't match")
+	}
+
+	var c claims
+	if err := idToken.Claims(&c); err != nil {
+		return nil, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+
+	username := c.PreferredUsername
+	if username == "" {
+		username = c.Name
+	}
+
+	verified := string(c.EmailVerified)
+	return &Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         c.Email,
+		EmailVerified: c.Email != "" && (verified == "true" || verified == `"true"`),
+		Username:      username,
+	}, nil
+}
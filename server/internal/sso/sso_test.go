@@ -0,0 +1,209 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"expenser/internal/config"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProvider is an OpenID provider that logs everyone in as Claims, checking
+// the PKCE verifier of every code.
+type mockProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	Claims map[string]any
+
+	mu    sync.Mutex
+	codes map[string]url.Values // codes are the authorization requests of the issued codes.
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockProvider{key: key, codes: map[string]url.Values{}}
+	discovery := &oidctest.Server{
+		PublicKeys: []oidctest.PublicKey{{PublicKey: key.Public(), KeyID: "test", Algorithm: "RS256"}},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", discovery)
+	mux.HandleFunc("/auth", m.authorize)
+	mux.HandleFunc("/token", m.token)
+
+	m.Server = httptest.NewServer(mux)
+	discovery.SetIssuer(m.URL)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	m.mu.Lock()
+	code := fmt.Sprintf("code-%d", len(m.codes))
+	m.codes[code] = q
+	m.mu.Unlock()
+
+	http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+q.Get("state"), http.StatusFound)
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	clientID, secret, _ := r.BasicAuth()
+
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || clientID != "expenser" || secret != "secret" ||
+		auth.Get("code_challenge_method") != "S256" ||
+		auth.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	claims := map[string]any{
+		"iss":   m.URL,
+		"aud":   "expenser",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": auth.Get("nonce"),
+	}
+	for k, v := range m.Claims {
+		claims[k] = v
+	}
+	payload, _ := json.Marshal(claims)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     oidctest.SignIDToken(m.key, "test", "RS256", string(payload)),
+	})
+}
+
+// logIn follows the provider's URL the way a browser would and returns the
+// state and code of the redirect back.
+func logIn(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Get(authURL)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "/login/oidc/callback", callback.Path)
+	return callback.Query().Get("state"), callback.Query().Get("code")
+}
+
+func TestProvider(t *testing.T) {
+	m := newMockProvider(t)
+	m.Claims = map[string]any{
+		"sub":                "user-1",
+		"email":              "ivan@example.com",
+		"email_verified":     true,
+		"preferred_username": "ivan",
+	}
+
+	p := New(config.OIDC{IssuerURL: m.URL, ClientID: "expenser", ClientSecret: "secret"}, "http://localhost:8080")
+	ctx := context.Background()
+
+	authURL, state, err := p.Begin(ctx)
+	require.NoError(t, err)
+	callbackState, code := logIn(t, authURL)
+	assert.Equal(t, state, callbackState)
+
+	identity, err := p.Finish(ctx, state, callbackState, code)
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Issuer:        m.URL,
+		Subject:       "user-1",
+		Email:         "ivan@example.com",
+		EmailVerified: true,
+		Username:      "ivan",
+	}, identity)
+
+	// Each login finishes once.
+	_, err = p.Finish(ctx, state, callbackState, code)
+	assert.ErrorIs(t, err, ErrNoLogin)
+
+	// The redirect back has to come from the browser that started the login.
+	authURL, _, err = p.Begin(ctx)
+	require.NoError(t, err)
+	callbackState, code = logIn(t, authURL)
+	_, err = p.Finish(ctx, "other", callbackState, code)
+	assert.ErrorIs(t, err, ErrNoLogin)
+
+	// Providers sending email_verified as a string are understood.
+	m.Claims["email_verified"] = "false"
+	authURL, state, err = p.Begin(ctx)
+	require.NoError(t, err)
+	callbackState, code = logIn(t, authURL)
+	identity, err = p.Finish(ctx, state, callbackState, code)
+	require.NoError(t, err)
+	assert.False(t, identity.EmailVerified)
+}
+
+func TestMaxLogins(t *testing.T) {
+	m := newMockProvider(t)
+	p := New(config.OIDC{IssuerURL: m.URL, ClientID: "expenser", ClientSecret: "secret"}, "http://localhost:8080")
+	ctx := context.Background()
+
+	for i := 0; i < MaxLogins; i++ {
+		_, _, err := p.Begin(ctx)
+		require.NoError(t, err)
+	}
+
+	_, _, err := p.Begin(ctx)
+	assert.ErrorIs(t, err, ErrBusy)
+
+	// Expired logins make room again.
+	for _, l := range p.logins {
+		l.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	_, _, err = p.Begin(ctx)
+	assert.NoError(t, err)
+}
+
+func TestProviderRejectsWrongClient(t *testing.T) {
+	m := newMockProvider(t)
+	m.Claims = map[string]any{"sub": "user-1"}
+
+	p := New(config.OIDC{IssuerURL: m.URL, ClientID: "expenser", ClientSecret: "wrong"}, "http://localhost:8080")
+	ctx := context.Background()
+
+	authURL, state, err := p.Begin(ctx)
+	require.NoError(t, err)
+	callbackState, code := logIn(t, authURL)
+
+	_, err = p.Finish(ctx, state, callbackState, code)
+	assert.Error(t, err)
+}
+
+func TestProviderDown(t *testing.T) {
+	p := New(config.OIDC{IssuerURL: "http://127.0.0.1:1", ClientID: "expenser"}, "http://localhost:8080")
+
+	_, _, err := p.Begin(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,406 @@
+// Package statement builds monthly and yearly expense statements, of one tracker or
+// both combined, and renders them as PDF in pure Go for printing and archiving.
+package statement
+
+import (
+	_ "embed"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/svgchart"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// The DejaVu fonts are embedded so notes in Cyrillic print the same in any container.
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	regularFont []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	boldFont []byte
+)
+
+const font = "DejaVu"
+
+// Period returns the first and last day of the month or year that date is in.
+func Period(period models.StatementPeriod, date time.Time) (time.Time, time.Time) {
+	if period == models.StatementYear {
+		from := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(1, 0, -1)
+	}
+	from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, -1)
+}
+
+// Lines fetches the user's expenses of the trackers in the month or year of date.
+func Lines(db *database.DB, userID uuid.UUID, period models.StatementPeriod, date time.Time, trackers []models.Tracker) ([]models.StatementLine, error) {
+	var lines []models.StatementLine
+
+	for _, tracker := range trackers {
+		switch tracker {
+		case models.TrackerHouse:
+			var expenses *[]models.HouseExpense
+			var err error
+			if period == models.StatementYear {
+				expenses, err = db.GetHouseExpensesForYear(date.Year(), userID)
+			} else {
+				expenses, err = db.GetHouseExpensesForMonth(date.Month(), date.Year(), userID)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for _, exp := range *expenses {
+				lines = append(lines, models.StatementLine{
+					Tracker: tracker,
+					Date:    exp.ExpenseDate,
+					Type:    exp.UtilityType,
+					Amount:  exp.Amount,
+					Notes:   exp.Notes,
+				})
+			}
+		case models.TrackerCar:
+			var expenses *[]models.CarExpense
+			var err error
+			if period == models.StatementYear {
+				expenses, err = db.GetCarExpensesForYear(date.Year(), userID)
+			} else {
+				expenses, err = db.GetCarExpensesForMonth(date.Month(), date.Year(), userID)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for _, exp := range *expenses {
+				lines = append(lines, models.StatementLine{
+					Tracker: tracker,
+					Date:    exp.Date,
+					Type:    exp.Type,
+					Amount:  exp.Amount,
+					Notes:   exp.Notes,
+				})
+			}
+		}
+	}
+
+	return lines, nil
+}
+
+// Build sums up the expenses of a period into a statement: the total of each
+// tracker and the breakdown by tracker and type.
+func Build(period models.StatementPeriod, date time.Time, trackers []models.Tracker, lines []models.StatementLine) *models.Statement {
+	from, to := Period(period, date)
+	s := &models.Statement{
+		Period:      period,
+		From:        from,
+		To:          to,
+		Trackers:    trackers,
+		Lines:       lines,
+		Totals:      map[models.Tracker]float64{},
+		GeneratedAt: time.Now(),
+	}
+
+	sort.SliceStable(s.Lines, func(i, j int) bool {
+		if !s.Lines[i].Date.Equal(s.Lines[j].Date) {
+			return s.Lines[i].Date.Before(s.Lines[j].Date)
+		}
+		return s.Lines[i].Tracker > s.Lines[j].Tracker
+	})
+
+	index := map[string]int{}
+	for _, line := range s.Lines {
+		s.Totals[line.Tracker] += line.Amount
+		s.Total += line.Amount
+
+		key := string(line.Tracker) + ":" + line.Type
+		i, ok := index[key]
+		if !ok {
+			i = len(s.Categories)
+			index[key] = i
+			s.Categories = append(s.Categories, models.StatementCategory{
+				Tracker:  line.Tracker,
+				TypeCost: models.TypeCost{Type: line.Type},
+			})
+		}
+		s.Categories[i].Amount += line.Amount
+		s.Categories[i].Count++
+	}
+
+	for i := range s.Categories {
+		s.Categories[i].Amount = round(s.Categories[i].Amount)
+		if s.Total > 0 {
+			s.Categories[i].Share = math.Round(s.Categories[i].Amount/s.Total*1000) / 10
+		}
+	}
+	sort.SliceStable(s.Categories, func(i, j int) bool {
+		return s.Categories[i].Amount > s.Categories[j].Amount
+	})
+
+	for t, total := range s.Totals {
+		s.Totals[t] = round(total)
+	}
+	s.Total = round(s.Total)
+
+	return s
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Layout of an A4 page in mm.
+const (
+	pageWidth  = 210.0
+	margin     = 15.0
+	lineHeight = 6.0
+	barHeight  = 4.0
+)
+
+// WritePDF renders the statement as an A4 PDF: the totals, the breakdown by type
+// with a bar chart, the monthly totals of a yearly statement, and every expense
+// with its notes.
+func WritePDF(w io.Writer, s *models.Statement) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin+5)
+	pdf.AddUTF8FontFromBytes(font, "", regularFont)
+	pdf.AddUTF8FontFromBytes(font, "B", boldFont)
+	pdf.SetTitle(s.Title(), true)
+	pdf.SetCreator("Expenser", true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-margin)
+		pdf.SetFont(font, "", 8)
+		pdf.SetTextColor(90, 100, 120)
+		pdf.CellFormat(0, 5, s.Title(), "", 0, "L", false, 0, "")
+		pdf.SetX(margin)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	writeHeader(pdf, s)
+	writeSummary(pdf, s)
+	writeCategories(pdf, s)
+	if s.Period == models.StatementYear {
+		writeMonths(pdf, s)
+	}
+	writeLines(pdf, s)
+
+	return pdf.Output(w)
+}
+
+func writeHeader(pdf *gofpdf.Fpdf, s *models.Statement) {
+	pdf.SetFont(font, "B", 18)
+	pdf.SetTextColor(20, 26, 42)
+	pdf.CellFormat(0, 10, s.Title(), "", 1, "L", false, 0, "")
+
+	pdf.SetFont(font, "", 9)
+	pdf.SetTextColor(58, 71, 99)
+	pdf.CellFormat(0, 5, fmt.Sprintf("%s to %s, generated on %s",
+		s.From.Format("02.01.2006"), s.To.Format("02.01.2006"), s.GeneratedAt.Format("02.01.2006 15:04")),
+		"", 1, "L", false, 0, "")
+	pdf.Ln(4)
+}
+
+func heading(pdf *gofpdf.Fpdf, title string) {
+	pdf.Ln(3)
+	pdf.SetFont(font, "B", 13)
+	pdf.SetTextColor(20, 26, 42)
+	pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+	pdf.SetFont(font, "", 10)
+}
+
+func writeSummary(pdf *gofpdf.Fpdf, s *models.Statement) {
+	heading(pdf, "Summary")
+
+	if s.Combined() {
+		for _, t := range s.Trackers {
+			pdf.CellFormat(60, lineHeight, t.Label(), "", 0, "L", false, 0, "")
+			pdf.CellFormat(40, lineHeight, amount(s.Totals[t]), "", 1, "R", false, 0, "")
+		}
+	}
+	pdf.CellFormat(60, lineHeight, "Expenses", "", 0, "L", false, 0, "")
+	pdf.CellFormat(40, lineHeight, strconv.Itoa(len(s.Lines)), "", 1, "R", false, 0, "")
+
+	pdf.SetFont(font, "B", 11)
+	pdf.CellFormat(60, lineHeight+1, "Total", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(40, lineHeight+1, amount(s.Total), "T", 1, "R", false, 0, "")
+	pdf.SetFont(font, "", 10)
+}
+
+func writeCategories(pdf *gofpdf.Fpdf, s *models.Statement) {
+	heading(pdf, "By category")
+	if len(s.Categories) == 0 {
+		pdf.CellFormat(0, lineHeight, "No expenses in this period.", "", 1, "L", false, 0, "")
+		return
+	}
+
+	max := s.Categories[0].Amount
+	chartWidth := pageWidth - 2*margin - 128
+	for i, cat := range s.Categories {
+		label := cat.Type
+		if s.Combined() {
+			label = cat.Tracker.Label() + ": " + cat.Type
+		}
+		pdf.CellFormat(60, lineHeight, label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(15, lineHeight, strconv.Itoa(cat.Count), "", 0, "R", false, 0, "")
+		pdf.CellFormat(30, lineHeight, amount(cat.Amount), "", 0, "R", false, 0, "")
+		pdf.CellFormat(20, lineHeight, fmt.Sprintf("%.1f%%", cat.Share), "", 0, "R", false, 0, "")
+
+		if max > 0 {
+			x, y := pdf.GetXY()
+			fill(pdf, typeColor(cat.Type, i))
+			pdf.Rect(x+3, y+(lineHeight-barHeight)/2, chartWidth*cat.Amount/max, barHeight, "F")
+		}
+		pdf.Ln(lineHeight)
+	}
+}
+
+// writeMonths draws the monthly totals of a yearly statement as a column chart.
+func writeMonths(pdf *gofpdf.Fpdf, s *models.Statement) {
+	entries := make([]svgchart.Entry, len(s.Lines))
+	for i, line := range s.Lines {
+		entries[i] = svgchart.Entry{Label: line.Type, Date: line.Date, Amount: line.Amount}
+	}
+	months := svgchart.ByMonth(entries, s.From.Year())
+
+	const chartHeight = 50.0
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY()+chartHeight+30 > pageHeight-margin-5 {
+		pdf.AddPage()
+	}
+	heading(pdf, "By month")
+
+	max := 0.0
+	for _, m := range months {
+		max = math.Max(max, m.Value)
+	}
+
+	slot := (pageWidth - 2*margin) / float64(len(months))
+	top := pdf.GetY()
+	bottom := top + chartHeight
+	pdf.SetDrawColor(200, 205, 220)
+	pdf.Line(margin, bottom, pageWidth-margin, bottom)
+
+	pdf.SetFont(font, "", 7)
+	fill(pdf, svgchart.Light.Palette[0])
+	for i, m := range months {
+		x := margin + slot*float64(i)
+		if max > 0 && m.Value > 0 {
+			h := (chartHeight - 6) * m.Value / max
+			pdf.Rect(x+slot*0.15, bottom-h, slot*0.7, h, "F")
+			pdf.SetXY(x, bottom-h-4)
+			pdf.CellFormat(slot, 4, strconv.FormatFloat(math.Round(m.Value), 'f', 0, 64), "", 0, "C", false, 0, "")
+		}
+		pdf.SetXY(x, bottom+1)
+		pdf.CellFormat(slot, 4, m.Label, "", 0, "C", false, 0, "")
+	}
+	pdf.SetXY(margin, bottom+6)
+	pdf.SetFont(font, "", 10)
+}
+
+func writeLines(pdf *gofpdf.Fpdf, s *models.Statement) {
+	heading(pdf, "Expenses")
+	if len(s.Lines) == 0 {
+		pdf.CellFormat(0, lineHeight, "No expenses in this period.", "", 1, "L", false, 0, "")
+		return
+	}
+
+	typeWidth := 45.0
+	if s.Combined() {
+		typeWidth = 30
+	}
+	widths := []float64{22, typeWidth, 25}
+	if s.Combined() {
+		widths = []float64{22, 15, typeWidth, 25}
+	}
+	notesWidth := pageWidth - 2*margin
+	for _, w := range widths {
+		notesWidth -= w
+	}
+
+	header := func() {
+		pdf.SetFont(font, "B", 9)
+		pdf.SetFillColor(226, 230, 240)
+		titles := []string{"Date", "Type", "Amount"}
+		if s.Combined() {
+			titles = []string{"Date", "Tracker", "Type", "Amount"}
+		}
+		for i, title := range titles {
+			align := "L"
+			if title == "Amount" {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], lineHeight, title, "", 0, align, true, 0, "")
+		}
+		pdf.CellFormat(notesWidth, lineHeight, "  Notes", "", 1, "L", true, 0, "")
+		pdf.SetFont(font, "", 9)
+	}
+	header()
+
+	_, pageHeight := pdf.GetPageSize()
+	for _, line := range s.Lines {
+		pdf.SetFont(font, "", 9)
+		notes := pdf.SplitText(line.Notes, notesWidth-3)
+		if len(notes) == 0 {
+			notes = []string{""}
+		}
+		rowHeight := float64(len(notes)) * 5
+		if pdf.GetY()+rowHeight > pageHeight-margin-5 {
+			pdf.AddPage()
+			header()
+		}
+
+		cells := []string{line.Date.Format("02.01.2006"), line.Type, amount(line.Amount)}
+		if s.Combined() {
+			cells = []string{line.Date.Format("02.01.2006"), line.Tracker.Label(), line.Type, amount(line.Amount)}
+		}
+		y := pdf.GetY()
+		for i, cell := range cells {
+			align := "L"
+			if i == len(cells)-1 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 5, cell, "", 0, align, false, 0, "")
+		}
+		x := pdf.GetX()
+		for i, note := range notes {
+			pdf.SetXY(x+3, y+float64(i)*5)
+			pdf.CellFormat(notesWidth-3, 5, note, "", 0, "L", false, 0, "")
+		}
+		pdf.SetXY(margin, y+rowHeight)
+
+		pdf.SetDrawColor(226, 230, 240)
+		pdf.Line(margin, pdf.GetY(), pageWidth-margin, pdf.GetY())
+	}
+}
+
+func amount(value float64) string {
+	return fmt.Sprintf("%.2f BGN", value)
+}
+
+// typeColor is the color the type is charted in everywhere else, or the i-th
+// color of the light theme's palette.
+func typeColor(expenseType string, i int) string {
+	if color, ok := svgchart.TypeColors[expenseType]; ok {
+		return color
+	}
+	palette := svgchart.Light.Palette
+	return palette[i%len(palette)]
+}
+
+// fill sets the fill color from a "#rrggbb" color.
+func fill(pdf *gofpdf.Fpdf, color string) {
+	var r, g, b int
+	if _, err := fmt.Sscanf(color, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		r, g, b = 128, 128, 128
+	}
+	pdf.SetFillColor(r, g, b)
+}
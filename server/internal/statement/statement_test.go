@@ -0,0 +1,71 @@
+package statement
+
+import (
+	"bytes"
+	"expenser/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriod(t *testing.T) {
+	from, to := Period(models.StatementMonth, day(2024, time.February, 17))
+	assert.Equal(t, day(2024, time.February, 1), from)
+	assert.Equal(t, day(2024, time.February, 29), to)
+
+	from, to = Period(models.StatementYear, day(2025, time.October, 5))
+	assert.Equal(t, day(2025, time.January, 1), from)
+	assert.Equal(t, day(2025, time.December, 31), to)
+}
+
+func lines() []models.StatementLine {
+	return []models.StatementLine{
+		{Tracker: models.TrackerCar, Date: day(2025, time.March, 3), Type: "Fuel", Amount: 80.10},
+		{Tracker: models.TrackerHouse, Date: day(2025, time.January, 10), Type: "Water", Amount: 20, Notes: "Вода, януари"},
+		{Tracker: models.TrackerHouse, Date: day(2025, time.February, 10), Type: "Water", Amount: 20.05},
+		{Tracker: models.TrackerCar, Date: day(2025, time.January, 15), Type: "Insurance", Amount: 300},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	s := Build(models.StatementYear, day(2025, time.June, 1), []models.Tracker{models.TrackerHouse, models.TrackerCar}, lines())
+
+	assert.Equal(t, "House and Car statement, 2025", s.Title())
+	assert.True(t, s.Combined())
+	assert.Equal(t, 420.15, s.Total)
+	assert.Equal(t, 40.05, s.Totals[models.TrackerHouse])
+	assert.Equal(t, 380.1, s.Totals[models.TrackerCar])
+
+	assert.Equal(t, day(2025, time.January, 10), s.Lines[0].Date)
+	assert.Equal(t, day(2025, time.March, 3), s.Lines[3].Date)
+
+	assert.Len(t, s.Categories, 3)
+	assert.Equal(t, "Insurance", s.Categories[0].Type)
+	assert.Equal(t, 71.4, s.Categories[0].Share)
+	assert.Equal(t, "Water", s.Categories[2].Type)
+	assert.Equal(t, 2, s.Categories[2].Count)
+	assert.Equal(t, 40.05, s.Categories[2].Amount)
+
+	month := Build(models.StatementMonth, day(2025, time.October, 1), []models.Tracker{models.TrackerCar}, nil)
+	assert.Equal(t, "Car statement, October 2025", month.Title())
+	assert.Equal(t, 0.0, month.Total)
+}
+
+func TestWritePDF(t *testing.T) {
+	for _, period := range []models.StatementPeriod{models.StatementMonth, models.StatementYear} {
+		s := Build(period, day(2025, time.January, 1), []models.Tracker{models.TrackerHouse, models.TrackerCar}, lines())
+
+		var buf bytes.Buffer
+		assert.NoError(t, WritePDF(&buf, s))
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	}
+
+	// A statement without expenses still prints.
+	var buf bytes.Buffer
+	assert.NoError(t, WritePDF(&buf, Build(models.StatementMonth, day(2025, time.May, 1), []models.Tracker{models.TrackerHouse}, nil)))
+}
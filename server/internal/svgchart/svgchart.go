@@ -0,0 +1,408 @@
+// Package svgchart renders accessible bar, line and pie charts as inline SVG on the
+// server, so charts work in HTMX fragments, PDF reports and emails without any
+// client-side JavaScript.
+package svgchart
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the shape of a chart.
+type Kind string
+
+const (
+	KindBar  Kind = "bar"
+	KindLine Kind = "line"
+	KindPie  Kind = "pie"
+)
+
+// Theme holds the colors a chart is drawn with. The colors are plain hex values
+// rather than the stylesheet's variables, so a chart looks the same outside the app.
+type Theme struct {
+	Name       string
+	Background string
+	Text       string
+	Muted      string
+	Grid       string
+	Palette    []string // Palette colors the points that have no color of their own, in turn.
+}
+
+// Light and Dark match the app's light and dark themes toggled by the theme-toggle.
+var (
+	Light = Theme{
+		Name:       "light",
+		Background: "#fbfcff",
+		Text:       "#141a2a",
+		Muted:      "#3a4763",
+		Grid:       "#dfe3ee",
+		Palette:    []string{"#4e79a7", "#f28e2b", "#59a14f", "#e15759", "#76b7b2", "#edc948", "#b07aa1", "#9c755f"},
+	}
+	Dark = Theme{
+		Name:       "dark",
+		Background: "#252a36",
+		Text:       "#eef1f8",
+		Muted:      "#9eaece",
+		Grid:       "#3d4352",
+		Palette:    []string{"#7aa6d6", "#ffb066", "#8cd17d", "#ff8a8c", "#a0dcd7", "#f6dc7a", "#d4a6c8", "#c9a58f"},
+	}
+)
+
+// ThemeNamed returns the theme with the given name, the light theme when there is none.
+func ThemeNamed(name string) Theme {
+	if name == Dark.Name {
+		return Dark
+	}
+	return Light
+}
+
+// TypeColors keeps the expense types in the colors they have always been charted in.
+var TypeColors = map[string]string{
+	"Water":              "#36a2eb",
+	"TV":                 "#9966ff",
+	"Electricity":        "#ffce56",
+	"Gas":                "#ff6384",
+	"Internet":           "#4bc0c0",
+	"Waste":              "#ff9f40",
+	"Fuel":               "#e8413c",
+	"Insurance":          "#1f6fe5",
+	"Maintenance/Repair": "#3cb043",
+	"Parking/Tolls":      "#ffa500",
+	"Tires":              "#707070",
+	"Oil":                "#aa4b00",
+	"Car Wash":           "#8fd3f0",
+}
+
+// Point is one value of a chart.
+type Point struct {
+	Label string
+	Value float64
+	Color string // Color is empty to take the next color of the theme's palette.
+}
+
+// Chart is a titled set of points to draw.
+type Chart struct {
+	ID          string // ID makes the title and description ids unique when a page has several charts.
+	Title       string
+	Description string // Description is read out by screen readers, the values are listed when empty.
+	Unit        string
+	Width       int
+	Height      int
+	Theme       Theme
+	Points      []Point
+}
+
+const (
+	defaultWidth  = 640
+	defaultHeight = 360
+	marginTop     = 44
+	marginRight   = 16
+	marginBottom  = 48
+	marginLeft    = 64
+	gridLines     = 4
+)
+
+// HTML renders the chart for use in a template. A chart that can't be rendered
+// comes out empty.
+func (c *Chart) HTML(kind Kind) template.HTML {
+	var sb strings.Builder
+	if err := c.Render(&sb, kind); err != nil {
+		return ""
+	}
+	return template.HTML(sb.String())
+}
+
+// Render writes the chart as an SVG element.
+func (c *Chart) Render(w io.Writer, kind Kind) error {
+	width, height := c.size()
+
+	var sb strings.Builder
+	id := c.ID
+	if id == "" {
+		id = "chart-" + string(kind)
+	}
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" class="svg-chart svg-chart-%s" viewBox="0 0 %d %d" width="%d" height="%d" role="img" aria-labelledby="%s-title %s-desc" font-family="sans-serif">`,
+		kind, width, height, width, height, esc(id), esc(id))
+	fmt.Fprintf(&sb, `<title id="%s-title">%s</title>`, esc(id), esc(c.Title))
+	fmt.Fprintf(&sb, `<desc id="%s-desc">%s</desc>`, esc(id), esc(c.description()))
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" rx="8" fill="%s"/>`, c.Theme.Background)
+	fmt.Fprintf(&sb, `<text x="%d" y="26" text-anchor="middle" font-size="16" font-weight="700" fill="%s">%s</text>`,
+		width/2, c.Theme.Text, esc(c.Title))
+
+	switch kind {
+	case KindBar:
+		c.bar(&sb, width, height)
+	case KindLine:
+		c.line(&sb, width, height)
+	case KindPie:
+		c.pie(&sb, width, height)
+	default:
+		return fmt.Errorf("unknown chart kind %q", kind)
+	}
+
+	sb.WriteString(`</svg>`)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (c *Chart) size() (int, int) {
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = defaultWidth
+	}
+	if height <= 0 {
+		height = defaultHeight
+	}
+	return width, height
+}
+
+// description lists the values when the chart has no description of its own.
+func (c *Chart) description() string {
+	if c.Description != "" {
+		return c.Description
+	}
+	if len(c.Points) == 0 {
+		return "No data."
+	}
+
+	values := make([]string, len(c.Points))
+	for i, p := range c.Points {
+		values[i] = p.Label + ": " + c.amount(p.Value)
+	}
+	return strings.Join(values, ", ") + "."
+}
+
+func (c *Chart) amount(value float64) string {
+	if c.Unit == "" {
+		return fmt.Sprintf("%.2f", value)
+	}
+	return fmt.Sprintf("%.2f %s", value, c.Unit)
+}
+
+func (c *Chart) color(i int) string {
+	if c.Points[i].Color != "" {
+		return c.Points[i].Color
+	}
+	if len(c.Theme.Palette) == 0 {
+		return c.Theme.Muted
+	}
+	return c.Theme.Palette[i%len(c.Theme.Palette)]
+}
+
+// axis draws the value grid of the bar and line charts and returns the value at
+// the top of the plot.
+func (c *Chart) axis(sb *strings.Builder, width, height int) float64 {
+	top := niceMax(c.maxValue())
+	plotHeight := float64(height - marginTop - marginBottom)
+
+	sb.WriteString(`<g aria-hidden="true">`)
+	for i := 0; i <= gridLines; i++ {
+		value := top * float64(i) / gridLines
+		y := float64(height-marginBottom) - plotHeight*float64(i)/gridLines
+		fmt.Fprintf(sb, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="%s" stroke-width="1"/>`,
+			marginLeft, y, width-marginRight, y, c.Theme.Grid)
+		fmt.Fprintf(sb, `<text x="%d" y="%.1f" text-anchor="end" font-size="11" fill="%s">%s</text>`,
+			marginLeft-8, y+4, c.Theme.Muted, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	sb.WriteString(`</g>`)
+	return top
+}
+
+// slots spreads the points evenly over the plot width and draws their labels
+// below it, returning the center of each slot and the slot width.
+func (c *Chart) slots(sb *strings.Builder, width, height int) ([]float64, float64) {
+	slot := float64(width-marginLeft-marginRight) / math.Max(float64(len(c.Points)), 1)
+	centers := make([]float64, len(c.Points))
+
+	sb.WriteString(`<g aria-hidden="true">`)
+	for i, p := range c.Points {
+		centers[i] = float64(marginLeft) + slot*(float64(i)+0.5)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%d" text-anchor="middle" font-size="11" fill="%s">%s</text>`,
+			centers[i], height-marginBottom+18, c.Theme.Muted, esc(p.Label))
+	}
+	sb.WriteString(`</g>`)
+	return centers, slot
+}
+
+func (c *Chart) maxValue() float64 {
+	max := 0.0
+	for _, p := range c.Points {
+		max = math.Max(max, p.Value)
+	}
+	return max
+}
+
+func (c *Chart) bar(sb *strings.Builder, width, height int) {
+	top := c.axis(sb, width, height)
+	centers, slot := c.slots(sb, width, height)
+	plotHeight := float64(height - marginTop - marginBottom)
+	barWidth := slot * 0.7
+
+	for i, p := range c.Points {
+		h := plotHeight * math.Max(p.Value, 0) / top
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s</title></rect>`,
+			centers[i]-barWidth/2, float64(height-marginBottom)-h, barWidth, h, c.color(i), esc(p.Label+": "+c.amount(p.Value)))
+	}
+}
+
+func (c *Chart) line(sb *strings.Builder, width, height int) {
+	top := c.axis(sb, width, height)
+	centers, _ := c.slots(sb, width, height)
+	plotHeight := float64(height - marginTop - marginBottom)
+
+	stroke := c.Theme.Muted
+	if len(c.Points) > 0 {
+		stroke = c.color(0)
+	}
+
+	coords := make([]string, len(c.Points))
+	ys := make([]float64, len(c.Points))
+	for i, p := range c.Points {
+		ys[i] = float64(height-marginBottom) - plotHeight*math.Max(p.Value, 0)/top
+		coords[i] = fmt.Sprintf("%.1f,%.1f", centers[i], ys[i])
+	}
+	fmt.Fprintf(sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2.5" stroke-linejoin="round"/>`,
+		strings.Join(coords, " "), stroke)
+
+	for i, p := range c.Points {
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="4" fill="%s"><title>%s</title></circle>`,
+			centers[i], ys[i], stroke, esc(p.Label+": "+c.amount(p.Value)))
+	}
+}
+
+func (c *Chart) pie(sb *strings.Builder, width, height int) {
+	total := 0.0
+	for _, p := range c.Points {
+		total += math.Max(p.Value, 0)
+	}
+
+	plotHeight := float64(height - marginTop - 16)
+	radius := plotHeight / 2
+	cx, cy := float64(marginLeft)+radius, float64(marginTop)+radius
+	legendX := cx + radius + 32
+
+	if total == 0 {
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s" stroke-width="2"/>`,
+			cx, cy, radius, c.Theme.Grid)
+		return
+	}
+
+	angle := -math.Pi / 2
+	for i, p := range c.Points {
+		if p.Value <= 0 {
+			continue
+		}
+		share := p.Value / total
+		label := esc(fmt.Sprintf("%s: %s (%.1f%%)", p.Label, c.amount(p.Value), share*100))
+
+		if share >= 1 {
+			fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"><title>%s</title></circle>`,
+				cx, cy, radius, c.color(i), label)
+			continue
+		}
+
+		end := angle + share*2*math.Pi
+		largeArc := 0
+		if share > 0.5 {
+			largeArc = 1
+		}
+		fmt.Fprintf(sb, `<path d="M%.1f,%.1f L%.1f,%.1f A%.1f,%.1f 0 %d 1 %.1f,%.1f Z" fill="%s" stroke="%s" stroke-width="1"><title>%s</title></path>`,
+			cx, cy,
+			cx+radius*math.Cos(angle), cy+radius*math.Sin(angle),
+			radius, radius, largeArc,
+			cx+radius*math.Cos(end), cy+radius*math.Sin(end),
+			c.color(i), c.Theme.Background, label)
+		angle = end
+	}
+
+	sb.WriteString(`<g aria-hidden="true">`)
+	row := 0
+	for i, p := range c.Points {
+		if p.Value <= 0 {
+			continue
+		}
+		y := float64(marginTop) + 8 + float64(row)*22
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="12" height="12" rx="2" fill="%s"/>`, legendX, y, c.color(i))
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-size="12" fill="%s">%s %.1f%%</text>`,
+			legendX+18, y+10, c.Theme.Muted, esc(p.Label), p.Value/total*100)
+		row++
+	}
+	sb.WriteString(`</g>`)
+}
+
+// niceMax rounds the largest value up to a number that divides into round grid steps.
+func niceMax(max float64) float64 {
+	if max <= 0 {
+		return 1
+	}
+
+	step := math.Pow(10, math.Floor(math.Log10(max/gridLines)))
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if step*m*gridLines >= max {
+			return step * m * gridLines
+		}
+	}
+	return step * 10 * gridLines
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
+
+// Entry is an amount to chart, such as an expense of a type.
+type Entry struct {
+	Label  string
+	Date   time.Time
+	Amount float64
+}
+
+// ByLabel totals the entries per label, largest total first, in the label's color
+// from TypeColors when it has one.
+func ByLabel(entries []Entry) []Point {
+	index := map[string]int{}
+	var points []Point
+	for _, e := range entries {
+		i, ok := index[e.Label]
+		if !ok {
+			i = len(points)
+			index[e.Label] = i
+			points = append(points, Point{Label: e.Label, Color: TypeColors[e.Label]})
+		}
+		points[i].Value += e.Amount
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Value > points[j].Value
+	})
+	for i := range points {
+		points[i].Value = math.Round(points[i].Value*100) / 100
+	}
+	return points
+}
+
+// ByMonth totals the entries of a year per month, January to December.
+func ByMonth(entries []Entry, year int) []Point {
+	points := make([]Point, 12)
+	for i := range points {
+		points[i].Label = time.Month(i + 1).String()[:3]
+	}
+
+	for _, e := range entries {
+		if e.Date.Year() != year {
+			continue
+		}
+		points[e.Date.Month()-1].Value += e.Amount
+	}
+
+	for i := range points {
+		points[i].Value = math.Round(points[i].Value*100) / 100
+	}
+	return points
+}
@@ -0,0 +1,86 @@
+package svgchart
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNiceMax(t *testing.T) {
+	assert.Equal(t, 1.0, niceMax(0))
+	assert.Equal(t, 400.0, niceMax(321))
+	assert.Equal(t, 100.0, niceMax(100))
+	assert.Equal(t, 8.0, niceMax(7.5))
+	assert.Equal(t, 2000.0, niceMax(1999))
+}
+
+func TestRender(t *testing.T) {
+	chart := &Chart{
+		ID:    "house",
+		Title: "Home <Expenses>",
+		Unit:  "lv",
+		Theme: Dark,
+		Points: []Point{
+			{Label: "Water", Value: 30, Color: TypeColors["Water"]},
+			{Label: "Gas & Heat", Value: 90},
+		},
+	}
+
+	bar := string(chart.HTML(KindBar))
+	assert.True(t, strings.HasPrefix(bar, "<svg "))
+	assert.Contains(t, bar, `role="img"`)
+	assert.Contains(t, bar, `aria-labelledby="house-title house-desc"`)
+	assert.Contains(t, bar, "Home &lt;Expenses&gt;")
+	assert.Contains(t, bar, "Water: 30.00 lv, Gas &amp; Heat: 90.00 lv.")
+	assert.Contains(t, bar, Dark.Background)
+	assert.Contains(t, bar, TypeColors["Water"])
+	assert.Contains(t, bar, Dark.Palette[1])
+	assert.Equal(t, 2, strings.Count(bar, "<rect x="))
+
+	line := string(chart.HTML(KindLine))
+	assert.Contains(t, line, "<polyline")
+	assert.Equal(t, 2, strings.Count(line, "<circle"))
+
+	pie := string(chart.HTML(KindPie))
+	assert.Equal(t, 2, strings.Count(pie, "<path"))
+	assert.Contains(t, pie, "Gas &amp; Heat: 90.00 lv (75.0%)")
+
+	// A single slice is a full circle, as an arc can't start and end in the same point.
+	chart.Points = chart.Points[:1]
+	pie = string(chart.HTML(KindPie))
+	assert.NotContains(t, pie, "<path")
+	assert.Contains(t, pie, "Water: 30.00 lv (100.0%)")
+
+	assert.Equal(t, "", string(chart.HTML(Kind("radar"))))
+}
+
+func TestThemeNamed(t *testing.T) {
+	assert.Equal(t, Dark, ThemeNamed("dark"))
+	assert.Equal(t, Light, ThemeNamed("light"))
+	assert.Equal(t, Light, ThemeNamed(""))
+}
+
+func TestAggregation(t *testing.T) {
+	entries := []Entry{
+		{Label: "Water", Date: time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC), Amount: 20.1},
+		{Label: "Gas", Date: time.Date(2025, time.January, 20, 0, 0, 0, 0, time.UTC), Amount: 80},
+		{Label: "Water", Date: time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC), Amount: 25.2},
+		{Label: "Other", Date: time.Date(2024, time.December, 10, 0, 0, 0, 0, time.UTC), Amount: 5},
+	}
+
+	byLabel := ByLabel(entries)
+	assert.Equal(t, []Point{
+		{Label: "Gas", Value: 80, Color: TypeColors["Gas"]},
+		{Label: "Water", Value: 45.3, Color: TypeColors["Water"]},
+		{Label: "Other", Value: 5},
+	}, byLabel)
+
+	byMonth := ByMonth(entries, 2025)
+	assert.Len(t, byMonth, 12)
+	assert.Equal(t, Point{Label: "Jan", Value: 100.1}, byMonth[0])
+	assert.Equal(t, 0.0, byMonth[1].Value)
+	assert.Equal(t, 25.2, byMonth[2].Value)
+	assert.Equal(t, 0.0, byMonth[11].Value)
+}
@@ -0,0 +1,157 @@
+// Package tariff estimates utility bills from meter readings and the tariffs in
+// effect, and matches the estimates to the bills that arrived.
+package tariff
+
+import (
+	"expenser/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// daysPerMonth is the average month length the fixed fee is spread over.
+const daysPerMonth = 365.25 / 12
+
+// A bill matches the period of its closing reading when dated from billGraceDays
+// before the reading, utilities read meters a few days ahead, to billWindowDays after it.
+const (
+	billGraceDays  = 7
+	billWindowDays = 45
+)
+
+// InEffect returns the latest tariff that took effect by day, nil when there is none.
+// The tariffs must all be of the same utility type.
+func InEffect(tariffs []models.UtilityTariff, day time.Time) *models.UtilityTariff {
+	var current *models.UtilityTariff
+	for i := range tariffs {
+		t := &tariffs[i]
+		if t.EffectiveFrom.After(day) {
+			continue
+		}
+		if current == nil || t.EffectiveFrom.After(current.EffectiveFrom) {
+			current = t
+		}
+	}
+	return current
+}
+
+// Estimate returns the expected bill for the consumption between two readings of a
+// meter. The consumption is spread evenly over the days after prev up to cur, and
+// every day is charged by the tariff in effect on it, so a period spanning a tariff
+// change is pro-rated. It returns false when a day has no tariff or the meter went back.
+func Estimate(tariffs []models.UtilityTariff, prev, cur models.MeterReading) (models.BillEstimate, bool) {
+	days := int(math.Round(cur.Date.Sub(prev.Date).Hours() / 24))
+	units := cur.Value - prev.Value
+	var nightUnits float64
+	if prev.NightValue != nil && cur.NightValue != nil {
+		nightUnits = *cur.NightValue - *prev.NightValue
+	}
+	if days <= 0 || units < 0 || nightUnits < 0 {
+		return models.BillEstimate{}, false
+	}
+
+	dayShare, nightShare := units/float64(days), nightUnits/float64(days)
+	var amount float64
+	for d := 1; d <= days; d++ {
+		t := InEffect(tariffs, prev.Date.AddDate(0, 0, d))
+		if t == nil {
+			return models.BillEstimate{}, false
+		}
+
+		net := dayShare*t.UnitPrice + nightShare*t.NightUnitPrice() + t.FixedFee/daysPerMonth
+		amount += net * (1 + t.VATPercent/100)
+	}
+
+	return models.BillEstimate{
+		UtilityTypeID: cur.UtilityTypeID,
+		UtilityType:   cur.UtilityType,
+		From:          prev.Date,
+		To:            cur.Date,
+		Units:         units + nightUnits,
+		NightUnits:    nightUnits,
+		Amount:        math.Round(amount*100) / 100,
+	}, true
+}
+
+// Estimates returns the expected bills for the consecutive readings of every utility
+// type, newest first, each with the bill of its period when it arrived. Periods a bill
+// can't be estimated for are left out.
+func Estimates(tariffs []models.UtilityTariff, readings []models.MeterReading, bills []models.HouseExpense) []models.BillEstimate {
+	tariffsByType := make(map[int][]models.UtilityTariff)
+	for _, t := range tariffs {
+		tariffsByType[t.UtilityTypeID] = append(tariffsByType[t.UtilityTypeID], t)
+	}
+
+	readingsByType := make(map[int][]models.MeterReading)
+	for _, r := range readings {
+		readingsByType[r.UtilityTypeID] = append(readingsByType[r.UtilityTypeID], r)
+	}
+
+	var estimates []models.BillEstimate
+	for typeID, typeReadings := range readingsByType {
+		sort.Slice(typeReadings, func(i, j int) bool { return typeReadings[i].Date.Before(typeReadings[j].Date) })
+
+		var typeBills []models.HouseExpense
+		for _, bill := range bills {
+			if bill.UtilityTypeID == typeID {
+				typeBills = append(typeBills, bill)
+			}
+		}
+		sort.Slice(typeBills, func(i, j int) bool { return typeBills[i].ExpenseDate.Before(typeBills[j].ExpenseDate) })
+
+		used := make([]bool, len(typeBills))
+		for i := 1; i < len(typeReadings); i++ {
+			estimate, ok := Estimate(tariffsByType[typeID], typeReadings[i-1], typeReadings[i])
+			if !ok {
+				continue
+			}
+
+			for j, bill := range typeBills {
+				if used[j] || bill.ExpenseDate.Before(estimate.To.AddDate(0, 0, -billGraceDays)) {
+					continue
+				}
+				if bill.ExpenseDate.After(estimate.To.AddDate(0, 0, billWindowDays)) {
+					break
+				}
+				used[j] = true
+				estimate.Bill = &typeBills[j]
+				break
+			}
+
+			estimates = append(estimates, estimate)
+		}
+	}
+
+	sort.Slice(estimates, func(i, j int) bool {
+		if !estimates[i].To.Equal(estimates[j].To) {
+			return estimates[i].To.After(estimates[j].To)
+		}
+		return estimates[i].UtilityType < estimates[j].UtilityType
+	})
+	return estimates
+}
+
+// Flagged returns the estimates whose bill, dated since, deviates significantly from them.
+func Flagged(estimates []models.BillEstimate, since time.Time) []models.BillEstimate {
+	var flagged []models.BillEstimate
+	for _, estimate := range estimates {
+		if estimate.Flagged() && !estimate.Bill.ExpenseDate.Before(since) {
+			flagged = append(flagged, estimate)
+		}
+	}
+	return flagged
+}
+
+// Due returns the estimates whose bill hasn't arrived yet but still can, their
+// closing reading was taken less than the matching window before now.
+func Due(estimates []models.BillEstimate, now time.Time) []models.BillEstimate {
+	since := now.AddDate(0, 0, -billWindowDays)
+
+	var due []models.BillEstimate
+	for _, estimate := range estimates {
+		if estimate.Bill == nil && estimate.To.After(since) && !estimate.To.After(now) {
+			due = append(due, estimate)
+		}
+	}
+	return due
+}
@@ -0,0 +1,101 @@
+package tariff
+
+import (
+	"expenser/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func reading(date time.Time, value float64, night *float64) models.MeterReading {
+	return models.MeterReading{UtilityTypeID: 1, UtilityType: "Electricity", Date: date, Value: value, NightValue: night}
+}
+
+func TestInEffect(t *testing.T) {
+	tariffs := []models.UtilityTariff{
+		{Name: "2025", EffectiveFrom: day(2025, time.January, 1)},
+		{Name: "2024", EffectiveFrom: day(2024, time.January, 1)},
+	}
+
+	assert.Nil(t, InEffect(tariffs, day(2023, time.December, 31)))
+	assert.Equal(t, "2024", InEffect(tariffs, day(2024, time.December, 31)).Name)
+	assert.Equal(t, "2025", InEffect(tariffs, day(2025, time.January, 1)).Name)
+}
+
+func TestEstimate(t *testing.T) {
+	night := 0.1
+	tariffs := []models.UtilityTariff{
+		{EffectiveFrom: day(2025, time.January, 1), FixedFee: 3.0436, UnitPrice: 0.2, NightPrice: &night, VATPercent: 20},
+	}
+
+	nightStart, nightEnd := 500.0, 600.0
+	estimate, ok := Estimate(tariffs, reading(day(2025, time.January, 1), 1000, &nightStart), reading(day(2025, time.January, 31), 1200, &nightEnd))
+	assert.True(t, ok)
+	assert.Equal(t, 300.0, estimate.Units)
+	assert.Equal(t, 100.0, estimate.NightUnits)
+	// (200 * 0.2 + 100 * 0.1 + 30 days of the fixed fee) * 1.2
+	assert.InDelta(t, 63.6, estimate.Amount, 0.01)
+
+	// The last 16 days at a doubled price and without the fixed fee.
+	tariffs = append(tariffs, models.UtilityTariff{EffectiveFrom: day(2025, time.January, 16), UnitPrice: 0.4, NightPrice: &night, VATPercent: 20})
+	estimate, _ = Estimate(tariffs, reading(day(2025, time.January, 1), 1000, &nightStart), reading(day(2025, time.January, 31), 1200, &nightEnd))
+	assert.InDelta(t, 87.28, estimate.Amount, 0.01)
+
+	_, ok = Estimate(tariffs, reading(day(2024, time.December, 1), 900, nil), reading(day(2025, time.January, 1), 1000, nil))
+	assert.False(t, ok)
+
+	_, ok = Estimate(tariffs, reading(day(2025, time.February, 1), 1000, nil), reading(day(2025, time.March, 1), 900, nil))
+	assert.False(t, ok)
+}
+
+func TestEstimates(t *testing.T) {
+	tariffs := []models.UtilityTariff{
+		{UtilityTypeID: 1, EffectiveFrom: day(2025, time.January, 1), UnitPrice: 0.25, VATPercent: 20},
+	}
+	readings := []models.MeterReading{
+		reading(day(2025, time.March, 1), 1400, nil),
+		reading(day(2025, time.January, 1), 1000, nil),
+		reading(day(2025, time.February, 1), 1200, nil),
+	}
+	bills := []models.HouseExpense{
+		{ID: 1, UtilityTypeID: 1, Amount: 61, ExpenseDate: day(2025, time.February, 10)},
+		{ID: 2, UtilityTypeID: 1, Amount: 90, ExpenseDate: day(2025, time.March, 8)},
+		{ID: 3, UtilityTypeID: 2, Amount: 20, ExpenseDate: day(2025, time.March, 8)},
+	}
+
+	estimates := Estimates(tariffs, readings, bills)
+	assert.Len(t, estimates, 2)
+
+	assert.Equal(t, day(2025, time.March, 1), estimates[0].To)
+	assert.Equal(t, 60.0, estimates[0].Amount)
+	assert.Equal(t, 2, estimates[0].Bill.ID)
+	assert.True(t, estimates[0].Flagged())
+	assert.Equal(t, "+50%", estimates[0].DeviationLabel())
+
+	assert.Equal(t, 1, estimates[1].Bill.ID)
+	assert.False(t, estimates[1].Flagged())
+
+	flagged := Flagged(estimates, day(2025, time.March, 1))
+	assert.Len(t, flagged, 1)
+	assert.Empty(t, Flagged(estimates, day(2025, time.April, 1)))
+}
+
+func TestDue(t *testing.T) {
+	estimates := []models.BillEstimate{
+		{UtilityType: "Electricity", To: day(2025, time.March, 1)},
+		{UtilityType: "Electricity", To: day(2025, time.February, 1), Bill: &models.HouseExpense{ID: 1}},
+		{UtilityType: "Water", To: day(2025, time.January, 1)},
+	}
+
+	due := Due(estimates, day(2025, time.March, 10))
+	assert.Len(t, due, 1)
+	assert.Equal(t, "Electricity", due[0].UtilityType)
+
+	assert.Empty(t, Due(estimates, day(2025, time.February, 20)))
+	assert.Empty(t, Due(estimates, day(2025, time.May, 1)))
+}
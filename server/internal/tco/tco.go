@@ -0,0 +1,226 @@
+// Package tco computes the total cost of ownership of a vehicle over a period,
+// from its depreciation, car expenses and odometer readings.
+package tco
+
+import (
+	"encoding/csv"
+	"expenser/internal/models"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// daysPerMonth and daysPerYear are the average lengths used to spread costs over time.
+const (
+	daysPerMonth = 365.25 / 12
+	daysPerYear  = 365.25
+)
+
+// Input is everything known about a vehicle that the cost of a period depends on.
+type Input struct {
+	Vehicle    models.Vehicle
+	Valuations []models.VehicleValuation
+	Expenses   []models.CarExpense // Expenses are the vehicle's car expenses within the period.
+	Readings   []models.OdometerReading
+	From       time.Time
+	To         time.Time
+}
+
+// Compute returns the cost of owning the vehicle from From to To, both days included.
+func Compute(in *Input) *models.OwnershipCost {
+	days := in.To.Sub(in.From).Hours()/24 + 1
+	report := &models.OwnershipCost{
+		Vehicle: in.Vehicle,
+		From:    in.From,
+		To:      in.To,
+		Months:  days / daysPerMonth,
+		Km:      Distance(in.Readings, in.From, in.To),
+	}
+
+	start, startOK := Value(&in.Vehicle, in.Valuations, in.From)
+	end, endOK := Value(&in.Vehicle, in.Valuations, in.To.AddDate(0, 0, 1))
+	if startOK && endOK {
+		report.StartValue = &start
+		report.EndValue = &end
+		report.Depreciation = start - end
+	}
+
+	byType := make(map[string]*models.TypeCost)
+	for _, exp := range in.Expenses {
+		cost, ok := byType[exp.Type]
+		if !ok {
+			cost = &models.TypeCost{Type: exp.Type}
+			byType[exp.Type] = cost
+		}
+		cost.Amount += exp.Amount
+		cost.Count++
+		report.ExpensesTotal += exp.Amount
+
+		if exp.FuelLitres != nil {
+			report.FuelLitres += *exp.FuelLitres
+			report.FuelCost += exp.Amount
+		}
+	}
+
+	for _, cost := range byType {
+		report.Expenses = append(report.Expenses, *cost)
+	}
+	sort.Slice(report.Expenses, func(i, j int) bool {
+		if report.Expenses[i].Amount != report.Expenses[j].Amount {
+			return report.Expenses[i].Amount > report.Expenses[j].Amount
+		}
+		return report.Expenses[i].Type < report.Expenses[j].Type
+	})
+
+	report.Total = report.Depreciation + report.ExpensesTotal
+	if report.Months > 0 {
+		report.CostPerMonth = report.Total / report.Months
+	}
+
+	if report.Km > 0 {
+		perKm := report.Total / float64(report.Km)
+		report.CostPerKm = &perKm
+
+		if report.FuelLitres > 0 {
+			consumption := report.FuelLitres / float64(report.Km) * 100
+			report.Consumption = &consumption
+		}
+	}
+
+	return report
+}
+
+// Value returns what the vehicle was worth on a date. It reports false when the
+// purchase price is unknown. The vehicle keeps its purchase price until it's bought.
+func Value(v *models.Vehicle, valuations []models.VehicleValuation, at time.Time) (float64, bool) {
+	if v.PurchasePrice == nil || v.PurchaseDate == nil {
+		return 0, false
+	}
+
+	price, bought := *v.PurchasePrice, *v.PurchaseDate
+	if !at.After(bought) {
+		return price, true
+	}
+
+	if v.Depreciation == models.DepreciationValuations {
+		return interpolate(price, bought, valuations, at), true
+	}
+
+	if v.UsefulLifeYears == nil {
+		return price, true
+	}
+
+	elapsed := at.Sub(bought).Hours() / 24 / daysPerYear / float64(*v.UsefulLifeYears)
+	if elapsed > 1 {
+		elapsed = 1
+	}
+	return price - (price-v.ResidualValue)*elapsed, true
+}
+
+// interpolate returns the value on a date between the purchase and the valuations
+// around it, holding the last known value after the last valuation.
+func interpolate(price float64, bought time.Time, valuations []models.VehicleValuation, at time.Time) float64 {
+	points := []models.VehicleValuation{{Date: bought, Value: price}}
+	for _, valuation := range valuations {
+		if valuation.Date.After(bought) {
+			points = append(points, valuation)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Date.Before(points[j].Date) })
+
+	for i := 1; i < len(points); i++ {
+		prev, next := points[i-1], points[i]
+		if at.After(next.Date) {
+			continue
+		}
+
+		share := at.Sub(prev.Date).Hours() / next.Date.Sub(prev.Date).Hours()
+		return prev.Value + (next.Value-prev.Value)*share
+	}
+
+	return points[len(points)-1].Value
+}
+
+// Distance returns the km driven from the latest reading before the period to the
+// latest reading within it. Without a reading before the period it starts from the
+// first reading within it. It returns 0 when the readings don't tell.
+func Distance(readings []models.OdometerReading, from, to time.Time) int {
+	start, end := -1, -1
+	firstInPeriod := -1
+
+	for _, reading := range readings {
+		switch {
+		case reading.Date.Before(from):
+			if reading.Km > start {
+				start = reading.Km
+			}
+		case !reading.Date.After(to):
+			if reading.Km > end {
+				end = reading.Km
+			}
+			if firstInPeriod == -1 || reading.Km < firstInPeriod {
+				firstInPeriod = reading.Km
+			}
+		}
+	}
+
+	if start == -1 {
+		start = firstInPeriod
+	}
+	if end == -1 || start == -1 || end < start {
+		return 0
+	}
+	return end - start
+}
+
+// WriteCSV writes the report as item and value rows, amounts in lv.
+func WriteCSV(w io.Writer, report *models.OwnershipCost) error {
+	writer := csv.NewWriter(w)
+
+	amount := func(value float64) string {
+		return fmt.Sprintf("%.2f", value)
+	}
+	optional := func(value *float64) string {
+		if value == nil {
+			return ""
+		}
+		return amount(*value)
+	}
+
+	rows := [][]string{
+		{"Item", "Value"},
+		{"Vehicle", report.Vehicle.Label()},
+		{"From", report.From.Format(time.DateOnly)},
+		{"To", report.To.Format(time.DateOnly)},
+		{"Months", fmt.Sprintf("%.1f", report.Months)},
+		{"Kilometres driven", fmt.Sprint(report.Km)},
+		{"Value at start", optional(report.StartValue)},
+		{"Value at end", optional(report.EndValue)},
+		{"Depreciation", amount(report.Depreciation)},
+	}
+	for _, cost := range report.Expenses {
+		rows = append(rows, []string{cost.Type, amount(cost.Amount)})
+	}
+	rows = append(rows,
+		[]string{"Expenses total", amount(report.ExpensesTotal)},
+		[]string{"Total cost", amount(report.Total)},
+		[]string{"Cost per month", amount(report.CostPerMonth)},
+		[]string{"Cost per km", optionalPerKm(report.CostPerKm)},
+		[]string{"Fuel litres", amount(report.FuelLitres)},
+		[]string{"Fuel cost", amount(report.FuelCost)},
+		[]string{"Consumption l/100 km", optional(report.Consumption)},
+	)
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write ownership cost: %w", err)
+	}
+	return nil
+}
+
+func optionalPerKm(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.3f", *value)
+}
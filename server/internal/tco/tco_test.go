@@ -0,0 +1,114 @@
+package tco
+
+import (
+	"bytes"
+	"expenser/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestValue(t *testing.T) {
+	price, years := 20000.0, 10
+	bought := day(2020, time.January, 1)
+	vehicle := &models.Vehicle{
+		PurchasePrice:   &price,
+		PurchaseDate:    &bought,
+		Depreciation:    models.DepreciationStraightLine,
+		UsefulLifeYears: &years,
+		ResidualValue:   2000,
+	}
+
+	value, ok := Value(vehicle, nil, day(2019, time.June, 1))
+	assert.True(t, ok)
+	assert.Equal(t, 20000.0, value)
+
+	value, _ = Value(vehicle, nil, bought.AddDate(0, 0, 1826))
+	assert.InDelta(t, 11000, value, 5)
+
+	value, _ = Value(vehicle, nil, day(2040, time.January, 1))
+	assert.Equal(t, 2000.0, value)
+
+	valuations := []models.VehicleValuation{
+		{Date: day(2022, time.January, 1), Value: 14000},
+		{Date: day(2021, time.January, 1), Value: 16000},
+	}
+	vehicle.Depreciation = models.DepreciationValuations
+
+	value, _ = Value(vehicle, valuations, day(2020, time.July, 2))
+	assert.InDelta(t, 18000, value, 10)
+
+	value, _ = Value(vehicle, valuations, day(2021, time.January, 1))
+	assert.Equal(t, 16000.0, value)
+
+	value, _ = Value(vehicle, valuations, day(2024, time.January, 1))
+	assert.Equal(t, 14000.0, value)
+
+	_, ok = Value(&models.Vehicle{}, nil, day(2024, time.January, 1))
+	assert.False(t, ok)
+}
+
+func TestDistance(t *testing.T) {
+	readings := []models.OdometerReading{
+		{Date: day(2024, time.December, 20), Km: 98000},
+		{Date: day(2025, time.January, 15), Km: 99000},
+		{Date: day(2025, time.March, 10), Km: 101500},
+		{Date: day(2025, time.May, 1), Km: 103000},
+	}
+
+	assert.Equal(t, 3500, Distance(readings, day(2025, time.January, 1), day(2025, time.March, 31)))
+	assert.Equal(t, 2500, Distance(readings[1:], day(2025, time.January, 1), day(2025, time.March, 31)))
+	assert.Equal(t, 0, Distance(readings, day(2025, time.June, 1), day(2025, time.June, 30)))
+	assert.Equal(t, 0, Distance(nil, day(2025, time.January, 1), day(2025, time.March, 31)))
+}
+
+func TestCompute(t *testing.T) {
+	price, years := 24000.0, 10
+	bought := day(2020, time.January, 1)
+	litres := 40.0
+
+	in := &Input{
+		Vehicle: models.Vehicle{
+			Name:            "Golf",
+			PlateNumber:     "CB1234AB",
+			PurchasePrice:   &price,
+			PurchaseDate:    &bought,
+			Depreciation:    models.DepreciationStraightLine,
+			UsefulLifeYears: &years,
+		},
+		Expenses: []models.CarExpense{
+			{Type: "Fuel", Amount: 100, FuelLitres: &litres},
+			{Type: "Fuel", Amount: 100, FuelLitres: &litres},
+			{Type: "Insurance", Amount: 400},
+		},
+		Readings: []models.OdometerReading{
+			{Date: day(2024, time.December, 31), Km: 100000},
+			{Date: day(2025, time.December, 31), Km: 110000},
+		},
+		From: day(2025, time.January, 1),
+		To:   day(2025, time.December, 31),
+	}
+
+	report := Compute(in)
+	assert.InDelta(t, 12, report.Months, 0.01)
+	assert.Equal(t, 10000, report.Km)
+	assert.InDelta(t, 2398, report.Depreciation, 5)
+	assert.Equal(t, 600.0, report.ExpensesTotal)
+	assert.Equal(t, "Insurance", report.Expenses[0].Type)
+	assert.Equal(t, 2, report.Expenses[1].Count)
+	assert.InDelta(t, 0.8, *report.Consumption, 0.001)
+	assert.InDelta(t, report.Total/10000, *report.CostPerKm, 0.0001)
+	assert.InDelta(t, report.Total/12, report.CostPerMonth, 1)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteCSV(&out, report))
+	assert.True(t, strings.HasPrefix(out.String(), "Item,Value\nVehicle,Golf (CB1234AB)\n"))
+	assert.Contains(t, out.String(), "Insurance,400.00\n")
+	assert.Contains(t, out.String(), "Kilometres driven,10000\n")
+}
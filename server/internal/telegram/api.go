@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// API calls the methods of the Telegram Bot API the bot uses.
+type API struct {
+	URL    string // URL is the Bot API server.
+	Token  string
+	Client *http.Client
+}
+
+// Update is an incoming message or inline keyboard press.
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// CallbackQuery is the press of an inline keyboard button on a message of the bot.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data"`
+}
+
+// InlineKeyboard is the buttons below a message, row by row.
+type InlineKeyboard struct {
+	Rows [][]Button `json:"inline_keyboard"`
+}
+
+type Button struct {
+	Text string `json:"text"`
+	Data string `json:"callback_data"`
+}
+
+// response is the envelope of every Bot API reply.
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// call posts the method with the params as JSON and decodes its result into result, when not nil.
+func (a *API) call(ctx context.Context, method string, params any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", method, err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/%s", strings.TrimRight(a.URL, "/"), a.Token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid telegram API URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.Client.Do(req)
+	if err != nil {
+		// The token is part of the address, keep it out of the error.
+		if a.Token != "" {
+			return fmt.Errorf("telegram %s: %s", method, strings.ReplaceAll(err.Error(), a.Token, "***"))
+		}
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	defer res.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("telegram %s: %s", method, res.Status)
+	}
+	if !r.OK {
+		return fmt.Errorf("telegram %s: %s", method, r.Description)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(r.Result, result); err != nil {
+			return fmt.Errorf("telegram %s: invalid result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// GetUpdates long polls for the updates from offset on, waiting up to timeout
+// for one to arrive.
+func (a *API) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := a.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+// SendMessage sends text to the chat, with the keyboard when not nil.
+func (a *API) SendMessage(ctx context.Context, chatID int64, text string, keyboard *InlineKeyboard) (*Message, error) {
+	params := map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if keyboard != nil {
+		params["reply_markup"] = keyboard
+	}
+
+	var m Message
+	if err := a.call(ctx, "sendMessage", params, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// EditMessageText replaces the text and keyboard of a message of the bot, a nil
+// keyboard removes it.
+func (a *API) EditMessageText(ctx context.Context, chatID, messageID int64, text string, keyboard *InlineKeyboard) error {
+	params := map[string]any{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+	}
+	if keyboard != nil {
+		params["reply_markup"] = keyboard
+	}
+
+	return a.call(ctx, "editMessageText", params, nil)
+}
+
+// AnswerCallbackQuery acknowledges a button press, showing text as a notice when not empty.
+func (a *API) AnswerCallbackQuery(ctx context.Context, id, text string) error {
+	params := map[string]any{
+		"callback_query_id": id,
+	}
+	if text != "" {
+		params["text"] = text
+	}
+
+	return a.call(ctx, "answerCallbackQuery", params, nil)
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBotAPI is a Bot API server recording the calls made to it and replying
+// with the results set per method.
+type fakeBotAPI struct {
+	*httptest.Server
+	calls   []fakeCall
+	results map[string]string
+}
+
+type fakeCall struct {
+	method string
+	params map[string]any
+}
+
+func newFakeBotAPI(t *testing.T, token string) *fakeBotAPI {
+	f := &fakeBotAPI{results: make(map[string]string)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/bot" + token + "/"
+		if len(r.URL.Path) <= len(prefix) || r.URL.Path[:len(prefix)] != prefix {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+			return
+		}
+		method := r.URL.Path[len(prefix):]
+
+		var params map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		f.calls = append(f.calls, fakeCall{method: method, params: params})
+
+		result, ok := f.results[method]
+		if !ok {
+			result = "true"
+		}
+		w.Write([]byte(`{"ok":true,"result":` + result + `}`))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeBotAPI) api(token string) *API {
+	return &API{URL: f.URL, Token: token, Client: f.Client()}
+}
+
+func TestGetUpdates(t *testing.T) {
+	fake := newFakeBotAPI(t, "123:abc")
+	fake.results["getUpdates"] = `[
+		{"update_id": 41, "message": {"message_id": 5, "chat": {"id": 99}, "text": "fuel 80"}},
+		{"update_id": 42, "callback_query": {"id": "q1", "message": {"message_id": 6, "chat": {"id": 99}}, "data": "save"}}
+	]`
+
+	updates, err := fake.api("123:abc").GetUpdates(context.Background(), 41, 30*time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, updates, 2)
+	assert.Equal(t, "fuel 80", updates[0].Message.Text)
+	assert.Equal(t, int64(99), updates[0].Message.Chat.ID)
+	assert.Equal(t, "save", updates[1].CallbackQuery.Data)
+	assert.Equal(t, int64(6), updates[1].CallbackQuery.Message.MessageID)
+
+	assert.Equal(t, "getUpdates", fake.calls[0].method)
+	assert.Equal(t, float64(41), fake.calls[0].params["offset"])
+	assert.Equal(t, float64(30), fake.calls[0].params["timeout"])
+}
+
+func TestSendMessage(t *testing.T) {
+	fake := newFakeBotAPI(t, "123:abc")
+	fake.results["sendMessage"] = `{"message_id": 7, "chat": {"id": 99}, "text": "Save it?"}`
+
+	keyboard := &InlineKeyboard{Rows: [][]Button{{{Text: "Save", Data: dataSave}}}}
+	m, err := fake.api("123:abc").SendMessage(context.Background(), 99, "Save it?", keyboard)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), m.MessageID)
+
+	params := fake.calls[0].params
+	assert.Equal(t, float64(99), params["chat_id"])
+	assert.Equal(t, "Save it?", params["text"])
+	assert.Equal(t, map[string]any{
+		"inline_keyboard": []any{[]any{map[string]any{"text": "Save", "callback_data": "save"}}},
+	}, params["reply_markup"])
+
+	assert.NoError(t, fake.api("123:abc").EditMessageText(context.Background(), 99, 7, "Saved.", nil))
+	assert.Equal(t, "editMessageText", fake.calls[1].method)
+	assert.Equal(t, float64(7), fake.calls[1].params["message_id"])
+	assert.NotContains(t, fake.calls[1].params, "reply_markup")
+
+	assert.NoError(t, fake.api("123:abc").AnswerCallbackQuery(context.Background(), "q1", ""))
+	assert.Equal(t, map[string]any{"callback_query_id": "q1"}, fake.calls[2].params)
+}
+
+func TestAPIError(t *testing.T) {
+	fake := newFakeBotAPI(t, "123:abc")
+
+	_, err := fake.api("456:wrong").SendMessage(context.Background(), 99, "hi", nil)
+	assert.EqualError(t, err, "telegram sendMessage: Unauthorized")
+
+	unreachable := &API{URL: "http://127.0.0.1:1", Token: "456:wrong", Client: http.DefaultClient}
+	_, err = unreachable.GetUpdates(context.Background(), 0, time.Second)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "456:wrong")
+}
@@ -0,0 +1,413 @@
+// Package telegram runs the Telegram bot users add and query expenses through.
+// A user links their chat by sending the bot the one-time code from the
+// notifications page. Phrases like "fuel 80" are read like the quick add box and
+// saved once the user confirmed the type and date on an inline keyboard,
+// "/month" and "/car" reply with the totals of the current month.
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"expenser/internal/anomaly"
+	"expenser/internal/config"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/quickadd"
+	"expenser/internal/webhook"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// pollTimeout is how long a getUpdates call waits for an update to arrive.
+	pollTimeout = 30 * time.Second
+	// retryDelay is the pause after a failed getUpdates call.
+	retryDelay = 5 * time.Second
+	// pendingTTL is how long an expense waits to be confirmed.
+	pendingTTL = time.Hour
+	// LinkCodeTTL is how long a link code can be sent to the bot.
+	LinkCodeTTL = 15 * time.Minute
+)
+
+const helpText = `Send an expense like "fuel 80", "ток 120 вчера" or "parking 5 yesterday lidl" to add it, you confirm its type and date before it is saved.
+
+/month - this month's totals
+/car - this month's car expenses
+/help - this message`
+
+const notLinkedText = `This chat isn't linked to an Expenser account yet. Get a link code on the Notifications page and send it here as "/start <code>".`
+
+// NewLinkCode returns a random code for linking a chat.
+func NewLinkCode() string {
+	b := make([]byte, 10)
+	rand.Read(b)
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+}
+
+// pendingKey is the confirmation message of a pending expense.
+type pendingKey struct {
+	chatID    int64
+	messageID int64
+}
+
+// Bot answers the messages sent to the bot, long polling for them.
+type Bot struct {
+	DB  *database.DB
+	API *API
+
+	mu      sync.Mutex
+	pending map[pendingKey]*Pending
+}
+
+func NewBot(db *database.DB, cfg config.Telegram) *Bot {
+	return &Bot{
+		DB: db,
+		API: &API{
+			URL:    cfg.APIURL,
+			Token:  cfg.BotToken,
+			Client: &http.Client{Timeout: pollTimeout + 10*time.Second},
+		},
+		pending: make(map[pendingKey]*Pending),
+	}
+}
+
+// Run polls for updates and handles them until the context is cancelled.
+func (b *Bot) Run(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := b.API.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("telegram: %v", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if err := b.Handle(ctx, u); err != nil {
+				log.Printf("telegram: update %d: %v", u.UpdateID, err)
+			}
+		}
+	}
+}
+
+// Handle answers one update.
+func (b *Bot) Handle(ctx context.Context, u Update) error {
+	switch {
+	case u.CallbackQuery != nil:
+		return b.press(ctx, u.CallbackQuery, time.Now())
+	case u.Message != nil && u.Message.Text != "":
+		return b.message(ctx, u.Message, time.Now())
+	}
+	return nil
+}
+
+// splitCommand returns the command a message starts with, without the bot name
+// of "/month@MyBot", and the rest of it. The command is empty for other messages.
+func splitCommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", text
+	}
+
+	command, arg, _ := strings.Cut(text, " ")
+	command, _, _ = strings.Cut(command, "@")
+	return strings.ToLower(command), strings.TrimSpace(arg)
+}
+
+func (b *Bot) reply(ctx context.Context, chatID int64, text string) error {
+	_, err := b.API.SendMessage(ctx, chatID, text, nil)
+	return err
+}
+
+func (b *Bot) message(ctx context.Context, m *Message, now time.Time) error {
+	chatID := m.Chat.ID
+	command, arg := splitCommand(m.Text)
+
+	if command == "/start" && arg != "" {
+		return b.link(ctx, chatID, arg, now)
+	}
+
+	userID, linked, err := b.DB.GetTelegramChatUser(chatID)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return b.reply(ctx, chatID, notLinkedText)
+	}
+
+	switch command {
+	case "":
+		return b.quickAdd(ctx, chatID, userID, arg, now)
+	case "/start", "/help":
+		return b.reply(ctx, chatID, helpText)
+	case "/month":
+		return b.month(ctx, chatID, userID, now)
+	case "/car":
+		return b.car(ctx, chatID, userID, now)
+	}
+	return b.reply(ctx, chatID, "Unknown command.\n\n"+helpText)
+}
+
+func (b *Bot) link(ctx context.Context, chatID int64, code string, now time.Time) error {
+	_, ok, err := b.DB.LinkTelegramChat(code, chatID, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return b.reply(ctx, chatID, "This link code is unknown or expired, get a new one on the Notifications page.")
+	}
+
+	return b.reply(ctx, chatID, "Linked to your Expenser account!\n\n"+helpText)
+}
+
+func (b *Bot) types() (*quickadd.Types, error) {
+	houseTypes, err := b.DB.GetHouseUtilityTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	carTypes, err := b.DB.GetCarExpenseTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &quickadd.Types{House: *houseTypes, Car: *carTypes}, nil
+}
+
+// quickAdd reads the phrase like the quick add box and asks to confirm it.
+func (b *Bot) quickAdd(ctx context.Context, chatID int64, userID uuid.UUID, text string, now time.Time) error {
+	if len(text) > 255 {
+		return b.reply(ctx, chatID, "The text is limited to 255 characters.")
+	}
+
+	types, err := b.types()
+	if err != nil {
+		return err
+	}
+
+	entry, err := quickadd.Parse(text, now, types)
+	if err != nil {
+		if errors.Is(err, quickadd.ErrNoAmount) {
+			return b.reply(ctx, chatID, fmt.Sprintf("Couldn't find an amount in %q.\n\n%s", text, helpText))
+		}
+		return b.reply(ctx, chatID, helpText)
+	}
+
+	rules, err := b.DB.GetExpenseRules(userID)
+	if err != nil {
+		return err
+	}
+
+	subject := &models.RuleSubject{
+		Notes:  entry.Notes,
+		Amount: entry.Amount,
+		Date:   entry.Date,
+	}
+	if rule := models.FirstMatchingRule(*rules, subject); rule != nil {
+		entry.ApplyRule(rule)
+	}
+
+	p := &Pending{UserID: userID, Entry: entry, CreatedAt: now}
+	sent, err := b.API.SendMessage(ctx, chatID, p.Summary(), p.Keyboard(types, now))
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, old := range b.pending {
+		if now.Sub(old.CreatedAt) > pendingTTL {
+			delete(b.pending, key)
+		}
+	}
+	b.pending[pendingKey{chatID, sent.MessageID}] = p
+	return nil
+}
+
+// press handles a button of a confirmation.
+func (b *Bot) press(ctx context.Context, q *CallbackQuery, now time.Time) error {
+	if q.Message == nil {
+		return b.API.AnswerCallbackQuery(ctx, q.ID, "")
+	}
+	key := pendingKey{q.Message.Chat.ID, q.Message.MessageID}
+
+	b.mu.Lock()
+	p := b.pending[key]
+	b.mu.Unlock()
+
+	if p == nil || now.Sub(p.CreatedAt) > pendingTTL {
+		if err := b.API.EditMessageText(ctx, key.chatID, key.messageID, "This expense expired, send it again.", nil); err != nil {
+			return err
+		}
+		return b.API.AnswerCallbackQuery(ctx, q.ID, "")
+	}
+
+	// The chat may have been unlinked or linked to another user since.
+	userID, linked, err := b.DB.GetTelegramChatUser(key.chatID)
+	if err != nil {
+		return err
+	}
+	if !linked || userID != p.UserID {
+		b.forget(key)
+		return b.API.AnswerCallbackQuery(ctx, q.ID, "This chat isn't linked to the account anymore.")
+	}
+
+	types, err := b.types()
+	if err != nil {
+		return err
+	}
+
+	act, err := p.Apply(q.Data, types)
+	if err != nil {
+		return b.API.AnswerCallbackQuery(ctx, q.ID, err.Error())
+	}
+
+	switch act {
+	case actionCancel:
+		b.forget(key)
+		err = b.API.EditMessageText(ctx, key.chatID, key.messageID, "Cancelled, nothing was saved.", nil)
+	case actionSave:
+		b.forget(key)
+		var saved string
+		if saved, err = b.save(p); err != nil {
+			return err
+		}
+		err = b.API.EditMessageText(ctx, key.chatID, key.messageID, saved, nil)
+	default:
+		err = b.API.EditMessageText(ctx, key.chatID, key.messageID, p.Summary(), p.Keyboard(types, now))
+	}
+	if err != nil {
+		return err
+	}
+
+	return b.API.AnswerCallbackQuery(ctx, q.ID, "")
+}
+
+func (b *Bot) forget(key pendingKey) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pending, key)
+}
+
+// save creates the confirmed expense, flags it when unusual and queues its
+// webhooks like the add forms do. It returns the reply.
+func (b *Bot) save(p *Pending) (string, error) {
+	e := p.Entry
+	created := &models.CategorisedExpense{
+		Tracker: e.Tracker,
+		TypeID:  e.ExpenseTypeID,
+		Type:    e.ExpenseType,
+		Amount:  e.Amount,
+		Date:    e.Date,
+		Notes:   e.Notes,
+	}
+
+	if e.Tracker == models.TrackerCar {
+		exp := &models.CarExpense{
+			ExpenseTypeID: e.ExpenseTypeID,
+			Amount:        e.Amount,
+			Date:          e.Date,
+			Notes:         e.Notes,
+			Tags:          e.Tags,
+			CreatedBy:     p.UserID,
+			VehicleID:     e.VehicleID,
+		}
+		if err := b.DB.CreateCarExpense(exp); err != nil {
+			return "", err
+		}
+		created.ID = exp.ID
+	} else {
+		exp := &models.HouseExpense{
+			UtilityTypeID: e.ExpenseTypeID,
+			Amount:        e.Amount,
+			ExpenseDate:   e.Date,
+			Notes:         e.Notes,
+			Tags:          e.Tags,
+			CreatedBy:     p.UserID,
+		}
+		if err := b.DB.CreateHouseExpense(exp); err != nil {
+			return "", err
+		}
+		created.ID = exp.ID
+	}
+
+	reply := fmt.Sprintf("Saved %s · %s: %.2f BGN on %s.", e.Tracker.Label(), e.ExpenseType, e.Amount, e.Date.Format("02.01.2006"))
+
+	found, err := anomaly.NewDetector(b.DB).Check(p.UserID, created)
+	if err != nil {
+		log.Printf("anomaly check of %s expense %d: %v", created.Tracker, created.ID, err)
+	} else if found != nil {
+		reply += "\n\n" + found.Warning()
+	}
+
+	if err := webhook.Enqueue(b.DB, p.UserID, models.WebhookExpenseCreated, webhook.Expense(created)); err != nil {
+		log.Printf("webhooks: %s of %s expense %d: %v", models.WebhookExpenseCreated, created.Tracker, created.ID, err)
+	}
+
+	return reply, nil
+}
+
+// monthText is the reply to /month.
+func monthText(now time.Time, house, car, income float64) string {
+	return fmt.Sprintf("%s %d\nHouse: %.2f BGN\nCar: %.2f BGN\nTotal: %.2f BGN\nIncome: %.2f BGN",
+		now.Month(), now.Year(), house, car, house+car, income)
+}
+
+// carText is the reply to /car, without the highest expense when there is none.
+func carText(now time.Time, total, highest float64, highestType string) string {
+	text := fmt.Sprintf("Car expenses in %s %d: %.2f BGN", now.Month(), now.Year(), total)
+	if highest > 0 {
+		text += fmt.Sprintf("\nHighest: %s, %.2f BGN", highestType, highest)
+	}
+	return text
+}
+
+func (b *Bot) month(ctx context.Context, chatID int64, userID uuid.UUID, now time.Time) error {
+	house, err := b.DB.GetTotalHouseExpenseForMonth(now, userID)
+	if err != nil {
+		return err
+	}
+
+	car, err := b.DB.GetTotalCarExpenseForMonth(now.Month(), userID)
+	if err != nil {
+		return err
+	}
+
+	income, err := b.DB.GetTotalIncomeForMonth(now, userID)
+	if err != nil {
+		return err
+	}
+
+	return b.reply(ctx, chatID, monthText(now, house, car, income))
+}
+
+func (b *Bot) car(ctx context.Context, chatID int64, userID uuid.UUID, now time.Time) error {
+	total, err := b.DB.GetTotalCarExpenseForMonth(now.Month(), userID)
+	if err != nil {
+		return err
+	}
+
+	highest, highestType, err := b.DB.GetHighestCarExpenseForMonth(now.Month(), userID)
+	if err != nil {
+		return err
+	}
+
+	return b.reply(ctx, chatID, carText(now, total, highest, highestType))
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"expenser/internal/models"
+	"expenser/internal/quickadd"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTypes = &quickadd.Types{
+	House: []models.HomeUtilityType{{ID: 1, Name: "Electricity"}, {ID: 2, Name: "Water"}},
+	Car:   []models.CarExpenseType{{ID: 3, Name: "Fuel"}},
+}
+
+func TestSplitCommand(t *testing.T) {
+	command, arg := splitCommand("/start ABC123")
+	assert.Equal(t, "/start", command)
+	assert.Equal(t, "ABC123", arg)
+
+	command, arg = splitCommand("/Month@MyExpenserBot")
+	assert.Equal(t, "/month", command)
+	assert.Empty(t, arg)
+
+	command, arg = splitCommand("  fuel 80 ")
+	assert.Empty(t, command)
+	assert.Equal(t, "fuel 80", arg)
+}
+
+func TestConfirm(t *testing.T) {
+	now := time.Date(2025, time.November, 3, 18, 0, 0, 0, time.UTC)
+	entry, err := quickadd.Parse("fuel 80", now, testTypes)
+	assert.NoError(t, err)
+
+	p := &Pending{Entry: entry, CreatedAt: now}
+	assert.Equal(t, "Car · Fuel: 80.00 BGN\nDate: 03.11.2025\n\nSave it?", p.Summary())
+
+	keyboard := p.Keyboard(testTypes, now)
+	assert.Equal(t, []Button{{Text: "Type: Fuel", Data: "types"}}, keyboard.Rows[0])
+	assert.Equal(t, []Button{
+		{Text: "✓ Today", Data: "date:2025-11-03"},
+		{Text: "Yesterday", Data: "date:2025-11-02"},
+		{Text: "2 days ago", Data: "date:2025-11-01"},
+	}, keyboard.Rows[1])
+
+	act, err := p.Apply("date:2025-11-02", testTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, actionUpdate, act)
+	assert.Equal(t, "✓ Yesterday", p.Keyboard(testTypes, now).Rows[1][1].Text)
+
+	act, err = p.Apply("types", testTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, actionUpdate, act)
+	assert.Equal(t, [][]Button{
+		{{Text: "Car · Fuel", Data: "type:car:3"}, {Text: "House · Electricity", Data: "type:house:1"}},
+		{{Text: "House · Water", Data: "type:house:2"}},
+		{{Text: "Cancel", Data: "cancel"}},
+	}, p.Keyboard(testTypes, now).Rows)
+
+	_, err = p.Apply("type:house:9", testTypes)
+	assert.EqualError(t, err, "invalid type")
+
+	_, err = p.Apply("type:house:2", testTypes)
+	assert.NoError(t, err)
+	assert.False(t, p.ChoosingType)
+	assert.Equal(t, models.TrackerHouse, p.Entry.Tracker)
+	assert.Equal(t, "Water", p.Entry.ExpenseType)
+
+	act, err = p.Apply("save", testTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, actionSave, act)
+}
+
+func TestConfirmRuleVehicle(t *testing.T) {
+	now := time.Date(2025, time.November, 3, 18, 0, 0, 0, time.UTC)
+	entry, err := quickadd.Parse("80 shell", now, testTypes)
+	assert.NoError(t, err)
+
+	vehicleID := 4
+	entry.ApplyRule(&models.ExpenseRule{
+		MatchText:     "shell",
+		Tracker:       models.TrackerCar,
+		ExpenseTypeID: 3,
+		ExpenseType:   "Fuel",
+		VehicleID:     &vehicleID,
+		Vehicle:       "Golf",
+	})
+
+	p := &Pending{Entry: entry, CreatedAt: now}
+	assert.Equal(t, "Car · Fuel: 80.00 BGN\nDate: 03.11.2025\nVehicle: Golf\nNotes: shell\nMatched rule: notes contain \"shell\"\n\nSave it?", p.Summary())
+
+	// Booking it on the house drops the vehicle.
+	_, err = p.Apply("type:house:1", testTypes)
+	assert.NoError(t, err)
+	assert.Nil(t, p.Entry.VehicleID)
+	assert.Empty(t, p.Entry.Vehicle)
+}
+
+func TestConfirmWithoutType(t *testing.T) {
+	now := time.Date(2025, time.November, 3, 18, 0, 0, 0, time.UTC)
+	entry, err := quickadd.Parse("80 lidl", now, testTypes)
+	assert.NoError(t, err)
+
+	p := &Pending{Entry: entry, CreatedAt: now}
+	assert.Equal(t, "80.00 BGN\nDate: 03.11.2025\nNotes: lidl\n\nWhich type is it?", p.Summary())
+	assert.Equal(t, "type:car:3", p.Keyboard(testTypes, now).Rows[0][0].Data)
+
+	_, err = p.Apply("save", testTypes)
+	assert.ErrorIs(t, err, errNoType)
+
+	act, err := p.Apply("cancel", testTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, actionCancel, act)
+}
+
+func TestTotalsText(t *testing.T) {
+	now := time.Date(2025, time.November, 3, 18, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "November 2025\nHouse: 120.00 BGN\nCar: 80.50 BGN\nTotal: 200.50 BGN\nIncome: 1000.00 BGN",
+		monthText(now, 120, 80.5, 1000))
+	assert.Equal(t, "Car expenses in November 2025: 80.50 BGN\nHighest: Fuel, 60.00 BGN",
+		carText(now, 80.5, 60, "Fuel"))
+	assert.Equal(t, "Car expenses in November 2025: 0.00 BGN", carText(now, 0, 0, ""))
+}
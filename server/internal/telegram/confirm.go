@@ -0,0 +1,195 @@
+package telegram
+
+import (
+	"errors"
+	"expenser/internal/models"
+	"expenser/internal/quickadd"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Button data of the confirmation keyboard. Types are "type:<tracker>:<id>" and
+// dates "date:<yyyy-mm-dd>".
+const (
+	dataSave   = "save"
+	dataCancel = "cancel"
+	dataTypes  = "types"
+	prefixType = "type:"
+	prefixDate = "date:"
+)
+
+// action is what a button press on a confirmation asks for.
+type action int
+
+const (
+	actionUpdate action = iota // actionUpdate changed the expense, the confirmation is shown again.
+	actionSave
+	actionCancel
+)
+
+var errNoType = errors.New("pick a type first")
+
+// Pending is an expense sent to the bot, waiting for the user to confirm its type and date.
+type Pending struct {
+	UserID       uuid.UUID
+	Entry        *models.QuickAdd
+	ChoosingType bool // ChoosingType shows the expense types instead of the date and save buttons.
+	CreatedAt    time.Time
+}
+
+// Summary returns the text of the confirmation message.
+func (p *Pending) Summary() string {
+	var b strings.Builder
+	e := p.Entry
+
+	if e.Tracker == "" {
+		fmt.Fprintf(&b, "%.2f BGN", e.Amount)
+	} else {
+		fmt.Fprintf(&b, "%s · %s: %.2f BGN", e.Tracker.Label(), e.ExpenseType, e.Amount)
+	}
+	fmt.Fprintf(&b, "\nDate: %s", e.Date.Format("02.01.2006"))
+	if e.Vehicle != "" {
+		fmt.Fprintf(&b, "\nVehicle: %s", e.Vehicle)
+	}
+	if e.Notes != "" {
+		fmt.Fprintf(&b, "\nNotes: %s", e.Notes)
+	}
+	if e.Tags != "" {
+		fmt.Fprintf(&b, "\nTags: %s", e.Tags)
+	}
+	if e.Rule != "" {
+		fmt.Fprintf(&b, "\nMatched rule: %s", e.Rule)
+	}
+
+	if e.Tracker == "" || p.ChoosingType {
+		b.WriteString("\n\nWhich type is it?")
+	} else {
+		b.WriteString("\n\nSave it?")
+	}
+	return b.String()
+}
+
+// Keyboard returns the buttons of the confirmation: the expense types to pick
+// from while choosing one, otherwise the dates around now and save.
+func (p *Pending) Keyboard(types *quickadd.Types, now time.Time) *InlineKeyboard {
+	if p.Entry.Tracker == "" || p.ChoosingType {
+		return typeKeyboard(types)
+	}
+
+	var dates []Button
+	for days, label := range []string{"Today", "Yesterday", "2 days ago"} {
+		date := now.AddDate(0, 0, -days)
+		if sameDay(date, p.Entry.Date) {
+			label = "✓ " + label
+		}
+		dates = append(dates, Button{Text: label, Data: prefixDate + date.Format("2006-01-02")})
+	}
+
+	return &InlineKeyboard{Rows: [][]Button{
+		{{Text: "Type: " + p.Entry.ExpenseType, Data: dataTypes}},
+		dates,
+		{{Text: "Save", Data: dataSave}, {Text: "Cancel", Data: dataCancel}},
+	}}
+}
+
+// typeKeyboard lists the car and then the house types, two to a row.
+func typeKeyboard(types *quickadd.Types) *InlineKeyboard {
+	var buttons []Button
+	for _, typ := range types.Car {
+		buttons = append(buttons, Button{
+			Text: models.TrackerCar.Label() + " · " + typ.Name,
+			Data: fmt.Sprintf("%s%s:%d", prefixType, models.TrackerCar, typ.ID),
+		})
+	}
+	for _, typ := range types.House {
+		buttons = append(buttons, Button{
+			Text: models.TrackerHouse.Label() + " · " + typ.Name,
+			Data: fmt.Sprintf("%s%s:%d", prefixType, models.TrackerHouse, typ.ID),
+		})
+	}
+
+	keyboard := &InlineKeyboard{}
+	for i := 0; i < len(buttons); i += 2 {
+		keyboard.Rows = append(keyboard.Rows, buttons[i:min(i+2, len(buttons))])
+	}
+	keyboard.Rows = append(keyboard.Rows, []Button{{Text: "Cancel", Data: dataCancel}})
+	return keyboard
+}
+
+// Apply handles the press of the button with data, changing the expense when it
+// picked a type or a date.
+func (p *Pending) Apply(data string, types *quickadd.Types) (action, error) {
+	switch {
+	case data == dataSave:
+		if p.Entry.Tracker == "" {
+			return actionUpdate, errNoType
+		}
+		return actionSave, nil
+	case data == dataCancel:
+		return actionCancel, nil
+	case data == dataTypes:
+		p.ChoosingType = true
+		return actionUpdate, nil
+	case strings.HasPrefix(data, prefixType):
+		if err := p.setType(strings.TrimPrefix(data, prefixType), types); err != nil {
+			return actionUpdate, err
+		}
+		p.ChoosingType = false
+		return actionUpdate, nil
+	case strings.HasPrefix(data, prefixDate):
+		date, err := time.Parse("2006-01-02", strings.TrimPrefix(data, prefixDate))
+		if err != nil {
+			return actionUpdate, fmt.Errorf("invalid date")
+		}
+		p.Entry.Date = date
+		return actionUpdate, nil
+	}
+	return actionUpdate, fmt.Errorf("unknown button")
+}
+
+// setType books the expense as the "tracker:id" type, which must exist. Only car
+// expenses keep the vehicle a rule set.
+func (p *Pending) setType(target string, types *quickadd.Types) error {
+	trackerStr, idStr, _ := strings.Cut(target, ":")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid type")
+	}
+
+	tracker := models.Tracker(trackerStr)
+	name := ""
+	switch tracker {
+	case models.TrackerCar:
+		for _, typ := range types.Car {
+			if typ.ID == id {
+				name = typ.Name
+			}
+		}
+	case models.TrackerHouse:
+		for _, typ := range types.House {
+			if typ.ID == id {
+				name = typ.Name
+			}
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("invalid type")
+	}
+
+	p.Entry.Tracker = tracker
+	p.Entry.ExpenseTypeID = id
+	p.Entry.ExpenseType = name
+	if tracker != models.TrackerCar {
+		p.Entry.VehicleID = nil
+		p.Entry.Vehicle = ""
+	}
+	return nil
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
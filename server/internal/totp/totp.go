@@ -0,0 +1,148 @@
+// Package totp implements the time-based one-time passwords of RFC 6238 that
+// authenticator apps generate, and the recovery codes that stand in for them.
+package totp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image/png"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+const (
+	Period = 30 * time.Second // Period is how long a code is valid.
+	Digits = 6
+
+	// Skew is how many periods before and after the current one are accepted,
+	// for clocks that are a little off.
+	Skew = 1
+
+	secretSize = 20 // secretSize is the bytes of a secret, the size of an HMAC-SHA1 key.
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random secret, base32 encoded like authenticator apps expect it.
+func NewSecret() string {
+	b := make([]byte, secretSize)
+	rand.Read(b)
+	return encoding.EncodeToString(b)
+}
+
+// Step returns the time step of t, the counter the code of t is generated from.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns the code of the secret for the time step.
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range Digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod), nil
+}
+
+// Validate checks the code against the secret at now, allowing Skew periods of
+// clock drift. It returns the time step the code is of, so that a code can be
+// refused once a code of its step or a later one was used.
+func Validate(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(now)
+	for step := current - Skew; step <= current+Skew; step++ {
+		want, err := Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(want), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// URI returns the otpauth URI of the secret that authenticator apps scan,
+// labelled with the issuer and the account.
+func URI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// QRCode returns the PNG of a QR code of the URI.
+func QRCode(uri string) ([]byte, error) {
+	matrix, err := qrcode.NewQRCodeWriter().Encode(uri, gozxing.BarcodeFormat_QR_CODE, 240, 240, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, matrix); err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RecoveryCodes is how many recovery codes a user gets at a time.
+const RecoveryCodes = 10
+
+// NewRecoveryCodes returns RecoveryCodes random single-use codes, like
+// "k7qm-2xvd-p4nh", to show the user once, and their hashes to store.
+func NewRecoveryCodes() ([]string, []string) {
+	codes := make([]string, RecoveryCodes)
+	hashes := make([]string, RecoveryCodes)
+	for i := range codes {
+		b := make([]byte, 8)
+		rand.Read(b)
+		s := strings.ToLower(encoding.EncodeToString(b))[:12]
+		codes[i] = s[:4] + "-" + s[4:8] + "-" + s[8:]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes
+}
+
+// HashRecoveryCode returns the hash a recovery code is stored as, the same
+// whatever the case, spaces and dashes it is typed with.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,104 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, the last 6 of the 8 digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(test.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, test.code, code, "at %d", test.unix)
+	}
+
+	_, err := Code("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	secret := NewSecret()
+	now := time.Date(2025, time.November, 12, 9, 30, 10, 0, time.UTC)
+
+	code, err := Code(secret, Step(now))
+	assert.NoError(t, err)
+
+	step, ok := Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// A period of drift either way is fine, two aren't.
+	_, ok = Validate(secret, code, now.Add(Period))
+	assert.True(t, ok)
+	_, ok = Validate(secret, code, now.Add(-Period))
+	assert.True(t, ok)
+	_, ok = Validate(secret, code, now.Add(2*Period))
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, code[:3]+" "+code[3:], now)
+	assert.True(t, ok)
+
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		_, ok = Validate(secret, bad, now)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Expenser", "ivan", "JBSWY3DPEHPK3PXP")
+
+	u, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Expenser:ivan", u.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "Expenser", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+
+	qr, err := QRCode(uri)
+	assert.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(qr))
+	assert.NoError(t, err)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes := NewRecoveryCodes()
+	assert.Len(t, codes, RecoveryCodes)
+	assert.Len(t, hashes, RecoveryCodes)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		assert.Len(t, code, 14)
+		assert.Equal(t, hashes[i], HashRecoveryCode(code))
+		seen[code] = true
+	}
+	assert.Len(t, seen, RecoveryCodes)
+
+	// Typed in another case or without dashes, it's the same code.
+	assert.Equal(t, hashes[0], HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))+" "))
+	assert.NotEqual(t, hashes[0], HashRecoveryCode(codes[1]))
+}
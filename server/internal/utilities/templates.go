@@ -2,50 +2,142 @@ package utilities
 
 // Pages defines the names for full application pages.
 type Pages struct {
-	Index    string // Index is the name for the main index page template.
-	Register string
-	Login    string
-	House    string // Home is the name for the expense page template.
-	Car      string
+	Index          string // Index is the name for the main index page template.
+	Register       string
+	Login          string
+	House          string // Home is the name for the expense page template.
+	Car            string
+	Income         string
+	Accounts       string
+	Import         string
+	Rules          string
+	Vehicles       string
+	Notifications  string
+	OwnershipCost  string
+	Webhooks       string
+	Settings       string
+	ForgotPassword string
+	ResetPassword  string
+	VerifyEmail    string
+	TwoFactorLogin string
+	Sessions       string
 }
 
 // HTMXComponents defines the names for reusable HTMX-specific UI components.
 type HTMXComponents struct {
-	Header             string // Header is the name for the application's header component.
-	Footer             string // Footer is the name for the application's footer component.
-	CreateHouseExpForm string // CreateExpForm is the name for the expense creation form component.
-	CreateCarExpForm   string // CreateExpForm is the name for the expense creation form component.
-	NewHouseExp        string // NewExp is the name for the new expense component (often a row or card).
-	NewCarExp          string // NewExp is the name for the new expense component (often a row or card).
-	EditHouseExpForm   string // EditExpForm is the name for the expense editing form component.
-	EditCarExpForm     string // EditExpForm is the name for the expense editing form component.
-	HouseExpRow        string
-	CarExpRow          string
-	TotalCard          string
-	HighestCard        string
-	Modal              string
-	ModalSuccess       string
-	ModalError         string
-	ModalConfirm       string
-	Chart              string
-	HouseCurrent       string
-	CarCurrent         string
-	Dialog             string
-	Search             string
-	SearchResultsHouse string
-	SearchResultsCar   string
+	Header                      string // Header is the name for the application's header component.
+	Footer                      string // Footer is the name for the application's footer component.
+	CreateHouseExpForm          string // CreateExpForm is the name for the expense creation form component.
+	CreateCarExpForm            string // CreateExpForm is the name for the expense creation form component.
+	NewHouseExp                 string // NewExp is the name for the new expense component (often a row or card).
+	NewCarExp                   string // NewExp is the name for the new expense component (often a row or card).
+	EditHouseExpForm            string // EditExpForm is the name for the expense editing form component.
+	EditCarExpForm              string // EditExpForm is the name for the expense editing form component.
+	HouseExpRow                 string
+	CarExpRow                   string
+	TotalCard                   string
+	HighestCard                 string
+	Modal                       string
+	ModalSuccess                string
+	ModalError                  string
+	ModalConfirm                string
+	Chart                       string
+	HouseCurrent                string
+	CarCurrent                  string
+	Dialog                      string
+	Search                      string
+	SearchResultsHouse          string
+	SearchResultsCar            string
+	IncomeRow                   string
+	CreateIncomeForm            string
+	EditIncomeForm              string
+	IncomeCurrent               string
+	IncomeTotalCard             string
+	IncomeHighestCard           string
+	SearchResultsIncome         string
+	CashFlow                    string
+	AccountForm                 string
+	AccountRow                  string
+	AccountRows                 string
+	AccountSelect               string
+	TransferForm                string
+	AccountStatement            string
+	Reconciliation              string
+	ImportPreview               string
+	RuleForm                    string
+	RuleRow                     string
+	RuleRows                    string
+	RuleTestResult              string
+	QuickAdd                    string
+	QuickAddConfirm             string
+	ReceiptScanForm             string
+	ReceiptForm                 string
+	VehicleForm                 string
+	VehicleRow                  string
+	VehicleSelect               string
+	ServicePlanForm             string
+	ServicePlanRow              string
+	ServicePlanRows             string
+	ServiceDue                  string
+	DocumentForm                string
+	DocumentRow                 string
+	DocumentRows                string
+	RenewalCalendar             string
+	OwnershipCostReport         string
+	TripLog                     string
+	TripForm                    string
+	Reimbursement               string
+	Charging                    string
+	ChargingForm                string
+	Tariffs                     string
+	TariffsContent              string
+	TariffForm                  string
+	MeterReadingForm            string
+	BillCheck                   string
+	Anomalies                   string
+	SVGChart                    string
+	NotificationSettings        string
+	NotificationChannels        string
+	NotificationChannelsContent string
+	NotificationChannelForm     string
+	WebhookEndpoints            string
+	WebhookDeliveries           string
+	WebhookEndpointForm         string
+	TelegramLink                string
+	PasskeySettings             string
+	SessionList                 string
 }
 
 // Responses defines the names for specific HTMX partial responses.
 // These are often fragments returned by HTMX requests that swap content on the page.
 type Responses struct {
-	CreateHouseExp  string // CreateHouseExp is the name for the response partial after creating a home expense.
-	UpdateHouseExp  string // UpdateHomeExp is the name for the response partial after updating a home expense.
-	DeleteHouseExp  string // DeleteHomeExp is the name for the response partial after deleting a home expense.
-	CreateCarExp    string // CreateHomeExp is the name for the response partial after creating a home expense.
-	UpdateCarExp    string // UpdateHomeExp is the name for the response partial after updating a home expense.
-	DeleteCarExp    string // DeleteHomeExp is the name for the response partial after deleting a home expense.
-	RegisterSuccess string
+	CreateHouseExp          string // CreateHouseExp is the name for the response partial after creating a home expense.
+	UpdateHouseExp          string // UpdateHomeExp is the name for the response partial after updating a home expense.
+	DeleteHouseExp          string // DeleteHomeExp is the name for the response partial after deleting a home expense.
+	CreateCarExp            string // CreateHomeExp is the name for the response partial after creating a home expense.
+	UpdateCarExp            string // UpdateHomeExp is the name for the response partial after updating a home expense.
+	DeleteCarExp            string // DeleteHomeExp is the name for the response partial after deleting a home expense.
+	RegisterSuccess         string
+	CreateIncome            string // CreateIncome is the name for the response partial after creating or updating an income.
+	DeleteIncome            string // DeleteIncome is the name for the response partial after deleting an income.
+	SaveAccount             string
+	CreateTransfer          string
+	SaveRule                string
+	ImportResult            string
+	ReapplyRules            string
+	SaveVehicle             string
+	DeleteVehicle           string
+	SaveServicePlan         string
+	SaveDocument            string
+	SaveTrip                string
+	SaveCharging            string
+	SaveTariff              string
+	SaveNotificationChannel string
+	SaveWebhook             string
+	SaveEmail               string
+	SaveTwoFactor           string
+	SavePasskeys            string
+	SaveSessions            string
 }
 
 // HTMLTemplates groups all template names used throughout the application.
@@ -58,44 +150,136 @@ type HTMLTemplates struct {
 }
 
 var pages = &Pages{
-	Index:    "index-page",
-	Register: "register-page",
-	Login:    "login-page",
-	House:    "house-page",
-	Car:      "car-page",
+	Index:          "index-page",
+	Register:       "register-page",
+	Login:          "login-page",
+	House:          "house-page",
+	Car:            "car-page",
+	Income:         "income-page",
+	Accounts:       "accounts-page",
+	Import:         "import-page",
+	Rules:          "rules-page",
+	Vehicles:       "vehicles-page",
+	Notifications:  "notifications-page",
+	OwnershipCost:  "vehicle-costs-page",
+	Webhooks:       "webhooks-page",
+	Settings:       "settings-page",
+	ForgotPassword: "forgot-password-page",
+	ResetPassword:  "reset-password-page",
+	VerifyEmail:    "verify-email-page",
+	TwoFactorLogin: "two-factor-page",
+	Sessions:       "sessions-page",
 }
 
 var components = &HTMXComponents{
-	Header:             "header",
-	Footer:             "footer",
-	HouseExpRow:        "house-exp-row",
-	CarExpRow:          "car-exp-row",
-	CreateHouseExpForm: "create-house-exp-form",
-	EditHouseExpForm:   "edit-house-exp-form",
-	CreateCarExpForm:   "create-car-exp-form",
-	EditCarExpForm:     "edit-car-exp-form",
-	TotalCard:          "total-card",
-	HighestCard:        "highest-card",
-	Modal:              "modal",
-	ModalSuccess:       "success-modal",
-	ModalError:         "error-modal",
-	ModalConfirm:       "confirm-modal",
-	Chart:              "exp-chart",
-	HouseCurrent:       "house-current",
-	CarCurrent:         "car-current",
-	Dialog:             "dialog",
-	Search:             "search",
-	SearchResultsHouse: "search-results-house",
-	SearchResultsCar:   "search-results-car",
+	Header:                      "header",
+	Footer:                      "footer",
+	HouseExpRow:                 "house-exp-row",
+	CarExpRow:                   "car-exp-row",
+	CreateHouseExpForm:          "create-house-exp-form",
+	EditHouseExpForm:            "edit-house-exp-form",
+	CreateCarExpForm:            "create-car-exp-form",
+	EditCarExpForm:              "edit-car-exp-form",
+	TotalCard:                   "total-card",
+	HighestCard:                 "highest-card",
+	Modal:                       "modal",
+	ModalSuccess:                "success-modal",
+	ModalError:                  "error-modal",
+	ModalConfirm:                "confirm-modal",
+	Chart:                       "exp-chart",
+	HouseCurrent:                "house-current",
+	CarCurrent:                  "car-current",
+	Dialog:                      "dialog",
+	Search:                      "search",
+	SearchResultsHouse:          "search-results-house",
+	SearchResultsCar:            "search-results-car",
+	IncomeRow:                   "income-row",
+	CreateIncomeForm:            "create-income-form",
+	EditIncomeForm:              "edit-income-form",
+	IncomeCurrent:               "income-current",
+	IncomeTotalCard:             "income-total-card",
+	IncomeHighestCard:           "income-highest-card",
+	SearchResultsIncome:         "search-results-income",
+	CashFlow:                    "cash-flow",
+	AccountForm:                 "account-form",
+	AccountRow:                  "account-row",
+	AccountRows:                 "account-rows",
+	AccountSelect:               "account-select",
+	TransferForm:                "transfer-form",
+	AccountStatement:            "account-statement",
+	Reconciliation:              "reconciliation",
+	ImportPreview:               "import-preview",
+	RuleForm:                    "rule-form",
+	RuleRow:                     "rule-row",
+	RuleRows:                    "rule-rows",
+	RuleTestResult:              "rule-test-result",
+	QuickAdd:                    "quick-add",
+	QuickAddConfirm:             "quick-add-confirm",
+	ReceiptScanForm:             "receipt-scan-form",
+	ReceiptForm:                 "receipt-form",
+	VehicleForm:                 "vehicle-form",
+	VehicleRow:                  "vehicle-row",
+	VehicleSelect:               "vehicle-select",
+	ServicePlanForm:             "service-plan-form",
+	ServicePlanRow:              "service-plan-row",
+	ServicePlanRows:             "service-plan-rows",
+	ServiceDue:                  "service-due",
+	DocumentForm:                "document-form",
+	DocumentRow:                 "document-row",
+	DocumentRows:                "document-rows",
+	RenewalCalendar:             "renewal-calendar",
+	OwnershipCostReport:         "ownership-cost-report",
+	TripLog:                     "trip-log",
+	TripForm:                    "trip-form",
+	Reimbursement:               "reimbursement-report",
+	Charging:                    "charging",
+	ChargingForm:                "charging-form",
+	Tariffs:                     "tariffs",
+	TariffsContent:              "tariffs-content",
+	TariffForm:                  "tariff-form",
+	MeterReadingForm:            "meter-reading-form",
+	BillCheck:                   "bill-check",
+	Anomalies:                   "anomalies",
+	SVGChart:                    "svg-chart",
+	NotificationSettings:        "notification-settings",
+	NotificationChannels:        "notification-channels",
+	NotificationChannelsContent: "notification-channels-content",
+	NotificationChannelForm:     "notification-channel-form",
+	WebhookEndpoints:            "webhook-endpoints",
+	WebhookDeliveries:           "webhook-deliveries",
+	WebhookEndpointForm:         "webhook-endpoint-form",
+	TelegramLink:                "telegram-link",
+	PasskeySettings:             "passkey-settings",
+	SessionList:                 "session-list",
 }
 
 // responses initializes the Responses struct with specific template identifiers.
 var responses = &Responses{
-	CreateHouseExp:  "create-house-exp",
-	DeleteHouseExp:  "delete-house-exp",
-	CreateCarExp:    "create-car-exp",
-	DeleteCarExp:    "delete-car-exp",
-	RegisterSuccess: "register-success",
+	CreateHouseExp:          "create-house-exp",
+	DeleteHouseExp:          "delete-house-exp",
+	CreateCarExp:            "create-car-exp",
+	DeleteCarExp:            "delete-car-exp",
+	RegisterSuccess:         "register-success",
+	CreateIncome:            "create-income",
+	DeleteIncome:            "delete-income",
+	SaveAccount:             "save-account",
+	CreateTransfer:          "create-transfer",
+	SaveRule:                "save-rule",
+	ImportResult:            "import-result",
+	ReapplyRules:            "reapply-rules",
+	SaveVehicle:             "save-vehicle",
+	DeleteVehicle:           "delete-vehicle",
+	SaveServicePlan:         "save-service-plan",
+	SaveDocument:            "save-document",
+	SaveTrip:                "save-trip",
+	SaveCharging:            "save-charging",
+	SaveTariff:              "save-tariff",
+	SaveNotificationChannel: "save-notification-channel",
+	SaveWebhook:             "save-webhook",
+	SaveEmail:               "save-email",
+	SaveTwoFactor:           "save-two-factor",
+	SavePasskeys:            "save-passkeys",
+	SaveSessions:            "save-sessions",
 }
 
 // Templates is the main exported variable that provides access to all
@@ -0,0 +1,279 @@
+// Package webhook posts a user's expense events to the endpoints they registered:
+// expenses created, updated and deleted, and the totals of every month that
+// closed. Events are queued as deliveries and posted by a background worker,
+// retried with exponential backoff until they are accepted or run out of attempts.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	database "expenser/internal/db"
+	"expenser/internal/models"
+	"expenser/internal/netguard"
+	"expenser/internal/notify"
+	"expenser/internal/statement"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxAttempts is how often a delivery is attempted before it is given up on.
+	MaxAttempts = 8
+	// firstRetry is the delay after the first failed attempt, it doubles with every further one.
+	firstRetry = 30 * time.Second
+	// maxRetry caps the delay between attempts.
+	maxRetry = 6 * time.Hour
+	// batchSize is how many due deliveries the worker claims at once.
+	batchSize = 20
+	// postTimeout is how long an endpoint gets to accept a delivery.
+	postTimeout = 10 * time.Second
+)
+
+// DeliveryHeader carries the id of the delivery, the same event redelivered gets a new one.
+const DeliveryHeader = "X-Expenser-Delivery"
+
+// Payload is the JSON body posted to an endpoint.
+type Payload struct {
+	Event     models.WebhookEvent `json:"event"`
+	CreatedAt time.Time           `json:"created_at"`
+	Data      any                 `json:"data"`
+}
+
+// Expense returns the data of an expense event.
+func Expense(exp *models.CategorisedExpense) models.WebhookExpense {
+	return models.WebhookExpense{
+		ID:      exp.ID,
+		Tracker: exp.Tracker,
+		Type:    exp.Type,
+		Amount:  exp.Amount,
+		Date:    exp.Date.Format("2006-01-02"),
+		Notes:   exp.Notes,
+	}
+}
+
+// Month returns the data of a month close event from the statement of the month
+// and its income.
+func Month(s *models.Statement, income float64) models.WebhookMonth {
+	return models.WebhookMonth{
+		Month:      s.From.Format("2006-01"),
+		HouseTotal: s.Totals[models.TrackerHouse],
+		CarTotal:   s.Totals[models.TrackerCar],
+		Total:      s.Total,
+		Income:     income,
+		Net:        income - s.Total,
+		Count:      len(s.Lines),
+	}
+}
+
+// Enqueue queues the event for every endpoint of the user subscribed to it.
+func Enqueue(db *database.DB, userID uuid.UUID, event models.WebhookEvent, data any) error {
+	endpoints, err := db.GetSubscribedWebhookEndpoints(userID, event)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(Payload{Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	for _, e := range endpoints {
+		if err := db.CreateWebhookDelivery(&models.WebhookDelivery{EndpointID: e.ID, Event: event, Payload: payload}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redeliver queues the payload of a delivery again, as a new delivery.
+func Redeliver(db *database.DB, d *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	again := &models.WebhookDelivery{EndpointID: d.EndpointID, Event: d.Event, Payload: d.Payload}
+	if err := db.CreateWebhookDelivery(again); err != nil {
+		return nil, err
+	}
+	return again, nil
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// failed ones: 30 seconds after the first, doubling up to 6 hours.
+func Backoff(attempts int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetry {
+			return maxRetry
+		}
+	}
+	return delay
+}
+
+// Post makes one attempt at a delivery. It returns the status code the endpoint
+// replied with, 0 when it didn't, and an error for anything but 2xx. The reply
+// body is never kept, the endpoint could be anything the user entered.
+func Post(ctx context.Context, client *http.Client, d *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Expenser")
+	req.Header.Set(notify.EventHeader, string(d.Event))
+	req.Header.Set(DeliveryHeader, strconv.Itoa(d.ID))
+	req.Header.Set(notify.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(notify.SignatureHeader, notify.Sign(d.Secret, timestamp, d.Payload))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, errors.New(res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// Record updates the delivery with the outcome of an attempt made at now: it
+// succeeded on 2xx, failed for good after MaxAttempts, and is retried after the
+// backoff otherwise.
+func Record(d *models.WebhookDelivery, code int, err error, now time.Time) {
+	d.Attempts++
+	d.StatusCode = nil
+	if code != 0 {
+		d.StatusCode = &code
+	}
+
+	switch {
+	case err == nil:
+		d.Status = models.DeliverySucceeded
+		d.Error = ""
+		d.DeliveredAt = &now
+	case d.Attempts >= MaxAttempts:
+		d.Status = models.DeliveryFailed
+		d.Error = err.Error()
+	default:
+		d.Status = models.DeliveryPending
+		d.Error = err.Error()
+		d.NextAttemptAt = now.Add(Backoff(d.Attempts))
+	}
+}
+
+// Worker posts the queued deliveries and the month closes in the background.
+type Worker struct {
+	DB       *database.DB
+	Client   *http.Client
+	Interval time.Duration
+}
+
+func NewWorker(db *database.DB, interval time.Duration) *Worker {
+	return &Worker{
+		DB:       db,
+		Client:   netguard.NewClient(postTimeout),
+		Interval: interval,
+	}
+}
+
+// Run works right away and then every interval until the context is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.CloseMonths(time.Now()); err != nil {
+			log.Printf("webhooks: %v", err)
+		}
+		if err := w.DeliverDue(ctx, time.Now()); err != nil {
+			log.Printf("webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts the deliveries due by now, batch by batch until none are left.
+func (w *Worker) DeliverDue(ctx context.Context, now time.Time) error {
+	for ctx.Err() == nil {
+		// A claimed delivery is held off for longer than all attempts of a batch can take.
+		deliveries, err := w.DB.ClaimDueWebhookDeliveries(now, now.Add(batchSize*postTimeout+time.Minute), batchSize)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		for i := range deliveries {
+			d := &deliveries[i]
+			code, err := Post(ctx, w.Client, d)
+			Record(d, code, err, time.Now())
+			if err := w.DB.RecordWebhookAttempt(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CloseMonths queues the close of the month before now for every endpoint
+// subscribed to it that wasn't posted it yet.
+func (w *Worker) CloseMonths(now time.Time) error {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+	endpoints, err := w.DB.GetWebhookEndpointsToClose(month)
+	if err != nil {
+		return err
+	}
+
+	closes := make(map[uuid.UUID][]byte)
+	for _, e := range endpoints {
+		payload, ok := closes[e.UserID]
+		if !ok {
+			data, err := w.month(e.UserID, month)
+			if err != nil {
+				return err
+			}
+
+			payload, err = json.Marshal(Payload{Event: models.WebhookMonthClosed, CreatedAt: now, Data: data})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+			closes[e.UserID] = payload
+		}
+
+		if err := w.DB.CreateWebhookDelivery(&models.WebhookDelivery{EndpointID: e.ID, Event: models.WebhookMonthClosed, Payload: payload}); err != nil {
+			return err
+		}
+		if err := w.DB.MarkMonthClosed(e.ID, month); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// month gathers the totals of the user's month starting on month.
+func (w *Worker) month(userID uuid.UUID, month time.Time) (models.WebhookMonth, error) {
+	trackers := []models.Tracker{models.TrackerHouse, models.TrackerCar}
+	lines, err := statement.Lines(w.DB, userID, models.StatementMonth, month, trackers)
+	if err != nil {
+		return models.WebhookMonth{}, err
+	}
+
+	income, err := w.DB.GetTotalIncomeForMonth(month, userID)
+	if err != nil {
+		return models.WebhookMonth{}, err
+	}
+
+	return Month(statement.Build(models.StatementMonth, month, trackers, lines), income), nil
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expenser/internal/models"
+	"expenser/internal/notify"
+	"expenser/internal/statement"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, 64*time.Minute, Backoff(8))
+	assert.Equal(t, 6*time.Hour, Backoff(20))
+}
+
+func TestRecord(t *testing.T) {
+	now := time.Date(2025, time.October, 30, 9, 0, 0, 0, time.UTC)
+
+	d := &models.WebhookDelivery{Status: models.DeliveryPending}
+	Record(d, 503, errors.New("503 Service Unavailable"), now)
+	assert.Equal(t, models.DeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, 503, *d.StatusCode)
+	assert.Equal(t, now.Add(30*time.Second), d.NextAttemptAt)
+
+	Record(d, 0, errors.New("connection refused"), now)
+	assert.Equal(t, 2, d.Attempts)
+	assert.Nil(t, d.StatusCode)
+	assert.Equal(t, "connection refused", d.Error)
+	assert.Equal(t, now.Add(time.Minute), d.NextAttemptAt)
+
+	Record(d, 204, nil, now)
+	assert.Equal(t, models.DeliverySucceeded, d.Status)
+	assert.Equal(t, 204, *d.StatusCode)
+	assert.Empty(t, d.Error)
+	assert.Equal(t, now, *d.DeliveredAt)
+
+	d = &models.WebhookDelivery{Status: models.DeliveryPending, Attempts: MaxAttempts - 1}
+	Record(d, 500, errors.New("500 Internal Server Error"), now)
+	assert.Equal(t, models.DeliveryFailed, d.Status)
+}
+
+func TestPost(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	exp := &models.CategorisedExpense{
+		ID:      12,
+		Tracker: models.TrackerCar,
+		Type:    "Fuel",
+		Amount:  80,
+		Date:    time.Date(2025, time.October, 29, 0, 0, 0, 0, time.UTC),
+		Notes:   "Shell",
+	}
+	payload, err := json.Marshal(Payload{Event: models.WebhookExpenseCreated, Data: Expense(exp)})
+	assert.NoError(t, err)
+
+	d := &models.WebhookDelivery{ID: 7, URL: server.URL + "/hook", Secret: "s3cret", Event: models.WebhookExpenseCreated, Payload: payload}
+	code, err := Post(context.Background(), server.Client(), d)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	assert.Equal(t, "/hook", received.URL.Path)
+	assert.Equal(t, "expense.created", received.Header.Get(notify.EventHeader))
+	assert.Equal(t, "7", received.Header.Get(DeliveryHeader))
+	timestamp, err := strconv.ParseInt(received.Header.Get(notify.TimestampHeader), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, notify.Sign("s3cret", timestamp, body), received.Header.Get(notify.SignatureHeader))
+	assert.JSONEq(t, `{"event":"expense.created","created_at":"0001-01-01T00:00:00Z",
+		"data":{"id":12,"tracker":"car","type":"Fuel","amount":80,"date":"2025-10-29","notes":"Shell"}}`, string(body))
+}
+
+func TestPostRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown hook", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	d := &models.WebhookDelivery{ID: 1, URL: server.URL, Secret: "s3cret", Payload: []byte(`{}`)}
+	code, err := Post(context.Background(), server.Client(), d)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.EqualError(t, err, "404 Not Found")
+}
+
+func TestMonth(t *testing.T) {
+	month := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	lines := []models.StatementLine{
+		{Tracker: models.TrackerHouse, Date: month.AddDate(0, 0, 4), Type: "Electricity", Amount: 84},
+		{Tracker: models.TrackerCar, Date: month.AddDate(0, 0, 11), Type: "Fuel", Amount: 120},
+	}
+	s := statement.Build(models.StatementMonth, month, []models.Tracker{models.TrackerHouse, models.TrackerCar}, lines)
+
+	assert.Equal(t, models.WebhookMonth{
+		Month:      "2025-09",
+		HouseTotal: 84,
+		CarTotal:   120,
+		Total:      204,
+		Income:     1000,
+		Net:        796,
+		Count:      2,
+	}, Month(s, 1000))
+}
@@ -1,8 +0,0 @@
-import { initChart } from "./chart-core.js";
-
-const HOUSE_CONFIG = {
-  prefix: "house",
-  title: "Home Expenses by Utility Type",
-};
-
-initChart(HOUSE_CONFIG);
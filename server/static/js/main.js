@@ -10,6 +10,8 @@ document.addEventListener("DOMContentLoaded", () => {
       body.classList.remove("dark");
       localStorage.setItem("theme", "light");
     }
+    // The server renders the charts in the theme kept in this cookie.
+    document.cookie = `theme=${theme === "dark" ? "dark" : "light"}; path=/; max-age=31536000; SameSite=Lax`;
   }
 
   // Initialize Theme
@@ -34,11 +36,8 @@ document.addEventListener("DOMContentLoaded", () => {
         setTheme("dark");
       }
 
-      // const chart = document.getElementById("chart");
-      // if (chart) {
-      //   chart.
-      //
-      // }
+      // Redraw the charts on the page in the new theme.
+      body.dispatchEvent(new Event("theme-changed"));
     });
   }
 
@@ -176,3 +175,74 @@ function hideModal() {
     dialog.textContent = "";
   }
 }
+
+// --- Passkeys (global for inline 'onclick') ---
+
+function base64urlToBuffer(value) {
+  const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  const binary = atob(base64.padEnd(base64.length + ((4 - (base64.length % 4)) % 4), "="));
+  return Uint8Array.from(binary, (c) => c.charCodeAt(0)).buffer;
+}
+
+function bufferToBase64url(buffer) {
+  const binary = String.fromCharCode(...new Uint8Array(buffer));
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+// Runs a passkey prompt: the options come from beginURL, then the browser's
+// answer is put in the form's "credential" field and the form's htmx request
+// is triggered with a "passkey" event, so the reply is handled like any other.
+async function passkeyCeremony(form, beginURL, create) {
+  const res = await fetch(beginURL, { method: "POST" });
+  if (!res.ok) {
+    htmx.swap(document.body, await res.text(), { swapStyle: "none" });
+    return;
+  }
+
+  const options = (await res.json()).publicKey;
+  options.challenge = base64urlToBuffer(options.challenge);
+  (options.excludeCredentials || []).concat(options.allowCredentials || []).forEach((c) => {
+    c.id = base64urlToBuffer(c.id);
+  });
+
+  let credential;
+  try {
+    if (create) {
+      options.user.id = base64urlToBuffer(options.user.id);
+      credential = await navigator.credentials.create({ publicKey: options });
+    } else {
+      credential = await navigator.credentials.get({ publicKey: options });
+    }
+  } catch (err) {
+    // The user closed the prompt, or the authenticator refused.
+    console.warn("Passkey prompt failed:", err);
+    return;
+  }
+
+  const response = {};
+  for (const key of ["clientDataJSON", "attestationObject", "authenticatorData", "signature", "userHandle"]) {
+    if (credential.response[key]) {
+      response[key] = bufferToBase64url(credential.response[key]);
+    }
+  }
+  if (credential.response.getTransports) {
+    response.transports = credential.response.getTransports();
+  }
+
+  form.elements.credential.value = JSON.stringify({
+    id: credential.id,
+    rawId: bufferToBase64url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    response: response,
+  });
+  htmx.trigger(form, "passkey");
+}
+
+function loginWithPasskey(form) {
+  passkeyCeremony(form, "/login/passkey/begin", false);
+}
+
+function addPasskey(form) {
+  passkeyCeremony(form, "/settings/passkeys/begin", true);
+}